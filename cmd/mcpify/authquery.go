@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// authQueryParams resolves the query parameters every tool call sends:
+// the config's auth_query, overridden by --auth-query. Secret references
+// must resolve, or mcpify exits.
+func authQueryParams(cfg *config.Config, flagValue string) map[string]string {
+	params := maps.Clone(cfg.AuthQuery)
+	if flagValue != "" {
+		parsed, err := config.ParseAuthQuery(flagValue)
+		if err != nil {
+			fatal("Invalid --auth-query", err)
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		maps.Copy(params, parsed)
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	resolved, err := config.ResolveSecrets(params)
+	if err != nil {
+		fatal("Invalid --auth-query", err)
+	}
+	names := slices.Sorted(maps.Keys(resolved))
+	slog.Info("Tool calls send query parameters from mcpify", "params", strings.Join(names, ","))
+	return resolved
+}
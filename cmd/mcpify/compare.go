@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/compare"
+)
+
+// runCompare handles `mcpify compare --primary URL --candidate URL`.
+func runCompare(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	primary := fs.String("primary", "", "Current implementation to compare against (required)")
+	candidate := fs.String("candidate", "", "New implementation to check (required)")
+	onlyReads := fs.Bool("only-reads", false, "Only replay requests with safe methods (GET, HEAD, OPTIONS, PROPFIND, ...)")
+	allowUnsafe := fs.Bool("allow-unsafe", false, "Also replay non-idempotent methods (POST, PATCH, non-standard methods) against both targets")
+	ignore := fs.String("ignore", "", "Comma-separated JSON paths ($.meta.updated_at) or field names (id) to ignore in bodies")
+	rate := fs.Float64("rate", 10, "Maximum requests per second (0 for unlimited)")
+	timeout := fs.Duration("timeout", 30*time.Second, "Timeout for each request")
+	format := fs.String("format", "table", "Report format (table, json)")
+	configPath := fs.String("config", "", "Custom config file path")
+	fs.Parse(args)
+
+	if *primary == "" || *candidate == "" {
+		fatalf("Usage: mcpify compare --primary http://old:3000 --candidate http://new:3000 [--only-reads]")
+	}
+
+	opts := compare.Options{
+		OnlyReads:   *onlyReads,
+		AllowUnsafe: *allowUnsafe,
+		Rate:        *rate,
+		Timeout:     *timeout,
+	}
+	var err error
+	if opts.Primary, err = url.Parse(*primary); err != nil {
+		fatal("Invalid primary URL", err)
+	}
+	if opts.Candidate, err = url.Parse(*candidate); err != nil {
+		fatal("Invalid candidate URL", err)
+	}
+	if *ignore != "" {
+		opts.Ignore = strings.Split(*ignore, ",")
+	}
+
+	cfg := loadConfig(*configPath)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	report := compare.Run(ctx, cfg.ListTools(), opts)
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	case "table":
+		report.WriteTable(os.Stdout)
+	default:
+		fatalf("Unknown format %q (want table or json)", *format)
+	}
+
+	if report.Differed > 0 || report.Errors > 0 {
+		os.Exit(1)
+	}
+}
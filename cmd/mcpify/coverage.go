@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/coverage"
+)
+
+// runCoverage handles `mcpify coverage --scenario name [flags]`, printing
+// the coverage report of a scenario from a running instance.
+func runCoverage(args []string) {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	scenario := fs.String("scenario", "", "Scenario to report on (required)")
+	mcpPort := fs.String("mcp-port", "", "MCP server port of the running instance (default from config)")
+	configPath := fs.String("config", "", "Custom config file path")
+	adminToken := fs.String("admin-token", os.Getenv("MCPIFY_ADMIN_TOKEN"), "Bearer token of the running instance's admin endpoints")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	failUnder := fs.Float64("fail-under", 0, "Exit with an error when tool coverage is below this percentage")
+	fs.Parse(args)
+
+	if *scenario == "" {
+		fmt.Fprintln(os.Stderr, "Usage: mcpify coverage --scenario <name> [--json] [--fail-under <percent>]")
+		os.Exit(exitUsage)
+	}
+
+	port := *mcpPort
+	if port == "" {
+		port = loadConfig(*configPath).MCPPort
+	}
+	coverageURL := fmt.Sprintf("http://localhost:%s/api/coverage?scenario=%s", port, url.QueryEscape(*scenario))
+
+	req, err := http.NewRequest(http.MethodGet, coverageURL, nil)
+	if err != nil {
+		fatal("Invalid coverage URL", err)
+	}
+	if *adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*adminToken)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fatal("mcpify is not reachable at "+coverageURL, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fatal("Failed to read the coverage report", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		fatal("No coverage report", fmt.Errorf("%w: %s", coverage.ErrScenarioNotFound, *scenario))
+	case resp.StatusCode != http.StatusOK:
+		fatal("No coverage report", errors.New(strings.TrimSpace(string(body))))
+	}
+
+	var report coverage.Report
+	if err := json.Unmarshal(body, &report); err != nil {
+		fatal("Invalid coverage report", err)
+	}
+	if *asJSON {
+		os.Stdout.Write(body)
+	} else {
+		printCoverage(&report)
+	}
+
+	if report.Summary.CoveragePercent < *failUnder {
+		fmt.Fprintf(os.Stderr, "Coverage %.1f%% is below %.1f%%\n", report.Summary.CoveragePercent, *failUnder)
+		os.Exit(exitError)
+	}
+}
+
+func printCoverage(r *coverage.Report) {
+	state := "stopped"
+	if r.Active {
+		state = "running"
+	}
+	fmt.Printf("Scenario:  %s (%s, started %s)\n", r.Name, state, r.Started.Format(time.DateTime))
+	fmt.Printf("Coverage:  %.1f%% (%d of %d tools)\n", r.Summary.CoveragePercent, r.Summary.ToolsCalled, r.Summary.Tools)
+	fmt.Printf("Endpoints: %d, parameter combinations: %d\n", r.Summary.Endpoints, r.Summary.ParamCombinations)
+	fmt.Printf("Calls:     %d, %.1f%% successful\n", r.Summary.Calls, r.Summary.SuccessPercent)
+
+	if len(r.Groups) > 0 {
+		fmt.Println("\nGroups:")
+		for _, g := range r.Groups {
+			fmt.Printf("  %-30s %5.1f%% (%d/%d)\n", g.Name, g.CoveragePercent, g.ToolsCalled, g.Tools)
+			for _, name := range g.Untouched {
+				fmt.Printf("    - %s\n", name)
+			}
+		}
+	}
+	if len(r.Untouched) > 0 {
+		fmt.Println("\nUntouched tools:")
+		for _, name := range r.Untouched {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+}
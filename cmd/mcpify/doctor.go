@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/capture"
+)
+
+// captureStartWait is how long doctor waits for capture to open before
+// giving up on the self-test.
+const captureStartWait = 10 * time.Second
+
+// doctorReport is what `mcpify doctor --json` prints.
+type doctorReport struct {
+	*capture.CaptureSupport
+	// SelfTest is nil unless a target was given
+	SelfTest *capture.SelfTestResult `json:"self_test,omitempty"`
+}
+
+// runDoctor handles `mcpify doctor [flags]`, reporting which capture modes
+// work on this host and how to fix the ones that don't. With --target it
+// also runs the capture self-test against it.
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	target := fs.String("target", "", "Run the capture self-test against this target URL")
+	iface := fs.String("interface", "", "Interface the self-test captures on; default loopback")
+	fs.Parse(args)
+
+	report := doctorReport{CaptureSupport: capture.CheckCaptureSupport()}
+	if *target != "" {
+		report.SelfTest = doctorSelfTest(*target, *iface)
+	}
+	if *asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	support := report.CaptureSupport
+
+	fmt.Printf("OS:         %s\n", support.OS)
+	fmt.Printf("Privileged: %t\n", support.Privileged)
+	if support.BPF != "" {
+		fmt.Printf("BPF:        %s\n", support.BPF)
+	}
+	if support.Error != "" {
+		fmt.Printf("Interfaces: %s\n", support.Error)
+	} else if len(support.Interfaces) > 0 {
+		fmt.Printf("Interfaces: %s\n", strings.Join(support.Interfaces, ", "))
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "MODE\tINTERFACE\tWORKS\tNOTE")
+	for _, option := range support.Options {
+		works := "no"
+		if option.Available {
+			works = "yes"
+		}
+		iface := option.Interface
+		if iface == "" {
+			iface = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", option.Mode, iface, works, option.Note)
+	}
+	w.Flush()
+
+	fmt.Println()
+	if st := report.SelfTest; st != nil {
+		fmt.Printf("Self-test:  %s (%d packets in %s)\n            %s\n", st.Stage, st.Packets, st.Duration, st.Message)
+	} else {
+		fmt.Println("Self-test:  skipped; pass --target <url> to run it")
+	}
+
+	if len(support.Fix) > 0 {
+		fmt.Println("\nTo enable packet capture:")
+		for _, fix := range support.Fix {
+			fmt.Printf("  %s\n", fix)
+		}
+	}
+	if !support.PacketCaptureAvailable() {
+		fmt.Println("\nUntil then, capture through the proxy: mcpify --target <url> --mode proxy")
+	}
+}
+
+// doctorSelfTest captures traffic to target just long enough to run the
+// self-test against it.
+func doctorSelfTest(target, iface string) *capture.SelfTestResult {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return &capture.SelfTestResult{Stage: capture.StageRequestFail, Message: fmt.Sprintf("invalid target %q", target), StartedAt: time.Now()}
+	}
+	ec := capture.NewEndpointCapture(u, discardRegistrar{}, false, "", "", "")
+	ec.SetInterface(iface)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	captured := make(chan error, 1)
+	go func() { captured <- ec.StartCapture(ctx) }()
+	defer func() {
+		cancel()
+		<-captured
+	}()
+
+	start := time.Now()
+	select {
+	case <-ec.Ready():
+		return ec.RunSelfTest(selfTestWait)
+	case err := <-captured:
+		captured <- err
+		return &capture.SelfTestResult{Stage: capture.StageCaptureFail, Message: fmt.Sprintf("capture failed to start: %v", err), StartedAt: start, Duration: time.Since(start).Round(time.Millisecond).String()}
+	case <-time.After(captureStartWait):
+		return &capture.SelfTestResult{Stage: capture.StageCaptureFail, Message: "capture did not start within " + captureStartWait.String(), StartedAt: start, Duration: captureStartWait.String()}
+	}
+}
+
+// discardRegistrar drops the tools doctor's capture would register.
+type discardRegistrar struct{}
+
+func (discardRegistrar) RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error {
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	"github.com/NilayYadav/mcpify/internal/capture"
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	"github.com/NilayYadav/mcpify/internal/logging"
+	"github.com/NilayYadav/mcpify/internal/openapi"
+	"github.com/NilayYadav/mcpify/internal/prompts"
+	"github.com/NilayYadav/mcpify/internal/replica"
+	"github.com/NilayYadav/mcpify/internal/server"
+	"github.com/NilayYadav/mcpify/internal/update"
+	"github.com/NilayYadav/mcpify/internal/webhook"
+)
+
+// Exit codes, so scripts can tell failures apart.
+const (
+	exitError       = 1
+	exitUsage       = 2
+	exitConfig      = 3
+	exitPermission  = 4
+	exitUnsupported = 5
+	exitNotFound    = 6
+)
+
+func exitCode(err error) int {
+	var corrupt *config.ErrConfigCorrupt
+	var unsupported *capture.ErrCaptureUnsupported
+	switch {
+	case errors.As(err, &corrupt), errors.Is(err, config.ErrConfigTooNew):
+		return exitConfig
+	case errors.Is(err, fs.ErrPermission):
+		return exitPermission
+	case errors.As(err, &unsupported), errors.Is(err, config.ErrUnsupportedOS):
+		return exitUnsupported
+	case errors.Is(err, server.ErrToolNotFound), errors.Is(err, config.ErrRevisionNotFound), errors.Is(err, coverage.ErrScenarioNotFound):
+		return exitNotFound
+	case errors.Is(err, server.ErrUnknownToolView), errors.Is(err, server.ErrUnknownEviction), errors.Is(err, server.ErrUnknownBinaryMode), errors.Is(err, server.ErrInvalidConfirmRule), errors.Is(err, config.ErrProfileNotFound), errors.Is(err, capture.ErrUnknownInterface), errors.Is(err, capture.ErrInvalidFilter), errors.Is(err, capture.ErrInvalidPathPattern),
+		errors.Is(err, prompts.ErrUnknownPrompt), errors.Is(err, prompts.ErrInvalidPrompt),
+		errors.Is(err, replica.ErrInvalidReplica), errors.Is(err, replica.ErrUnknownStrategy),
+		errors.Is(err, openapi.ErrUnsupportedFormat), errors.Is(err, openapi.ErrUnsupportedVersion), errors.Is(err, errNoServerURL), errors.Is(err, errApprovalWithoutToken),
+		errors.Is(err, config.ErrInvalidAuthQuery), errors.Is(err, config.ErrSecretNotSet),
+		errors.Is(err, diskbudget.ErrInvalidBudget), errors.Is(err, logging.ErrUnknownLevel), errors.Is(err, logging.ErrUnknownFormat),
+		errors.Is(err, webhook.ErrInvalidURL), errors.Is(err, config.ErrUnknownStore), errors.Is(err, update.ErrMajorUpgrade):
+		return exitUsage
+	}
+	return exitError
+}
+
+// fatal logs msg and err and exits with the code matching err.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(exitCode(err))
+}
+
+// fatalf logs a message formatted from format and args and exits with
+// exitError.
+func fatalf(format string, args ...any) {
+	slog.Error(fmt.Sprintf(format, args...))
+	os.Exit(exitError)
+}
+
+// loadConfig loads the config at path, or the default location when path
+// is empty, and exits if that fails.
+func loadConfig(path string) *config.Config {
+	if path == "" {
+		var err error
+		if path, err = config.GetConfigPath(); err != nil {
+			fatal("Failed to locate config", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+	return cfg
+}
+
+// loadStore loads the config from the store named by spec, a --store
+// value, and exits if that fails. The JSON store is the config at path,
+// which a new SQLite store imports.
+func loadStore(path, spec string) *config.Config {
+	kind, dbPath, err := config.ParseStore(spec)
+	if err != nil {
+		fatal("Invalid --store", err)
+	}
+	if kind == config.StoreJSON {
+		return loadConfig(path)
+	}
+	if path == "" {
+		if path, err = config.GetConfigPath(); err != nil {
+			fatal("Failed to locate config", err)
+		}
+	}
+
+	cfg, err := config.LoadSQLite(dbPath, path)
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+	return cfg
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/capture"
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	"github.com/NilayYadav/mcpify/internal/logging"
+	"github.com/NilayYadav/mcpify/internal/openapi"
+	"github.com/NilayYadav/mcpify/internal/prompts"
+	"github.com/NilayYadav/mcpify/internal/replica"
+	"github.com/NilayYadav/mcpify/internal/server"
+	"github.com/NilayYadav/mcpify/internal/update"
+	"github.com/NilayYadav/mcpify/internal/webhook"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{&config.ErrConfigCorrupt{Path: "config.json", Cause: errors.New("bad")}, exitConfig},
+		{config.ErrConfigTooNew, exitConfig},
+		{fs.ErrPermission, exitPermission},
+		{&capture.ErrCaptureUnsupported{Reason: "no pktap"}, exitUnsupported},
+		{config.ErrUnsupportedOS, exitUnsupported},
+		{server.ErrToolNotFound, exitNotFound},
+		{config.ErrRevisionNotFound, exitNotFound},
+		{coverage.ErrScenarioNotFound, exitNotFound},
+		{server.ErrUnknownToolView, exitUsage},
+		{server.ErrUnknownEviction, exitUsage},
+		{server.ErrUnknownBinaryMode, exitUsage},
+		{server.ErrInvalidConfirmRule, exitUsage},
+		{config.ErrProfileNotFound, exitUsage},
+		{config.ErrInvalidAuthQuery, exitUsage},
+		{config.ErrSecretNotSet, exitUsage},
+		{config.ErrUnknownStore, exitUsage},
+		{capture.ErrUnknownInterface, exitUsage},
+		{capture.ErrInvalidFilter, exitUsage},
+		{capture.ErrInvalidPathPattern, exitUsage},
+		{prompts.ErrUnknownPrompt, exitUsage},
+		{prompts.ErrInvalidPrompt, exitUsage},
+		{replica.ErrInvalidReplica, exitUsage},
+		{replica.ErrUnknownStrategy, exitUsage},
+		{openapi.ErrUnsupportedFormat, exitUsage},
+		{openapi.ErrUnsupportedVersion, exitUsage},
+		{errNoServerURL, exitUsage},
+		{errApprovalWithoutToken, exitUsage},
+		{diskbudget.ErrInvalidBudget, exitUsage},
+		{logging.ErrUnknownLevel, exitUsage},
+		{logging.ErrUnknownFormat, exitUsage},
+		{webhook.ErrInvalidURL, exitUsage},
+		{update.ErrMajorUpgrade, exitUsage},
+		{server.ErrToolLimitReached, exitError},
+		{errors.New("connection refused"), exitError},
+	}
+	for _, tt := range tests {
+		// Errors reach fatal wrapped, sometimes twice
+		err := fmt.Errorf("loading: %w", fmt.Errorf("%w: detail", tt.err))
+		if got := exitCode(err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/export"
+	"github.com/NilayYadav/mcpify/internal/session"
+)
+
+// runExport handles `mcpify export <kind> [flags]`.
+func runExport(args []string) {
+	if len(args) == 0 {
+		fatalf("Usage: mcpify export guide [-o FILE] [--format markdown|llms-txt]\n       mcpify export openapi|postman [-o FILE]\n       mcpify export har [--session FILE] [-o FILE]\n       mcpify export config [-o FILE] [--blobs inline|ref]\n       mcpify export subset [--group G] [--tag T] [--target URL] [-o FILE]")
+	}
+
+	kind := args[0]
+	fs := flag.NewFlagSet("export "+kind, flag.ExitOnError)
+	output := fs.String("o", "", "Output file (default stdout)")
+	format := fs.String("format", export.FormatMarkdown, "Guide output format (markdown, llms-txt)")
+	configPath := fs.String("config", "", "Custom config file path")
+	mcpName := fs.String("mcp-name", "mcpify", "Name of the MCP server")
+	blobs := fs.String("blobs", "inline", "How config exports hold large bodies: inline (portable) or ref (blob references, valid next to this config only)")
+	groups := fs.String("group", "", "Comma-separated groups a subset takes its tools from")
+	tags := fs.String("tag", "", "Comma-separated tags a subset's tools carry one of")
+	targets := fs.String("target", "", "Comma-separated target URLs a subset's tools call one of")
+	sessionFile := fs.String("session", "", "Session file (--session-file) whose exchanges a HAR export holds, instead of one entry per tool")
+	fs.Parse(args[1:])
+
+	cfg := loadConfig(*configPath)
+
+	var out string
+	var err error
+	switch kind {
+	case "guide":
+		out, err = export.Guide(cfg, *mcpName, *format)
+	case "openapi":
+		var doc []byte
+		doc, err = export.OpenAPI(cfg, *mcpName)
+		out = string(doc)
+	case "postman":
+		var collection []byte
+		collection, err = export.Postman(cfg, *mcpName)
+		out = string(collection)
+	case "har":
+		var exchanges []session.Entry
+		if *sessionFile != "" {
+			if exchanges, err = readSession(*sessionFile); err != nil {
+				break
+			}
+		}
+		var archive []byte
+		archive, err = export.HAR(cfg, exchanges)
+		out = string(archive)
+	case "config":
+		var data []byte
+		switch *blobs {
+		case "inline", "ref":
+			data, err = cfg.Export(*blobs == "inline")
+		default:
+			err = fmt.Errorf("unknown --blobs %q (want inline or ref)", *blobs)
+		}
+		out = string(data)
+	case "subset":
+		var data []byte
+		var warnings []string
+		data, warnings, err = export.Subset(cfg, export.Selection{
+			Groups:  splitList(*groups),
+			Tags:    splitList(*tags),
+			Targets: splitList(*targets),
+		})
+		for _, warning := range warnings {
+			slog.Warn(warning)
+		}
+		out = string(data)
+	default:
+		err = fmt.Errorf("unknown export %q", kind)
+	}
+	if err != nil {
+		fatal("Export failed", err)
+	}
+
+	if *output == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(out), 0644); err != nil {
+		fatal("Failed to write export", err)
+	}
+	slog.Info("Wrote export", "file", *output)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// runFsck handles `mcpify fsck [--fix] [flags]`, checking that every
+// reference in the config resolves. With --fix, the fixable problems are
+// repaired after the config is backed up to <config>.bak. It exits
+// non-zero while errors remain.
+func runFsck(args []string) {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	configPath := fs.String("config", "", "Custom config file path")
+	fix := fs.Bool("fix", false, "Repair the fixable problems, keeping the config as it was in <config>.bak")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	path := *configPath
+	if path == "" {
+		var err error
+		if path, err = config.GetConfigPath(); err != nil {
+			fatal("Failed to locate config", err)
+		}
+	}
+	cfg, err := config.LoadConfig(path)
+	var corrupt *config.ErrConfigCorrupt
+	if errors.As(err, &corrupt) {
+		report := config.CheckReport{CheckedAt: time.Now(), Problems: []config.Problem{{
+			Severity: config.SeverityFatal,
+			Kind:     "corrupt_config",
+			Subject:  path,
+			Message:  fmt.Sprintf("%v; restore it from %s.bak or a backup", corrupt.Cause, path),
+		}}}
+		printCheckReport(report, *asJSON)
+		os.Exit(exitConfig)
+	}
+	if err != nil {
+		fatal("Failed to load config", err)
+	}
+
+	var report config.CheckReport
+	if *fix {
+		report = repairConfig(cfg)
+	} else {
+		report = config.CheckReport{CheckedAt: time.Now(), Problems: cfg.Check()}
+	}
+	printCheckReport(report, *asJSON)
+	for _, problem := range report.Problems {
+		if problem.Severity != config.SeverityWarning {
+			os.Exit(exitError)
+		}
+	}
+}
+
+// repairConfig checks cfg and, when anything is fixable, backs it up to
+// <config>.bak, repairs it and saves it. Problems left are in Problems and
+// the repaired ones in Fixed. Nothing is repaired when the backup fails.
+func repairConfig(cfg *config.Config) config.CheckReport {
+	report := config.CheckReport{CheckedAt: time.Now(), Problems: cfg.Check()}
+	fixable := false
+	for _, problem := range report.Problems {
+		fixable = fixable || problem.Fixable
+	}
+	if !fixable {
+		return report
+	}
+
+	if err := backupConfig(cfg.Path); err != nil {
+		slog.Warn("Not repairing the config, it couldn't be backed up", "error", err)
+		return report
+	}
+	report.Fixed = cfg.Repair()
+	report.Problems = cfg.Check()
+	if err := cfg.Save(cfg.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+	return report
+}
+
+// backupConfig copies the config at path to <path>.bak.
+func backupConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+".bak", data, 0644)
+}
+
+func printCheckReport(report config.CheckReport, asJSON bool) {
+	if asJSON {
+		out, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+	for _, problem := range report.Fixed {
+		fmt.Printf("fixed %s\n", problem)
+	}
+	for _, problem := range report.Problems {
+		line := problem.String()
+		if problem.Fixable {
+			line += " (fixable with --fix)"
+		}
+		fmt.Println(line)
+	}
+	if len(report.Problems) == 0 {
+		fmt.Println("No problems found")
+	}
+}
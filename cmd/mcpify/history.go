@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// historyValueWidth is how much of a changed value `show --history`
+// prints; the full values are in the config and at
+// /api/tools/{name}/history.
+const historyValueWidth = 60
+
+// runShow handles `mcpify show TOOL [--history] [--config FILE]`.
+func runShow(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fatalf("Usage: mcpify show TOOL [--history] [--config FILE]")
+	}
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	configPath := fs.String("config", "", "Custom config file path")
+	history := fs.Bool("history", false, "Also show the revisions of the tool's definition")
+	fs.Parse(args[1:])
+
+	cfg := loadConfig(*configPath)
+	tool := cfg.LookupTool(args[0])
+	if tool == nil {
+		fatal("Failed to show tool", fmt.Errorf("%w: %q", config.ErrToolNotFound, args[0]))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "name:\t%s\n", tool.Name)
+	fmt.Fprintf(w, "id:\t%s\n", tool.ID)
+	fmt.Fprintf(w, "endpoint:\t%s %s\n", tool.Method, tool.URL)
+	fmt.Fprintf(w, "description:\t%s\n", tool.Description)
+	fmt.Fprintf(w, "captured:\t%s\n", tool.Provenance)
+	if tool.BodyUnavailable() {
+		fmt.Fprintf(w, "body:\tunavailable (blob %s is missing)\n", tool.BodyRef)
+	}
+	if tool.Response != nil && tool.Response.BodyUnavailable() {
+		fmt.Fprintf(w, "response example:\tunavailable (blob %s is missing)\n", tool.Response.BodyRef)
+	}
+	w.Flush()
+	if !*history {
+		return
+	}
+
+	fmt.Println()
+	if len(tool.History) == 0 {
+		fmt.Println("No history recorded")
+		return
+	}
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, rev := range tool.History {
+		fmt.Fprintf(w, "rev %d\t%s\t%s\n", rev.Rev, rev.At.Local().Format(time.DateTime), rev.Source)
+		for _, change := range rev.Changes {
+			fmt.Fprintf(w, "\t%s:\t%s -> %s\n", change.Field,
+				truncate(string(change.Old), historyValueWidth), truncate(string(change.New), historyValueWidth))
+		}
+	}
+	w.Flush()
+}
+
+// runRevert handles `mcpify revert TOOL --to REV [--config FILE]`. The
+// config as it was is kept next to it as <config>.bak.
+func runRevert(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fatalf("Usage: mcpify revert TOOL --to REV [--config FILE]")
+	}
+	fs := flag.NewFlagSet("revert", flag.ExitOnError)
+	configPath := fs.String("config", "", "Custom config file path")
+	to := fs.Int("to", 0, "Revision to restore, as listed by `mcpify show TOOL --history`")
+	fs.Parse(args[1:])
+	if *to <= 0 {
+		fatalf("Usage: mcpify revert TOOL --to REV [--config FILE]")
+	}
+
+	cfg := loadConfig(*configPath)
+	tool, _, err := cfg.RevertTool(args[0], *to)
+	if err != nil {
+		fatal("Failed to revert tool", err)
+	}
+
+	if err := backupConfig(cfg.Path); err != nil {
+		fatal("Failed to back up config", err)
+	}
+	if err := cfg.Save(cfg.Path); err != nil {
+		fatal("Failed to save config", err)
+	}
+	if _, err := cfg.CollectBlobs(); err != nil {
+		slog.Warn("Failed to delete unreferenced blobs", "error", err)
+	}
+	fmt.Printf("Reverted %s to revision %d (previous config saved to %s.bak)\n", tool.Name, *to, cfg.Path)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NilayYadav/mcpify/internal/session"
+)
+
+// runInspect handles `mcpify inspect FILE [flags]`, browsing a session
+// file written with --session-file.
+func runInspect(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fatalf("Usage: mcpify inspect SESSION.jsonl [--method M] [--path TEXT] [--status CODE] [--summary | --show N]")
+	}
+	fs := flag.NewFlagSet("inspect", flag.ExitOnError)
+	method := fs.String("method", "", "Only exchanges with this method")
+	path := fs.String("path", "", "Only exchanges whose path contains this")
+	status := fs.String("status", "", "Only exchanges with this status, e.g. 404 or 5xx; 'none' for ones without a response")
+	summary := fs.Bool("summary", false, "Count the exchanges of each endpoint by status instead of listing them")
+	show := fs.Int("show", 0, "Print the Nth exchange listed in full, request and response")
+	fs.Parse(args[1:])
+
+	entries, err := readSession(args[0])
+	if err != nil {
+		fatal("Failed to read session file", err)
+	}
+
+	var matched []session.Entry
+	for _, e := range entries {
+		if (*method == "" || strings.EqualFold(e.Method, *method)) &&
+			strings.Contains(e.Path, *path) && statusMatches(e.Response, *status) {
+			matched = append(matched, e)
+		}
+	}
+
+	switch {
+	case *show != 0:
+		if *show < 0 || *show > len(matched) {
+			fatalf("No exchange %d; %d are listed", *show, len(matched))
+		}
+		out, _ := json.MarshalIndent(matched[*show-1], "", "  ")
+		fmt.Println(string(out))
+	case *summary:
+		printSessionSummary(matched)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for i, e := range matched {
+			code, elapsed := "-", "-"
+			if e.Response != nil {
+				code = strconv.Itoa(e.Response.Status)
+				elapsed = fmt.Sprintf("%dms", e.Response.Elapsed)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, e.Time.Local().Format("15:04:05.000"), e.Method, e.Path, code, elapsed)
+		}
+		w.Flush()
+	}
+}
+
+// readSession reads the session file at path, logging how many lines
+// couldn't be parsed.
+func readSession(path string) ([]session.Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	entries, skipped, err := session.Read(file)
+	if skipped > 0 {
+		slog.Warn("Skipped session file lines that couldn't be parsed", "file", path, "lines", skipped)
+	}
+	if entries == nil && err == nil {
+		entries = []session.Entry{}
+	}
+	return entries, err
+}
+
+// statusMatches reports whether resp has status: a code, a class such as
+// 4xx, "none" for no response, or "" for any.
+func statusMatches(resp *session.Response, status string) bool {
+	switch {
+	case status == "":
+		return true
+	case resp == nil:
+		return status == "none"
+	case len(status) == 3 && strings.HasSuffix(strings.ToLower(status), "xx"):
+		return strconv.Itoa(resp.Status/100) == status[:1]
+	}
+	return strconv.Itoa(resp.Status) == status
+}
+
+// printSessionSummary prints each endpoint with how many of its exchanges
+// got each status, the busiest first.
+func printSessionSummary(entries []session.Entry) {
+	counts := make(map[string]map[string]int)
+	for _, e := range entries {
+		if counts[e.Endpoint] == nil {
+			counts[e.Endpoint] = make(map[string]int)
+		}
+		code := "none"
+		if e.Response != nil {
+			code = strconv.Itoa(e.Response.Status)
+		}
+		counts[e.Endpoint][code]++
+	}
+	total := func(endpoint string) int {
+		n := 0
+		for _, c := range counts[endpoint] {
+			n += c
+		}
+		return n
+	}
+	endpoints := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		if ta, tb := total(a), total(b); ta != tb {
+			return tb - ta
+		}
+		return strings.Compare(a, b)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, endpoint := range endpoints {
+		var statuses []string
+		for _, code := range slices.Sorted(maps.Keys(counts[endpoint])) {
+			statuses = append(statuses, fmt.Sprintf("%s×%d", code, counts[endpoint][code]))
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\n", endpoint, total(endpoint), strings.Join(statuses, " "))
+	}
+	w.Flush()
+}
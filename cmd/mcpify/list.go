@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// runList handles `mcpify list [flags]`, printing the tools saved in the
+// config.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	configPath := fs.String("config", "", "Custom config file path")
+	long := fs.Bool("long", false, "Also show where each tool's stored request was captured and its headers")
+	fs.Parse(args)
+
+	tools := loadConfig(*configPath).ListTools()
+	slices.SortFunc(tools, func(a, b *config.Tool) int { return strings.Compare(a.Name, b.Name) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, tool := range tools {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", tool.Name, tool.Method, tool.URL)
+		if !*long {
+			continue
+		}
+		fmt.Fprintf(w, "\tcaptured:\t%s\n", tool.Provenance)
+		if tool.Provenance != nil && tool.Provenance.NamedBy != "" {
+			fmt.Fprintf(w, "\tnamed by:\t%s\n", tool.Provenance.NamedBy)
+		}
+		if len(tool.Headers) > 0 {
+			names := make([]string, 0, len(tool.Headers))
+			for name := range tool.Headers {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			fmt.Fprintf(w, "\theaders:\t%s\n", strings.Join(names, ", "))
+		}
+	}
+	w.Flush()
+}
@@ -2,139 +2,920 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/NilayYadav/mcpify/internal/approval"
 	"github.com/NilayYadav/mcpify/internal/capture"
+	"github.com/NilayYadav/mcpify/internal/chaos"
 	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	"github.com/NilayYadav/mcpify/internal/events"
+	"github.com/NilayYadav/mcpify/internal/export"
+	"github.com/NilayYadav/mcpify/internal/grouping"
+	llmhealth "github.com/NilayYadav/mcpify/internal/llm"
+	"github.com/NilayYadav/mcpify/internal/logging"
+	"github.com/NilayYadav/mcpify/internal/metrics"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/prompts"
+	"github.com/NilayYadav/mcpify/internal/redact"
+	"github.com/NilayYadav/mcpify/internal/replica"
 	"github.com/NilayYadav/mcpify/internal/server"
+	"github.com/NilayYadav/mcpify/internal/session"
+	"github.com/NilayYadav/mcpify/internal/utils"
+	"github.com/NilayYadav/mcpify/internal/webhook"
+	"github.com/NilayYadav/mcpify/internal/workflow"
 )
 
+// errApprovalWithoutToken is returned for --approval-mode manual without
+// --admin-token, which would let anyone who reaches the port approve calls.
+var errApprovalWithoutToken = errors.New("--approval-mode manual requires --admin-token")
+
 var mcpServer interface {
-	RegisterTool(name string, method, url string, headers map[string]string, body []byte, description string) error
+	RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error
 	Start(ctx context.Context, addr string) error
+	ServeStdio(ctx context.Context) error
+	AddDebugInfo(key string, fn func() any)
+	Handle(pattern string, handler http.Handler)
+	SetWorkflows(m *workflow.Miner)
+	SetObservedValues(t *observed.Tracker)
+	VerifyTools(ctx context.Context)
+	SetChaos(c *chaos.Chaos)
+	SetApprovals(g *approval.Gate)
+	SetRetryAfterMax(d time.Duration)
+	SetRequestTimeout(d time.Duration)
+	SetRetries(n int)
+	SetKeepAlive(on bool)
+	SetReadOnly(on bool)
+	SetConfirmRules(rules []server.ConfirmRule)
+	SetEviction(policy server.Eviction)
+	SetCoverage(t *coverage.Tracker)
+	SetMetrics(m *metrics.Metrics)
+	SetEvents(b *events.Bus)
+	SetResultMeta(on bool)
+	SetMaxResponseSize(n int64)
+	SetStructuredResults(on bool)
+	SetBinaryMode(mode server.BinaryMode)
+	RemoveBinaryFiles() error
+	SetPreserveUserAgent(on bool)
+	SetReplicas(p *replica.Pool)
+	SetAuthQuery(params map[string]string)
+	ToolChanged(tool *config.Tool, oldName string)
+	RemoveTool(name string) error
 }
 
 func main() {
 	var (
-		target     = flag.String("target", "", "Target server URL to observe (required)")
-		mcpPort    = flag.String("mcp-port", "8081", "MCP server port")
-		verbose    = flag.Bool("verbose", false, "Enable verbose logging")
-		maxTools   = flag.Int("max-tools", 100, "Maximum number of tools to capture")
-		useLLM     = flag.Bool("use-llm", false, "Enable LLM for tool name generation")
-		mcpName    = flag.String("mcp-name", "mcpify", "Name of the MCP server")
-		configPath = flag.String("config", "", "Custom config file path")
-		grouping   = flag.Bool("grouping", false, "Enable intelligent grouping of endpoints using LLM")
+		target        = flag.String("target", "", "Target server URL to observe (required)")
+		mcpPort       = flag.String("mcp-port", "8081", "MCP server port")
+		verbose       = flag.Bool("verbose", false, "Deprecated: same as --log-level debug")
+		logLevel      = flag.String("log-level", "info", "Log level: debug, info, warn or error (debug adds --capture-verbosity 1)")
+		logFormat     = flag.String("log-format", "text", "Log format: text (key=value) or json (one object per line)")
+		captureLevel  = flag.Int("capture-verbosity", 0, "Capture diagnostics: 1 endpoint events, 2 request summaries with timing, 3 parse failures with payloads")
+		v1            = flag.Bool("v", false, "Same as --capture-verbosity 1")
+		v2            = flag.Bool("vv", false, "Same as --capture-verbosity 2")
+		v3            = flag.Bool("vvv", false, "Same as --capture-verbosity 3")
+		maxTools      = flag.Int("max-tools", 100, "Maximum number of tools to capture")
+		maxResponse   = flag.String("max-response-size", "100KB", "Largest response body a tool result includes, e.g. 100KB or 2MB; longer ones are cut off with a notice (0 includes everything)")
+		eviction      = flag.String("eviction", "reject", "What happens to a new endpoint at --max-tools: reject it, or evict the lru (least recently used) or fifo (oldest) tool")
+		useLLM        = flag.Bool("use-llm", false, "Enable LLM for tool name generation")
+		mcpName       = flag.String("mcp-name", "mcpify", "Name of the MCP server")
+		configPath    = flag.String("config", "", "Custom config file path")
+		storeSpec     = flag.String("store", config.StoreJSON, "Where the config is kept: json (the --config file) or sqlite:PATH, a SQLite database that saves only what changed; a new database imports the JSON config")
+		useGrouping   = flag.Bool("grouping", false, "Enable intelligent grouping of endpoints using LLM")
+		groupingMode  = flag.String("grouping-mode", "llm", "How endpoints are grouped: llm, or heuristic (by path prefix, nothing is sent to an LLM; implies --grouping)")
+		hybrid        = flag.Bool("hybrid", false, "Serve grouped and individual tools together and let each session pick its view")
+		selfTest      = flag.Bool("self-test", false, "Verify the capture pipeline at startup with one internal request to the target")
+		adminToken    = flag.String("admin-token", os.Getenv("MCPIFY_ADMIN_TOKEN"), "Bearer token required by admin and ingestion endpoints")
+		verify        = flag.Bool("verify-on-start", false, "Probe saved tools against the target and hide the ones it doesn't serve")
+		chaosSpec     = flag.String("chaos", "", "Inject faults into tool calls, e.g. 'error_rate=0.2,latency=500ms±300ms,status=503,seed=1'")
+		secretEntropy = flag.Float64("secret-entropy", redact.DefaultEntropy, "Entropy (bits/char) above which captured values are redacted as secrets")
+		secretAllow   = flag.String("secret-allow", "", "Comma-separated header/field names never redacted by secret detection")
+		sensitiveQs   = flag.String("sensitive-params", strings.Join(redact.DefaultSensitiveParams, ","), "Comma-separated query parameters whose values are never stored, e.g. API keys")
+		authQuery     = flag.String("auth-query", "", "Query parameters sent with every tool call, e.g. 'api_key=${secret:API_KEY}' (read from the API_KEY environment variable)")
+		observedTTL   = flag.Duration("observed-ttl", observed.DefaultTTL, "How long observed parameter values are remembered")
+		noObserve     = flag.String("no-observe", "", "Comma-separated parameter names whose values are never recorded")
+		toolView      = flag.String("tool-view", "", "Default tool view for sessions in hybrid mode (individual, grouped, both)")
+		captureMode   = flag.String("mode", "", "Capture mode: pcap (default, needs root) or proxy (saved in config)")
+		proxyPort     = flag.String("proxy-port", "8082", "Port of the capture proxy in proxy mode")
+		captureIface  = flag.String("interface", "", "Network interface to capture packets on, or 'any' (Linux); default loopback (saved in config)")
+		bpfFilter     = flag.String("bpf", "", "Packet filter used verbatim instead of the generated 'tcp port <target port>'")
+		noDefIgnores  = flag.Bool("no-default-ignores", false, "Also capture static assets like *.js, *.css, *.png and /favicon.ico (saved in config)")
+		extraPorts    = flag.String("extra-ports", "", "Comma-separated ports on the target host also captured, e.g. '3001,3002'")
+		tlsCert       = flag.String("tls-cert", "", "Certificate the capture proxy serves HTTPS with (default: self-signed, kept next to the config)")
+		tlsKey        = flag.String("tls-key", "", "Private key for --tls-cert")
+		approvalMode  = flag.String("approval-mode", "off", "Approval for --approval-methods and dangerous-tagged tool calls (off, manual)")
+		approvalWait  = flag.Duration("approval-timeout", approval.DefaultTimeout, "How long a call waits for approval before it is blocked")
+		approvalVerbs = flag.String("approval-methods", strings.Join(approval.DefaultMethods, ","), "Comma-separated methods whose calls need approval in manual mode, e.g. DELETE,MOVE")
+		noLLMCheck    = flag.Bool("no-llm-check", false, "Skip the LLM provider check at startup")
+		noUpdateCheck = flag.Bool("no-update-check", false, "Don't check GitHub once a day for a newer mcpify (or set "+noUpdateCheckEnv+")")
+		templateDates = flag.Bool("template-dates", false, "Treat date path segments (2024-01-01) as parameters instead of separate endpoints")
+		templateSlugs = flag.Bool("template-slugs", false, "Treat mixed letter-digit path segments (a1b2c3) as parameters instead of separate endpoints")
+		profileName   = flag.String("profile", "", "Named settings profile from the config; explicit flags override it")
+		transport     = flag.String("transport", "sse", "MCP transport: sse (HTTP on --mcp-port) or stdio")
+		resultMeta    = flag.Bool("result-meta", true, "Add latency, size and rate-limit metadata to tool results")
+		binaryMode    = flag.String("binary-mode", "resource", "How tool results carry binary bodies such as images and PDFs: resource (base64 content), file (saved to a temporary file, removed on shutdown) or summary")
+		structured    = flag.Bool("structured-results", true, "Return JSON responses as structured content and mark 4xx and 5xx results as errors (false keeps the plain-text results of earlier versions)")
+		reqTimeout    = flag.Duration("request-timeout", server.DefaultRequestTimeout, "How long a tool call may take, unless its tool sets a timeout (0 is unbounded)")
+		retries       = flag.Int("retries", server.DefaultRetries, "How often a GET, PUT or DELETE tool call failing with a connection error or a 502, 503 or 504 is resent, with exponential backoff (0 sends every call once)")
+		confirmCalls  = flag.String("confirm", server.DefaultConfirm, "Comma-separated METHOD or 'METHOD /path' rules for tool calls that must pass \"confirm\": true, e.g. 'DELETE,POST */purge' (* matches anything; '' turns it off)")
+		readOnly      = flag.Bool("read-only", false, "Refuse tool calls with methods other than GET and HEAD; such endpoints are still captured (allow_execute on a tool overrides it)")
+		keepAlive     = flag.Bool("keep-alive", true, "Reuse connections to the target across tool calls (false opens one per call, for targets that mishandle reused connections)")
+		serveMetrics  = flag.Bool("metrics", false, "Serve Prometheus metrics at /metrics on --mcp-port")
+		sessionFile   = flag.String("session-file", "", "Append every captured request and its response, redacted, to this JSON-lines file; read it with `mcpify inspect`")
+		refreshNames  = flag.Bool("refresh-names", false, "Name every endpoint with the LLM again instead of reusing the names cached in the config, and replace them")
+		discoveryHook = flag.String("discovery-webhook", "", "URL to POST a JSON notice to whenever capture registers a tool for a new endpoint (saved in the config)")
+		retryAfterMax = flag.Duration("retry-after-max", server.DefaultRetryAfterMax, "Longest Retry-After or rate-limit reset a tool call waits out before retrying; longer ones are returned to the agent (0 never waits)")
+		serveOnly     = flag.Bool("serve-only", false, "Serve the tools saved in the config without capturing; needs no target and no root")
+		captureOnly   = flag.Bool("capture-only", false, "Capture endpoints into the config without starting the MCP server, e.g. in CI")
+		pcapFile      = flag.String("pcap-file", "", "Seed tools from a recorded .pcap file instead of capturing live traffic")
+		replicaList   = flag.String("replicas", "", "Comma-separated base URLs of other instances of the target, each optionally =weight, e.g. 'http://localhost:3002,http://localhost:3003=2'")
+		replicaMode   = flag.String("replica-strategy", "", "How tool calls pick a replica: round-robin (default) or weighted")
+		stickyReplica = flag.Bool("sticky-replicas", false, "Keep each MCP session on one replica while it is up")
+		importSpec    = flag.String("import-openapi", "", "Register a tool for each operation in an OpenAPI 3 JSON document (file path or URL)")
+		promptDir     = flag.String("prompt-dir", "", "Directory with naming.tmpl and grouping.tmpl overriding the built-in LLM prompts")
+		diskBudget    = flag.String("disk-budget", "256MB", "Disk space the files mcpify keeps next to the config may use before the oldest are deleted (0 disables cleanup)")
+		diskCaps      = flag.String("disk-caps", "", "Comma-separated per-category disk caps, e.g. 'backups=20MB,cache=100MB'")
+		keepAuth      = flag.Bool("keep-auth", false, "Store sensitive headers as ${secret:NAME} references resolved from the environment at call time instead of leaving them out")
+		preserveUA    = flag.Bool("preserve-user-agent", false, "Send the captured User-Agent with tool calls instead of identifying as mcpify")
 	)
-	flag.Parse()
 
-	var finalConfigPath string
-	if *configPath != "" {
-		finalConfigPath = *configPath
-	} else {
-		finalConfigPath = config.GetConfigPath()
+	var ignorePaths, includePaths pathList
+	flag.Var(&ignorePaths, "ignore-path", "Path pattern whose requests never become tools, e.g. '/health' or '/static/**' or 're:^/v[0-9]+/metrics'; repeatable (saved in config)")
+	flag.Var(&includePaths, "include-path", "Path pattern requests must match to become tools, e.g. '/api/**'; repeatable (saved in config)")
+
+	// Subcommands come after the flags so `profiles show` can list them
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "list":
+			runList(os.Args[2:])
+			return
+		case "show":
+			runShow(os.Args[2:])
+			return
+		case "revert":
+			runRevert(os.Args[2:])
+			return
+		case "compare":
+			runCompare(os.Args[2:])
+			return
+		case "prompts":
+			runPrompts(os.Args[2:])
+			return
+		case "status":
+			runStatus(os.Args[2:])
+			return
+		case "coverage":
+			runCoverage(os.Args[2:])
+			return
+		case "profiles":
+			runProfiles(os.Args[2:])
+			return
+		case "doctor":
+			runDoctor(os.Args[2:])
+			return
+		case "fsck":
+			runFsck(os.Args[2:])
+			return
+		case "inspect":
+			runInspect(os.Args[2:])
+			return
+		case "self-update":
+			runSelfUpdate(os.Args[2:])
+			return
+		case "version":
+			fmt.Println("mcpify " + config.Version)
+			return
+		case "serve":
+			// `mcpify serve [flags]` is `mcpify --serve-only [flags]`
+			os.Args = slices.Delete(os.Args, 1, 2)
+			*serveOnly = true
+		}
 	}
 
-	log.Printf("Using config file: %s", finalConfigPath)
+	flag.Parse()
 
-	cfg, err := config.LoadConfig(finalConfigPath)
+	level, err := logging.ParseLevel(*logLevel)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Invalid --log-level", err)
+	}
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	if *captureLevel > 0 || *v1 || *v2 || *v3 {
+		// Capture diagnostics are logged at debug level
+		level = min(level, slog.LevelDebug)
+	}
+	// Logs go to stderr; with stdio, stdout carries the protocol
+	if err := logging.Setup(os.Stderr, level, *logFormat); err != nil {
+		fatal("Invalid --log-format", err)
 	}
 
-	targetURL := *target
-	if targetURL == "" && cfg.LastTarget != "" {
-		targetURL = cfg.LastTarget
-		log.Printf("Using saved target: %s", targetURL)
+	if *serveOnly && *captureOnly {
+		fatalf("--serve-only and --capture-only can't be combined; capture in one run and serve the config in another")
+	}
+	if *serveOnly && *pcapFile != "" {
+		fatalf("--serve-only and --pcap-file can't be combined; replay the file with --capture-only, then serve the config")
+	}
+
+	stdio := *transport == "stdio"
+	if !stdio && *transport != "sse" {
+		fatalf("Invalid transport %q (want sse or stdio)", *transport)
+	}
+	if *captureLevel < 0 {
+		fatalf("Invalid --capture-verbosity %d (want 0 to %d)", *captureLevel, capture.MaxVerbosity)
 	}
+	verbosity := capture.Verbosity(*captureLevel)
+	for v, set := range []bool{level == slog.LevelDebug || *v1, *v2, *v3} {
+		if set {
+			verbosity = max(verbosity, capture.Verbosity(v+1))
+		}
+	}
+
+	cfg := loadStore(*configPath, *storeSpec)
+	finalConfigPath := cfg.Path
+	slog.Info("Using config file", "path", finalConfigPath)
 
-	if targetURL == "" {
-		log.Fatal("Target server URL required. Usage: mcpify --target http://localhost:3000")
+	// Safe problems heal themselves; the rest are logged and left to
+	// `mcpify fsck`
+	configCheck := repairConfig(cfg)
+	for _, problem := range configCheck.Fixed {
+		slog.Info("Repaired config", "problem", problem)
+	}
+	for _, problem := range configCheck.Problems {
+		slog.Warn("Config problem; see mcpify fsck", "problem", problem)
 	}
 
-	// Update config if new target provided
-	if *target != "" && *target != cfg.LastTarget {
-		cfg.LastTarget = *target
+	var profileSettings map[string]string
+	if *profileName != "" {
+		var err error
+		if profileSettings, err = applyProfile(flag.CommandLine, cfg, *profileName); err != nil {
+			fatal("Invalid profile", err)
+		}
+		slog.Info("Using profile", "profile", *profileName, "settings", formatSettings(profileSettings))
+	}
+
+	// Of the settings the config keeps, explicit flags are saved and the
+	// rest come from the config
+	savedValues, settingsChanged := applySavedSettings(flag.CommandLine, cfg, profileSettings)
+	if len(savedValues) > 0 {
+		slog.Info("Using saved settings", "settings", formatSettings(savedValues))
+	}
+	if settingsChanged {
 		cfg.Save(finalConfigPath)
 	}
 
-	parsedURL, err := url.Parse(targetURL)
+	if !*noUpdateCheck {
+		checkForUpdate(cfg)
+	}
+
+	switch *groupingMode {
+	case "llm":
+	case "heuristic":
+		if !*hybrid {
+			*useGrouping = true
+		}
+	default:
+		fatalf("Invalid grouping mode %q (want llm or heuristic)", *groupingMode)
+	}
+	llmGrouping := (*useGrouping || *hybrid) && *groupingMode == "llm"
+	evictionPolicy, err := server.ParseEviction(*eviction)
+	if err != nil {
+		fatal("Invalid eviction policy", err)
+	}
+	maxResponseSize, err := diskbudget.ParseSize(*maxResponse)
+	if err != nil {
+		fatal("Invalid --max-response-size", err)
+	}
+	var notifier *webhook.Notifier
+	if *discoveryHook != "" {
+		hookURL, err := webhook.ParseURL(*discoveryHook)
+		if err != nil {
+			fatal("Invalid --discovery-webhook", err)
+		}
+		notifier = webhook.New(hookURL, webhook.DefaultPerMinute)
+	}
+	binaryResults, err := server.ParseBinaryMode(*binaryMode)
+	if err != nil {
+		fatal("Invalid binary mode", err)
+	}
+	confirmRules, err := server.ParseConfirmRules(*confirmCalls)
 	if err != nil {
-		log.Fatalf("Invalid target URL: %v", err)
+		fatal("Invalid --confirm", err)
 	}
 
-	if err := checkTargetServer(targetURL); err != nil {
-		log.Fatalf("Target server check failed: %v", err)
+	targetURL := *target
+	if targetURL == "" && cfg.LastTarget != "" {
+		targetURL = cfg.LastTarget
+		if !*serveOnly {
+			slog.Info("Using saved target", "target", targetURL)
+		}
+	}
+
+	// Each target has its own tools; only this one's are served and
+	// captured into
+	if targetURL != "" {
+		if switched, err := cfg.SelectTarget(targetURL); err != nil {
+			fatal("Failed to load the tools of "+targetURL, err)
+		} else if switched {
+			slog.Info("Using the tools of the target", "target", cfg.ActiveTarget(), "tools", len(cfg.ListTools()))
+		}
+	}
+
+	var httpsTarget bool
+	var mode string
+	parsedURL := &url.URL{}
+	if *serveOnly {
+		// Saved tools carry their own URLs; nothing is captured
+		slog.Info("Serving saved tools without capture", "tools", len(cfg.ListTools()))
+	} else {
+		if targetURL == "" {
+			fatalf("Target server URL required. Usage: mcpify --target http://localhost:3000")
+		}
+
+		// Update config if new target provided; profiles never change the
+		// saved settings
+		_, profileTarget := profileSettings["target"]
+		if *target != "" && *target != cfg.LastTarget && !profileTarget {
+			cfg.LastTarget = *target
+			cfg.Save(finalConfigPath)
+		}
+
+		httpsTarget = strings.HasPrefix(strings.ToLower(targetURL), "https://")
+		mode = *captureMode
+		if mode == "" {
+			mode = cfg.CaptureMode
+		}
+		if *pcapFile != "" {
+			if *captureMode == "proxy" {
+				fatalf("--pcap-file replays packets; it can't be combined with --mode proxy")
+			}
+			mode = "pcap"
+		}
+		if mode == "" {
+			mode = "pcap"
+			// Packet capture only ever sees ciphertext for HTTPS targets
+			if httpsTarget {
+				mode = "proxy"
+			}
+		}
+		if mode != "pcap" && mode != "proxy" {
+			fatalf("Invalid capture mode %q (want pcap or proxy)", mode)
+		}
+		if mode == "pcap" && httpsTarget {
+			fatal("Cannot capture "+targetURL, &capture.ErrCaptureUnsupported{Reason: "TLS traffic can't be read from packets; use --mode proxy"})
+		}
+
+		if _, profileMode := profileSettings["mode"]; *captureMode != "" && *captureMode != cfg.CaptureMode && !profileMode {
+			cfg.CaptureMode = *captureMode
+			cfg.Save(finalConfigPath)
+		}
+		if mode == "pcap" && *bpfFilter != "" {
+			if err := capture.ValidateBPFFilter(*bpfFilter); err != nil {
+				fatal("Invalid --bpf", err)
+			}
+		}
+		if mode == "proxy" && (*bpfFilter != "" || *extraPorts != "") {
+			slog.Warn("--bpf and --extra-ports only apply to pcap mode; ignoring them")
+		}
+
+		if _, profileIface := profileSettings["interface"]; *captureIface != "" && *captureIface != cfg.Interface && !profileIface {
+			cfg.Interface = *captureIface
+			cfg.Save(finalConfigPath)
+		}
+
+		var err error
+		if parsedURL, err = url.Parse(targetURL); err != nil {
+			fatalf("Invalid target URL: %v", err)
+		}
+
+		// A recording can outlive the server it was made against
+		if *pcapFile == "" {
+			if err := checkTargetServer(targetURL); err != nil {
+				fatalf("Target server check failed: %v", err)
+			}
+		}
 	}
 
 	llm := os.Getenv("LLM")
 	llmEndpoint := os.Getenv("LLM_ENDPOINT")
 	llmKey := os.Getenv("LLM_API_KEY")
 
-	if *useLLM || *grouping {
+	var llmHealth *llmhealth.Breaker
+	var llmLimiter *llmhealth.Limiter
+	if *useLLM || llmGrouping {
 		if llm == "" {
-			log.Fatal(`LLM model required when using LLM or grouping. Set the LLM environment variable: export LLM="your-llm-model"`)
+			fatalf(`LLM model required when using LLM or grouping. Set the LLM environment variable: export LLM="your-llm-model"`)
 		}
 
 		if llmEndpoint == "" {
-			log.Fatal(`LLM endpoint required when using LLM or grouping. Set the LLM_ENDPOINT environment variable: export LLM_ENDPOINT="https://your-llm-provider-endpoint"`)
+			fatalf(`LLM endpoint required when using LLM or grouping. Set the LLM_ENDPOINT environment variable: export LLM_ENDPOINT="https://your-llm-provider-endpoint"`)
 		}
 
 		if llmKey == "" {
-			log.Fatal(`LLM API key required when using LLM or grouping . Set the LLM_API_KEY environment variable: export LLM_API_KEY="your-api-key-here"`)
+			fatalf(`LLM API key required when using LLM or grouping. Set the LLM_API_KEY environment variable: export LLM_API_KEY="your-api-key-here"`)
+		}
+
+		slog.Info("Using LLM", "model", llm, "endpoint", llmEndpoint)
+
+		llmHealth = llmhealth.NewBreaker(llmEndpoint, llm)
+		llmLimiter = llmhealth.NewLimiter(llmhealth.DefaultConcurrency, llmhealth.DefaultBackgroundInterval)
+		if *noLLMCheck {
+			slog.Info("Skipping LLM provider check")
+		} else {
+			checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := llmhealth.Check(checkCtx, llmEndpoint, llmKey, llm); err != nil {
+				llmHealth.Trip(err)
+			} else {
+				llmHealth.Success()
+				slog.Info("LLM provider check passed")
+			}
+			cancel()
 		}
+	}
 
-		log.Printf("Using LLM model: %s", llm)
-		log.Printf("Using LLM endpoint: %s", llmEndpoint)
+	secrets := redact.NewDetector()
+	secrets.Entropy = *secretEntropy
+	if *secretAllow != "" {
+		secrets.Allow = strings.Split(*secretAllow, ",")
+	}
+	secrets.SensitiveParams = nil
+	if *sensitiveQs != "" {
+		secrets.SensitiveParams = strings.Split(*sensitiveQs, ",")
+	}
+	// Values sent from --auth-query are never stored either
+	authParams := authQueryParams(cfg, *authQuery)
+	for name := range authParams {
+		secrets.SensitiveParams = append(secrets.SensitiveParams, name)
+	}
+	if n := cfg.RedactQueryParams(secrets.SensitiveParam); n > 0 {
+		slog.Info("Redacted stored sensitive query parameter values", "tools", n)
+		if err := cfg.Save(finalConfigPath); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+	if cfg.SensitiveFields != nil {
+		secrets.SensitiveFields = cfg.SensitiveFields
+	}
+	if n := cfg.RedactBodies(secrets.Fields); n > 0 {
+		slog.Info("Redacted stored sensitive body fields", "tools", n)
+		if err := cfg.Save(finalConfigPath); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+	if n := cfg.RedactHeaders(*keepAuth); n > 0 {
+		slog.Info("Redacted stored sensitive header values", "tools", n)
+		if err := cfg.Save(finalConfigPath); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
 	}
 
-	if *grouping {
-		log.Printf("Using LLM grouping with model: %s", llm)
-		mcpServer = server.NewGroupedMCPServer(*mcpName, "1.0.0", cfg, llmKey, llmEndpoint, llm)
+	llmPrompts, err := prompts.Load(*promptDir)
+	if err != nil {
+		fatal("Failed to load prompts", err)
+	}
+	if *useLLM || llmGrouping {
+		for _, name := range prompts.Names {
+			prompt := llmPrompts.MustGet(name)
+			if prompt.Source != "" {
+				slog.Info("Using prompt", "prompt", name, "source", prompt.Source, "ref", prompt.Ref())
+			}
+		}
+	}
+
+	var grouper grouping.Grouper
+	if llmGrouping {
+		llmGrouper := grouping.NewLLMGrouper(llmKey, llmEndpoint, llm)
+		llmGrouper.SetHealth(llmHealth)
+		llmGrouper.SetLimiter(llmLimiter)
+		llmGrouper.SetPrompts(llmPrompts)
+		llmGrouper.SetMaxTools(*maxTools)
+		grouper = llmGrouper
+	} else {
+		grouper = grouping.NewPrefixGrouper(*maxTools)
+	}
+
+	if *hybrid {
+		viewName := *toolView
+		if viewName == "" {
+			viewName = cfg.ToolView
+		}
+		defaultView, err := server.ParseToolView(viewName)
+		if err != nil {
+			fatal("Invalid tool view", err)
+		}
+		slog.Info("Using hybrid mode", "default_view", defaultView)
+		mcpServer = server.NewHybridMCPServer(*mcpName, config.Version, *maxTools, cfg, defaultView, grouper)
+	} else if *useGrouping {
+		if llmGrouping {
+			slog.Info("Using LLM grouping", "model", llm)
+		} else {
+			slog.Info("Using heuristic grouping by path prefix")
+		}
+		mcpServer = server.NewGroupedMCPServer(*mcpName, config.Version, *maxTools, cfg, grouper)
 	} else {
-		log.Printf("Using individual tool mode")
-		mcpServer = server.NewMCPServer(*mcpName, "1.0.0", *maxTools, cfg)
+		slog.Info("Using individual tool mode")
+		mcpServer = server.NewMCPServer(*mcpName, config.Version, *maxTools, cfg)
 	}
 
 	endpointCapture := capture.NewEndpointCapture(parsedURL, mcpServer, *useLLM, llmKey, llmEndpoint, llm)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	endpointCapture.SetSecretDetector(secrets)
+	endpointCapture.SetSensitiveHeaders(cfg.SensitiveHeaderNames())
+	endpointCapture.SetKeepAuth(*keepAuth)
+	if len(authParams) > 0 {
+		mcpServer.SetAuthQuery(authParams)
+	}
+	endpointCapture.SetBPFFilter(*bpfFilter)
+	includes, ignores := pathFilters(cfg, includePaths, ignorePaths, *noDefIgnores, profileSettings)
+	if err := endpointCapture.SetPathFilters(includes, ignores); err != nil {
+		fatal("Invalid path pattern", err)
+	}
+	endpointCapture.SetVerbosity(verbosity)
+	endpointCapture.SetAliases(cfg.Aliases)
+	if pool := replicaPool(cfg, targetURL, *replicaList, *replicaMode, *stickyReplica); pool != nil {
+		endpointCapture.SetReplicas(pool.Hosts())
+		mcpServer.SetReplicas(pool)
+		mcpServer.AddDebugInfo("replicas", func() any { return pool.Status() })
+	}
+	if *extraPorts != "" {
+		ports := strings.Split(*extraPorts, ",")
+		for i, port := range ports {
+			ports[i] = strings.TrimSpace(port)
+			if n, err := strconv.Atoi(ports[i]); err != nil || n < 1 || n > 65535 {
+				fatalf("Invalid port %q in --extra-ports", port)
+			}
+		}
+		endpointCapture.SetExtraPorts(ports)
+	}
+	if *captureIface != "" {
+		endpointCapture.SetInterface(*captureIface)
+	} else {
+		endpointCapture.SetInterface(cfg.Interface)
+	}
+	endpointCapture.SetLLMHealth(llmHealth)
+	endpointCapture.SetLLMLimiter(llmLimiter)
+	endpointCapture.SetPrompts(llmPrompts)
+	if llmHealth != nil {
+		mcpServer.AddDebugInfo("llm", func() any { return llmHealth.Status() })
+		mcpServer.AddDebugInfo("llm_queue", func() any { return llmLimiter.Status() })
+	}
+	endpointCapture.SetResponseHeaders(cfg.ResponseHeadersForEndpoint)
+	endpointCapture.SetPathTemplater(observed.Templater{Dates: *templateDates, Slugs: *templateSlugs})
+
+	var optOut []string
+	if *noObserve != "" {
+		optOut = strings.Split(*noObserve, ",")
+	}
+	observedValues := observed.NewTracker(*observedTTL, optOut)
+	endpointCapture.SetObservedValues(observedValues)
+	mcpServer.SetObservedValues(observedValues)
+
+	workflows := workflow.NewMiner()
+	endpointCapture.SetWorkflowMiner(workflows)
+	endpointCapture.SetDiscoveryWebhook(notifier)
+	endpointCapture.SetNameCache(cfg, *refreshNames)
+	var recorder *session.Recorder
+	if *sessionFile != "" && !*serveOnly {
+		if recorder, err = session.Open(*sessionFile); err != nil {
+			fatal("Failed to open session file", err)
+		}
+		endpointCapture.SetSessionRecorder(recorder)
+		slog.Info("Recording captured exchanges", "path", *sessionFile)
+	}
+	mcpServer.SetWorkflows(workflows)
+
+	if *profileName != "" {
+		mcpServer.AddDebugInfo("profile", func() any {
+			return map[string]any{"name": *profileName, "settings": profileSettings}
+		})
+	}
+	if !*resultMeta {
+		mcpServer.SetResultMeta(false)
+	}
+	mcpServer.SetStructuredResults(*structured)
+	mcpServer.SetBinaryMode(binaryResults)
+	mcpServer.SetPreserveUserAgent(*preserveUA)
+	mcpServer.SetRetryAfterMax(*retryAfterMax)
+	mcpServer.SetRequestTimeout(*reqTimeout)
+	mcpServer.SetRetries(*retries)
+	mcpServer.SetKeepAlive(*keepAlive)
+	mcpServer.SetConfirmRules(confirmRules)
+	if *readOnly {
+		mcpServer.SetReadOnly(true)
+		slog.Info("Read-only mode: tool calls other than GET and HEAD are refused")
+	}
+	mcpServer.SetEviction(evictionPolicy)
+	mcpServer.SetMaxResponseSize(maxResponseSize)
+
+	bus := events.NewBus(events.DefaultHistory)
+	mcpServer.SetEvents(bus)
+	endpointCapture.SetEvents(bus)
+	mcpServer.Handle("GET /api/events", utils.RequireToken(*adminToken, bus.Handler()))
+
+	if *serveMetrics {
+		m := metrics.New()
+		mcpServer.SetMetrics(m)
+		m.CounterFunc("mcpify_packets_processed_total", "Packets read by capture.", func() int64 { return endpointCapture.Counts().Packets })
+		m.CounterFunc("mcpify_http_requests_parsed_total", "HTTP requests parsed from captured traffic.", func() int64 { return endpointCapture.Counts().Requests })
+		m.CounterFunc("mcpify_http_parse_failures_total", "Captured requests and responses that couldn't be parsed.", func() int64 { return endpointCapture.Counts().ParseFailures })
+		m.CounterFunc("mcpify_endpoints_discovered_total", "Endpoints seen in captured traffic for the first time.", func() int64 { return endpointCapture.Counts().Endpoints })
+		m.CounterFunc("mcpify_capture_duplicates_suppressed_total", "Sightings of a known endpoint kept from registering it again.", endpointCapture.DuplicatesSuppressed)
+		m.CounterFunc("mcpify_config_save_errors_total", "Config saves that failed.", cfg.SaveErrors)
+		if llmHealth != nil {
+			m.CounterFunc("mcpify_llm_calls_total", "LLM calls for naming and grouping, the startup check included.", func() int64 {
+				calls, _ := llmHealth.Counts()
+				return calls
+			})
+			m.CounterFunc("mcpify_llm_failures_total", "LLM calls that failed.", func() int64 {
+				_, failed := llmHealth.Counts()
+				return failed
+			})
+		}
+		mcpServer.Handle("GET /metrics", m.Handler())
+	}
+
+	mcpServer.AddDebugInfo("target", func() any { return cfg.TargetInfo() })
+	mcpServer.AddDebugInfo("usage", func() any { return server.UsageSummary(cfg) })
+	mcpServer.Handle("GET /debug/usage", server.UsageHandler(cfg))
+	mcpServer.AddDebugInfo("config_check", func() any { return configCheck })
+	mcpServer.AddDebugInfo("endpoints", func() any { return endpointCapture.Endpoints() })
+	mcpServer.AddDebugInfo("duplicates_suppressed", func() any { return endpointCapture.DuplicatesSuppressed() })
+	mcpServer.AddDebugInfo("response_changes", func() any { return cfg.ResponseChanges() })
+
+	budget, err := diskbudget.Parse(*diskBudget, *diskCaps)
+	if err != nil {
+		fatal("Invalid --disk-budget or --disk-caps", err)
+	}
+	disk := diskbudget.New(finalConfigPath, budget)
+	disk.SetCollector(cfg.CollectBlobs)
+	mcpServer.AddDebugInfo("disk", func() any { return disk.Usage() })
+	if !*serveOnly {
+		mcpServer.Handle("/api/ingest", utils.RequireToken(*adminToken, endpointCapture.IngestHandler()))
+	}
+	mcpServer.Handle("GET /export/guide", export.GuideHandler(cfg, *mcpName))
+	mcpServer.Handle("GET /export/openapi", export.OpenAPIHandler(cfg, *mcpName))
+	mcpServer.Handle("GET /export/postman", export.PostmanHandler(cfg, *mcpName))
+	mcpServer.Handle("GET /api/tools/{name}", utils.RequireToken(*adminToken, server.ToolHandler(cfg)))
+	mcpServer.Handle("GET /api/tools/{name}/history", utils.RequireToken(*adminToken, server.HistoryHandler(cfg)))
+	mcpServer.Handle("POST /api/tools/{name}/revert", utils.RequireToken(*adminToken, server.RevertHandler(cfg, mcpServer.ToolChanged)))
+	mcpServer.Handle("/api/tools/{name}/response-headers", utils.RequireToken(*adminToken, server.ResponseHeadersHandler(cfg)))
+	mcpServer.Handle("/api/tools/{name}/serialize", utils.RequireToken(*adminToken, server.SerializeHandler(cfg)))
+	mcpServer.Handle("/api/groups/{name}/serialize", utils.RequireToken(*adminToken, server.GroupSerializeHandler(cfg)))
+	mcpServer.Handle("POST /admin/tools", utils.RequireToken(*adminToken, server.AddToolHandler(cfg, mcpServer.RegisterTool)))
+	mcpServer.Handle("PATCH /admin/tools/{name}", utils.RequireToken(*adminToken, server.UpdateToolHandler(cfg, mcpServer.ToolChanged)))
+	mcpServer.Handle("DELETE /admin/tools/{name}", utils.RequireToken(*adminToken, server.RemoveToolHandler(mcpServer.RemoveTool)))
+
+	scenarios := coverage.New()
+	mcpServer.SetCoverage(scenarios)
+	mcpServer.Handle("GET /api/scenarios", utils.RequireToken(*adminToken, scenarios.ScenariosHandler()))
+	mcpServer.Handle("POST /api/scenarios/{name}/{action}", utils.RequireToken(*adminToken, scenarios.ScenariosHandler()))
+	mcpServer.Handle("GET /api/coverage", utils.RequireToken(*adminToken, scenarios.Handler(cfg)))
+
+	// Chaos is only ever enabled by the explicit flag, never from config
+	if *chaosSpec != "" {
+		chaosCfg, err := chaos.Parse(*chaosSpec)
+		if err != nil {
+			fatalf("Invalid chaos spec: %v", err)
+		}
+		injector := chaos.New(chaosCfg)
+		mcpServer.SetChaos(injector)
+		mcpServer.Handle("/api/chaos", utils.RequireToken(*adminToken, injector.Handler()))
+		slog.Warn("Chaos mode enabled", "spec", *chaosSpec)
+	}
+
+	switch *approvalMode {
+	case "off":
+	case "manual":
+		if *adminToken == "" {
+			fatal("Refusing to start", errApprovalWithoutToken)
+		}
+		var methods []string
+		for _, method := range strings.Split(*approvalVerbs, ",") {
+			if method = strings.ToUpper(strings.TrimSpace(method)); method == "" {
+				continue
+			}
+			if !config.ValidMethod(method) {
+				fatalf("Invalid method %q in --approval-methods", method)
+			}
+			methods = append(methods, method)
+		}
+		gate := approval.New(*approvalWait, methods)
+		mcpServer.SetApprovals(gate)
+		mcpServer.Handle("/api/approvals", utils.RequireToken(*adminToken, gate.Handler()))
+		mcpServer.AddDebugInfo("approvals", func() any { return gate.Summary() })
+		slog.Info("Manual approval enabled for these methods and dangerous tools", "methods", strings.Join(methods, ","), "timeout", *approvalWait)
+	default:
+		fatalf("Invalid approval mode %q (want off or manual)", *approvalMode)
+	}
+
+	// The server runs until interrupted or, with stdio, until the client
+	// disconnects; capture runs alongside it. Capture-only runs just the
+	// capture, until interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go disk.Run(ctx, diskbudget.DefaultInterval)
+	go cfg.SaveUsage(ctx, config.UsageSaveInterval)
+	if notifier != nil {
+		go notifier.Run(ctx)
+	}
+	if recorder != nil {
+		go recorder.Run(ctx, session.FlushInterval)
+	}
+
+	if *importSpec != "" {
+		if err := importOpenAPI(ctx, *importSpec, targetURL, cfg); err != nil {
+			fatal("Failed to import "+*importSpec, err)
+		}
+	}
+
+	// Shutdown waits for the server and capture to stop, so nothing
+	// discovered is lost
+	serverDone := make(chan struct{})
+	captureDone := make(chan struct{})
+	if *captureOnly {
+		slog.Info("Capture only: discovered endpoints are saved; no MCP server is started", "path", finalConfigPath)
+		close(serverDone)
+	} else {
+		if *verify {
+			mcpServer.VerifyTools(ctx)
+		}
+
+		go func() {
+			defer close(serverDone)
+			addr := ":" + *mcpPort
+			if stdio {
+				slog.Info("Debug and admin endpoints starting", "url", "http://localhost"+addr)
+			} else {
+				slog.Info("MCP server starting", "url", "http://localhost"+addr+"/mcp")
+			}
+			err := mcpServer.Start(ctx, addr)
+			switch {
+			case err == nil || err == http.ErrServerClosed:
+			case stdio:
+				// Clients may start several instances; only the first gets the port
+				slog.Warn("Debug and admin endpoints unavailable", "error", err)
+			default:
+				fatal("MCP server failed", err)
+			}
+		}()
+
+		bus.Publish(events.ServerStarted, map[string]any{"transport": *transport, "addr": ":" + *mcpPort, "tools": len(cfg.ListTools())})
+
+		if stdio {
+			go func() {
+				if err := mcpServer.ServeStdio(ctx); err != nil && ctx.Err() == nil {
+					slog.Warn("MCP stdio session ended", "error", err)
+				}
+				slog.Info("MCP client disconnected")
+				stop()
+			}()
+		}
+	}
+
+	if *serveOnly {
+		close(captureDone)
+	} else {
+		// lo0 on macOS misses servers bound to other addresses, so the
+		// self-test always checks it there, moving capture to pktap if needed
+		loopbackCheck := runtime.GOOS == "darwin" && mode == "pcap" && *pcapFile == "" && *captureIface == "" && cfg.Interface == ""
+		if *selfTest && *pcapFile != "" {
+			slog.Warn("--self-test needs live capture; skipping it while replaying", "file", *pcapFile)
+		} else if *selfTest || loopbackCheck {
+			mcpServer.AddDebugInfo("self_test", func() any { return endpointCapture.LastSelfTest() })
+			go func() {
+				select {
+				case <-endpointCapture.Ready():
+					endpointCapture.RunSelfTest(selfTestWait)
+				case <-ctx.Done():
+				}
+			}()
+		}
+
+		if !*captureOnly {
+			slog.Info("Discovered endpoints will be available as MCP tools")
+		}
+		go func() {
+			defer close(captureDone)
+			if *pcapFile != "" {
+				slog.Info("Replaying", "file", *pcapFile)
+				stats, err := endpointCapture.ReplayFile(*pcapFile)
+				if err != nil {
+					fatal("Replay failed", err)
+				}
+				slog.Info("Replayed", "file", *pcapFile, "stats", stats.String())
+				if *captureOnly {
+					stop()
+				}
+				return
+			}
+			if err := runCapture(ctx, endpointCapture, mode, targetURL, httpsTarget, *tlsCert, *tlsKey, *proxyPort, filepath.Dir(finalConfigPath)); err != nil {
+				fatal("Capture failed", err)
+			}
+		}()
+	}
 
-	go func() {
-		addr := ":" + *mcpPort
-		log.Printf("MCP server starting on http://localhost%s/mcp", addr)
-		if err := mcpServer.Start(ctx, addr); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("MCP server failed: %v", err)
+	<-ctx.Done()
+	slog.Info("Shutting down mcpify")
+	bus.Publish(events.ServerStopping, nil)
+	<-serverDone
+	if err := mcpServer.RemoveBinaryFiles(); err != nil {
+		slog.Warn("Failed to remove saved binary bodies", "error", err)
+	}
+	if !*serveOnly {
+		// A replay isn't interrupted; shutting down leaves it unfinished
+		if *pcapFile == "" {
+			<-captureDone
 		}
-	}()
+		if !endpointCapture.WaitRegistrations(registrationWait) {
+			slog.Warn("Some discovered endpoints were still being registered; they are captured again next run", "waited", registrationWait)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		slog.Error("Failed to close session file", "path", *sessionFile, "error", err)
+	}
+
+	// Tools are saved as they're found; this catches anything the grouper
+	// or response tracking changed since
+	if err := cfg.Save(finalConfigPath); err != nil {
+		fatal("Failed to save config", err)
+	}
+	slog.Info("Saved tools", "tools", len(cfg.ListTools()), "path", finalConfigPath)
+	if !*serveOnly {
+		printEndpointSummary(endpointCapture.Endpoints())
+	}
+}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-c
-		log.Println("Shutting down mcpify...")
-		cancel()
-		os.Exit(0)
-	}()
+// selfTestWait is how long the self-test waits for its request to be
+// captured.
+const selfTestWait = 2 * time.Second
 
-	log.Printf("Observing traffic to %s", *target)
-	log.Printf("Discovered endpoints will be available as MCP tools")
+// registrationWait is how long shutdown waits for tools still being
+// registered, which can wait on the LLM to name them.
+const registrationWait = 10 * time.Second
 
-	if err := endpointCapture.StartCapture(*verbose); err != nil {
-		log.Fatalf("Failed to start capture: %v", err)
+// printEndpointSummary lists the endpoints discovered this run.
+func printEndpointSummary(endpoints []capture.APICall) {
+	if len(endpoints) == 0 {
+		slog.Info("No endpoints discovered this run")
+		return
 	}
+	slog.Info("Endpoints discovered this run", "count", len(endpoints))
+	for _, endpoint := range endpoints {
+		slog.Info("Discovered endpoint", "method", endpoint.Method, "path", endpoint.Path, "calls", endpoint.CallCount)
+	}
+}
+
+// runCapture captures traffic to the target until ctx is done, either
+// from packets or through the capture proxy.
+func runCapture(ctx context.Context, ec *capture.EndpointCapture, mode, targetURL string, httpsTarget bool, tlsCert, tlsKey, proxyPort, configDir string) error {
+	if mode == "proxy" {
+		// The proxy terminates TLS for HTTPS targets, or when given a cert
+		var tlsConfig *tls.Config
+		scheme := "http"
+		if httpsTarget || tlsCert != "" || tlsKey != "" {
+			var err error
+			tlsConfig, err = capture.ProxyTLSConfig(tlsCert, tlsKey, configDir)
+			if err != nil {
+				return fmt.Errorf("invalid capture proxy TLS settings: %w", err)
+			}
+			scheme = "https"
+		}
+
+		slog.Info("Send traffic through the capture proxy to capture it", "target", targetURL, "proxy", fmt.Sprintf("%s://localhost:%s", scheme, proxyPort))
+		if err := ec.StartProxy(ctx, ":"+proxyPort, tlsConfig); err != nil {
+			return fmt.Errorf("capture proxy: %w", err)
+		}
+		return nil
+	}
+
+	slog.Info("Observing traffic", "target", targetURL)
+	if err := ec.StartCapture(ctx); err != nil {
+		return fmt.Errorf("start capture: %w", err)
+	}
+	return nil
 }
 
 func checkTargetServer(target string) error {
-	log.Printf("Checking target server at %s", target)
+	slog.Info("Checking target server", "target", target)
 
 	client := &http.Client{
 		Timeout: 5 * time.Second,
@@ -148,6 +929,6 @@ func checkTargetServer(target string) error {
 	}
 	defer resp.Body.Close()
 
-	log.Printf("Target server response: %s", resp.Status)
+	slog.Info("Target server responded", "status", resp.Status)
 	return nil
 }
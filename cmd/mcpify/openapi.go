@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/openapi"
+	"github.com/NilayYadav/mcpify/internal/server"
+)
+
+// errNoServerURL is returned when imported operations have nowhere to be
+// called: there is no target and the document has no absolute server URL.
+var errNoServerURL = errors.New("no target and no absolute server URL in the document")
+
+// importOpenAPI registers a tool for each operation in the OpenAPI
+// document at source. Operations that already have a tool are documented
+// instead, so captured and imported tools for an endpoint end up as one.
+func importOpenAPI(ctx context.Context, source, targetURL string, cfg *config.Config) error {
+	doc, err := openapi.Load(ctx, source)
+	if err != nil {
+		return err
+	}
+	endpoints, err := doc.Endpoints()
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	base, err := importBase(doc, targetURL)
+	if err != nil {
+		return err
+	}
+
+	added, documented := 0, 0
+	for _, e := range endpoints {
+		callURL := base + e.Path
+		namedBy := "openapi-path"
+		if e.NamedByID {
+			namedBy = "operationId"
+		}
+
+		if cfg.ToolFor(e.Method, callURL) == nil {
+			name := e.Name
+			for i := 2; cfg.GetTool(name) != nil; i++ {
+				name = fmt.Sprintf("%s_%d", e.Name, i)
+			}
+			err := mcpServer.RegisterTool(name, e.Method, callURL, e.PathParams, e.Headers, []byte(e.Body), e.Description)
+			if errors.Is(err, server.ErrToolLimitReached) {
+				slog.Warn("Stopped importing", "source", source, "added", added, "error", err)
+				break
+			}
+			if err != nil {
+				slog.Warn("Failed to import operation", "method", e.Method, "path", e.Path, "tool", name, "error", err)
+				continue
+			}
+			added++
+		} else {
+			documented++
+		}
+
+		tool, changed := cfg.DocumentTool(e.Method, callURL, config.ToolDoc{
+			Spec:              source,
+			QueryParams:       e.QueryParams,
+			ParamDescriptions: e.ParamDescriptions,
+			NamedBy:           namedBy,
+		})
+		if changed {
+			mcpServer.ToolChanged(tool, tool.Name)
+		}
+	}
+	if err := cfg.Save(cfg.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+
+	title := doc.Info.Title
+	if title == "" {
+		title = source
+	}
+	slog.Info("Imported API description", "title", title, "operations", len(endpoints), "added", added, "documented", documented)
+	return nil
+}
+
+// importBase is the URL operation paths are appended to: the target with
+// the document's server path, or the document's server URL when there is
+// no target.
+func importBase(doc *openapi.Document, targetURL string) (string, error) {
+	if targetURL != "" {
+		target, err := url.Parse(targetURL)
+		if err != nil {
+			return "", err
+		}
+		base := url.URL{Scheme: target.Scheme, User: target.User, Host: target.Host}
+		return base.String() + strings.TrimSuffix(target.EscapedPath(), "/") + doc.ServerPath(), nil
+	}
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil && u.IsAbs() {
+			return strings.TrimSuffix(doc.Servers[0].URL, "/"), nil
+		}
+	}
+	return "", errNoServerURL
+}
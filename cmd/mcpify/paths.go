@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/capture"
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// pathList is a flag that can be given more than once, collecting a path
+// pattern each time. Patterns aren't split on commas, since regular
+// expressions may hold them.
+type pathList []string
+
+func (l *pathList) String() string {
+	return strings.Join(*l, " ")
+}
+
+func (l *pathList) Set(pattern string) error {
+	*l = append(*l, pattern)
+	return nil
+}
+
+// pathFilters returns the include and ignore path patterns for capture:
+// those of --include-path, --ignore-path and --no-default-ignores where
+// given, else the config's. Flags given on the command line are saved;
+// those from a profile are not.
+func pathFilters(cfg *config.Config, include, ignore pathList, noDefaults bool, profileSettings map[string]string) (includes, ignores []string) {
+	// Broken patterns are never saved
+	for _, patterns := range [][]string{include, ignore, cfg.IncludePaths, cfg.IgnorePaths} {
+		if err := capture.ValidatePathPatterns(patterns); err != nil {
+			fatal("Invalid path pattern", err)
+		}
+	}
+
+	includes, ignores = cfg.IncludePaths, cfg.IgnorePaths
+	useDefaults := !cfg.NoDefaultIgnores
+	save := false
+	flag.Visit(func(f *flag.Flag) {
+		_, fromProfile := profileSettings[f.Name]
+		switch f.Name {
+		case "include-path":
+			includes = include
+			if !fromProfile {
+				cfg.IncludePaths = slices.Clone(include)
+			}
+		case "ignore-path":
+			ignores = ignore
+			if !fromProfile {
+				cfg.IgnorePaths = slices.Clone(ignore)
+			}
+		case "no-default-ignores":
+			useDefaults = !noDefaults
+			if !fromProfile {
+				cfg.NoDefaultIgnores = noDefaults
+			}
+		default:
+			return
+		}
+		save = save || !fromProfile
+	})
+	if save {
+		if err := cfg.Save(cfg.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+
+	if useDefaults {
+		ignores = append(slices.Clone(capture.DefaultIgnorePaths), ignores...)
+	}
+	return includes, ignores
+}
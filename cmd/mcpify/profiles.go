@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// Flags a profile can't set: they pick the config the profile comes from.
+var unprofiledFlags = []string{"profile", "config"}
+
+// applyProfile sets every flag of the named profile that wasn't given on
+// the command line, so explicit flags always win. It returns the values
+// it applied.
+func applyProfile(fs *flag.FlagSet, cfg *config.Config, name string) (map[string]string, error) {
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	applied := make(map[string]string)
+	values := profile.Values()
+	for _, flagName := range slices.Sorted(maps.Keys(values)) {
+		if slices.Contains(unprofiledFlags, flagName) {
+			return nil, fmt.Errorf("profile %q: --%s can't be set by a profile", name, flagName)
+		}
+		if fs.Lookup(flagName) == nil {
+			return nil, fmt.Errorf("profile %q: unknown flag --%s", name, flagName)
+		}
+		if explicit[flagName] {
+			continue
+		}
+		if err := fs.Set(flagName, values[flagName]); err != nil {
+			return nil, fmt.Errorf("profile %q: --%s: %w", name, flagName, err)
+		}
+		applied[flagName] = values[flagName]
+	}
+	return applied, nil
+}
+
+// formatSettings renders flag values as a single line for logs.
+func formatSettings(values map[string]string) string {
+	parts := make([]string, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		parts = append(parts, fmt.Sprintf("--%s=%s", name, values[name]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// runProfiles handles `mcpify profiles list` and `mcpify profiles show
+// NAME [flags]`. Flags given to show override the profile as they would
+// at startup.
+func runProfiles(args []string) {
+	if len(args) == 0 || (args[0] == "show" && len(args) < 2) {
+		fatalf("Usage: mcpify profiles list [--config FILE] | mcpify profiles show NAME [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		flag.CommandLine.Parse(args[1:])
+		cfg := loadConfig(flag.Lookup("config").Value.String())
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			fmt.Println("No profiles in " + cfg.Path)
+			return
+		}
+		for _, name := range names {
+			profile, _ := cfg.Profile(name)
+			fmt.Printf("%s\t%s\n", name, formatSettings(profile.Values()))
+		}
+	case "show":
+		name := args[1]
+		flag.CommandLine.Parse(args[2:])
+		cfg := loadConfig(flag.Lookup("config").Value.String())
+		applied, err := applyProfile(flag.CommandLine, cfg, name)
+		if err != nil {
+			fatal("Invalid profile", err)
+		}
+
+		saved, _ := applySavedSettings(flag.CommandLine, cfg, applied)
+
+		explicit := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+		fmt.Printf("Profile %s (effective settings)\n", name)
+		flag.VisitAll(func(f *flag.Flag) {
+			// Dependencies register underscored debug flags of their own
+			if strings.Contains(f.Name, "_") {
+				return
+			}
+			source := "default"
+			switch _, fromProfile := applied[f.Name]; {
+			case fromProfile:
+				source = "profile"
+			case explicit[f.Name]:
+				source = "flag"
+			case saved[f.Name] != "":
+				source = "config"
+			}
+			value := f.Value.String()
+			if f.Name == "admin-token" && value != "" {
+				value = "(set)"
+			}
+			fmt.Printf("  %-18s %-30s %s\n", f.Name, value, source)
+		})
+	default:
+		fatalf("Unknown profiles command %q (want list or show)", args[0])
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/NilayYadav/mcpify/internal/prompts"
+)
+
+// runPrompts handles `mcpify prompts show naming|grouping [--prompt-dir
+// DIR]`, printing the template the LLM is given after overrides.
+func runPrompts(args []string) {
+	if len(args) < 2 || args[0] != "show" {
+		fatalf("Usage: mcpify prompts show naming|grouping [--prompt-dir DIR]")
+	}
+	fs := flag.NewFlagSet("prompts", flag.ExitOnError)
+	promptDir := fs.String("prompt-dir", "", "Directory with naming.tmpl and grouping.tmpl overriding the built-in LLM prompts")
+	fs.Parse(args[2:])
+
+	set, err := prompts.Load(*promptDir)
+	if err != nil {
+		fatal("Failed to load prompts", err)
+	}
+	prompt, err := set.Get(args[1])
+	if err != nil {
+		fatal("Failed to show prompt", err)
+	}
+
+	source := prompt.Source
+	if source == "" {
+		source = "built in"
+	}
+	fmt.Printf("# %s (%s)\n\n%s", prompt.Ref(), source, prompt.Text)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/replica"
+)
+
+// replicaPool builds the replica pool from the flags, falling back to the
+// config for each. It returns nil when the target has no replicas.
+func replicaPool(cfg *config.Config, targetURL, list, strategy string, sticky bool) *replica.Pool {
+	replicas := cfg.Replicas
+	if list != "" {
+		var err error
+		if replicas, err = replica.Parse(list); err != nil {
+			fatal("Invalid --replicas", err)
+		}
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+	if strategy == "" {
+		strategy = cfg.ReplicaStrategy
+	}
+	if targetURL == "" {
+		fatal("Invalid --replicas", fmt.Errorf("%w: replicas need a target", replica.ErrInvalidReplica))
+	}
+	target, err := url.Parse(targetURL)
+	if err != nil {
+		fatalf("Invalid target URL: %v", err)
+	}
+
+	pool, err := replica.New(target, replicas, strategy, sticky || cfg.StickyReplicas)
+	if err != nil {
+		fatal("Invalid --replicas", err)
+	}
+	status := pool.Status()
+	urls := make([]string, len(status))
+	for i, r := range status {
+		urls[i] = r.URL
+	}
+	slog.Info("Spreading tool calls across replicas", "replicas", strings.Join(urls, ","))
+	return pool
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/update"
+)
+
+// noUpdateCheckEnv disables the passive update check like
+// --no-update-check.
+const noUpdateCheckEnv = "MCPIFY_NO_UPDATE_CHECK"
+
+// runSelfUpdate handles `mcpify self-update [--check] [--allow-major]`.
+func runSelfUpdate(args []string) {
+	fs := flag.NewFlagSet("self-update", flag.ExitOnError)
+	checkOnly := fs.Bool("check", false, "Only report whether an update is available")
+	allowMajor := fs.Bool("allow-major", false, "Allow updating to a new major version, which may change on-disk formats")
+	releasesURL := fs.String("releases-url", update.DefaultReleasesURL, "Releases API endpoint returning the latest release")
+	fs.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	u := update.New(config.Version)
+	u.ReleasesURL = *releasesURL
+	release, err := u.Latest(ctx)
+	if err != nil {
+		fatal("Update check failed", err)
+	}
+	if !u.Newer(release) {
+		fmt.Printf("mcpify %s is up to date\n", config.Version)
+		return
+	}
+	if *checkOnly {
+		fmt.Printf("mcpify %s is available (current %s)\n", release.Version(), config.Version)
+		if u.CrossesMajor(release) {
+			fmt.Println("It is a new major version; install it with --allow-major")
+		}
+		return
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fatal("Cannot locate the mcpify binary", err)
+	}
+
+	fmt.Printf("Updating mcpify %s to %s...\n", config.Version, release.Version())
+	if err := u.Apply(ctx, release, exe, *allowMajor); err != nil {
+		if errors.Is(err, update.ErrMajorUpgrade) {
+			fatal("Update refused; rerun with --allow-major after reading the release notes", err)
+		}
+		fatal("Update failed", err)
+	}
+	fmt.Printf("Updated %s to mcpify %s\n", exe, release.Version())
+}
+
+// checkForUpdate prints a notice when a newer release exists. It asks
+// GitHub at most once a day and never holds up startup.
+func checkForUpdate(cfg *config.Config) {
+	if os.Getenv(noUpdateCheckEnv) != "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		u := update.New(config.Version)
+		latest, err := u.CheckDaily(ctx, filepath.Join(filepath.Dir(cfg.Path), "update-check.json"))
+		if err != nil || latest == "" {
+			return
+		}
+		slog.Info("A newer mcpify is available; run 'mcpify self-update' to install it", "latest", latest, "current", config.Version)
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// savedSetting is a flag whose value the config keeps between runs.
+type savedSetting struct {
+	flag string
+	// get returns the saved value, "" when there is none
+	get func(cfg *config.Config) string
+	set func(cfg *config.Config, value string)
+}
+
+var savedSettings = []savedSetting{
+	{
+		flag: "mcp-port",
+		get:  func(cfg *config.Config) string { return cfg.MCPPort },
+		set:  func(cfg *config.Config, value string) { cfg.MCPPort = value },
+	},
+	{
+		flag: "max-tools",
+		get: func(cfg *config.Config) string {
+			if cfg.MaxTools <= 0 {
+				return ""
+			}
+			return strconv.Itoa(cfg.MaxTools)
+		},
+		set: func(cfg *config.Config, value string) { cfg.MaxTools, _ = strconv.Atoi(value) },
+	},
+	{
+		flag: "use-llm",
+		get:  func(cfg *config.Config) string { return strconv.FormatBool(cfg.UseLLM) },
+		set:  func(cfg *config.Config, value string) { cfg.UseLLM, _ = strconv.ParseBool(value) },
+	},
+	{
+		flag: "max-response-size",
+		get:  func(cfg *config.Config) string { return cfg.MaxResponseSize },
+		set:  func(cfg *config.Config, value string) { cfg.MaxResponseSize = value },
+	},
+	{
+		flag: "discovery-webhook",
+		get:  func(cfg *config.Config) string { return cfg.DiscoveryWebhook },
+		set:  func(cfg *config.Config, value string) { cfg.DiscoveryWebhook = value },
+	},
+}
+
+// applySavedSettings gives the flags of savedSettings their config values
+// unless they were given on the command line or by a profile, so an
+// explicit flag wins over the config, which wins over the flag default.
+// Values given on the command line are copied to cfg, to be saved; those
+// of a profile are not. It returns the values it took from the config
+// and whether cfg changed.
+func applySavedSettings(fs *flag.FlagSet, cfg *config.Config, profileSettings map[string]string) (applied map[string]string, changed bool) {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	applied = make(map[string]string)
+	for _, setting := range savedSettings {
+		f := fs.Lookup(setting.flag)
+		_, fromProfile := profileSettings[setting.flag]
+		switch saved := setting.get(cfg); {
+		case fromProfile:
+		case explicit[setting.flag]:
+			if value := f.Value.String(); value != saved {
+				setting.set(cfg, value)
+				changed = true
+			}
+		case saved != "" && saved != f.Value.String():
+			// Set through the value, so the flag still reads as not given
+			if err := f.Value.Set(saved); err != nil {
+				fatal("Invalid "+setting.flag+" in config", err)
+			}
+			applied[setting.flag] = saved
+		}
+	}
+	return applied, changed
+}
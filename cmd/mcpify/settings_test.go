@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// settingsFlags is a flag set with the saved settings of main. Like
+// --admin-token, discovery-webhook takes its default from the environment
+// here, so a test can give one.
+func settingsFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("mcpify", flag.ContinueOnError)
+	fs.String("mcp-port", "8081", "")
+	fs.Int("max-tools", 100, "")
+	fs.Bool("use-llm", false, "")
+	fs.String("max-response-size", "100KB", "")
+	fs.String("discovery-webhook", os.Getenv("MCPIFY_TEST_WEBHOOK"), "")
+	return fs
+}
+
+func TestSavedSettingsPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		saved   func(cfg *config.Config)
+		profile map[string]string
+		// want are the flag values after applying the settings
+		want map[string]string
+		// applied are the values taken from the config
+		applied map[string]string
+		changed bool
+		// kept checks what cfg holds afterwards
+		kept func(t *testing.T, cfg *config.Config)
+	}{
+		{
+			name: "defaults",
+			want: map[string]string{"mcp-port": "8081", "max-tools": "100", "use-llm": "false", "max-response-size": "100KB"},
+		},
+		{
+			name: "config over defaults",
+			saved: func(cfg *config.Config) {
+				cfg.MCPPort, cfg.MaxTools, cfg.UseLLM = "9000", 25, true
+			},
+			want:    map[string]string{"mcp-port": "9000", "max-tools": "25", "use-llm": "true", "max-response-size": "100KB"},
+			applied: map[string]string{"mcp-port": "9000", "max-tools": "25", "use-llm": "true"},
+		},
+		{
+			name: "flags over config",
+			args: []string{"--mcp-port", "7000", "--max-tools", "5", "--use-llm=false"},
+			saved: func(cfg *config.Config) {
+				cfg.MCPPort, cfg.MaxTools, cfg.UseLLM = "9000", 25, true
+			},
+			want:    map[string]string{"mcp-port": "7000", "max-tools": "5", "use-llm": "false"},
+			changed: true,
+			kept: func(t *testing.T, cfg *config.Config) {
+				if cfg.MCPPort != "7000" || cfg.MaxTools != 5 || cfg.UseLLM {
+					t.Errorf("config has port %s, max tools %d, use LLM %v; want the flags saved", cfg.MCPPort, cfg.MaxTools, cfg.UseLLM)
+				}
+			},
+		},
+		{
+			name: "flag at its default over config",
+			args: []string{"--mcp-port", "8081"},
+			saved: func(cfg *config.Config) {
+				cfg.MCPPort = "9000"
+			},
+			want:    map[string]string{"mcp-port": "8081"},
+			changed: true,
+			kept: func(t *testing.T, cfg *config.Config) {
+				if cfg.MCPPort != "8081" {
+					t.Errorf("config has port %s, want 8081", cfg.MCPPort)
+				}
+			},
+		},
+		{
+			name: "flag equal to config",
+			args: []string{"--max-tools", "25"},
+			saved: func(cfg *config.Config) {
+				cfg.MaxTools = 25
+			},
+			want: map[string]string{"max-tools": "25"},
+		},
+		{
+			name:    "flag given while config has nothing",
+			args:    []string{"--max-response-size", "2MB"},
+			want:    map[string]string{"max-response-size": "2MB"},
+			changed: true,
+			kept: func(t *testing.T, cfg *config.Config) {
+				if cfg.MaxResponseSize != "2MB" {
+					t.Errorf("config has max response size %q, want 2MB", cfg.MaxResponseSize)
+				}
+			},
+		},
+		{
+			name:    "profile over config, not saved",
+			args:    []string{"--mcp-port", "7000"},
+			profile: map[string]string{"mcp-port": "7000"},
+			saved: func(cfg *config.Config) {
+				cfg.MCPPort = "9000"
+			},
+			want: map[string]string{"mcp-port": "7000"},
+			kept: func(t *testing.T, cfg *config.Config) {
+				if cfg.MCPPort != "9000" {
+					t.Errorf("config has port %s, want 9000 kept", cfg.MCPPort)
+				}
+			},
+		},
+		{
+			name: "environment default",
+			env:  "https://hooks.example.com/env",
+			want: map[string]string{"discovery-webhook": "https://hooks.example.com/env"},
+		},
+		{
+			name: "config over environment default",
+			env:  "https://hooks.example.com/env",
+			saved: func(cfg *config.Config) {
+				cfg.DiscoveryWebhook = "https://hooks.example.com/config"
+			},
+			want:    map[string]string{"discovery-webhook": "https://hooks.example.com/config"},
+			applied: map[string]string{"discovery-webhook": "https://hooks.example.com/config"},
+		},
+		{
+			name: "flag over environment default and config",
+			env:  "https://hooks.example.com/env",
+			args: []string{"--discovery-webhook", "https://hooks.example.com/flag"},
+			saved: func(cfg *config.Config) {
+				cfg.DiscoveryWebhook = "https://hooks.example.com/config"
+			},
+			want:    map[string]string{"discovery-webhook": "https://hooks.example.com/flag"},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MCPIFY_TEST_WEBHOOK", tt.env)
+			fs := settingsFlags()
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			cfg := config.DefaultConfig(filepath.Join(t.TempDir(), "config.json"))
+			if tt.saved != nil {
+				tt.saved(cfg)
+			}
+
+			applied, changed := applySavedSettings(fs, cfg, tt.profile)
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("--%s = %q, want %q", name, got, want)
+				}
+			}
+			if tt.applied == nil {
+				tt.applied = map[string]string{}
+			}
+			if !maps.Equal(applied, tt.applied) {
+				t.Errorf("applied %v, want %v", applied, tt.applied)
+			}
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			// Values from the config don't count as given on the command line
+			fs.Visit(func(f *flag.Flag) {
+				if _, ok := tt.applied[f.Name]; ok {
+					t.Errorf("--%s reads as given", f.Name)
+				}
+			})
+			if tt.kept != nil {
+				tt.kept(t, cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	llmhealth "github.com/NilayYadav/mcpify/internal/llm"
+)
+
+// runStatus handles `mcpify status [flags]`, summarising the /debug output
+// of a running instance.
+func runStatus(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	mcpPort := fs.String("mcp-port", "", "MCP server port of the running instance (default from config)")
+	configPath := fs.String("config", "", "Custom config file path")
+	asJSON := fs.Bool("json", false, "Print the raw /debug JSON")
+	fs.Parse(args)
+
+	port := *mcpPort
+	if port == "" {
+		port = loadConfig(*configPath).MCPPort
+	}
+	debugURL := fmt.Sprintf("http://localhost:%s/debug", port)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(debugURL)
+	if err != nil {
+		fatal("mcpify is not reachable at "+debugURL, err)
+	}
+	defer resp.Body.Close()
+
+	var info map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		fatal("Invalid /debug response", err)
+	}
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(out))
+		return
+	}
+
+	fmt.Printf("Server:  http://localhost:%s\n", port)
+	var profile struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(info["profile"], &profile) == nil && profile.Name != "" {
+		fmt.Printf("Profile: %s\n", profile.Name)
+	}
+	var count int
+	if json.Unmarshal(info["tool_count"], &count) == nil {
+		fmt.Printf("Tools:   %d\n", count)
+	}
+	var target config.TargetInfo
+	if json.Unmarshal(info["target"], &target) == nil && target.Active != "" {
+		fmt.Printf("Target:  %s", target.Active)
+		if len(target.Others) > 0 {
+			fmt.Printf(" (%d other targets kept)", len(target.Others))
+		}
+		fmt.Println()
+	}
+
+	printDiskUsage(info["disk"])
+	printConfigCheck(info["config_check"])
+
+	fallback := printLLMStatus(info["llm"])
+	changed := printResponseChanges(info["response_changes"])
+	if fallback || changed {
+		os.Exit(exitError)
+	}
+}
+
+// printLLMStatus prints the provider health and reports whether the
+// heuristic fallback is active.
+func printLLMStatus(raw json.RawMessage) bool {
+	var llm llmhealth.Status
+	if raw == nil || json.Unmarshal(raw, &llm) != nil {
+		fmt.Println("LLM:     not in use")
+		return false
+	}
+	fmt.Printf("LLM:     %s (%s)\n", llm.Model, llm.Provider)
+	fmt.Printf("  last success:         %s\n", ago(llm.LastSuccess))
+	fmt.Printf("  consecutive failures: %d\n", llm.ConsecutiveFailures)
+	if llm.LastError != "" {
+		fmt.Printf("  last error:           %s (%s)\n", llm.LastError, ago(llm.LastFailure))
+	}
+	if llm.Fallback {
+		fmt.Printf("  fallback:             active, next retry %s\n", llm.RetryAt.Format(time.TimeOnly))
+		return true
+	}
+	fmt.Println("  fallback:             inactive")
+	return false
+}
+
+// printConfigCheck prints what the startup config check repaired and
+// what it left.
+func printConfigCheck(raw json.RawMessage) {
+	var report config.CheckReport
+	if raw == nil || json.Unmarshal(raw, &report) != nil || report.CheckedAt.IsZero() {
+		return
+	}
+	if len(report.Problems) == 0 && len(report.Fixed) == 0 {
+		fmt.Println("Config:  consistent")
+		return
+	}
+	fmt.Printf("Config:  %d problems, %d repaired at startup (run mcpify fsck)\n", len(report.Problems), len(report.Fixed))
+	for _, problem := range report.Problems {
+		fmt.Printf("  %s\n", problem)
+	}
+}
+
+// printDiskUsage prints how much of its budget the config directory uses,
+// by category.
+func printDiskUsage(raw json.RawMessage) {
+	var usage diskbudget.Usage
+	if raw == nil || json.Unmarshal(raw, &usage) != nil || usage.CheckedAt.IsZero() {
+		return
+	}
+	budget := "no budget"
+	if usage.Budget > 0 {
+		budget = "of " + diskbudget.FormatSize(usage.Budget)
+	}
+	fmt.Printf("Disk:    %s %s in %s (checked %s)\n", diskbudget.FormatSize(usage.Bytes), budget, usage.Dir, ago(usage.CheckedAt))
+	for _, c := range usage.Categories {
+		line := fmt.Sprintf("  %-9s %10s in %d files", c.Name+":", diskbudget.FormatSize(c.Bytes), c.Files)
+		switch {
+		case c.Protected:
+			line += ", never deleted"
+		case c.Cap > 0:
+			line += ", cap " + diskbudget.FormatSize(c.Cap)
+		}
+		fmt.Println(line)
+	}
+	if usage.Deleted > 0 {
+		fmt.Printf("  deleted:  %d files since startup\n", usage.Deleted)
+	}
+}
+
+// printResponseChanges lists tools whose response schema changed and
+// reports whether there were any.
+func printResponseChanges(raw json.RawMessage) bool {
+	var changes []config.ResponseChange
+	if raw == nil || json.Unmarshal(raw, &changes) != nil || len(changes) == 0 {
+		return false
+	}
+	fmt.Println("Response schema changed:")
+	for _, c := range changes {
+		fmt.Printf("  %s (%s)\n", c.Tool, ago(c.DetectedAt))
+		for _, path := range c.Removed {
+			fmt.Printf("    - %s\n", path)
+		}
+		for _, path := range c.Added {
+			fmt.Printf("    + %s\n", path)
+		}
+	}
+	return true
+}
+
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return time.Since(t).Round(time.Second).String() + " ago"
+}
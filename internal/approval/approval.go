@@ -0,0 +1,221 @@
+// Package approval holds destructive tool calls until a human approves or
+// denies them.
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+const (
+	// DangerousTag marks a tool as needing approval regardless of method.
+	DangerousTag = "dangerous"
+	// DefaultTimeout is how long a call waits for a decision.
+	DefaultTimeout = 2 * time.Minute
+	// maxDecisions bounds the in-memory decision history.
+	maxDecisions = 200
+)
+
+// DefaultMethods are the methods whose calls need approval by default.
+var DefaultMethods = []string{http.MethodDelete}
+
+var (
+	ErrBlocked  = errors.New("blocked_by_policy")
+	ErrDecided  = errors.New("approval already decided")
+	ErrNotFound = errors.New("approval not found")
+)
+
+// Request is a parked tool call.
+type Request struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Body      string    `json:"body,omitempty"`
+	Session   string    `json:"session,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Expires   time.Time `json:"expires_at"`
+	decision  chan bool
+}
+
+// Decision records how a request was resolved.
+type Decision struct {
+	ID        string    `json:"id"`
+	Tool      string    `json:"tool"`
+	Approved  bool      `json:"approved"`
+	By        string    `json:"by"`
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Gate parks calls that need approval. Pending requests only live in
+// memory, so a restart drops them and their callers fail. A nil *Gate
+// approves everything.
+type Gate struct {
+	mu        sync.Mutex
+	timeout   time.Duration
+	methods   []string
+	pending   map[string]*Request
+	decisions []Decision
+}
+
+// New makes a gate holding calls with one of methods, which may be any
+// method, e.g. WebDAV's MOVE, along with dangerous-tagged tools.
+func New(timeout time.Duration, methods []string) *Gate {
+	return &Gate{
+		timeout: timeout,
+		methods: methods,
+		pending: make(map[string]*Request),
+	}
+}
+
+// Needs reports whether calling tool requires approval.
+func (g *Gate) Needs(tool *config.Tool) bool {
+	if g == nil {
+		return false
+	}
+	return slices.ContainsFunc(g.methods, func(method string) bool { return strings.EqualFold(tool.Method, method) }) ||
+		slices.Contains(tool.Tags, DangerousTag)
+}
+
+// Wait parks a call to tool until it is approved, denied, times out or ctx
+// is done. It returns nil only when the call may proceed.
+func (g *Gate) Wait(ctx context.Context, tool *config.Tool, body, session string) error {
+	if !g.Needs(tool) {
+		return nil
+	}
+
+	now := time.Now()
+	req := &Request{
+		ID:        config.NewID(),
+		Tool:      tool.Name,
+		Method:    tool.Method,
+		URL:       tool.URL,
+		Body:      body,
+		Session:   session,
+		CreatedAt: now,
+		Expires:   now.Add(g.timeout),
+		decision:  make(chan bool, 1),
+	}
+
+	g.mu.Lock()
+	g.pending[req.ID] = req
+	g.mu.Unlock()
+	slog.Info("Call waiting for approval", "tool", req.Tool, "method", req.Method, "url", req.URL, "id", req.ID)
+
+	timer := time.NewTimer(g.timeout)
+	defer timer.Stop()
+
+	select {
+	case approved := <-req.decision:
+		if !approved {
+			return fmt.Errorf("%w: call to %s was denied", ErrBlocked, req.Tool)
+		}
+		return nil
+	case <-timer.C:
+		g.resolve(req.ID, false, "timeout")
+		return fmt.Errorf("%w: no approval for %s within %s", ErrBlocked, req.Tool, g.timeout)
+	case <-ctx.Done():
+		g.resolve(req.ID, false, "cancelled")
+		return ctx.Err()
+	}
+}
+
+// Decide approves or denies a pending request. Each request is resolved at
+// most once; later decisions return ErrDecided.
+func (g *Gate) Decide(id string, approve bool, by string) (Decision, error) {
+	return g.resolve(id, approve, by)
+}
+
+func (g *Gate) resolve(id string, approve bool, by string) (Decision, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	req := g.pending[id]
+	if req == nil {
+		for _, d := range g.decisions {
+			if d.ID == id {
+				return d, ErrDecided
+			}
+		}
+		return Decision{}, ErrNotFound
+	}
+	delete(g.pending, id)
+
+	d := Decision{ID: id, Tool: req.Tool, Approved: approve, By: by, DecidedAt: time.Now()}
+	g.decisions = append(g.decisions, d)
+	if len(g.decisions) > maxDecisions {
+		g.decisions = g.decisions[len(g.decisions)-maxDecisions:]
+	}
+	req.decision <- approve
+
+	slog.Info("Call decided", "tool", req.Tool, "id", id, "approved", approve, "by", by)
+	return d, nil
+}
+
+// Summary lists pending requests and recent decisions.
+func (g *Gate) Summary() map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pending := make([]*Request, 0, len(g.pending))
+	for _, req := range g.pending {
+		pending = append(pending, req)
+	}
+	slices.SortFunc(pending, func(a, b *Request) int { return strings.Compare(a.ID, b.ID) })
+
+	return map[string]interface{}{
+		"timeout":   g.timeout.String(),
+		"pending":   pending,
+		"decisions": slices.Clone(g.decisions),
+	}
+}
+
+// Handler serves /api/approvals. GET lists pending requests and recent
+// decisions; POST accepts {"id": "...", "approve": bool, "by": "..."}.
+func (g *Gate) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(g.Summary())
+		case http.MethodPost:
+			var in struct {
+				ID      string `json:"id"`
+				Approve bool   `json:"approve"`
+				By      string `json:"by"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+				return
+			}
+			if in.By == "" {
+				in.By = r.RemoteAddr
+			}
+
+			d, err := g.Decide(in.ID, in.Approve, in.By)
+			if errors.Is(err, ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			// Repeated decisions report the original one and change nothing
+			w.Header().Set("Content-Type", "application/json")
+			if errors.Is(err, ErrDecided) {
+				w.WriteHeader(http.StatusConflict)
+			}
+			json.NewEncoder(w).Encode(d)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
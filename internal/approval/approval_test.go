@@ -0,0 +1,197 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+var deleteUser = &config.Tool{Name: "delete_user", Method: "DELETE", URL: "http://localhost:3000/users/42"}
+
+// park starts a call to tool waiting at g and returns the ID it is parked
+// under and where its result arrives.
+func park(ctx context.Context, t *testing.T, g *Gate, tool *config.Tool) (string, <-chan error) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- g.Wait(ctx, tool, "", "session-1") }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if pending := g.Summary()["pending"].([]*Request); len(pending) == 1 {
+			return pending[0].ID, done
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("the call was never parked")
+	return "", nil
+}
+
+// result waits for a parked call to return.
+func result(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("the call is still waiting")
+		return nil
+	}
+}
+
+func TestDecisionsAreFinal(t *testing.T) {
+	tests := []struct {
+		name    string
+		approve bool
+	}{
+		{name: "approved", approve: true},
+		{name: "denied", approve: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(time.Minute, DefaultMethods)
+			id, done := park(context.Background(), t, g, deleteUser)
+
+			first, err := g.Decide(id, tt.approve, "ada")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = result(t, done)
+			if tt.approve != (err == nil) || !tt.approve && !errors.Is(err, ErrBlocked) {
+				t.Fatalf("Wait = %v after approve=%v", err, tt.approve)
+			}
+
+			// Deciding again, either way, reports the first decision
+			for _, approve := range []bool{true, false} {
+				again, err := g.Decide(id, approve, "grace")
+				if !errors.Is(err, ErrDecided) || again != first {
+					t.Errorf("Decide again = %+v, %v; want %+v, ErrDecided", again, err, first)
+				}
+			}
+			summary := g.Summary()
+			if pending := summary["pending"].([]*Request); len(pending) != 0 {
+				t.Errorf("%d calls still pending", len(pending))
+			}
+			if decisions := summary["decisions"].([]Decision); len(decisions) != 1 || decisions[0] != first {
+				t.Errorf("decisions %+v, want only %+v", decisions, first)
+			}
+		})
+	}
+}
+
+func TestConcurrentApprovalsRunTheCallOnce(t *testing.T) {
+	g := New(time.Minute, DefaultMethods)
+	api := httptest.NewServer(g.Handler())
+	defer api.Close()
+
+	id, done := park(context.Background(), t, g, deleteUser)
+
+	const approvers = 20
+	statuses := make(chan int, approvers)
+	decisions := make(chan Decision, approvers)
+	var wg sync.WaitGroup
+	for range approvers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Post(api.URL, "application/json", strings.NewReader(`{"id":"`+id+`","approve":true,"by":"ada"}`))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			var d Decision
+			if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+				t.Error(err)
+			}
+			statuses <- resp.StatusCode
+			decisions <- d
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+	close(decisions)
+
+	if err := result(t, done); err != nil {
+		t.Fatalf("approved call failed: %v", err)
+	}
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusOK] != 1 || counts[http.StatusConflict] != approvers-1 {
+		t.Errorf("statuses %v, want one 200 and %d 409s", counts, approvers-1)
+	}
+	var first Decision
+	for d := range decisions {
+		if first.ID == "" {
+			first = d
+		}
+		if d != first || !d.Approved {
+			t.Errorf("decision %+v, want every answer to be %+v", d, first)
+		}
+	}
+	if n := len(g.Summary()["decisions"].([]Decision)); n != 1 {
+		t.Errorf("%d decisions recorded, want 1", n)
+	}
+}
+
+func TestLateDecisions(t *testing.T) {
+	tests := []struct {
+		name string
+		// end ends the wait without a decision
+		end func(cancel context.CancelFunc)
+		by  string
+		err error
+	}{
+		{name: "timed out", end: func(context.CancelFunc) {}, by: "timeout", err: ErrBlocked},
+		{name: "cancelled", end: func(cancel context.CancelFunc) { cancel() }, by: "cancelled", err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := New(100*time.Millisecond, DefaultMethods)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			id, done := park(ctx, t, g, deleteUser)
+			tt.end(cancel)
+
+			if err := result(t, done); !errors.Is(err, tt.err) {
+				t.Fatalf("Wait = %v, want %v", err, tt.err)
+			}
+			// Approving after the fact changes nothing
+			d, err := g.Decide(id, true, "ada")
+			if !errors.Is(err, ErrDecided) || d.Approved || d.By != tt.by {
+				t.Errorf("late Decide = %+v, %v; want the %s denial", d, err, tt.by)
+			}
+		})
+	}
+}
+
+func TestDecideUnknown(t *testing.T) {
+	g := New(time.Minute, DefaultMethods)
+	if _, err := g.Decide("01JH0000000000000000000000", true, "ada"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Decide = %v, want ErrNotFound", err)
+	}
+
+	rec := httptest.NewRecorder()
+	g.Handler().ServeHTTP(rec, httptest.NewRequest("POST", "/api/approvals", strings.NewReader(`{"id":"nope","approve":true}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST for an unknown ID answered %d, want 404", rec.Code)
+	}
+
+	// Calls that need no approval aren't parked
+	get := &config.Tool{Name: "get_user", Method: "GET", URL: "http://localhost:3000/users/42"}
+	if err := g.Wait(context.Background(), get, "", ""); err != nil {
+		t.Errorf("Wait for a GET = %v", err)
+	}
+	if pending := g.Summary()["pending"].([]*Request); len(pending) != 0 {
+		t.Errorf("%d calls parked, want none", len(pending))
+	}
+}
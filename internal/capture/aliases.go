@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/events"
+)
+
+// SetAliases adds other names the target is reached under, e.g. api.local
+// or host.docker.internal. An alias with a port matches only that port;
+// without one it matches every target port. Requests to any alias become
+// the same tools as requests to the target.
+func (ec *EndpointCapture) SetAliases(aliases []string) {
+	ec.aliases = ec.aliases[:0]
+	for _, alias := range aliases {
+		if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" {
+			ec.aliases = append(ec.aliases, alias)
+		}
+	}
+}
+
+// isTargetHost reports whether host, without a port, names the target:
+// it is the target's host name, an alias, or localhost. For a loopback
+// target every loopback name is equivalent.
+func (ec *EndpointCapture) isTargetHost(host string) bool {
+	host = strings.ToLower(host)
+	target := strings.ToLower(ec.currentTarget().Hostname())
+	switch {
+	case host == target, host == "localhost", slices.Contains(ec.aliases, host):
+		return true
+	case isLoopback(target) && isLoopback(host):
+		return true
+	}
+	return false
+}
+
+// isReplicaHost reports whether name, without a port, is a replica's.
+func (ec *EndpointCapture) isReplicaHost(name string) bool {
+	for _, host := range ec.replicas {
+		if h, _, err := net.SplitHostPort(host); err == nil && h == name || host == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopback reports whether host always means this machine.
+func isLoopback(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// noteHost records the Host header apiCall was requested with. An endpoint
+// requested under more than one alias may be served by virtual hosts that
+// answer differently; its tool still calls the target, so the conflict is
+// reported rather than resolved. The target's own name, those of replicas
+// and loopback names never conflict. Callers must hold ec.mu.
+func (ec *EndpointCapture) noteHost(apiCall *APICall, host string) {
+	host = strings.ToLower(host)
+	if host == "" || slices.Contains(apiCall.Hosts, host) {
+		return
+	}
+	apiCall.Hosts = append(apiCall.Hosts, host)
+
+	target := strings.ToLower(ec.currentTarget().Hostname())
+	var named []string
+	for _, h := range apiCall.Hosts {
+		name, _, err := net.SplitHostPort(h)
+		if err != nil {
+			name = h
+		}
+		if name != target && !isLoopback(name) && !ec.isReplicaHost(name) && !slices.Contains(named, name) {
+			named = append(named, name)
+		}
+	}
+	if len(named) < 2 || apiCall.HostConflict {
+		return
+	}
+	apiCall.HostConflict = true
+	slog.Warn("Endpoint was requested under several hosts; its tool calls the target, which may not answer for the others",
+		"method", apiCall.Method, "path", apiCall.Path, "hosts", strings.Join(apiCall.Hosts, ","), "target", ec.currentTarget().Host)
+	ec.events.Publish(events.EndpointHostConflict, map[string]any{
+		"method": apiCall.Method, "path": apiCall.Path, "hosts": slices.Clone(apiCall.Hosts),
+	})
+}
@@ -0,0 +1,25 @@
+package capture
+
+import (
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// BodySchemaRecorder is implemented by registrars that want to learn about
+// JSON body fields seen after an endpoint was registered.
+type BodySchemaRecorder interface {
+	RecordBodySchema(method, url string, schema *config.BodySchema)
+}
+
+// mergeBodySchema folds the structure of body into apiCall's body schema
+// and reports whether it grew. Callers must hold ec.mu.
+func mergeBodySchema(apiCall *APICall, body string) bool {
+	merged, grew := config.MergeBodySchemas(apiCall.BodySchema, config.InferBodySchema(body))
+	apiCall.BodySchema = merged
+	return grew
+}
+
+func (ec *EndpointCapture) forwardBodySchema(apiCall *APICall, schema *config.BodySchema) {
+	if recorder, ok := ec.toolRegistrar.(BodySchemaRecorder); ok {
+		recorder.RecordBodySchema(apiCall.Method, ec.callURL(apiCall), schema)
+	}
+}
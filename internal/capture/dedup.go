@@ -0,0 +1,27 @@
+package capture
+
+import "strings"
+
+// ToolLookup is implemented by registrars that know which endpoints
+// already have a tool, so those aren't named and registered again, and
+// which names are taken.
+type ToolLookup interface {
+	HasTool(method, url string) bool
+	HasToolName(name string) bool
+}
+
+// endpointKey identifies the logical endpoint of a templated path. Method
+// case and a trailing slash don't make a different endpoint.
+func endpointKey(method, path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return strings.ToUpper(method) + " " + path
+}
+
+// DuplicatesSuppressed returns how many sightings of an endpoint were kept
+// from registering it again, either because its registration was still
+// in flight or because its tool already existed.
+func (ec *EndpointCapture) DuplicatesSuppressed() int64 {
+	return ec.duplicates.Load()
+}
@@ -0,0 +1,28 @@
+package capture
+
+import "errors"
+
+// ErrInvalidRequest is returned for ingested requests that can't be
+// recorded.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrUnknownInterface is returned when the capture interface doesn't
+// exist.
+var ErrUnknownInterface = errors.New("no such network interface")
+
+// ErrInvalidFilter is returned for a packet filter pcap can't compile.
+var ErrInvalidFilter = errors.New("invalid packet filter")
+
+// ErrInvalidPathPattern is returned for an ignore or include path pattern
+// that doesn't compile.
+var ErrInvalidPathPattern = errors.New("invalid path pattern")
+
+// ErrCaptureUnsupported reports that packet capture can't run here, for
+// example on an unsupported platform.
+type ErrCaptureUnsupported struct {
+	Reason string
+}
+
+func (e *ErrCaptureUnsupported) Error() string {
+	return "packet capture unsupported: " + e.Reason
+}
@@ -0,0 +1,60 @@
+package capture
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+func TestValidationErrors(t *testing.T) {
+	ec := newTestCapture(t, &recordingRegistrar{})
+	ingest := func(method, path string) func() error {
+		return func() error {
+			return ec.Ingest(&IngestRequest{Method: method, Path: path}, config.Provenance{Via: config.ViaIngest})
+		}
+	}
+
+	tests := []struct {
+		name string
+		op   func() error
+		want error
+	}{
+		{"ingest without a method", ingest("", "/users"), ErrInvalidRequest},
+		{"ingest a method that isn't a token", ingest("GET /users", "/users"), ErrInvalidRequest},
+		{"ingest an unparseable path", ingest("GET", "/users/%zz"), ErrInvalidRequest},
+		{"ingest a relative path", ingest("GET", "users"), ErrInvalidRequest},
+		{"bad regexp pattern", func() error { return ValidatePathPatterns([]string{"re:("}) }, ErrInvalidPathPattern},
+		{"empty pattern", func() error { return ValidatePathPatterns([]string{"/health", ""}) }, ErrInvalidPathPattern},
+		{"bad filter", func() error { return ValidateBPFFilter("tcp port") }, ErrInvalidFilter},
+		{"unknown interface", func() error {
+			ec := newTestCapture(t, &recordingRegistrar{})
+			ec.SetInterface("nosuch0")
+			_, err := ec.captureInterface()
+			return err
+		}, ErrUnknownInterface},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCaptureUnsupported(t *testing.T) {
+	iface := "pktap"
+	if runtime.GOOS == "darwin" {
+		iface = "any"
+	}
+	ec := newTestCapture(t, &recordingRegistrar{})
+	ec.SetInterface(iface)
+
+	_, err := ec.captureInterface()
+	var unsupported *ErrCaptureUnsupported
+	if !errors.As(err, &unsupported) || unsupported.Reason == "" {
+		t.Errorf("--interface %s: err = %v, want *ErrCaptureUnsupported with a reason", iface, err)
+	}
+}
@@ -0,0 +1,95 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcap"
+)
+
+// SetExtraPorts makes packet capture also record requests for the target
+// host on ports, e.g. an admin API next to the main one. Their tools call
+// the port the request was sent to.
+func (ec *EndpointCapture) SetExtraPorts(ports []string) {
+	ec.extraPorts = ports
+}
+
+// SetReplicas makes capture cover other instances of the target, given as
+// host:port. Unlike extra ports, their requests become the same tools as
+// requests to the target.
+func (ec *EndpointCapture) SetReplicas(hosts []string) {
+	ec.replicas = ec.replicas[:0]
+	for _, host := range hosts {
+		ec.replicas = append(ec.replicas, strings.ToLower(host))
+	}
+}
+
+// SetBPFFilter replaces the generated packet filter with expr. Requests
+// are still only recorded when they're for the target's ports.
+func (ec *EndpointCapture) SetBPFFilter(expr string) {
+	ec.bpfFilter = expr
+}
+
+// ValidateBPFFilter compiles expr the way StartCapture would, so a typo
+// fails before capture starts.
+func ValidateBPFFilter(expr string) error {
+	if _, err := pcap.CompileBPFFilter(layers.LinkTypeEthernet, 65536, expr); err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidFilter, expr, err)
+	}
+	return nil
+}
+
+// captureFilter is the --bpf filter, or one matching TCP on every target
+// port.
+func (ec *EndpointCapture) captureFilter() string {
+	if ec.bpfFilter != "" {
+		return ec.bpfFilter
+	}
+	ports := ec.targetPorts()
+	if len(ports) == 1 {
+		return "tcp port " + ports[0]
+	}
+	for i, port := range ports {
+		ports[i] = "port " + port
+	}
+	return "tcp and (" + strings.Join(ports, " or ") + ")"
+}
+
+// targetPorts returns the target's own port followed by the replica and
+// extra ports.
+func (ec *EndpointCapture) targetPorts() []string {
+	ports := []string{ec.targetPort()}
+	for _, port := range append(ec.replicaPorts(), ec.extraPorts...) {
+		if !slices.Contains(ports, port) {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+func (ec *EndpointCapture) replicaPorts() []string {
+	var ports []string
+	for _, host := range ec.replicas {
+		if _, port, err := net.SplitHostPort(host); err == nil {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+func (ec *EndpointCapture) isTargetPort(port string) bool {
+	return slices.Contains(ec.targetPorts(), port)
+}
+
+// extraPort returns the port of host when it is one of the extra ports,
+// or "" for the target's own port and those of replicas.
+func (ec *EndpointCapture) extraPort(host string) string {
+	_, port, err := net.SplitHostPort(host)
+	if err != nil || port == ec.targetPort() || slices.Contains(ec.replicaPorts(), port) {
+		return ""
+	}
+	return port
+}
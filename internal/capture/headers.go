@@ -0,0 +1,55 @@
+package capture
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// SetSensitiveHeaders replaces the request headers whose values are
+// never stored, config.DefaultSensitiveHeaders unless set. Tools name the
+// ones their captured requests carried, so agents know to supply them.
+func (ec *EndpointCapture) SetSensitiveHeaders(names []string) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.sensitiveHeaders = names
+}
+
+// SetKeepAuth stores a ${secret:NAME} reference in place of each sensitive
+// header instead of leaving it out, so tool calls send the header from the
+// environment of the server.
+func (ec *EndpointCapture) SetKeepAuth(on bool) {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.keepAuth = on
+}
+
+func (ec *EndpointCapture) isSensitiveHeader(name string) bool {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+	names := ec.sensitiveHeaders
+	if names == nil {
+		names = config.DefaultSensitiveHeaders
+	}
+	return slices.ContainsFunc(names, func(s string) bool {
+		return strings.EqualFold(name, strings.TrimSpace(s))
+	})
+}
+
+// mergeStrippedHeaders adds the header names in stripped that apiCall
+// hasn't recorded yet and reports whether there were any. Callers must
+// hold ec.mu.
+func mergeStrippedHeaders(apiCall *APICall, stripped []string) bool {
+	grew := false
+	for _, name := range stripped {
+		name = http.CanonicalHeaderKey(name)
+		if !slices.Contains(apiCall.Stripped, name) {
+			apiCall.Stripped = append(apiCall.Stripped, name)
+			grew = true
+		}
+	}
+	slices.Sort(apiCall.Stripped)
+	return grew
+}
@@ -0,0 +1,124 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// maxIngestSize bounds a single ingestion payload.
+const maxIngestSize = 10 << 20
+
+// IngestRequest describes one request observed outside of packet capture,
+// for example by a test suite using mcpifytest.RoundTripper.
+type IngestRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Response *IngestResponse   `json:"response,omitempty"`
+}
+
+type IngestResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Ingest feeds a described request through the same pipeline as captured
+// traffic. prov says who handed it over.
+func (ec *EndpointCapture) Ingest(in *IngestRequest, prov config.Provenance) error {
+	method := strings.ToUpper(strings.TrimSpace(in.Method))
+	if method == "" {
+		return fmt.Errorf("%w: method is required", ErrInvalidRequest)
+	}
+	if !config.ValidMethod(method) {
+		return fmt.Errorf("%w: unsupported method %q", ErrInvalidRequest, in.Method)
+	}
+
+	u, err := url.Parse(in.Path)
+	if err != nil {
+		return fmt.Errorf("%w: invalid path %q: %w", ErrInvalidRequest, in.Path, err)
+	}
+	if u.Path == "" || !strings.HasPrefix(u.Path, "/") {
+		return fmt.Errorf("%w: path must start with /", ErrInvalidRequest)
+	}
+
+	headers := make(http.Header)
+	for k, v := range in.Headers {
+		headers.Set(k, v)
+	}
+
+	apiCall, x := ec.handleRequest(method, "", u.EscapedPath(), u.Query(), headers, []byte(in.Body), prov)
+	if in.Response != nil && in.Response.Status > 0 {
+		respHeaders := make(http.Header)
+		for k, v := range in.Response.Headers {
+			respHeaders.Set(k, v)
+		}
+		ec.recordResponse(apiCall, x, in.Response.Status, respHeaders, []byte(in.Response.Body))
+	}
+	return nil
+}
+
+// IngestHandler serves POST /api/ingest. It accepts a single IngestRequest
+// or a JSON array of them.
+func (ec *EndpointCapture) IngestHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxIngestSize+1))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		if len(data) > maxIngestSize {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		var batch []*IngestRequest
+		if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(data, &batch)
+		} else {
+			single := &IngestRequest{}
+			err = json.Unmarshal(data, single)
+			batch = append(batch, single)
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		accepted := 0
+		var errs []string
+		for _, in := range batch {
+			if err := ec.Ingest(in, config.Provenance{Via: config.ViaIngest, Client: r.RemoteAddr, TLS: r.TLS != nil}); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			accepted++
+		}
+
+		if len(errs) > 0 {
+			slog.Warn("Ingestion rejected requests", "rejected", len(errs), "errors", strings.Join(errs, "; "))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if accepted == 0 && len(errs) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accepted": accepted,
+			"errors":   errs,
+		})
+	})
+}
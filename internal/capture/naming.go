@@ -0,0 +1,173 @@
+package capture
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/prompts"
+)
+
+const (
+	// maxToolNameLength is the longest tool name MCP clients reliably
+	// accept.
+	maxToolNameLength = 64
+	// compressAbove is the name length past which paths are reduced to
+	// their last few resource nouns.
+	compressAbove = 40
+	// maxNameNouns is how many trailing resource nouns a compressed name
+	// keeps.
+	maxNameNouns = 3
+)
+
+// HeuristicNaming is recorded as what named tools whose names were made
+// from their paths.
+const HeuristicNaming = "heuristic"
+
+// NameCache keeps the names the LLM gave endpoints, keyed by method and
+// templated path, so an endpoint discovered again on a later run gets the
+// same name without another LLM call. *config.Config implements it.
+type NameCache interface {
+	CachedName(endpoint string) (config.CachedName, bool)
+	CacheName(endpoint string, name config.CachedName)
+}
+
+// NamingInput is what the naming prompt is rendered with.
+type NamingInput struct {
+	Method string
+	Path   string
+	// Body is the request body, cut to 500 bytes
+	Body string
+	// Headers holds "Name: value" lines
+	Headers string
+	// Suggested is the name generateToolName would give
+	Suggested string
+}
+
+var (
+	validToolName = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+	invalidChars  = regexp.MustCompile(`[^a-z0-9_-]+`)
+	versionNoun   = regexp.MustCompile(`^v\d+$`)
+)
+
+// generateToolName derives a tool name from method and path, e.g.
+// get_users_user_id for GET /users/{user_id}. Long names keep only the
+// last few resource nouns, and anything still over maxToolNameLength is
+// cut and given a hash of the endpoint.
+func (ec *EndpointCapture) generateToolName(method, path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	// Method tokens may hold characters tool names can't, like . or ~
+	verb := nameWord(method)
+
+	var parts []string
+	for _, segment := range strings.Split(path, "/") {
+		if part := nameWord(strings.Trim(segment, "{}")); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return verb + "_root"
+	}
+
+	name := verb + "_" + strings.Join(parts, "_")
+	if len(name) > compressAbove {
+		name = verb + "_" + strings.Join(compressPath(path), "_")
+	}
+	if len(name) > maxToolNameLength {
+		suffix := "_" + endpointHash(method, path)
+		name = strings.TrimRight(name[:maxToolNameLength-len(suffix)], "_") + suffix
+	}
+	return name
+}
+
+// compressPath returns the last maxNameNouns resource nouns of path,
+// skipping boilerplate like api and v1, followed by the trailing
+// parameter if the path ends in one, so collections and items differ.
+func compressPath(path string) []string {
+	var nouns []string
+	var param string
+	for _, segment := range strings.Split(path, "/") {
+		param = ""
+		if strings.HasPrefix(segment, "{") {
+			param = nameWord(strings.Trim(segment, "{}"))
+			continue
+		}
+		word := nameWord(segment)
+		if word == "" || word == "api" || versionNoun.MatchString(word) {
+			continue
+		}
+		nouns = append(nouns, word)
+	}
+	if len(nouns) > maxNameNouns {
+		nouns = nouns[len(nouns)-maxNameNouns:]
+	}
+	if param != "" {
+		nouns = append(nouns, param)
+	}
+	return nouns
+}
+
+// nameWord lowercases s and replaces characters tool names can't hold.
+func nameWord(s string) string {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, ".json"), ".xml")
+	return strings.Trim(invalidChars.ReplaceAllString(strings.ToLower(s), "_"), "_")
+}
+
+// endpointHash is a short, stable hash of an endpoint.
+func endpointHash(method, path string) string {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToUpper(method) + " " + path))
+	return fmt.Sprintf("%06x", h.Sum32()&0xffffff)
+}
+
+// uniqueToolName returns name, or name with a hash of the endpoint when
+// another endpoint's tool already has it.
+func (ec *EndpointCapture) uniqueToolName(name, method, path string) string {
+	lookup, ok := ec.toolRegistrar.(ToolLookup)
+	if !ok || !lookup.HasToolName(name) {
+		return name
+	}
+	suffix := "_" + endpointHash(method, path)
+	unique := name
+	if len(unique)+len(suffix) > maxToolNameLength {
+		unique = strings.TrimRight(unique[:maxToolNameLength-len(suffix)], "_")
+	}
+	unique += suffix
+	slog.Info("Tool name is taken; using another", "name", name, "unique", unique)
+	return unique
+}
+
+// namingPrompt is the prompt set with SetPrompts, or the embedded one.
+func (ec *EndpointCapture) namingPrompt() *prompts.Prompt {
+	set := ec.prompts
+	if set == nil {
+		set = prompts.Default()
+	}
+	return set.MustGet(prompts.Naming)
+}
+
+// llmToolName names the endpoint with the LLM, or takes the name cached
+// for it. Names made by the LLM are cached; heuristic fallbacks aren't, so
+// the endpoint is asked about again next time.
+func (ec *EndpointCapture) llmToolName(req toolRequest) (string, string) {
+	key := endpointKey(req.method, req.path)
+	if ec.names != nil && !ec.refreshNames {
+		if cached, ok := ec.names.CachedName(key); ok && cached.Name != "" {
+			slog.Debug("Using cached tool name", "endpoint", key, "tool", cached.Name)
+			return cached.Name, cached.NamedBy
+		}
+	}
+	// An unhealthy provider gets no new calls until its breaker probes it
+	if !ec.llmHealth.Allow() {
+		return ec.generateToolName(req.method, req.path), HeuristicNaming
+	}
+	name, namedBy := ec.GenerateToolNameWithLLM(req.method, req.path, []byte(req.body), req.headers)
+	if ec.names != nil && namedBy != HeuristicNaming {
+		ec.names.CacheName(key, config.CachedName{Name: name, NamedBy: namedBy, At: time.Now()})
+	}
+	return name, namedBy
+}
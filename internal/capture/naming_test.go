@@ -0,0 +1,139 @@
+package capture
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+func TestFailingLLMFallsBackToHeuristicName(t *testing.T) {
+	var attempts atomic.Int32
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, `{"error":{"message":"unavailable"}}`, http.StatusServiceUnavailable)
+	}))
+	defer provider.Close()
+
+	registrar := &recordingRegistrar{}
+	target, _ := url.Parse("http://localhost:8080")
+	ec := NewEndpointCapture(target, registrar, true, "test-key", provider.URL, "test-model")
+
+	if err := ec.Ingest(&IngestRequest{Method: "GET", Path: "/widgets/7"}, config.Provenance{Via: config.ViaIngest}); err != nil {
+		t.Fatal(err)
+	}
+	if !ec.WaitRegistrations(llmNamingTimeout) {
+		t.Fatal("registration did not finish")
+	}
+
+	tools := registrar.registered()
+	if len(tools) != 1 {
+		t.Fatalf("got %d registrations, want 1", len(tools))
+	}
+	if want := ec.generateToolName("GET", "/widgets/{widget_id}"); tools[0].name != want {
+		t.Errorf("tool name = %q, want the heuristic %q", tools[0].name, want)
+	}
+	if got, want := attempts.Load(), int32(1+llmNamingRetries); got != want {
+		t.Errorf("provider called %d times, want %d", got, want)
+	}
+
+	ec.mu.RLock()
+	namedBy := ec.seenAPIs["GET /widgets/{widget_id}"].namedBy
+	ec.mu.RUnlock()
+	if namedBy != HeuristicNaming {
+		t.Errorf("named by %q, want %q", namedBy, HeuristicNaming)
+	}
+}
+
+func TestEmptyLLMAnswerFallsBackToHeuristicName(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","object":"chat.completion","created":0,"model":"test-model","choices":[]}`))
+	}))
+	defer provider.Close()
+
+	target, _ := url.Parse("http://localhost:8080")
+	ec := NewEndpointCapture(target, &recordingRegistrar{}, true, "test-key", provider.URL, "test-model")
+
+	done := make(chan struct{})
+	var name, namedBy string
+	go func() {
+		defer close(done)
+		name, namedBy = ec.GenerateToolNameWithLLM("DELETE", "/widgets/{widget_id}", nil, nil)
+	}()
+	select {
+	case <-done:
+	case <-time.After(llmNamingTimeout):
+		t.Fatal("naming did not return")
+	}
+	if want := "delete_widgets_widget_id"; name != want || namedBy != HeuristicNaming {
+		t.Errorf("got %q by %q, want %q by %q", name, namedBy, want, HeuristicNaming)
+	}
+}
+
+// nameLookup reports the names in taken as used by other endpoints.
+type nameLookup struct {
+	recordingRegistrar
+	taken map[string]bool
+}
+
+func (l *nameLookup) HasTool(method, url string) bool { return false }
+func (l *nameLookup) HasToolName(name string) bool    { return l.taken[name] }
+
+// randomEndpoint is a method and path of up to 20 segments mixing
+// resource nouns, parameters, version and api segments and characters
+// tool names can't hold.
+type randomEndpoint struct {
+	Method, Path string
+}
+
+func (randomEndpoint) Generate(r *rand.Rand, size int) reflect.Value {
+	methods := []string{"GET", "POST", "PUT", "PATCH", "DELETE", "PROPFIND", "x.custom~verb"}
+	words := []string{"api", "v1", "v22", "users", "orders", "line-items", "{id}", "{order_id}", "Ünïcode", "a.json", "%2F", "", "report.xml", "UPPER_case", "very_long_resource_name_that_goes_on"}
+	var path strings.Builder
+	for range r.Intn(20) + 1 {
+		path.WriteString("/" + words[r.Intn(len(words))])
+		if r.Intn(4) == 0 {
+			path.WriteString(strconv.Itoa(r.Intn(1000)))
+		}
+	}
+	return reflect.ValueOf(randomEndpoint{Method: methods[r.Intn(len(methods))], Path: path.String()})
+}
+
+func TestGeneratedToolNamesAreValidAndBounded(t *testing.T) {
+	ec := newTestCapture(t, &recordingRegistrar{})
+	valid := func(e randomEndpoint) bool {
+		name := ec.generateToolName(e.Method, e.Path)
+		return len(name) <= maxToolNameLength && validToolName.MatchString(name) && name == ec.generateToolName(e.Method, e.Path)
+	}
+	if err := quick.Check(valid, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUniqueToolNamesDifferFromTakenOnes(t *testing.T) {
+	lookup := &nameLookup{taken: map[string]bool{}}
+	ec := newTestCapture(t, lookup)
+	unique := func(a, b randomEndpoint) bool {
+		if endpointKey(a.Method, a.Path) == endpointKey(b.Method, b.Path) {
+			return true
+		}
+		first := ec.generateToolName(a.Method, a.Path)
+		lookup.taken = map[string]bool{first: true}
+		// b's name is made unique against a's whether or not they clash
+		second := ec.uniqueToolName(ec.generateToolName(b.Method, b.Path), b.Method, b.Path)
+		return second != first && len(second) <= maxToolNameLength && validToolName.MatchString(second)
+	}
+	if err := quick.Check(unique, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,132 @@
+package capture
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultIgnorePaths are the static assets capture skips unless default
+// ignores are turned off.
+var DefaultIgnorePaths = []string{
+	"*.js", "*.mjs", "*.css", "*.map",
+	"*.png", "*.jpg", "*.jpeg", "*.gif", "*.svg", "*.ico",
+	"*.woff", "*.woff2", "/favicon.ico",
+}
+
+// pathPattern is a compiled --ignore-path or --include-path pattern.
+type pathPattern struct {
+	source string
+	re     *regexp.Regexp
+}
+
+// compilePathPattern compiles pattern. "re:" starts a regular expression
+// matched anywhere in the path. Anything else is a glob: one without a
+// slash matches the last path segment, as "*.js" does, and one with a
+// slash the whole path, with * matching within a segment and ** across
+// segments, as "/static/**" does.
+func compilePathPattern(pattern string) (pathPattern, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return pathPattern{}, fmt.Errorf("%w %q: %w", ErrInvalidPathPattern, pattern, err)
+		}
+		return pathPattern{source: pattern, re: re}, nil
+	}
+	if pattern == "" {
+		return pathPattern{}, fmt.Errorf("%w: empty pattern", ErrInvalidPathPattern)
+	}
+
+	var expr strings.Builder
+	if !strings.Contains(pattern, "/") {
+		// The last segment, wherever it is
+		expr.WriteString("(^|/)")
+	} else {
+		expr.WriteString("^")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return pathPattern{source: pattern, re: regexp.MustCompile(expr.String())}, nil
+}
+
+func defaultIgnorePatterns() []pathPattern {
+	patterns, _ := compilePathPatterns(DefaultIgnorePaths)
+	return patterns
+}
+
+func compilePathPatterns(patterns []string) ([]pathPattern, error) {
+	compiled := make([]pathPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := compilePathPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+// ValidatePathPatterns checks patterns the way SetPathFilters would, so a
+// typo fails before capture starts.
+func ValidatePathPatterns(patterns []string) error {
+	_, err := compilePathPatterns(patterns)
+	return err
+}
+
+// SetPathFilters makes capture skip requests whose path matches one of
+// ignore, and, when include isn't empty, those matching none of include.
+// Ignoring wins. Skipped requests never become tools, so health checks and
+// assets don't use up max tools.
+func (ec *EndpointCapture) SetPathFilters(include, ignore []string) error {
+	in, err := compilePathPatterns(include)
+	if err != nil {
+		return err
+	}
+	out, err := compilePathPatterns(ignore)
+	if err != nil {
+		return err
+	}
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.includePaths, ec.ignorePaths = in, out
+	return nil
+}
+
+// skipPath reports whether requests for the escaped path p are skipped,
+// and why.
+func (ec *EndpointCapture) skipPath(p string) (reason string, skip bool) {
+	ec.mu.RLock()
+	include, ignore := ec.includePaths, ec.ignorePaths
+	ec.mu.RUnlock()
+
+	p = path.Clean("/" + p)
+	for _, pattern := range ignore {
+		if pattern.re.MatchString(p) {
+			return "matches ignore pattern " + pattern.source, true
+		}
+	}
+	if len(include) == 0 {
+		return "", false
+	}
+	for _, pattern := range include {
+		if pattern.re.MatchString(p) {
+			return "", false
+		}
+	}
+	return "matches no include pattern", true
+}
@@ -0,0 +1,43 @@
+package capture
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// SampleRecorder is implemented by registrars that keep track of which
+// captured request a tool's headers and body came from.
+type SampleRecorder interface {
+	RecordRequestSample(method, url string, sample *config.RequestSample)
+}
+
+// preferSample reports whether a request from next should replace the one
+// stored from current: requests handed over on purpose win over ambient
+// traffic, and are never replaced by it.
+func preferSample(current, next *config.Provenance) bool {
+	return next.Trusted() && !current.Trusted()
+}
+
+// requestSample copies the stored request, with what named its tool.
+// Callers must hold ec.mu.
+func (c *APICall) requestSample() *config.RequestSample {
+	sample := &config.RequestSample{
+		Headers:         maps.Clone(c.Headers),
+		StrippedHeaders: slices.Clone(c.Stripped),
+		Body:            c.Body,
+	}
+	if c.Provenance != nil {
+		prov := *c.Provenance
+		prov.NamedBy = c.namedBy
+		sample.Provenance = &prov
+	}
+	return sample
+}
+
+func (ec *EndpointCapture) forwardSample(apiCall *APICall, sample *config.RequestSample) {
+	if recorder, ok := ec.toolRegistrar.(SampleRecorder); ok {
+		recorder.RecordRequestSample(apiCall.Method, ec.callURL(apiCall), sample)
+	}
+}
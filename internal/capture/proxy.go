@@ -1,32 +1,47 @@
 package capture
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"fmt"
-	"io"
-	"log"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"net/url"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/events"
+	"github.com/NilayYadav/mcpify/internal/llm"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/prompts"
+	"github.com/NilayYadav/mcpify/internal/redact"
+	"github.com/NilayYadav/mcpify/internal/session"
+	"github.com/NilayYadav/mcpify/internal/webhook"
+	"github.com/NilayYadav/mcpify/internal/workflow"
 	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcap"
+	"github.com/google/gopacket/tcpassembly"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
 
 type ToolRegistrar interface {
-	RegisterTool(name string, method, url string, headers map[string]string, body []byte, description string) error
+	RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error
 }
 
 type EndpointCapture struct {
-	target        *url.URL
+	// target is swapped by Restart, so it is read with currentTarget
+	target        atomic.Pointer[url.URL]
 	toolRegistrar ToolRegistrar
 	seenAPIs      map[string]*APICall
 	mu            sync.RWMutex
@@ -34,64 +49,302 @@ type EndpointCapture struct {
 	llmKey        string
 	llmEndpoint   string
 	llm           string
+	selfTest      selfTest
+	workflows     *workflow.Miner
+	secrets       *redact.Detector
+	observed      *observed.Tracker
+	templater     observed.Templater
+	// responseHeaders returns the response header allowlist for a tool URL
+	responseHeaders func(method, url string) []string
+	proxyAddr       string
+	proxyTLS        bool
+	// iface is the interface packets are captured on; empty means loopback
+	iface      string
+	extraPorts []string
+	bpfFilter  string
+	// includePaths and ignorePaths pick the requests that become tools
+	includePaths []pathPattern
+	ignorePaths  []pathPattern
+	// aliases are other host names of the target, lowercased
+	aliases []string
+	// replicas are the host:port of other instances of the target,
+	// lowercased
+	replicas   []string
+	llmHealth  *llm.Breaker
+	llmLimiter *llm.Limiter
+	prompts    *prompts.Set
+	events     *events.Bus
+	webhook    *webhook.Notifier
+	session    *session.Recorder
+	// names caches LLM-made tool names; refreshNames ignores the cached
+	// ones and replaces them
+	names        NameCache
+	refreshNames bool
+	// duplicates counts sightings of an endpoint that would otherwise have
+	// registered it a second time
+	duplicates atomic.Int64
+	// requests counts HTTP requests parsed from packets
+	requests atomic.Int64
+	// parseFailures counts requests and responses in packets that
+	// couldn't be parsed
+	parseFailures atomic.Int64
+	// discovered counts endpoints seen for the first time
+	discovered atomic.Int64
+	verbosity  atomic.Int32
+	// sensitiveHeaders are never stored; nil means
+	// config.DefaultSensitiveHeaders
+	sensitiveHeaders []string
+	keepAuth         bool
+	// registering tracks tool registrations still running, so a replay
+	// can wait for them
+	registering sync.WaitGroup
+	// restart stops running capture so it starts again; nil while none
+	// runs
+	restart context.CancelFunc
+	// ready is closed once capture has opened its handle or proxy
+	// listener; a restart replaces it
+	ready chan struct{}
 }
 
 type APICall struct {
 	Method      string            `json:"method"`
 	Path        string            `json:"path"`
+	PathParams  map[string]string `json:"path_params,omitempty"`
+	QueryParams map[string]string `json:"query_params,omitempty"`
 	Headers     map[string]string `json:"headers,omitempty"`
-	Body        string            `json:"body,omitempty"`
-	FirstSeen   time.Time         `json:"first_seen"`
-	LastSeen    time.Time         `json:"last_seen"`
-	CallCount   int               `json:"call_count"`
-	StatusCodes []int             `json:"status_codes,omitempty"`
+	// Stripped names the sensitive headers seen but never stored
+	Stripped []string `json:"stripped_headers,omitempty"`
+	Body     string   `json:"body,omitempty"`
+	// BodySchema is merged from every JSON body seen, not just Body
+	BodySchema  *config.BodySchema `json:"body_schema,omitempty"`
+	FirstSeen   time.Time          `json:"first_seen"`
+	LastSeen    time.Time          `json:"last_seen"`
+	CallCount   int                `json:"call_count"`
+	StatusCodes []int              `json:"status_codes,omitempty"`
+	// Port is set for requests captured on one of the extra ports
+	Port     string                 `json:"port,omitempty"`
+	Response *config.ResponseSample `json:"response,omitempty"`
+	// Provenance describes the request Headers and Body were taken from.
+	Provenance *config.Provenance `json:"provenance,omitempty"`
+	// Hosts are the Host headers the endpoint was requested with
+	Hosts []string `json:"hosts,omitempty"`
+	// HostConflict is set once Hosts names more than one alias
+	HostConflict bool `json:"host_conflict,omitempty"`
+
+	registered bool
+	// namedBy is what named the tool, passed on with its provenance
+	namedBy string
 }
 
 func NewEndpointCapture(target *url.URL, toolRegistrar ToolRegistrar, useLLM bool, llmKey, llmEndpoint string, llm string) *EndpointCapture {
-	return &EndpointCapture{
-		target:        target,
+	ec := &EndpointCapture{
 		toolRegistrar: toolRegistrar,
 		seenAPIs:      make(map[string]*APICall),
 		useLLM:        useLLM,
 		llmKey:        llmKey,
 		llmEndpoint:   llmEndpoint,
 		llm:           llm,
+		secrets:       redact.NewDetector(),
+		ignorePaths:   defaultIgnorePatterns(),
+		ready:         make(chan struct{}),
 	}
+	ec.target.Store(target)
+	return ec
 }
 
-func (ec *EndpointCapture) StartCapture(verbose bool) error {
+// SetSecretDetector replaces the default content-based secret detector.
+func (ec *EndpointCapture) SetSecretDetector(d *redact.Detector) {
+	ec.secrets = d
+}
+
+// SetObservedValues makes the capture remember path and query parameter
+// values in t.
+func (ec *EndpointCapture) SetObservedValues(t *observed.Tracker) {
+	ec.observed = t
+}
+
+// SetPathTemplater controls which path segments are collapsed into
+// parameters, so /users/1 and /users/2 become one tool.
+func (ec *EndpointCapture) SetPathTemplater(t observed.Templater) {
+	ec.templater = t
+}
+
+// SetResponseHeaders sets how the response header allowlist for a method
+// and tool URL is looked up. Without it DefaultResponseHeaders apply.
+func (ec *EndpointCapture) SetResponseHeaders(allowlist func(method, url string) []string) {
+	ec.responseHeaders = allowlist
+}
+
+// SetLLMHealth makes LLM naming report to b and use heuristic names while
+// b is open.
+func (ec *EndpointCapture) SetLLMHealth(b *llm.Breaker) {
+	ec.llmHealth = b
+}
 
-	iface, err := getLoopbackInterface()
+// SetLLMLimiter makes naming calls queue for l ahead of background work.
+// A name that can't get a slot within the naming timeout is made up from
+// the path instead.
+func (ec *EndpointCapture) SetLLMLimiter(l *llm.Limiter) {
+	ec.llmLimiter = l
+}
+
+// SetPrompts makes naming use p instead of the embedded prompts.
+func (ec *EndpointCapture) SetPrompts(p *prompts.Set) {
+	ec.prompts = p
+}
+
+// SetEvents makes the capture publish discovered endpoints and tool
+// registrations to b.
+func (ec *EndpointCapture) SetEvents(b *events.Bus) {
+	ec.events = b
+}
+
+// SetDiscoveryWebhook makes the capture tell n about each endpoint it
+// registers a tool for.
+func (ec *EndpointCapture) SetDiscoveryWebhook(n *webhook.Notifier) {
+	ec.webhook = n
+}
+
+// SetSessionRecorder makes the capture record every exchange it sees to
+// r, redacted as tools are.
+func (ec *EndpointCapture) SetSessionRecorder(r *session.Recorder) {
+	ec.session = r
+}
+
+// SetNameCache makes the capture take names the LLM gave endpoints on
+// earlier runs from c, and cache the ones it gives. With refresh set,
+// cached names are ignored and every endpoint is named again.
+func (ec *EndpointCapture) SetNameCache(c NameCache, refresh bool) {
+	ec.names = c
+	ec.refreshNames = refresh
+}
+
+// SetWorkflowMiner makes the capture feed request order into m.
+func (ec *EndpointCapture) SetWorkflowMiner(m *workflow.Miner) {
+	ec.workflows = m
+}
+
+// SetInterface makes StartCapture capture on the named interface instead of
+// loopback, for targets reached over docker0 and the like. "any" captures on
+// every interface (Linux only), as does "pktap" (macOS only).
+func (ec *EndpointCapture) SetInterface(name string) {
+	ec.iface = name
+}
+
+// StartCapture captures packets to the target until ctx is done. Requests
+// already in flight are then parsed and registered before it returns nil.
+func (ec *EndpointCapture) StartCapture(ctx context.Context) error {
+	return ec.restartable(ctx, ec.capturePackets)
+}
+
+// capturePackets captures packets to the current target until ctx is
+// done.
+func (ec *EndpointCapture) capturePackets(ctx context.Context) error {
+	iface, err := ec.captureInterface()
 	if err != nil {
 		return err
 	}
+	if ec.iface != "" {
+		slog.Info("Capturing packets", "interface", iface)
+	}
 
-	handle, err := pcap.OpenLive(iface, 65536, true, pcap.BlockForever)
+	handle, err := pcap.OpenLive(iface, 65536, true, captureReadTimeout)
 	if err != nil {
+		// libpcap only reports missing privileges as text
+		if msg := strings.ToLower(err.Error()); strings.Contains(msg, "permission") || strings.Contains(msg, "not permitted") {
+			if runtime.GOOS == "darwin" {
+				return fmt.Errorf("failed to open interface %s: %w: %w; to capture without root, %s", iface, fs.ErrPermission, err, strings.Join(bpfFix, "; "))
+			}
+			return fmt.Errorf("failed to open interface %s: %w: %w", iface, fs.ErrPermission, err)
+		}
 		return fmt.Errorf("failed to open interface %s: %w", iface, err)
 	}
 	defer handle.Close()
 
-	port, _ := strconv.Atoi(ec.target.Port())
-	if port == 0 {
-		log.Printf("Invalid or missing port in target URL")
+	if port, _ := strconv.Atoi(ec.currentTarget().Port()); port == 0 && ec.bpfFilter == "" {
+		slog.Warn("Invalid or missing port in target URL")
 	}
 
-	filter := fmt.Sprintf("tcp port %d", port)
+	filter := ec.captureFilter()
 	if err := handle.SetBPFFilter(filter); err != nil {
-		return fmt.Errorf("failed to set packet filter: %w", err)
+		return fmt.Errorf("failed to set packet filter %q: %w", filter, err)
 	}
+	ec.debug(VerbosityEndpoints, "Packet filter", "filter", filter)
+	ec.markReady()
 
 	packetSource := gopacket.NewPacketSource(handle, handle.LinkType())
+	factory := &httpStreamFactory{capture: ec, iface: iface}
+	assembler := tcpassembly.NewAssembler(tcpassembly.NewStreamPool(factory))
+
+	done := make(chan struct{})
+	defer close(done)
+	go ec.reportStats(done)
+
+	// Connections that were already open when capture started never show
+	// a SYN, so their data is pushed through after a short wait
+	flush := time.NewTicker(time.Second)
+	defer flush.Stop()
+	lastClose := time.Now()
+
+	packets := packetSource.Packets()
+	for {
+		select {
+		case packet, ok := <-packets:
+			if !ok {
+				assembler.FlushAll()
+				return nil
+			}
+			ec.processPacket(packet, assembler)
+		case <-ctx.Done():
+			// Connections are cut off here; what they carried so far is
+			// parsed like a finished connection's
+			assembler.FlushAll()
+			factory.streams.Wait()
+			return nil
+		case now := <-flush.C:
+			assembler.FlushWithOptions(tcpassembly.FlushOptions{T: now.Add(-time.Second)})
+			if now.Sub(lastClose) > time.Minute {
+				assembler.FlushOlderThan(now.Add(-streamIdleTimeout))
+				lastClose = now
+			}
+		}
+	}
+}
 
-	for packet := range packetSource.Packets() {
-		if verbose {
-			fmt.Printf("Packet captured\n")
+// captureInterface returns the interface set with SetInterface, checked
+// against the ones pcap can open, or loopback.
+func (ec *EndpointCapture) captureInterface() (string, error) {
+	switch ec.iface {
+	case "":
+		return getLoopbackInterface()
+	case "any":
+		// pcap's "any" pseudo-device only exists on Linux
+		if runtime.GOOS != "linux" {
+			return "", &ErrCaptureUnsupported{Reason: `--interface any is only available on Linux`}
 		}
-		ec.processPacket(packet, verbose)
+		return "any", nil
+	}
+	// pktap, or pktap,lo0,en0, is a macOS pseudo-device pcap doesn't list
+	if name, _, _ := strings.Cut(ec.iface, ","); name == "pktap" {
+		if runtime.GOOS != "darwin" {
+			return "", &ErrCaptureUnsupported{Reason: `--interface pktap is only available on macOS`}
+		}
+		return ec.iface, nil
 	}
 
-	return nil
+	devices, err := pcap.FindAllDevs()
+	if err != nil {
+		// Let OpenLive report the underlying problem
+		return ec.iface, nil
+	}
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		if device.Name == ec.iface {
+			return ec.iface, nil
+		}
+		names = append(names, device.Name)
+	}
+	return "", fmt.Errorf("%w: %q (available: %s)", ErrUnknownInterface, ec.iface, strings.Join(names, ", "))
 }
 
 func getLoopbackInterface() (string, error) {
@@ -101,90 +354,178 @@ func getLoopbackInterface() (string, error) {
 	case "darwin", "freebsd", "openbsd":
 		return "lo0", nil
 	case "windows":
-		return "", fmt.Errorf("Windows is not supported at the moment")
+		return "", &ErrCaptureUnsupported{Reason: "Windows is not supported at the moment"}
 	default:
 		return "lo0", nil
 	}
 }
 
-func (ec *EndpointCapture) processPacket(packet gopacket.Packet, verbose bool) {
-	if appLayer := packet.ApplicationLayer(); appLayer != nil {
-		payload := appLayer.Payload()
+// processPacket feeds TCP segments to the assembler, which hands complete
+// streams to httpStream readers. Requests whose bodies span several
+// segments are only parsed once they're whole.
+func (ec *EndpointCapture) processPacket(packet gopacket.Packet, assembler *tcpassembly.Assembler) {
+	ec.selfTest.packets.Add(1)
 
-		if ec.isHTTPRequest(payload) {
-			if verbose {
-				log.Printf("HTTP request detected")
-			}
-			ec.parseHTTPRequest(payload, verbose)
-		}
+	netLayer := packet.NetworkLayer()
+	tcp, ok := packet.TransportLayer().(*layers.TCP)
+	if netLayer == nil || !ok {
+		return
 	}
+	assembler.AssembleWithTimestamp(netLayer.NetworkFlow(), tcp, packet.Metadata().Timestamp)
 }
 
-func (ec *EndpointCapture) isHTTPRequest(payload []byte) bool {
-	payloadStr := string(payload)
-	return strings.HasPrefix(payloadStr, "GET ") ||
-		strings.HasPrefix(payloadStr, "POST ") ||
-		strings.HasPrefix(payloadStr, "PUT ") ||
-		strings.HasPrefix(payloadStr, "DELETE ") ||
-		strings.HasPrefix(payloadStr, "PATCH ") ||
-		strings.HasPrefix(payloadStr, "HEAD ") ||
-		strings.HasPrefix(payloadStr, "OPTIONS ")
+// processRequest handles one request read from a client stream and returns
+// the recorded call and its exchange in the session file, or nils if the
+// request was skipped.
+func (ec *EndpointCapture) processRequest(req *http.Request, body []byte, prov config.Provenance) (*APICall, *session.Exchange) {
+	isTarget := ec.isTargetRequest(req)
+
+	// mcpify's own requests, the self-test included, must never become tools
+	if ec.selfTest.observe(req, isTarget) {
+		ec.debug(VerbosityEndpoints, "Skipping request sent by mcpify", "marker", req.Header.Get(config.MarkerHeader))
+		return nil, nil
+	}
+
+	// Check if this request is for our target host
+	if !isTarget {
+		ec.debug(VerbosityEndpoints, "Skipping request for another host", "host", req.Host)
+		return nil, nil
+	}
+
+	prov.Host = req.Host
+	return ec.handleRequest(req.Method, ec.extraPort(req.Host), req.URL.EscapedPath(), req.URL.Query(), req.Header, body, prov)
 }
 
-func (ec *EndpointCapture) parseHTTPRequest(payload []byte, verbose bool) {
-	// Create a reader from the payload
-	reader := bytes.NewReader(payload)
-	bufReader := bufio.NewReader(reader)
+// handleRequest runs a parsed request for the target through the rest of
+// the pipeline, unless its path is skipped, in which case it returns
+// nils. Both live capture and ingestion end up here. path is the escaped
+// path as sent, so tools call exactly what was captured, and prov says
+// where the request came from. port is set when the request was for one
+// of the extra ports rather than the target's own. The exchange returned,
+// nil without a session file, takes the response.
+func (ec *EndpointCapture) handleRequest(method, port, path string, query url.Values, httpHeaders http.Header, bodyBytes []byte, prov config.Provenance) (*APICall, *session.Exchange) {
+	if reason, skip := ec.skipPath(path); skip {
+		ec.debug(VerbosityEndpoints, "Skipping request", "method", method, "path", ec.secrets.Path(path), "reason", reason)
+		return nil, nil
+	}
+	if prov.CapturedAt.IsZero() {
+		prov.CapturedAt = time.Now()
+	}
 
-	// parse http request
-	req, err := http.ReadRequest(bufReader)
-	if err != nil {
-		if verbose {
-			log.Printf("Failed to parse HTTP request: %v", err)
+	// Secrets are stripped before anything is stored or sent to the LLM
+	path = ec.secrets.Path(path)
+	bodyBytes = []byte(ec.secrets.Body(string(bodyBytes)))
+	template, pathParams := ec.templater.Template(path)
+	for name, value := range pathParams {
+		// A redacted value is no use as a default
+		if value == "{token}" {
+			delete(pathParams, name)
 		}
-		return
 	}
-	defer req.Body.Close()
 
-	// Check if this request is for our target host
-	if !ec.isTargetRequest(req) {
-		if verbose {
-			log.Printf("Skipping request for %s (not our target)", req.Host)
-		}
-		return
+	if ec.observed != nil {
+		ec.observeValues(method, template, pathParams, query)
 	}
 
-	// Read the request body
-	bodyBytes, err := io.ReadAll(req.Body)
-	if err != nil {
-		bodyBytes = []byte{}
+	if len(bodyBytes) > 0 {
+		ec.debug(VerbosityPayloads, "Request body", "method", method, "path", path, "body", ec.truncateString(string(bodyBytes), 100))
 	}
 
-	if verbose {
-		log.Printf("Captured: %s %s", req.Method, req.URL.Path)
-		if len(bodyBytes) > 0 {
-			log.Printf("Body: %s", ec.truncateString(string(bodyBytes), 100))
+	// Convert headers to simple map and filter sensitive ones
+	headers, stripped := ec.extractHeaders(httpHeaders)
+	headers = ec.secrets.Headers(headers)
+	ec.mu.RLock()
+	keepAuth := ec.keepAuth
+	ec.mu.RUnlock()
+	if keepAuth {
+		for _, name := range stripped {
+			headers[name] = config.HeaderSecretRef(name)
 		}
+		stripped = nil
 	}
 
-	// Convert headers to simple map and filter sensitive ones
-	headers := ec.extractHeaders(req.Header)
+	queryParams := ec.queryDefaults(query)
+	apiCall := ec.recordAPICall(method, port, template, pathParams, queryParams, headers, stripped, string(bodyBytes), &prov)
 
-	ec.recordAPICall(req.Method, req.URL.Path, headers, string(bodyBytes))
+	if ec.workflows != nil {
+		ec.workflows.Observe(workflowSession(httpHeaders, &prov), method+" "+template, time.Now())
+	}
+
+	// recordAPICall keeps the query and header maps, and merges into them
+	// later
+	x := ec.session.Request(session.Entry{
+		Time:     prov.CapturedAt,
+		Endpoint: endpointKey(method, template),
+		Method:   method,
+		Path:     path,
+		Query:    maps.Clone(queryParams),
+		Headers:  maps.Clone(headers),
+		Body:     string(bodyBytes),
+		Via:      prov.Via,
+	})
+	return apiCall, x
+}
+
+func (ec *EndpointCapture) observeValues(method, template string, params map[string]string, query url.Values) {
+	now := time.Now()
+	endpoint := method + " " + template
+
+	for name, value := range params {
+		ec.observed.Observe(endpoint, name, value, now)
+	}
+	for name, values := range query {
+		if len(values) == 0 {
+			continue
+		}
+		if ec.secrets.SensitiveParam(name) {
+			continue
+		}
+		value := values[0]
+		if !ec.secrets.Allowed(name) {
+			value = ec.secrets.Value(value)
+		}
+		ec.observed.Observe(endpoint, name, value, now)
+	}
+}
+
+// workflowSession keys request sequences by cookie when present, since
+// that survives new connections, falling back to the client address.
+// Ingested requests are keyed by connection, as a test runner's requests
+// all come from one host.
+func workflowSession(headers http.Header, prov *config.Provenance) string {
+	if cookie := headers.Get("Cookie"); cookie != "" {
+		h := fnv.New64a()
+		h.Write([]byte(cookie))
+		return fmt.Sprintf("cookie:%x", h.Sum64())
+	}
+	if prov.Via == config.ViaIngest {
+		return "addr:ingest:" + prov.Client
+	}
+	host, _, err := net.SplitHostPort(prov.Client)
+	if err != nil {
+		host = prov.Client
+	}
+	return "addr:" + host
 }
 
 func (ec *EndpointCapture) isTargetRequest(req *http.Request) bool {
-	targetHost := ec.target.Host
+	targetHost := ec.currentTarget().Host
 	reqHost := req.Host
 
 	if !strings.Contains(targetHost, ":") {
-		log.Printf("Target host missing port")
+		slog.Debug("Target host missing port")
+	}
+	if reqHost == targetHost {
+		return true
+	}
+
+	if slices.Contains(ec.aliases, strings.ToLower(reqHost)) || slices.Contains(ec.replicas, strings.ToLower(reqHost)) {
+		return true
 	}
 
-	// Check direct match or localhost variant
-	return reqHost == targetHost ||
-		reqHost == "localhost:"+ec.target.Port() ||
-		reqHost == ec.target.Hostname()+":"+ec.target.Port()
+	// The target host or one of its aliases on any target port
+	host, port, err := net.SplitHostPort(reqHost)
+	return err == nil && ec.isTargetHost(host) && ec.isTargetPort(port)
 }
 
 func (ec *EndpointCapture) truncateString(s string, maxLen int) string {
@@ -194,80 +535,233 @@ func (ec *EndpointCapture) truncateString(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
-func (ec *EndpointCapture) recordAPICall(method, path string, headers map[string]string, body string) {
+// recordAPICall keys calls on the templated path without the query, so
+// requests differing only in IDs or query strings share one tool. The first
+// value seen for each parameter becomes its default. stripped names the
+// sensitive headers left out of headers.
+func (ec *EndpointCapture) recordAPICall(method, port, path string, pathParams, queryParams map[string]string, headers map[string]string, stripped []string, body string, prov *config.Provenance) *APICall {
 	ec.mu.Lock()
 	defer ec.mu.Unlock()
 
-	key := fmt.Sprintf("%s_%s", method, path)
+	key := endpointKey(method, path)
+	if port != "" {
+		key = port + " " + key
+	}
 	now := time.Now()
 
 	if existing, exists := ec.seenAPIs[key]; exists {
 		existing.LastSeen = now
 		existing.CallCount++
-	} else {
-		apiCall := &APICall{
-			Method:    method,
-			Path:      path,
-			Headers:   ec.filterSensitiveHeaders(headers),
-			Body:      body,
-			FirstSeen: now,
-			LastSeen:  now,
-			CallCount: 1,
+		ec.noteHost(existing, prov.Host)
+		if !existing.registered {
+			ec.duplicates.Add(1)
+		}
+		// Unregistered calls pass their parameters on once registration is done
+		if added := mergeQueryParams(existing, queryParams); len(added) > 0 && existing.registered {
+			go ec.forwardQueryParams(existing, added)
+		}
+		if mergeBodySchema(existing, body) && existing.registered {
+			go ec.forwardBodySchema(existing, existing.BodySchema)
+		}
+		strippedGrew := mergeStrippedHeaders(existing, stripped)
+		preferred := preferSample(existing.Provenance, prov)
+		if preferred {
+			existing.Headers = headers
+			existing.Body = body
+			existing.Provenance = prov
 		}
+		if (preferred || strippedGrew) && existing.registered {
+			go ec.forwardSample(existing, existing.requestSample())
+		}
+		return existing
+	}
 
-		ec.seenAPIs[key] = apiCall
+	apiCall := &APICall{
+		Method:      method,
+		Path:        path,
+		Port:        port,
+		PathParams:  pathParams,
+		QueryParams: queryParams,
+		Headers:     headers,
+		Stripped:    stripped,
+		Body:        body,
+		BodySchema:  config.InferBodySchema(body),
+		Provenance:  prov,
+		FirstSeen:   now,
+		LastSeen:    now,
+		CallCount:   1,
+	}
+	ec.noteHost(apiCall, prov.Host)
 
-		go ec.registerMCPTool(apiCall)
+	ec.seenAPIs[key] = apiCall
 
-		log.Printf("New endpoint discovered: %s %s", method, path)
+	// A trusted sample may replace Headers and Body while registration
+	// runs, so it works from a copy
+	req := apiCall.toolRequest()
+	ec.registering.Add(1)
+	go func() {
+		defer ec.registering.Done()
+		ec.registerMCPTool(apiCall, req)
+	}()
+
+	if port != "" {
+		slog.Info("New endpoint discovered", "method", method, "path", path, "port", port)
+	} else {
+		slog.Info("New endpoint discovered", "method", method, "path", path)
 	}
+	ec.discovered.Add(1)
+	ec.events.Publish(events.EndpointDiscovered, map[string]string{"method": method, "path": path})
+	return apiCall
 }
 
-func (ec *EndpointCapture) registerMCPTool(apiCall *APICall) {
-	// toolName := ec.generateToolName(apiCall.Method, apiCall.Path)
-	// toolNameLLM := ec.GenerateToolNameWithLLM(apiCall.Method, apiCall.Path, []byte(apiCall.Body), apiCall.Headers)
-	var toolName string
+// toolRequest is what naming and registration read from an APICall.
+type toolRequest struct {
+	method     string
+	path       string
+	pathParams map[string]string
+	headers    map[string]string
+	body       string
+}
+
+// toolRequest copies the request a tool is registered from. Callers must
+// hold ec.mu.
+func (c *APICall) toolRequest() toolRequest {
+	return toolRequest{
+		method:     c.Method,
+		path:       c.Path,
+		pathParams: maps.Clone(c.PathParams),
+		headers:    maps.Clone(c.Headers),
+		body:       c.Body,
+	}
+}
+
+// registerMCPTool names and registers the tool for apiCall from req,
+// copied from it when it was discovered.
+func (ec *EndpointCapture) registerMCPTool(apiCall *APICall, req toolRequest) {
+	// Tools loaded from the config need neither a name nor a registration
+	if lookup, ok := ec.toolRegistrar.(ToolLookup); ok && lookup.HasTool(req.method, ec.callURL(apiCall)) {
+		ec.duplicates.Add(1)
+		ec.markRegistered(apiCall)
+		return
+	}
+
+	toolName, namedBy := "", HeuristicNaming
 
 	if !ec.useLLM {
-		toolName = ec.generateToolName(apiCall.Method, apiCall.Path)
+		toolName = ec.generateToolName(req.method, req.path)
 	} else {
-		toolName = ec.GenerateToolNameWithLLM(apiCall.Method, apiCall.Path, []byte(apiCall.Body), apiCall.Headers)
+		toolName, namedBy = ec.llmToolName(req)
 	}
 
-	url := ec.target.String() + apiCall.Path
-	description := fmt.Sprintf("Auto-discovered: %s %s", apiCall.Method, apiCall.Path)
+	toolName = ec.uniqueToolName(toolName, req.method, req.path)
+
+	url := ec.callURL(apiCall)
+	description := fmt.Sprintf("Auto-discovered: %s %s", req.method, req.path)
 
 	err := ec.toolRegistrar.RegisterTool(
 		toolName,
-		apiCall.Method,
+		req.method,
 		url,
-		apiCall.Headers,
-		[]byte(apiCall.Body),
+		req.pathParams,
+		req.headers,
+		[]byte(req.body),
 		description,
 	)
 
 	if err != nil {
-		log.Printf("Failed to register tool %s: %v", toolName, err)
-	} else {
-		log.Printf("MCP tool registered: %s", toolName)
+		slog.Error("Failed to register tool", "tool", toolName, "error", err)
+		ec.events.Publish(events.ToolRegistrationFailed, map[string]string{
+			"tool": toolName, "method": req.method, "url": url, "error": err.Error(),
+		})
+		return
 	}
+	ec.mu.Lock()
+	apiCall.namedBy = namedBy
+	ec.mu.Unlock()
+	slog.Info("MCP tool registered", "tool", toolName, "method", req.method, "url", url)
+	ec.events.Publish(events.ToolRegistered, map[string]string{"tool": toolName, "method": req.method, "url": url})
+	ec.webhook.Notify(webhook.Discovery{
+		Method:    req.method,
+		Path:      req.path,
+		Tool:      toolName,
+		Timestamp: time.Now(),
+		Target:    ec.currentTarget().String(),
+	})
+	ec.markRegistered(apiCall)
 }
 
-func (ec *EndpointCapture) generateToolName(method, path string) string {
-	safePath := strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
-	if safePath == "" {
-		safePath = "root"
+// WaitRegistrations waits up to timeout for tool registrations still
+// running, such as ones waiting on the LLM to name them, and reports
+// whether they all finished.
+func (ec *EndpointCapture) WaitRegistrations(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		ec.registering.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
 	}
+}
 
-	if queryPos := strings.Index(safePath, "?"); queryPos > 0 {
-		safePath = safePath[:queryPos]
+// markRegistered passes on what was recorded for apiCall while its tool
+// was being registered.
+func (ec *EndpointCapture) markRegistered(apiCall *APICall) {
+	ec.mu.Lock()
+	apiCall.registered = true
+	response := apiCall.Response
+	queryParams := maps.Clone(apiCall.QueryParams)
+	bodySchema := apiCall.BodySchema
+	sample := apiCall.requestSample()
+	ec.mu.Unlock()
+	ec.forwardSample(apiCall, sample)
+	if response != nil {
+		ec.forwardResponse(apiCall, response)
+	}
+	if len(queryParams) > 0 {
+		ec.forwardQueryParams(apiCall, queryParams)
+	}
+	if bodySchema != nil {
+		ec.forwardBodySchema(apiCall, bodySchema)
 	}
+}
 
-	return fmt.Sprintf("%s_%s", strings.ToLower(method), safePath)
+// toolURL is the URL tools for path are called with. path is escaped
+// already and is appended as is: re-encoding it through url.URL would
+// turn its {param} placeholders into %7B...%7D.
+func (ec *EndpointCapture) toolURL(path string) string {
+	target := ec.currentTarget()
+	base := url.URL{Scheme: target.Scheme, User: target.User, Host: target.Host}
+	return base.String() + strings.TrimSuffix(target.EscapedPath(), "/") + path
 }
 
-func (ec *EndpointCapture) GenerateToolNameWithLLM(method, path string, requestBody []byte, headers map[string]string) string {
-	println("Generating tool name with LLM for:", method, path)
+// callURL is the tool URL for apiCall, on the port it was captured on.
+// Extra ports serve their own APIs, so the target's base path isn't used.
+func (ec *EndpointCapture) callURL(apiCall *APICall) string {
+	if apiCall.Port == "" {
+		return ec.toolURL(apiCall.Path)
+	}
+	target := ec.currentTarget()
+	base := url.URL{Scheme: target.Scheme, User: target.User, Host: net.JoinHostPort(target.Hostname(), apiCall.Port)}
+	return base.String() + apiCall.Path
+}
+
+const (
+	// llmNamingRetries is how often a naming call is retried after a 429,
+	// 5xx or connection error; the client backs off 0.5s, then 1s.
+	llmNamingRetries = 2
+	// llmNamingTimeout bounds a naming call including its retries.
+	llmNamingTimeout = 20 * time.Second
+)
+
+// GenerateToolNameWithLLM names the endpoint with the naming prompt. It
+// also returns what made the name: the prompt's Ref, or HeuristicNaming
+// when it fell back to the path.
+func (ec *EndpointCapture) GenerateToolNameWithLLM(method, path string, requestBody []byte, headers map[string]string) (string, string) {
+	slog.Debug("Generating tool name with LLM", "method", method, "path", path)
 
 	body := string(requestBody)
 	if len(body) > 500 {
@@ -280,70 +774,37 @@ func (ec *EndpointCapture) GenerateToolNameWithLLM(method, path string, requestB
 	}
 	headersStr := strings.Join(headerParts, "\n")
 
-	systemPrompt := `Role:
-	You analyze HTTP API requests and output a single, concise snake_case tool name describing the endpoints primary action.
-
-	Output:
-	- Return ONLY the tool name. No quotes, no punctuation, no explanations.
-
-	Naming rules (strict):
-	- 2-4 words in snake_case, lowercase.
-	- Prefer resource names from the PATH. Ignore headers. Ignore the request body for GET and DELETE.
-	- Use CRUD verbs unless the path indicates a domain action.
-
-	Method → verb mapping:
-	- GET /collection           → list_<plural_resource>
-	- GET /collection/{id}      → get_<singular_resource>
-	- POST /collection          → create_<singular_resource>
-	- PUT/PATCH /collection/{id}→ update_<singular_resource>
-	- DELETE /collection/{id}   → delete_<singular_resource>
-
-	Refinements:
-	- Queries: if path includes /search OR query has q/query/search/keyword → search_<plural_resource>; otherwise use list_<plural_resource>.
-	- Sub-resources: /users/{id}/orders
-	- GET collection           → list_user_orders
-	- GET item                 → get_user_order
-	- POST collection          → create_user_order
-	- PUT/PATCH/DELETE item    → update/delete_user_order
-	- Action endpoints (last segment is a verb): e.g., /orders/{id}/cancel → cancel_order; /users/{id}/reset-password → reset_user_password.
-	- Auth/health/webhooks:
-	- /login → login
-	- /logout → logout
-	- /refresh or /token/refresh → refresh_token
-	- /health or /status → health_check
-	- /{provider}/webhook (POST) → receive_{provider}_webhook
-	- Reports/analytics nouns:
-	- GET /reports/sales → get_sales_report
-	- POST /reports/sales → generate_sales_report
-	- Bulk ops: paths with /bulk or /batch → prefix with bulk_, e.g., bulk_create_orders.
-	- Versioning and extensions: drop /v1, /v2, and extensions like .json from names.
-	- IDs: treat {id}, :id, numeric IDs, or UUIDs as identifiers → use singular for that segment.
-	- Singular/plural: collection segments are plural (users), item segments are singular (user). If unsure, keep the path noun as-is (but lowercase).
-
-	Validation guardrails:
-	- Do not infer business domains from headers or body if the path already defines the resource.
-	- Do not use generic names like api_call, http_request, or endpoint.
-	- When method and body conflict (e.g., GET with a JSON body), the METHOD and PATH win.
-
-	Return ONLY the tool name, nothing else.
-`
-
-	prompt := fmt.Sprintf(`HTTP Method: %s
-			Path: %s
-			Request Body: %s
-			Headers: %s
-			Generate a descriptive tool name for this API endpoint.`, method, path, body, headersStr,
-	)
+	prompt := ec.namingPrompt()
+	systemPrompt, userPrompt, err := prompt.Render(NamingInput{
+		Method:    method,
+		Path:      path,
+		Body:      body,
+		Headers:   headersStr,
+		Suggested: ec.generateToolName(method, path),
+	})
+	if err != nil {
+		slog.Warn("Naming prompt failed; using heuristic name", "error", err)
+		return ec.generateToolName(method, path), HeuristicNaming
+	}
 
 	client := openai.NewClient(
 		option.WithBaseURL(ec.llmEndpoint),
 		option.WithAPIKey(ec.llmKey),
+		option.WithMaxRetries(llmNamingRetries),
 	)
 
-	chatCompletion, err := client.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
+	ctx, cancel := context.WithTimeout(context.Background(), llmNamingTimeout)
+	defer cancel()
+	release, err := ec.llmLimiter.Acquire(ctx, llm.Interactive)
+	if err != nil {
+		slog.Warn("LLM busy; using heuristic name", "method", method, "path", path)
+		return ec.generateToolName(method, path), HeuristicNaming
+	}
+	defer release()
+	chatCompletion, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
 		Messages: []openai.ChatCompletionMessageParamUnion{
 			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(prompt),
+			openai.UserMessage(userPrompt),
 		},
 		Model:       ec.llm,
 		Temperature: openai.Float(0.0),
@@ -351,65 +812,38 @@ func (ec *EndpointCapture) GenerateToolNameWithLLM(method, path string, requestB
 	})
 
 	if err != nil {
-		log.Fatal(err)
+		slog.Warn("Failed to generate tool name with LLM; using heuristic name", "error", err)
+		ec.llmHealth.Failure(err)
+		return ec.generateToolName(method, path), HeuristicNaming
 	}
+	ec.llmHealth.Success()
 
-	if err != nil {
-		log.Printf("Failed to generate tool name with LLM: %v", err)
-		return ec.generateToolName(method, path)
+	if len(chatCompletion.Choices) == 0 {
+		slog.Warn("LLM returned no tool name; using heuristic name")
+		return ec.generateToolName(method, path), HeuristicNaming
 	}
+	toolName := strings.ToLower(strings.TrimSpace(chatCompletion.Choices[0].Message.Content))
 
-	// println("LLM response:", chatCompletion.Choices[0].Message.Content)
-	toolName := strings.TrimSpace(chatCompletion.Choices[0].Message.Content)
-
-	if toolName == "" || strings.Contains(toolName, " ") {
-		log.Printf("Invalid tool name generated: '%s', using fallback", toolName)
-		return ec.generateToolName(method, path)
+	if !validToolName.MatchString(toolName) {
+		slog.Warn("LLM generated an invalid tool name; using heuristic name", "name", toolName)
+		return ec.generateToolName(method, path), HeuristicNaming
 	}
 
-	println("Generated tool name:", toolName)
-	return toolName
+	slog.Debug("Generated tool name", "tool", toolName)
+	return toolName, prompt.Ref()
 }
 
-func (ec *EndpointCapture) filterSensitiveHeaders(headers map[string]string) map[string]string {
-	filtered := make(map[string]string)
-	sensitive := []string{"authorization", "cookie", "x-api-key", "x-auth-token"}
-
-	for k, v := range headers {
-		isSensitive := false
-		for _, s := range sensitive {
-			if strings.EqualFold(k, s) {
-				isSensitive = true
-				break
-			}
-		}
-
-		if !isSensitive {
-			filtered[k] = v
-		}
-	}
-
-	return filtered
-}
-
-func (ec *EndpointCapture) extractHeaders(httpHeaders http.Header) map[string]string {
-	headers := make(map[string]string)
-	sensitive := []string{"authorization", "cookie", "x-api-key", "x-auth-token"}
-
+// extractHeaders takes the first value of each header, leaving out
+// sensitive ones, which it names in stripped.
+func (ec *EndpointCapture) extractHeaders(httpHeaders http.Header) (headers map[string]string, stripped []string) {
+	headers = make(map[string]string)
 	for key, values := range httpHeaders {
-		// Skip sensitive headers
-		isSensitive := false
-		for _, s := range sensitive {
-			if strings.EqualFold(key, s) {
-				isSensitive = true
-				break
-			}
-		}
-
-		if !isSensitive && len(values) > 0 {
+		if ec.isSensitiveHeader(key) {
+			stripped = append(stripped, http.CanonicalHeaderKey(key))
+		} else if len(values) > 0 {
 			headers[key] = values[0] // Take first value
 		}
 	}
-
-	return headers
+	slices.Sort(stripped)
+	return headers, stripped
 }
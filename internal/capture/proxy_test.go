@@ -0,0 +1,223 @@
+package capture
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// recordingRegistrar keeps every tool registered with it.
+type recordingRegistrar struct {
+	mu    sync.Mutex
+	tools []registeredTool
+}
+
+type registeredTool struct {
+	name, method, url string
+	pathParams        map[string]string
+	headers           map[string]string
+	body              string
+}
+
+func (r *recordingRegistrar) RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools = append(r.tools, registeredTool{name: name, method: method, url: url, pathParams: pathParams, headers: headers, body: string(body)})
+	return nil
+}
+
+func (r *recordingRegistrar) registered() []registeredTool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]registeredTool(nil), r.tools...)
+}
+
+func newTestCapture(t *testing.T, registrar ToolRegistrar) *EndpointCapture {
+	t.Helper()
+	target, err := url.Parse("http://localhost:8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEndpointCapture(target, registrar, false, "", "", "")
+}
+
+func TestConcurrentSightingsRegisterOnce(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	ec := newTestCapture(t, registrar)
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Variants of one logical endpoint: other IDs, method case
+			// and a trailing slash
+			method, path := "GET", fmt.Sprintf("/users/%d", i+1)
+			if i%2 == 1 {
+				method = "get"
+			}
+			if i%3 == 0 {
+				path += "/"
+			}
+			if err := ec.Ingest(&IngestRequest{Method: method, Path: path}, config.Provenance{Via: config.ViaIngest}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if !ec.WaitRegistrations(5 * time.Second) {
+		t.Fatal("registrations did not finish")
+	}
+
+	tools := registrar.registered()
+	if len(tools) != 1 {
+		t.Fatalf("got %d registrations, want 1: %+v", len(tools), tools)
+	}
+	if got, want := tools[0].name, "get_users_user_id"; got != want {
+		t.Errorf("tool name = %q, want %q", got, want)
+	}
+}
+
+func TestTrustedSampleReplacesPcapSampleDuringRegistration(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	ec := newTestCapture(t, registrar)
+
+	ec.handleRequest("POST", "", "/orders", nil, http.Header{"X-Client": {"browser"}}, []byte(`{"item":"pcap"}`), config.Provenance{Via: config.ViaPcap})
+	// Registration of the pcap sample runs while ingestion replaces it
+	err := ec.Ingest(&IngestRequest{
+		Method:  "POST",
+		Path:    "/orders",
+		Headers: map[string]string{"X-Client": "suite"},
+		Body:    `{"item":"ingest"}`,
+	}, config.Provenance{Via: config.ViaIngest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ec.WaitRegistrations(5 * time.Second) {
+		t.Fatal("registrations did not finish")
+	}
+
+	tools := registrar.registered()
+	if len(tools) != 1 {
+		t.Fatalf("got %d registrations, want 1", len(tools))
+	}
+	if got := tools[0].body; got != `{"item":"pcap"}` && got != `{"item":"ingest"}` {
+		t.Errorf("registered body = %q", got)
+	}
+
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+	call := ec.seenAPIs["POST /orders"]
+	if call.Body != `{"item":"ingest"}` || call.Headers["X-Client"] != "suite" {
+		t.Errorf("stored sample = %q %v, want the ingested one", call.Body, call.Headers)
+	}
+}
+
+func TestNastyPathsTemplateAndReplay(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		toolURL string
+		params  map[string]string
+	}{
+		{
+			name:    "encoded slash",
+			path:    "/files/a%2Fb/content",
+			toolURL: "http://localhost:8080/files/a%2Fb/content",
+		},
+		{
+			name:    "encoded space",
+			path:    "/docs/my%20doc",
+			toolURL: "http://localhost:8080/docs/my%20doc",
+		},
+		{
+			name:    "uuid",
+			path:    "/orders/3F2C1A9E-8b7d-4c6e-9f01-23456789abcd",
+			toolURL: "http://localhost:8080/orders/{order_id}",
+			params:  map[string]string{"order_id": "3F2C1A9E-8b7d-4c6e-9f01-23456789abcd"},
+		},
+		{
+			name:    "trailing slash",
+			path:    "/users/42/",
+			toolURL: "http://localhost:8080/users/{user_id}/",
+			params:  map[string]string{"user_id": "42"},
+		},
+		{
+			name:    "matrix params",
+			path:    "/cars;color=red/2024",
+			toolURL: "http://localhost:8080/cars;color=red/{car_id}",
+			params:  map[string]string{"car_id": "2024"},
+		},
+		{
+			name:    "unicode",
+			path:    "/café/7",
+			toolURL: "http://localhost:8080/caf%C3%A9/{caf_id}",
+			params:  map[string]string{"caf_id": "7"},
+		},
+		{
+			name:    "encoded unicode",
+			path:    "/caf%C3%A9/7",
+			toolURL: "http://localhost:8080/caf%C3%A9/{caf_id}",
+			params:  map[string]string{"caf_id": "7"},
+		},
+		{
+			name:    "encoded braces",
+			path:    "/items/%7Bid%7D",
+			toolURL: "http://localhost:8080/items/%7Bid%7D",
+		},
+		{
+			name:    "empty segment",
+			path:    "/a//b/9",
+			toolURL: "http://localhost:8080/a//b/{b_id}",
+			params:  map[string]string{"b_id": "9"},
+		},
+		{
+			name:    "root",
+			path:    "/",
+			toolURL: "http://localhost:8080/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registrar := &recordingRegistrar{}
+			ec := newTestCapture(t, registrar)
+			if err := ec.Ingest(&IngestRequest{Method: "GET", Path: tt.path}, config.Provenance{Via: config.ViaIngest}); err != nil {
+				t.Fatal(err)
+			}
+			if !ec.WaitRegistrations(5 * time.Second) {
+				t.Fatal("registration did not finish")
+			}
+			tools := registrar.registered()
+			if len(tools) != 1 {
+				t.Fatalf("got %d registrations, want 1", len(tools))
+			}
+			if tools[0].url != tt.toolURL {
+				t.Errorf("tool URL = %q, want %q", tools[0].url, tt.toolURL)
+			}
+			if !validToolName.MatchString(tools[0].name) {
+				t.Errorf("invalid tool name %q", tools[0].name)
+			}
+			if !maps.Equal(tools[0].pathParams, tt.params) {
+				t.Errorf("path params = %v, want %v", tools[0].pathParams, tt.params)
+			}
+
+			// The captured defaults call exactly what was captured, and the
+			// path sent matches the tool
+			tool := &config.Tool{URL: tools[0].url, PathParams: tools[0].pathParams}
+			sent, _ := url.Parse(tt.path)
+			if got, want := tool.ResolveURL(nil, nil), "http://localhost:8080"+sent.EscapedPath(); got != want {
+				t.Errorf("resolved URL = %q, want %q", got, want)
+			}
+			if _, ok := tool.MatchPath(sent.EscapedPath()); !ok {
+				t.Errorf("%q does not match %q", sent.EscapedPath(), tool.RawPath())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package capture
+
+import (
+	"net/url"
+)
+
+// QueryRecorder is implemented by registrars that want to learn about
+// query parameters seen after an endpoint was registered.
+type QueryRecorder interface {
+	RecordQueryParams(method, url string, params map[string]string)
+}
+
+// queryDefaults keeps the first value of each query parameter as its
+// captured default. Values of sensitive parameters and values that look
+// like secrets are blanked, keeping only the name.
+func (ec *EndpointCapture) queryDefaults(query url.Values) map[string]string {
+	if len(query) == 0 {
+		return nil
+	}
+
+	params := make(map[string]string, len(query))
+	for name, values := range query {
+		value := ""
+		if len(values) > 0 {
+			value = values[0]
+		}
+		if ec.secrets.SensitiveParam(name) || !ec.secrets.Allowed(name) && ec.secrets.Value(value) != value {
+			value = ""
+		}
+		params[name] = value
+	}
+	return params
+}
+
+// mergeQueryParams adds the parameters apiCall hasn't seen before and
+// returns them. Callers must hold ec.mu.
+func mergeQueryParams(apiCall *APICall, params map[string]string) map[string]string {
+	var added map[string]string
+	for name, value := range params {
+		if _, ok := apiCall.QueryParams[name]; ok {
+			continue
+		}
+		if apiCall.QueryParams == nil {
+			apiCall.QueryParams = make(map[string]string)
+		}
+		if added == nil {
+			added = make(map[string]string)
+		}
+		apiCall.QueryParams[name] = value
+		added[name] = value
+	}
+	return added
+}
+
+func (ec *EndpointCapture) forwardQueryParams(apiCall *APICall, params map[string]string) {
+	if recorder, ok := ec.toolRegistrar.(QueryRecorder); ok {
+		recorder.RecordQueryParams(apiCall.Method, ec.callURL(apiCall), params)
+	}
+}
@@ -0,0 +1,70 @@
+package capture
+
+import (
+	"fmt"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/pcap"
+	"github.com/google/gopacket/tcpassembly"
+)
+
+// ReplayStats summarizes a replayed capture file.
+type ReplayStats struct {
+	Packets int64 `json:"packets"`
+	// Requests is the number of HTTP requests parsed from the packets
+	Requests int64 `json:"requests"`
+	// Endpoints is the number of unique endpoints with a tool, new or
+	// already in the config
+	Endpoints int `json:"endpoints"`
+}
+
+func (s *ReplayStats) String() string {
+	return fmt.Sprintf("%d packets processed, %d HTTP requests parsed, %d unique endpoints registered", s.Packets, s.Requests, s.Endpoints)
+}
+
+// ReplayFile runs the packets in a pcap file through the same pipeline as
+// StartCapture, and returns once every endpoint in it has been
+// registered. Unlike live capture it needs no privileges.
+func (ec *EndpointCapture) ReplayFile(path string) (*ReplayStats, error) {
+	handle, err := pcap.OpenOffline(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer handle.Close()
+
+	filter := ec.captureFilter()
+	if err := handle.SetBPFFilter(filter); err != nil {
+		return nil, fmt.Errorf("failed to set packet filter %q: %w", filter, err)
+	}
+	ec.debug(VerbosityEndpoints, "Packet filter", "filter", filter)
+
+	return ec.replay(gopacket.NewPacketSource(handle, handle.LinkType()), path), nil
+}
+
+// replay assembles every packet from source, then waits for the parsing
+// and registration they set off. source names the file in provenance.
+func (ec *EndpointCapture) replay(packets *gopacket.PacketSource, source string) *ReplayStats {
+	requests := ec.requests.Load()
+	factory := &httpStreamFactory{capture: ec, iface: source}
+	assembler := tcpassembly.NewAssembler(tcpassembly.NewStreamPool(factory))
+
+	stats := &ReplayStats{}
+	for packet := range packets.Packets() {
+		stats.Packets++
+		ec.processPacket(packet, assembler)
+	}
+	// The file's connections are over; whatever is left is complete
+	assembler.FlushAll()
+	factory.streams.Wait()
+	ec.registering.Wait()
+
+	stats.Requests = ec.requests.Load() - requests
+	ec.mu.RLock()
+	for _, apiCall := range ec.seenAPIs {
+		if apiCall.registered {
+			stats.Endpoints++
+		}
+	}
+	ec.mu.RUnlock()
+	return stats
+}
@@ -0,0 +1,138 @@
+package capture
+
+import (
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/fingerprint"
+	"github.com/NilayYadav/mcpify/internal/session"
+)
+
+const (
+	// maxResponseSample bounds the response body kept as an example.
+	maxResponseSample = 1024
+	// maxResponseRead bounds how much of a response is looked at, so
+	// redaction still sees complete JSON for reasonably sized responses.
+	maxResponseRead = 64 << 10
+)
+
+// ResponseRecorder is implemented by registrars that want to know what
+// captured endpoints return.
+type ResponseRecorder interface {
+	RecordResponse(method, url string, sample *config.ResponseSample)
+}
+
+// recordResponse stores the status and a redacted sample of the response to
+// apiCall and passes it on to the registrar, and completes x, the request's
+// exchange in the session file. Responses to skipped requests, whose
+// apiCall is nil, are dropped.
+func (ec *EndpointCapture) recordResponse(apiCall *APICall, x *session.Exchange, status int, header http.Header, body []byte) {
+	if apiCall == nil {
+		return
+	}
+	if len(body) > maxResponseRead {
+		body = body[:maxResponseRead]
+	}
+	if x != nil {
+		headers, _ := ec.extractHeaders(header)
+		resp := session.Response{Status: status, Headers: ec.secrets.Headers(headers)}
+		if ec.sampleable(header, body) {
+			resp.Body = ec.secrets.Body(string(body))
+		}
+		x.Response(resp)
+	}
+
+	sample := &config.ResponseSample{
+		Status:      status,
+		ContentType: header.Get("Content-Type"),
+		Headers:     ec.secrets.Headers(config.FilterHeaders(header, ec.responseHeaderAllowlist(apiCall))),
+		SeenAt:      time.Now(),
+	}
+	if ec.sampleable(header, body) {
+		sample.Body = ec.truncateString(ec.secrets.Body(string(body)), maxResponseSample)
+		// Only successes are fingerprinted; error bodies have shapes of
+		// their own
+		if status >= 200 && status < 300 {
+			sample.Shape, _ = fingerprint.Of(body)
+		}
+	}
+
+	ec.mu.Lock()
+	if !slices.Contains(apiCall.StatusCodes, status) {
+		apiCall.StatusCodes = append(apiCall.StatusCodes, status)
+	}
+	apiCall.Response = sample
+	registered := apiCall.registered
+	ec.mu.Unlock()
+
+	// Unregistered calls pass their response on once registration is done
+	if registered {
+		ec.forwardResponse(apiCall, sample)
+	}
+}
+
+// responseHeaderAllowlist returns the response headers worth keeping for
+// apiCall.
+func (ec *EndpointCapture) responseHeaderAllowlist(apiCall *APICall) []string {
+	if ec.responseHeaders == nil {
+		return config.DefaultResponseHeaders
+	}
+	return ec.responseHeaders(apiCall.Method, ec.callURL(apiCall))
+}
+
+func (ec *EndpointCapture) forwardResponse(apiCall *APICall, sample *config.ResponseSample) {
+	if recorder, ok := ec.toolRegistrar.(ResponseRecorder); ok {
+		recorder.RecordResponse(apiCall.Method, ec.callURL(apiCall), sample)
+	}
+}
+
+// sampleable reports whether body is readable text worth keeping.
+func (ec *EndpointCapture) sampleable(header http.Header, body []byte) bool {
+	if len(body) == 0 || !utf8.Valid(body) {
+		return false
+	}
+	if enc := header.Get("Content-Encoding"); enc != "" && enc != "identity" {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch mediaType {
+	case "", "application/json", "application/xml", "application/x-www-form-urlencoded":
+		return true
+	}
+	return len(mediaType) > 5 && (mediaType[:5] == "text/" || mediaType[len(mediaType)-5:] == "+json")
+}
+
+// Endpoints returns a snapshot of every captured endpoint.
+func (ec *EndpointCapture) Endpoints() []APICall {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+
+	calls := make([]APICall, 0, len(ec.seenAPIs))
+	for _, call := range ec.seenAPIs {
+		c := *call
+		c.StatusCodes = slices.Clone(call.StatusCodes)
+		c.QueryParams = maps.Clone(call.QueryParams)
+		calls = append(calls, c)
+	}
+	slices.SortFunc(calls, func(a, b APICall) int {
+		if a.Path != b.Path {
+			if a.Path < b.Path {
+				return -1
+			}
+			return 1
+		}
+		if a.Method < b.Method {
+			return -1
+		}
+		if a.Method > b.Method {
+			return 1
+		}
+		return 0
+	})
+	return calls
+}
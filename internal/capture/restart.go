@@ -0,0 +1,73 @@
+package capture
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+)
+
+// currentTarget returns the target capture records requests for.
+func (ec *EndpointCapture) currentTarget() *url.URL {
+	return ec.target.Load()
+}
+
+// Restart points capture at target. Running capture, from packets or
+// through the proxy, is torn down and opened again for the new target,
+// and its endpoints are discovered afresh; tools already registered are
+// kept.
+func (ec *EndpointCapture) Restart(target *url.URL) {
+	ec.mu.Lock()
+	ec.target.Store(target)
+	ec.seenAPIs = make(map[string]*APICall)
+	ec.ready = make(chan struct{})
+	restart := ec.restart
+	ec.mu.Unlock()
+
+	if restart != nil {
+		slog.Info("Restarting capture", "target", target.String())
+		restart()
+	}
+}
+
+// Ready is closed once capture is running: its packet capture handle is
+// open, or its proxy is listening. After a Restart it refers to the
+// restarted capture.
+func (ec *EndpointCapture) Ready() <-chan struct{} {
+	ec.mu.RLock()
+	defer ec.mu.RUnlock()
+	return ec.ready
+}
+
+// markReady closes the current Ready channel.
+func (ec *EndpointCapture) markReady() {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	select {
+	case <-ec.ready:
+	default:
+		close(ec.ready)
+	}
+}
+
+// restartable calls run until ctx is done or run returns by itself,
+// calling it again each time Restart stops it.
+func (ec *EndpointCapture) restartable(ctx context.Context, run func(context.Context) error) error {
+	defer func() {
+		ec.mu.Lock()
+		ec.restart = nil
+		ec.mu.Unlock()
+	}()
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		ec.mu.Lock()
+		ec.restart = cancel
+		ec.mu.Unlock()
+
+		err := run(runCtx)
+		restarted := runCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if err != nil || !restarted {
+			return err
+		}
+	}
+}
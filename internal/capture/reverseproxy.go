@@ -0,0 +1,140 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// responseRecorder remembers the status code and the start of the body
+// written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	// size counts the whole body, not just what was kept
+	size int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.size += len(b)
+	if room := maxResponseRead - r.body.Len(); room > 0 {
+		r.body.Write(b[:min(room, len(b))])
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// StartProxy serves a reverse proxy to the target on addr and records every
+// request that flows through it. Unlike StartCapture it needs no elevated
+// privileges and always sees complete bodies. With tlsConfig the proxy
+// terminates TLS itself, which is the only way to capture HTTPS targets;
+// tools still call the real target. The proxy runs until ctx is done and
+// returns nil once the requests going through it are recorded.
+func (ec *EndpointCapture) StartProxy(ctx context.Context, addr string, tlsConfig *tls.Config) error {
+	return ec.restartable(ctx, func(ctx context.Context) error {
+		return ec.serveProxy(ctx, addr, tlsConfig)
+	})
+}
+
+// serveProxy serves the proxy to the current target until ctx is done.
+func (ec *EndpointCapture) serveProxy(ctx context.Context, addr string, tlsConfig *tls.Config) error {
+	target := ec.currentTarget()
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+	}
+
+	ec.mu.Lock()
+	ec.proxyAddr = addr
+	ec.proxyTLS = tlsConfig != nil
+	ec.mu.Unlock()
+
+	srv := &http.Server{
+		Addr:      addr,
+		Handler:   ec.proxyHandler(proxy),
+		TLSConfig: tlsConfig,
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	serve := func() error { return srv.Serve(ln) }
+	if tlsConfig != nil {
+		slog.Info("Capture proxy listening", "url", "https://localhost"+addr, "target", target.String())
+		serve = func() error { return srv.ServeTLS(ln, "", "") }
+	} else {
+		slog.Info("Capture proxy listening", "url", "http://localhost"+addr, "target", target.String())
+	}
+	ec.markReady()
+
+	served := make(chan error, 1)
+	go func() { served <- serve() }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), proxyShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+	}
+	<-served
+	return nil
+}
+
+func (ec *EndpointCapture) proxyHandler(proxy http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ec.selfTest.packets.Add(1)
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIngestSize+1))
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Everything reaching the proxy is for the target. mcpify's own
+		// requests and oversized bodies are forwarded but never recorded
+		if ec.selfTest.observe(r, true) || len(body) > maxIngestSize {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+
+		prov := config.Provenance{Via: config.ViaProxy, Interface: ec.proxyAddr, Client: r.RemoteAddr, TLS: r.TLS != nil}
+		apiCall, x := ec.handleRequest(r.Method, "", r.URL.EscapedPath(), r.URL.Query(), r.Header, body, prov)
+
+		rec := &responseRecorder{ResponseWriter: w}
+		start := time.Now()
+		proxy.ServeHTTP(rec, r)
+		if rec.status > 0 {
+			ec.logExchange(r.Method, r.URL.EscapedPath(), rec.status, rec.size, time.Since(start))
+			ec.recordResponse(apiCall, x, rec.status, rec.Header(), rec.body.Bytes())
+		}
+	})
+}
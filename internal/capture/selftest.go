@@ -0,0 +1,248 @@
+package capture
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+type SelfTestStage string
+
+const (
+	StagePassed      SelfTestStage = "passed"
+	StageNoPackets   SelfTestStage = "no_packets"
+	StageNotParsed   SelfTestStage = "not_parsed"
+	StageFiltered    SelfTestStage = "filtered"
+	StageRequestFail SelfTestStage = "request_failed"
+	// StageCaptureFail means capture couldn't start, so there was nothing
+	// to test.
+	StageCaptureFail SelfTestStage = "capture_failed"
+)
+
+type SelfTestResult struct {
+	Stage     SelfTestStage `json:"stage"`
+	Message   string        `json:"message"`
+	Packets   int64         `json:"packets"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  string        `json:"duration"`
+}
+
+type selfTest struct {
+	mu       sync.Mutex
+	token    string
+	parsed   bool
+	filtered string
+	result   *SelfTestResult
+	packets  atomic.Int64
+}
+
+// observe reports whether mcpify sent the request itself; such requests
+// never become tools. For the self-test request it also records whether
+// it made it past host matching.
+func (st *selfTest) observe(req *http.Request, isTarget bool) bool {
+	value := req.Header.Get(config.MarkerHeader)
+	if value == "" {
+		return false
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if value != "self-test="+st.token {
+		return true
+	}
+	if isTarget {
+		st.parsed = true
+	} else {
+		st.filtered = req.Host
+	}
+	return true
+}
+
+// RunSelfTest sends one harmless request to the target and checks that the
+// capture pipeline saw and parsed it. StartCapture must already be running.
+// On macOS, when loopback capture sees no packets at all, capture moves to
+// the pktap pseudo-interface, which also sees servers bound to a specific
+// address, and the self-test runs again there.
+func (ec *EndpointCapture) RunSelfTest(wait time.Duration) *SelfTestResult {
+	result := ec.runSelfTest(wait)
+	if !ec.pktapFallback(systemHost{}, result) {
+		return result
+	}
+
+	slog.Warn("Loopback capture saw no packets; trying pktap", "target", ec.currentTarget().String())
+	select {
+	case <-ec.switchInterface("pktap"):
+	case <-time.After(pktapOpenWait):
+		return result
+	}
+	if result = ec.runSelfTest(wait); result.Stage == StagePassed {
+		slog.Info("Capturing on pktap from now on; pass --interface pktap to start there")
+	}
+	return result
+}
+
+// pktapOpenWait bounds how long RunSelfTest waits for capture to reopen on
+// pktap.
+const pktapOpenWait = 5 * time.Second
+
+// pktapFallback reports whether a self-test that ended with result should
+// be run again on pktap: only running loopback packet capture on macOS
+// falls back, and only when host can open pktap.
+func (ec *EndpointCapture) pktapFallback(host captureHost, result *SelfTestResult) bool {
+	ec.mu.RLock()
+	proxy, running := ec.proxyAddr, ec.restart != nil
+	ec.mu.RUnlock()
+	if host.GOOS() != "darwin" || result.Stage != StageNoPackets || ec.iface != "" || proxy != "" || !running {
+		return false
+	}
+	if err := host.Open("pktap"); err != nil {
+		slog.Debug("pktap can't be opened", "error", err)
+		return false
+	}
+	return true
+}
+
+// switchInterface restarts running packet capture on iface and returns
+// the Ready channel of the restarted capture.
+func (ec *EndpointCapture) switchInterface(iface string) <-chan struct{} {
+	ec.mu.Lock()
+	ec.iface = iface
+	ec.ready = make(chan struct{})
+	ready, restart := ec.ready, ec.restart
+	ec.mu.Unlock()
+	if restart != nil {
+		restart()
+	}
+	return ready
+}
+
+// runSelfTest runs the self-test once, on the interface capture uses now.
+func (ec *EndpointCapture) runSelfTest(wait time.Duration) *SelfTestResult {
+	token := make([]byte, 8)
+	rand.Read(token)
+
+	st := &ec.selfTest
+	st.mu.Lock()
+	st.token = hex.EncodeToString(token)
+	st.parsed = false
+	st.filtered = ""
+	st.mu.Unlock()
+
+	start := time.Now()
+	before := st.packets.Load()
+
+	result := &SelfTestResult{StartedAt: start}
+
+	req, err := http.NewRequest(http.MethodGet, ec.selfTestURL(), nil)
+	if err == nil {
+		req.Header.Set(config.MarkerHeader, "self-test="+st.token)
+		var resp *http.Response
+		resp, err = ec.selfTestClient().Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	if err != nil {
+		result.Stage = StageRequestFail
+		result.Message = fmt.Sprintf("self-test request to target failed: %v", err)
+	} else {
+		time.Sleep(wait)
+
+		st.mu.Lock()
+		parsed, filtered := st.parsed, st.filtered
+		st.mu.Unlock()
+		result.Packets = st.packets.Load() - before
+
+		switch {
+		case parsed:
+			result.Stage = StagePassed
+			result.Message = "capture pipeline observed and parsed the self-test request"
+		case filtered != "":
+			result.Stage = StageFiltered
+			result.Message = fmt.Sprintf("request parsed but filtered: host %q did not match target %q", filtered, ec.currentTarget().Host)
+		case result.Packets == 0:
+			result.Stage = StageNoPackets
+			result.Message = "no packets seen: check the capture interface and BPF filter"
+			switch {
+			case runtime.GOOS != "darwin" || ec.proxyAddr != "":
+			case ec.iface == "":
+				result.Message = fmt.Sprintf("no packets seen: lo0 on macOS misses traffic to servers bound to a specific address. "+
+					"Try --interface pktap, or capture through the proxy instead: mcpify --target %s --mode proxy", ec.currentTarget())
+			case ec.iface == "pktap":
+				result.Message = fmt.Sprintf("no packets seen on lo0 or pktap: capture through the proxy instead: mcpify --target %s --mode proxy", ec.currentTarget())
+			}
+		default:
+			result.Stage = StageNotParsed
+			result.Message = "packets seen but request not parsed: target may use TLS or an unsupported protocol"
+		}
+	}
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+
+	st.mu.Lock()
+	st.result = result
+	st.mu.Unlock()
+
+	if result.Stage == StagePassed {
+		slog.Info("Capture self-test passed", "packets", result.Packets)
+	} else {
+		slog.Error("Capture self-test failed", "stage", result.Stage, "message", result.Message)
+	}
+
+	return result
+}
+
+// selfTestURL is the target itself, or the capture proxy in front of it
+// when one is running.
+func (ec *EndpointCapture) selfTestURL() string {
+	ec.mu.RLock()
+	addr, useTLS := ec.proxyAddr, ec.proxyTLS
+	ec.mu.RUnlock()
+
+	if addr == "" {
+		return ec.currentTarget().String()
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	u := *ec.currentTarget()
+	u.Scheme = "http"
+	if useTLS {
+		u.Scheme = "https"
+	}
+	u.Host = net.JoinHostPort(host, port)
+	return u.String()
+}
+
+// selfTestClient trusts the capture proxy's own certificate, which is
+// usually self-signed. The self-test request only ever goes to the proxy.
+func (ec *EndpointCapture) selfTestClient() *http.Client {
+	ec.mu.RLock()
+	useTLS := ec.proxyTLS
+	ec.mu.RUnlock()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	if useTLS {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+	return client
+}
+
+// LastSelfTest returns the last self-test result, or nil if none has run.
+func (ec *EndpointCapture) LastSelfTest() *SelfTestResult {
+	ec.selfTest.mu.Lock()
+	defer ec.selfTest.mu.Unlock()
+	return ec.selfTest.result
+}
@@ -0,0 +1,226 @@
+package capture
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/session"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/tcpassembly"
+	"github.com/google/gopacket/tcpassembly/tcpreader"
+)
+
+const (
+	// captureReadTimeout bounds each read from a live capture handle, so
+	// the handle can be closed when capture stops.
+	captureReadTimeout = 500 * time.Millisecond
+	// proxyShutdownTimeout is how long a stopping capture proxy waits for
+	// the requests going through it.
+	proxyShutdownTimeout = 5 * time.Second
+	// streamIdleTimeout closes reassembled connections that have gone quiet.
+	streamIdleTimeout = 2 * time.Minute
+	// responseWait is how long a response waits for its request to be
+	// parsed before it is read without one.
+	responseWait = 5 * time.Second
+	// maxInFlight bounds the requests awaiting a response on a connection.
+	maxInFlight = 64
+)
+
+// exchange is a parsed request waiting for its response. call is nil for
+// requests that weren't recorded, which still need pairing.
+type exchange struct {
+	req  *http.Request
+	call *APICall
+	// recorded is the request in the session file
+	recorded *session.Exchange
+	// at is when the request was parsed
+	at time.Time
+}
+
+// conversation pairs the two directions of one TCP connection, so
+// responses can be matched to requests in order.
+type conversation struct {
+	pending chan *exchange
+	refs    int
+}
+
+// httpStreamFactory creates a reader for each direction of a captured TCP
+// connection.
+type httpStreamFactory struct {
+	capture       *EndpointCapture
+	iface         string
+	mu            sync.Mutex
+	conversations map[string]*conversation
+	// streams tracks the readers still parsing a connection
+	streams sync.WaitGroup
+}
+
+func (f *httpStreamFactory) New(netFlow, tcpFlow gopacket.Flow) tcpassembly.Stream {
+	stream := tcpreader.NewReaderStream()
+
+	toClient := f.capture.isTargetPort(tcpFlow.Src().String())
+	client := netFlow.Src().String() + ":" + tcpFlow.Src().String()
+	if toClient {
+		client = netFlow.Dst().String() + ":" + tcpFlow.Dst().String()
+	}
+	conv := f.join(client)
+
+	f.streams.Add(1)
+	go func() {
+		defer f.streams.Done()
+		defer f.leave(client)
+		if toClient {
+			f.capture.readResponses(&stream, conv)
+		} else {
+			prov := config.Provenance{Via: config.ViaPcap, Interface: f.iface, Client: client}
+			f.capture.readRequests(&stream, conv, prov)
+		}
+	}()
+	return &stream
+}
+
+func (f *httpStreamFactory) join(key string) *conversation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.conversations == nil {
+		f.conversations = make(map[string]*conversation)
+	}
+	conv := f.conversations[key]
+	if conv == nil {
+		conv = &conversation{pending: make(chan *exchange, maxInFlight)}
+		f.conversations[key] = conv
+	}
+	conv.refs++
+	return conv
+}
+
+func (f *httpStreamFactory) leave(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if conv := f.conversations[key]; conv != nil {
+		if conv.refs--; conv.refs <= 0 {
+			delete(f.conversations, key)
+		}
+	}
+}
+
+func (ec *EndpointCapture) targetPort() string {
+	target := ec.currentTarget()
+	if port := target.Port(); port != "" {
+		return port
+	}
+	if target.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// readRequests parses every request sent on one client stream, including
+// several on a keep-alive connection.
+func (ec *EndpointCapture) readRequests(r io.Reader, conv *conversation, prov config.Provenance) {
+	defer tcpreader.DiscardBytesToEOF(r)
+
+	buf := bufio.NewReader(r)
+	for failing := false; ; {
+		prefix := ec.peekPayload(buf)
+		req, err := http.ReadRequest(buf)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return
+		}
+		if err != nil {
+			// Capture may have started mid-request; skip ahead line by line
+			// until the next request line, counting the run as one failure
+			if !failing {
+				ec.parseFailures.Add(1)
+				ec.debug(VerbosityPayloads, "Failed to parse HTTP request", "client", prov.Client, "error", err, "payload", string(prefix))
+			}
+			failing = true
+			continue
+		}
+		failing = false
+		ec.requests.Add(1)
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxIngestSize))
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+		if err != nil {
+			ec.debug(VerbosityRequests, "Incomplete request body", "method", req.Method, "path", req.URL.Path, "expected_bytes", req.ContentLength, "error", err)
+			// Its response still comes, so it is paired with a placeholder
+			// rather than with the next request
+			conv.push(&exchange{req: req, at: time.Now()})
+			continue
+		}
+
+		prov.CapturedAt = time.Now()
+		call, recorded := ec.processRequest(req, body, prov)
+		conv.push(&exchange{req: req, call: call, recorded: recorded, at: prov.CapturedAt})
+	}
+}
+
+// push queues ex for the next response on the connection.
+func (c *conversation) push(ex *exchange) {
+	select {
+	case c.pending <- ex:
+	default:
+		// Nobody is reading responses on this connection
+	}
+}
+
+// readResponses parses the responses on one server stream and records each
+// against the request it answers.
+func (ec *EndpointCapture) readResponses(r io.Reader, conv *conversation) {
+	defer tcpreader.DiscardBytesToEOF(r)
+
+	buf := bufio.NewReader(r)
+	for {
+		// Wait for data so an idle keep-alive connection doesn't claim the
+		// next request early
+		if _, err := buf.Peek(1); err != nil {
+			return
+		}
+
+		var ex *exchange
+		select {
+		case ex = <-conv.pending:
+		case <-time.After(responseWait):
+		}
+		var req *http.Request
+		if ex != nil {
+			req = ex.req
+		}
+
+		prefix := ec.peekPayload(buf)
+		resp, err := http.ReadResponse(buf, req)
+		for err == nil && resp.StatusCode == http.StatusContinue {
+			resp, err = http.ReadResponse(buf, req)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return
+		}
+		if err != nil {
+			ec.parseFailures.Add(1)
+			ec.debug(VerbosityPayloads, "Failed to parse HTTP response", "error", err, "payload", string(prefix))
+			continue
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseRead))
+		size, _ := io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			ec.debug(VerbosityRequests, "Incomplete response body", "error", err)
+		}
+
+		if ex != nil {
+			ec.logExchange(req.Method, req.URL.EscapedPath(), resp.StatusCode, len(body)+int(size), time.Since(ex.at))
+		}
+		if ex != nil && ex.call != nil {
+			ec.recordResponse(ex.call, ex.recorded, resp.StatusCode, resp.Header, body)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package capture
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/tcpassembly"
+)
+
+// mtuPayload is how much TCP payload fits in one Ethernet frame.
+const mtuPayload = 1460
+
+// tcpConn replays one TCP connection between a client and the target
+// through a capture's assembler, as packets read off the wire would be.
+type tcpConn struct {
+	t          *testing.T
+	ec         *EndpointCapture
+	factory    *httpStreamFactory
+	assembler  *tcpassembly.Assembler
+	clientPort uint16
+	serverPort uint16
+	clientSeq  uint32
+	serverSeq  uint32
+	at         time.Time
+}
+
+func newTCPConn(t *testing.T, ec *EndpointCapture) *tcpConn {
+	factory := &httpStreamFactory{capture: ec, iface: "lo"}
+	c := &tcpConn{
+		t:          t,
+		ec:         ec,
+		factory:    factory,
+		assembler:  tcpassembly.NewAssembler(tcpassembly.NewStreamPool(factory)),
+		clientPort: 51000,
+		serverPort: 8080,
+		clientSeq:  1000,
+		serverSeq:  5000,
+		at:         time.Now(),
+	}
+	c.segment(true, &layers.TCP{SYN: true}, nil)
+	c.segment(false, &layers.TCP{SYN: true, ACK: true}, nil)
+	return c
+}
+
+// send sends data from the client, or from the server, in segments of
+// at most size bytes.
+func (c *tcpConn) send(fromClient bool, data string, size int) {
+	for len(data) > 0 {
+		n := min(size, len(data))
+		c.segment(fromClient, &layers.TCP{ACK: true, PSH: true}, []byte(data[:n]))
+		data = data[n:]
+	}
+}
+
+// close ends the connection and waits for both directions to be parsed.
+func (c *tcpConn) close() {
+	c.segment(true, &layers.TCP{FIN: true, ACK: true}, nil)
+	c.segment(false, &layers.TCP{FIN: true, ACK: true}, nil)
+	c.assembler.FlushAll()
+	c.factory.streams.Wait()
+	if !c.ec.WaitRegistrations(5 * time.Second) {
+		c.t.Fatal("registrations did not finish")
+	}
+}
+
+func (c *tcpConn) segment(fromClient bool, tcp *layers.TCP, payload []byte) {
+	c.t.Helper()
+	client, server := net.IPv4(127, 0, 0, 1), net.IPv4(127, 0, 0, 1)
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: client, DstIP: server}
+	seq := &c.clientSeq
+	tcp.SrcPort, tcp.DstPort = layers.TCPPort(c.clientPort), layers.TCPPort(c.serverPort)
+	if !fromClient {
+		ip.SrcIP, ip.DstIP = server, client
+		seq = &c.serverSeq
+		tcp.SrcPort, tcp.DstPort = tcp.DstPort, tcp.SrcPort
+	}
+	tcp.Seq = *seq
+	tcp.Window = 65535
+	tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, ip, tcp, gopacket.Payload(payload)); err != nil {
+		c.t.Fatal(err)
+	}
+	packet := gopacket.NewPacket(buf.Bytes(), layers.LayerTypeIPv4, gopacket.Default)
+	c.at = c.at.Add(time.Millisecond)
+	packet.Metadata().Timestamp = c.at
+	c.ec.processPacket(packet, c.assembler)
+
+	*seq += uint32(len(payload))
+	if tcp.SYN || tcp.FIN {
+		*seq++
+	}
+}
+
+func httpRequest(method, path, body string) string {
+	req := fmt.Sprintf("%s %s HTTP/1.1\r\nHost: localhost:8080\r\nContent-Type: application/json\r\n", method, path)
+	if body != "" {
+		req += fmt.Sprintf("Content-Length: %d\r\n", len(body))
+	}
+	return req + "\r\n" + body
+}
+
+func httpResponse(status int, body string) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", status, http.StatusText(status), len(body), body)
+}
+
+// endpointStatuses maps each captured endpoint to the statuses recorded
+// for it.
+func endpointStatuses(ec *EndpointCapture) map[string][]int {
+	statuses := make(map[string][]int)
+	for _, call := range ec.Endpoints() {
+		statuses[call.Method+" "+call.Path] = call.StatusCodes
+	}
+	return statuses
+}
+
+func TestRequestSpanningSegmentsIsReassembled(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	ec := newTestCapture(t, registrar)
+	conn := newTCPConn(t, ec)
+
+	body := `{"note":"` + strings.Repeat("x", 3*mtuPayload) + `"}`
+	conn.send(true, httpRequest("POST", "/notes", body), mtuPayload)
+	conn.send(false, httpResponse(201, `{"id":1}`), mtuPayload)
+	conn.close()
+
+	tools := registrar.registered()
+	if len(tools) != 1 {
+		t.Fatalf("got %d registrations, want 1", len(tools))
+	}
+	if tools[0].body != body {
+		t.Errorf("registered body is %d bytes, want the whole %d", len(tools[0].body), len(body))
+	}
+	if got := endpointStatuses(ec)["POST /notes"]; !slices.Equal(got, []int{201}) {
+		t.Errorf("statuses = %v, want [201]", got)
+	}
+	if got := ec.parseFailures.Load(); got != 0 {
+		t.Errorf("%d parse failures", got)
+	}
+}
+
+func TestUnreadableBodyKeepsResponsesPaired(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	ec := newTestCapture(t, registrar)
+	conn := newTCPConn(t, ec)
+
+	// The chunk size isn't hex, so the first body can't be read
+	broken := "POST /uploads HTTP/1.1\r\nHost: localhost:8080\r\nTransfer-Encoding: chunked\r\n\r\nzz\r\nbad\r\n0\r\n\r\n"
+	conn.send(true, broken+httpRequest("GET", "/status", ""), mtuPayload)
+	conn.send(false, httpResponse(400, `{"error":"bad chunk"}`)+httpResponse(200, `{"ok":true}`), mtuPayload)
+	conn.close()
+
+	statuses := endpointStatuses(ec)
+	if got := statuses["GET /status"]; !slices.Equal(got, []int{200}) {
+		t.Errorf("GET /status statuses = %v, want [200]", got)
+	}
+	if _, ok := statuses["POST /uploads"]; ok {
+		t.Error("request with an unreadable body was recorded")
+	}
+}
+
+func TestRequestsInOneSegmentBecomeTwoTools(t *testing.T) {
+	registrar := &recordingRegistrar{}
+	ec := newTestCapture(t, registrar)
+	conn := newTCPConn(t, ec)
+
+	// A pipelining client can put both requests in one segment
+	conn.send(true, httpRequest("GET", "/users", "")+httpRequest("POST", "/orders", `{"item":1}`), mtuPayload)
+	conn.send(false, httpResponse(200, `[]`)+httpResponse(201, `{"id":9}`), mtuPayload)
+	conn.close()
+
+	var names []string
+	for _, tool := range registrar.registered() {
+		names = append(names, tool.name)
+	}
+	slices.Sort(names)
+	if want := []string{"get_users", "post_orders"}; !slices.Equal(names, want) {
+		t.Errorf("registered %v, want %v", names, want)
+	}
+	statuses := endpointStatuses(ec)
+	if got := statuses["GET /users"]; !slices.Equal(got, []int{200}) {
+		t.Errorf("GET /users statuses = %v, want [200]", got)
+	}
+	if got := statuses["POST /orders"]; !slices.Equal(got, []int{201}) {
+		t.Errorf("POST /orders statuses = %v, want [201]", got)
+	}
+}
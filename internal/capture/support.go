@@ -0,0 +1,198 @@
+package capture
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/google/gopacket/pcap"
+)
+
+// BPF device states on macOS and the BSDs, where capture opens /dev/bpf*.
+const (
+	BPFReadable = "readable"
+	BPFDenied   = "denied"
+	BPFMissing  = "missing"
+	BPFBusy     = "busy"
+)
+
+// bpfFix is how to let a user capture on macOS without running mcpify as
+// root.
+var bpfFix = []string{
+	"install Wireshark's ChmodBPF, which gives the access_bpf group the BPF devices at every boot: brew install --cask wireshark-chmodbpf, then log out and back in",
+	"or for this boot only: sudo chgrp staff /dev/bpf* && sudo chmod g+rw /dev/bpf*",
+	"or run mcpify with sudo -E",
+}
+
+// captureHost is what checking capture support needs from the system, so
+// the checks can run against a fake one.
+type captureHost interface {
+	GOOS() string
+	// Privileged reports whether the process may capture without further
+	// setup: root, or CAP_NET_RAW on Linux.
+	Privileged() bool
+	// Devices lists the interfaces pcap can open.
+	Devices() ([]string, error)
+	// BPF reports whether a BPF device can be opened for reading.
+	BPF() string
+	// Open reports why pcap can't open the interface, or nil if it can.
+	Open(iface string) error
+}
+
+type systemHost struct{}
+
+func (systemHost) GOOS() string { return runtime.GOOS }
+
+func (systemHost) Privileged() bool {
+	if os.Geteuid() == 0 {
+		return true
+	}
+	// CAP_NET_RAW is bit 13 of the effective capability mask
+	status, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(status), "\n") {
+		if mask, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			caps, err := strconv.ParseUint(strings.TrimSpace(mask), 16, 64)
+			return err == nil && caps&(1<<13) != 0
+		}
+	}
+	return false
+}
+
+func (systemHost) Devices() ([]string, error) {
+	devices, err := pcap.FindAllDevs()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(devices))
+	for _, device := range devices {
+		names = append(names, device.Name)
+	}
+	return names, nil
+}
+
+func (systemHost) Open(iface string) error {
+	handle, err := pcap.OpenLive(iface, 256, false, captureReadTimeout)
+	if err != nil {
+		return err
+	}
+	handle.Close()
+	return nil
+}
+
+func (systemHost) BPF() string {
+	paths, _ := filepath.Glob("/dev/bpf*")
+	if len(paths) == 0 {
+		return BPFMissing
+	}
+	state := BPFBusy
+	for _, path := range paths {
+		f, err := os.Open(path)
+		switch {
+		case err == nil:
+			f.Close()
+			return BPFReadable
+		case errors.Is(err, fs.ErrPermission):
+			state = BPFDenied
+		case errors.Is(err, syscall.EBUSY):
+			// Another capture holds this one; try the next
+		}
+	}
+	return state
+}
+
+// CaptureOption is one way of capturing and whether it works here.
+type CaptureOption struct {
+	Mode      string `json:"mode"`
+	Interface string `json:"interface,omitempty"`
+	Available bool   `json:"available"`
+	Note      string `json:"note"`
+}
+
+// CaptureSupport is what capture can do on this host, as `mcpify doctor`
+// reports it.
+type CaptureSupport struct {
+	OS         string `json:"os"`
+	Privileged bool   `json:"privileged"`
+	// BPF is the state of the BPF devices on macOS and the BSDs.
+	BPF        string          `json:"bpf,omitempty"`
+	Interfaces []string        `json:"interfaces,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Options    []CaptureOption `json:"options"`
+	// Fix lists how to make packet capture available, when it isn't.
+	Fix []string `json:"fix,omitempty"`
+}
+
+// CheckCaptureSupport checks which capture modes and interfaces work here.
+func CheckCaptureSupport() *CaptureSupport {
+	return checkCaptureSupport(systemHost{})
+}
+
+func checkCaptureSupport(host captureHost) *CaptureSupport {
+	s := &CaptureSupport{OS: host.GOOS(), Privileged: host.Privileged()}
+	devices, err := host.Devices()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.Interfaces = devices
+
+	proxy := CaptureOption{Mode: "proxy", Available: true, Note: "needs no privileges; send traffic through the proxy port"}
+	switch s.OS {
+	case "windows":
+		s.Options = []CaptureOption{{Mode: "pcap", Note: "not supported on Windows"}, proxy}
+		return s
+
+	case "darwin", "freebsd", "openbsd":
+		s.BPF = host.BPF()
+		pcapOK := s.Privileged || s.BPF == BPFReadable
+		note := "sees requests to 127.0.0.1 and localhost"
+		switch {
+		case !pcapOK && s.BPF == BPFDenied:
+			note = "no access to /dev/bpf*"
+			s.Fix = bpfFix
+		case !pcapOK && s.BPF == BPFBusy:
+			note = "every /dev/bpf* device is in use by another capture"
+		case !pcapOK:
+			note = "no BPF devices found"
+		}
+		s.Options = append(s.Options, CaptureOption{Mode: "pcap", Interface: "lo0", Available: pcapOK, Note: note})
+		if s.OS == "darwin" {
+			pktapNote := "every interface at once, for apps bound to a specific address that lo0 misses"
+			if !pcapOK {
+				pktapNote = note
+			}
+			s.Options = append(s.Options, CaptureOption{Mode: "pcap", Interface: "pktap", Available: pcapOK, Note: pktapNote})
+		}
+
+	default:
+		pcapOK := s.Privileged
+		note := "sees requests to 127.0.0.1 and localhost"
+		if !pcapOK {
+			note = "needs root or CAP_NET_RAW"
+			s.Fix = []string{"run mcpify with sudo -E", "or grant it capture rights: sudo setcap cap_net_raw,cap_net_admin=eip $(which mcpify)"}
+		}
+		s.Options = append(s.Options,
+			CaptureOption{Mode: "pcap", Interface: "lo", Available: pcapOK, Note: note},
+			CaptureOption{Mode: "pcap", Interface: "any", Available: pcapOK, Note: "every interface, e.g. for targets in containers"},
+		)
+	}
+	s.Options = append(s.Options, proxy)
+	return s
+}
+
+// PacketCaptureAvailable reports whether any packet capture option works.
+func (s *CaptureSupport) PacketCaptureAvailable() bool {
+	for _, option := range s.Options {
+		if option.Mode == "pcap" && option.Available {
+			return true
+		}
+	}
+	return false
+}
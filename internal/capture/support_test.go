@@ -0,0 +1,203 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeHost is a captureHost with fixed answers.
+type fakeHost struct {
+	goos       string
+	privileged bool
+	devices    []string
+	devicesErr error
+	bpf        string
+	// unopenable are the interfaces Open fails for
+	unopenable []string
+}
+
+func (h fakeHost) GOOS() string               { return h.goos }
+func (h fakeHost) Privileged() bool           { return h.privileged }
+func (h fakeHost) Devices() ([]string, error) { return h.devices, h.devicesErr }
+func (h fakeHost) BPF() string                { return h.bpf }
+func (h fakeHost) Open(iface string) error {
+	if slices.Contains(h.unopenable, iface) {
+		return errors.New("no such device")
+	}
+	return nil
+}
+
+// findOption finds the capture option for mode and iface.
+func findOption(t *testing.T, s *CaptureSupport, mode, iface string) CaptureOption {
+	t.Helper()
+	for _, o := range s.Options {
+		if o.Mode == mode && o.Interface == iface {
+			return o
+		}
+	}
+	t.Fatalf("no %s option on %q in %+v", mode, iface, s.Options)
+	return CaptureOption{}
+}
+
+func TestCheckCaptureSupport(t *testing.T) {
+	tests := []struct {
+		name string
+		host fakeHost
+		// available lists the pcap interfaces that should work
+		available   []string
+		unavailable []string
+		wantFix     bool
+		wantBPF     string
+	}{
+		{
+			name:        "macOS without BPF access",
+			host:        fakeHost{goos: "darwin", bpf: BPFDenied, devices: []string{"lo0", "en0"}},
+			unavailable: []string{"lo0", "pktap"},
+			wantFix:     true,
+			wantBPF:     BPFDenied,
+		},
+		{
+			name:      "macOS with ChmodBPF",
+			host:      fakeHost{goos: "darwin", bpf: BPFReadable, devices: []string{"lo0", "en0"}},
+			available: []string{"lo0", "pktap"},
+			wantBPF:   BPFReadable,
+		},
+		{
+			name:      "macOS as root with busy devices",
+			host:      fakeHost{goos: "darwin", privileged: true, bpf: BPFBusy},
+			available: []string{"lo0", "pktap"},
+			wantBPF:   BPFBusy,
+		},
+		{
+			name:        "macOS with every device busy",
+			host:        fakeHost{goos: "darwin", bpf: BPFBusy},
+			unavailable: []string{"lo0", "pktap"},
+			wantBPF:     BPFBusy,
+		},
+		{
+			name:        "FreeBSD without BPF devices",
+			host:        fakeHost{goos: "freebsd", bpf: BPFMissing},
+			unavailable: []string{"lo0"},
+			wantBPF:     BPFMissing,
+		},
+		{
+			name:      "Linux as root",
+			host:      fakeHost{goos: "linux", privileged: true, devices: []string{"lo", "eth0"}},
+			available: []string{"lo", "any"},
+		},
+		{
+			name:        "Linux unprivileged",
+			host:        fakeHost{goos: "linux", devicesErr: errors.New("permission denied")},
+			unavailable: []string{"lo", "any"},
+			wantFix:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := checkCaptureSupport(tt.host)
+			for _, iface := range tt.available {
+				if !findOption(t, s, "pcap", iface).Available {
+					t.Errorf("pcap on %s unavailable, want available", iface)
+				}
+			}
+			for _, iface := range tt.unavailable {
+				if findOption(t, s, "pcap", iface).Available {
+					t.Errorf("pcap on %s available, want unavailable", iface)
+				}
+			}
+			if got := s.PacketCaptureAvailable(); got != (len(tt.available) > 0) {
+				t.Errorf("PacketCaptureAvailable() = %t", got)
+			}
+			if !findOption(t, s, "proxy", "").Available {
+				t.Error("proxy unavailable")
+			}
+			if got := len(s.Fix) > 0; got != tt.wantFix {
+				t.Errorf("fix = %q, want one: %t", s.Fix, tt.wantFix)
+			}
+			if s.BPF != tt.wantBPF {
+				t.Errorf("BPF = %q, want %q", s.BPF, tt.wantBPF)
+			}
+			if (tt.host.devicesErr != nil) != (s.Error != "") {
+				t.Errorf("error = %q", s.Error)
+			}
+		})
+	}
+}
+
+func TestCheckCaptureSupportOnWindows(t *testing.T) {
+	s := checkCaptureSupport(fakeHost{goos: "windows", privileged: true})
+	if s.PacketCaptureAvailable() {
+		t.Error("packet capture available on Windows")
+	}
+	if !findOption(t, s, "proxy", "").Available {
+		t.Error("proxy unavailable")
+	}
+	if s.BPF != "" {
+		t.Errorf("BPF = %q, want none", s.BPF)
+	}
+}
+
+func TestPktapFallback(t *testing.T) {
+	noPackets := &SelfTestResult{Stage: StageNoPackets}
+	tests := []struct {
+		name   string
+		host   fakeHost
+		result *SelfTestResult
+		iface  string
+		proxy  string
+		want   bool
+	}{
+		{name: "macOS loopback saw nothing", host: fakeHost{goos: "darwin"}, result: noPackets, want: true},
+		{name: "passed", host: fakeHost{goos: "darwin"}, result: &SelfTestResult{Stage: StagePassed}},
+		{name: "packets not parsed", host: fakeHost{goos: "darwin"}, result: &SelfTestResult{Stage: StageNotParsed}},
+		{name: "Linux", host: fakeHost{goos: "linux"}, result: noPackets},
+		{name: "interface chosen", host: fakeHost{goos: "darwin"}, result: noPackets, iface: "en0"},
+		{name: "already on pktap", host: fakeHost{goos: "darwin"}, result: noPackets, iface: "pktap"},
+		{name: "proxy mode", host: fakeHost{goos: "darwin"}, result: noPackets, proxy: ":8081"},
+		{name: "pktap can't be opened", host: fakeHost{goos: "darwin", unopenable: []string{"pktap"}}, result: noPackets},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec := newTestCapture(t, &recordingRegistrar{})
+			ec.SetInterface(tt.iface)
+			ec.proxyAddr = tt.proxy
+			ec.restart = func() {}
+			if got := ec.pktapFallback(tt.host, tt.result); got != tt.want {
+				t.Errorf("pktapFallback() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSwitchInterfaceRestartsCapture(t *testing.T) {
+	ec := newTestCapture(t, &recordingRegistrar{})
+	ec.markReady()
+
+	var restarted bool
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ec.restart = func() { restarted = true; cancel() }
+
+	ready := ec.switchInterface("pktap")
+	if !restarted || ctx.Err() == nil {
+		t.Fatal("capture was not restarted")
+	}
+	if ec.iface != "pktap" {
+		t.Errorf("interface = %q, want pktap", ec.iface)
+	}
+	select {
+	case <-ready:
+		t.Fatal("restarted capture is ready before it opened")
+	default:
+	}
+	ec.markReady()
+	select {
+	case <-ready:
+	default:
+		t.Fatal("restarted capture never became ready")
+	}
+}
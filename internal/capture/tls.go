@@ -0,0 +1,98 @@
+package capture
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	proxyCertFile = "proxy-cert.pem"
+	proxyKeyFile  = "proxy-key.pem"
+	// proxyCertValidity is how long a generated proxy certificate lasts.
+	proxyCertValidity = 365 * 24 * time.Hour
+)
+
+// ProxyTLSConfig returns the TLS config for the capture proxy. It uses
+// certFile and keyFile when given, and otherwise a self-signed localhost
+// certificate kept in dir, generating one on first use so clients only
+// need to trust it once.
+func ProxyTLSConfig(certFile, keyFile, dir string) (*tls.Config, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("--tls-cert and --tls-key must be given together")
+	}
+
+	if certFile == "" {
+		certFile = filepath.Join(dir, proxyCertFile)
+		keyFile = filepath.Join(dir, proxyKeyFile)
+		if _, err := os.Stat(certFile); errors.Is(err, fs.ErrNotExist) {
+			if err := writeSelfSignedCert(certFile, keyFile); err != nil {
+				return nil, fmt.Errorf("generate proxy certificate: %w", err)
+			}
+			slog.Info("Generated self-signed proxy certificate", "path", certFile)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load proxy certificate: %w", err)
+	}
+	fingerprint := sha256.Sum256(cert.Certificate[0])
+	slog.Info("Capture proxy certificate", "path", certFile, "sha256", fmt.Sprintf("%X", fingerprint))
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+func writeSelfSignedCert(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"mcpify capture proxy"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(proxyCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
@@ -0,0 +1,121 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"log/slog"
+	"time"
+)
+
+// Verbosity selects which capture diagnostics are logged. Each level adds
+// to the ones below it.
+type Verbosity int
+
+const (
+	// VerbosityQuiet logs discovered and registered endpoints only.
+	VerbosityQuiet Verbosity = iota
+	// VerbosityEndpoints adds requests filtered out, the packet filter and
+	// the periodic packet counts.
+	VerbosityEndpoints
+	// VerbosityRequests adds a summary of every captured request, with the
+	// time its response took.
+	VerbosityRequests
+	// VerbosityPayloads adds parse failures with the start of the payload
+	// that failed, and captured request bodies.
+	VerbosityPayloads
+
+	MaxVerbosity = VerbosityPayloads
+)
+
+// statsInterval is how often packet counts are reported.
+const statsInterval = 30 * time.Second
+
+// payloadPrefix is how much of an unparseable payload is logged.
+const payloadPrefix = 64
+
+// SetVerbosity sets which capture diagnostics are logged. Levels above
+// MaxVerbosity log everything.
+func (ec *EndpointCapture) SetVerbosity(v Verbosity) {
+	ec.verbosity.Store(int32(v))
+}
+
+func (ec *EndpointCapture) verbose(level Verbosity) bool {
+	return Verbosity(ec.verbosity.Load()) >= level
+}
+
+// debug logs msg with args at debug level when the verbosity is at least
+// level.
+func (ec *EndpointCapture) debug(level Verbosity, msg string, args ...any) {
+	if ec.verbose(level) {
+		slog.Debug(msg, args...)
+	}
+}
+
+// peekPayload returns the start of what buf holds, without waiting for
+// more, so a payload that fails to parse can be logged. It is only
+// collected at VerbosityPayloads.
+func (ec *EndpointCapture) peekPayload(buf *bufio.Reader) []byte {
+	if !ec.verbose(VerbosityPayloads) {
+		return nil
+	}
+	if _, err := buf.Peek(1); err != nil {
+		return nil
+	}
+	prefix, _ := buf.Peek(min(buf.Buffered(), payloadPrefix))
+	return bytes.Clone(prefix)
+}
+
+// logExchange logs the summary of one captured request at
+// VerbosityRequests. elapsed is how long its response took.
+func (ec *EndpointCapture) logExchange(method, path string, status int, size int, elapsed time.Duration) {
+	ec.debug(VerbosityRequests, "Captured exchange", "method", method, "path", ec.secrets.Path(path), "status", status, "bytes", size, "elapsed", elapsed.Round(time.Millisecond))
+}
+
+// reportStats logs the packet counts of the last statsInterval until done
+// is closed. Below VerbosityEndpoints they are only logged when they
+// suggest capture isn't working.
+func (ec *EndpointCapture) reportStats(done <-chan struct{}) {
+	ticker := time.NewTicker(statsInterval)
+	defer ticker.Stop()
+
+	packets, requests, failures := ec.selfTest.packets.Load(), ec.requests.Load(), ec.parseFailures.Load()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		p, r, f := ec.selfTest.packets.Load(), ec.requests.Load(), ec.parseFailures.Load()
+		dp, dr, df := p-packets, r-requests, f-failures
+		packets, requests, failures = p, r, f
+
+		counts := []any{"packets", dp, "requests", dr, "parse_failures", df, "interval", statsInterval}
+		switch {
+		case dp > 0 && dr == 0:
+			slog.Warn("Capture saw packets but no HTTP requests; the target may use TLS or a protocol other than HTTP/1.x", counts...)
+		case df > 0 && df*10 >= dr:
+			slog.Warn("Capture failed to parse many payloads; run with -vvv to see them", counts...)
+		default:
+			ec.debug(VerbosityEndpoints, "Capture counts", counts...)
+		}
+	}
+}
+
+// Counts are the capture's running totals since it was created.
+type Counts struct {
+	Packets       int64
+	Requests      int64
+	ParseFailures int64
+	Endpoints     int64
+}
+
+// Counts returns the packets processed, HTTP requests parsed, requests
+// and responses that failed to parse, and endpoints discovered so far.
+func (ec *EndpointCapture) Counts() Counts {
+	return Counts{
+		Packets:       ec.selfTest.packets.Load(),
+		Requests:      ec.requests.Load(),
+		ParseFailures: ec.parseFailures.Load(),
+		Endpoints:     ec.discovered.Load(),
+	}
+}
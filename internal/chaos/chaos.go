@@ -0,0 +1,221 @@
+// Package chaos injects deterministic faults into tool execution so agents
+// can be tested against flaky APIs.
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes which faults to inject and how often.
+type Config struct {
+	ErrorRate    float64       `json:"error_rate"`
+	Status       int           `json:"status"`
+	Latency      time.Duration `json:"latency"`
+	Jitter       time.Duration `json:"jitter"`
+	TruncateRate float64       `json:"truncate_rate"`
+	Seed         int64         `json:"seed"`
+	Tools        []string      `json:"tools,omitempty"`
+}
+
+// Parse reads a spec such as
+// "error_rate=0.2,latency=500ms±300ms,status=503,truncate_rate=0.1,seed=7,tools=get_user|list_users".
+func Parse(spec string) (*Config, error) {
+	cfg := &Config{Status: http.StatusServiceUnavailable, Seed: 1}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("chaos: expected key=value, got %q", part)
+		}
+
+		var err error
+		switch strings.TrimSpace(key) {
+		case "error_rate":
+			cfg.ErrorRate, err = parseRate(value)
+		case "truncate_rate":
+			cfg.TruncateRate, err = parseRate(value)
+		case "status":
+			cfg.Status, err = strconv.Atoi(value)
+			if err == nil && (cfg.Status < 400 || cfg.Status > 599) {
+				err = fmt.Errorf("status must be 4xx or 5xx")
+			}
+		case "latency":
+			base, jitter, _ := strings.Cut(value, "±")
+			if cfg.Latency, err = time.ParseDuration(base); err == nil && jitter != "" {
+				cfg.Jitter, err = time.ParseDuration(jitter)
+			}
+		case "seed":
+			cfg.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "tools":
+			cfg.Tools = strings.Split(value, "|")
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos: invalid %s: %w", part, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err == nil && (rate < 0 || rate > 1) {
+		err = fmt.Errorf("rate must be between 0 and 1")
+	}
+	return rate, err
+}
+
+// Chaos decides, per call, which faults to inject. A nil *Chaos injects
+// nothing, so callers don't need to check whether chaos is enabled.
+type Chaos struct {
+	mu      sync.Mutex
+	cfg     Config
+	enabled bool
+	rng     *rand.Rand
+}
+
+func New(cfg *Config) *Chaos {
+	return &Chaos{
+		cfg:     *cfg,
+		enabled: true,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+// Plan is the set of faults chosen for one tool call.
+type Plan struct {
+	Delay    time.Duration
+	Fail     bool
+	Status   int
+	Truncate bool
+}
+
+func (p Plan) injected() bool {
+	return p.Delay > 0 || p.Fail || p.Truncate
+}
+
+// Plan draws the faults for a call to tool.
+func (c *Chaos) Plan(tool string) Plan {
+	if c == nil {
+		return Plan{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled || (len(c.cfg.Tools) > 0 && !slices.Contains(c.cfg.Tools, tool)) {
+		return Plan{}
+	}
+
+	plan := Plan{Status: c.cfg.Status}
+	if c.cfg.Latency > 0 || c.cfg.Jitter > 0 {
+		plan.Delay = c.cfg.Latency
+		if c.cfg.Jitter > 0 {
+			plan.Delay += time.Duration(c.rng.Int63n(int64(2*c.cfg.Jitter))) - c.cfg.Jitter
+		}
+		plan.Delay = max(plan.Delay, 0)
+	}
+	plan.Fail = c.rng.Float64() < c.cfg.ErrorRate
+	plan.Truncate = !plan.Fail && c.rng.Float64() < c.cfg.TruncateRate
+
+	if plan.injected() {
+		slog.Info("Chaos injected", "tool", tool, "delay", plan.Delay, "fail", plan.Fail, "status", plan.Status, "truncate", plan.Truncate)
+	}
+	return plan
+}
+
+// Wait sleeps for the planned delay or until ctx is done.
+func (p Plan) Wait(ctx context.Context) error {
+	if p.Delay <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(p.Delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// FailureBody is the body returned with an injected error status.
+func (p Plan) FailureBody() []byte {
+	return []byte(http.StatusText(p.Status))
+}
+
+// Apply truncates body when the plan says so.
+func (p Plan) Apply(body []byte) []byte {
+	if !p.Truncate || len(body) < 2 {
+		return body
+	}
+	return body[:len(body)/2]
+}
+
+func (c *Chaos) status() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return map[string]interface{}{
+		"enabled": c.enabled,
+		"config":  c.cfg,
+	}
+}
+
+// Handler serves /api/chaos. GET returns the current state; POST accepts
+// {"enabled": bool, "spec": "..."} to toggle or reconfigure at runtime.
+func (c *Chaos) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var update struct {
+				Enabled *bool  `json:"enabled"`
+				Spec    string `json:"spec"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+				http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+				return
+			}
+
+			var cfg *Config
+			if update.Spec != "" {
+				var err error
+				if cfg, err = Parse(update.Spec); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+
+			c.mu.Lock()
+			if cfg != nil {
+				c.cfg = *cfg
+				c.rng = rand.New(rand.NewSource(cfg.Seed))
+			}
+			if update.Enabled != nil {
+				c.enabled = *update.Enabled
+			}
+			slog.Info("Chaos updated", "enabled", c.enabled, "config", fmt.Sprintf("%+v", c.cfg))
+			c.mu.Unlock()
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.status())
+	})
+}
@@ -0,0 +1,366 @@
+// Package compare replays captured requests against two targets and
+// reports where their responses differ.
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// Options controls which requests are replayed and how responses are
+// compared.
+type Options struct {
+	Primary   *url.URL
+	Candidate *url.URL
+	// OnlyReads restricts the run to safe methods: GET, HEAD, OPTIONS and
+	// the like, such as WebDAV's PROPFIND.
+	OnlyReads bool
+	// AllowUnsafe also replays POST, PATCH and methods mcpify doesn't
+	// know, which are skipped by default because replaying them twice may
+	// not be idempotent.
+	AllowUnsafe bool
+	// Ignore lists JSON paths ("$.meta.updated_at") or bare field names
+	// ("updated_at") excluded from body comparison.
+	Ignore []string
+	// Rate is the maximum number of requests per second across both
+	// targets. Zero means unlimited.
+	Rate    float64
+	Timeout time.Duration
+}
+
+// Diff is one difference between the two JSON bodies.
+type Diff struct {
+	Path      string      `json:"path"`
+	Kind      string      `json:"kind"` // added, removed or changed
+	Primary   interface{} `json:"primary,omitempty"`
+	Candidate interface{} `json:"candidate,omitempty"`
+}
+
+// Result is the comparison for one endpoint.
+type Result struct {
+	Tool             string `json:"tool"`
+	Method           string `json:"method"`
+	Path             string `json:"path"`
+	Skipped          string `json:"skipped,omitempty"`
+	Error            string `json:"error,omitempty"`
+	PrimaryStatus    int    `json:"primary_status,omitempty"`
+	CandidateStatus  int    `json:"candidate_status,omitempty"`
+	StatusMismatch   bool   `json:"status_mismatch,omitempty"`
+	BodyMismatch     bool   `json:"body_mismatch,omitempty"`
+	Diffs            []Diff `json:"diffs,omitempty"`
+	PrimaryLatency   int64  `json:"primary_latency_ms,omitempty"`
+	CandidateLatency int64  `json:"candidate_latency_ms,omitempty"`
+}
+
+func (r *Result) Matches() bool {
+	return r.Skipped == "" && r.Error == "" && !r.StatusMismatch && !r.BodyMismatch
+}
+
+type Report struct {
+	Primary   string    `json:"primary"`
+	Candidate string    `json:"candidate"`
+	Results   []*Result `json:"results"`
+	Matched   int       `json:"matched"`
+	Differed  int       `json:"differed"`
+	Skipped   int       `json:"skipped"`
+	Errors    int       `json:"errors"`
+}
+
+// Run replays each tool's request against both targets, in name order.
+func Run(ctx context.Context, tools []*config.Tool, opts Options) *Report {
+	sorted := append([]*config.Tool(nil), tools...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	client := &http.Client{Timeout: opts.Timeout}
+	report := &Report{Primary: opts.Primary.String(), Candidate: opts.Candidate.String()}
+
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	wait := func() error {
+		if tick == nil {
+			return ctx.Err()
+		}
+		select {
+		case <-tick:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for _, tool := range sorted {
+		result := &Result{Tool: tool.Name, Method: tool.Method, Path: toolPath(tool)}
+		report.Results = append(report.Results, result)
+
+		if reason := skipReason(tool.Method, opts); reason != "" {
+			result.Skipped = reason
+			report.Skipped++
+			continue
+		}
+
+		if err := wait(); err != nil {
+			result.Error = err.Error()
+			report.Errors++
+			continue
+		}
+		primary, err := send(ctx, client, tool, opts.Primary)
+		if err != nil {
+			result.Error = "primary: " + err.Error()
+			report.Errors++
+			continue
+		}
+
+		if err := wait(); err != nil {
+			result.Error = err.Error()
+			report.Errors++
+			continue
+		}
+		candidate, err := send(ctx, client, tool, opts.Candidate)
+		if err != nil {
+			result.Error = "candidate: " + err.Error()
+			report.Errors++
+			continue
+		}
+
+		result.PrimaryStatus, result.CandidateStatus = primary.status, candidate.status
+		result.PrimaryLatency = primary.latency.Milliseconds()
+		result.CandidateLatency = candidate.latency.Milliseconds()
+		result.StatusMismatch = primary.status != candidate.status
+		result.Diffs, result.BodyMismatch = compareBodies(primary.body, candidate.body, opts.Ignore)
+
+		if result.Matches() {
+			report.Matched++
+		} else {
+			report.Differed++
+		}
+	}
+
+	return report
+}
+
+func skipReason(method string, opts Options) string {
+	switch method = strings.ToUpper(method); {
+	case config.SafeMethod(method):
+		return ""
+	case method == http.MethodPut || method == http.MethodDelete:
+		if opts.OnlyReads {
+			return "not a read"
+		}
+	default:
+		if opts.OnlyReads || !opts.AllowUnsafe {
+			return "non-idempotent method"
+		}
+	}
+	return ""
+}
+
+func toolPath(tool *config.Tool) string {
+	u, err := url.Parse(tool.URL)
+	if err != nil {
+		return tool.URL
+	}
+	if u.RawQuery != "" {
+		return tool.RawPath() + "?" + u.RawQuery
+	}
+	return tool.RawPath()
+}
+
+type response struct {
+	status  int
+	body    []byte
+	latency time.Duration
+}
+
+// send issues tool's request against target, keeping the tool's path and
+// query but replacing its scheme and host.
+func send(ctx context.Context, client *http.Client, tool *config.Tool, target *url.URL) (*response, error) {
+	u, err := url.Parse(tool.ResolveURL(nil, nil))
+	if err != nil {
+		return nil, err
+	}
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	u.Path = strings.TrimSuffix(target.Path, "/") + u.Path
+
+	var body io.Reader
+	if tool.Body != "" {
+		body = bytes.NewReader([]byte(tool.Body))
+	}
+	req, err := http.NewRequestWithContext(ctx, tool.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range tool.Headers {
+		req.Header.Set(k, v)
+	}
+	config.StripHopHeaders(req.Header)
+	// Headers whose secret isn't set are left out
+	config.ResolveHeaderSecrets(req.Header)
+	req.Header.Set("User-Agent", config.DefaultUserAgent(tool.Name))
+	req.Header.Set(config.MarkerHeader, "compare="+tool.Name)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	return &response{status: resp.StatusCode, body: data, latency: time.Since(start)}, nil
+}
+
+// compareBodies diffs JSON bodies structurally and anything else byte for
+// byte.
+func compareBodies(primary, candidate []byte, ignore []string) ([]Diff, bool) {
+	var a, b interface{}
+	if json.Unmarshal(primary, &a) != nil || json.Unmarshal(candidate, &b) != nil {
+		return nil, !bytes.Equal(primary, candidate)
+	}
+
+	var diffs []Diff
+	diffJSON("$", a, b, ignore, &diffs)
+	return diffs, len(diffs) > 0
+}
+
+func ignored(path string, ignore []string) bool {
+	for _, p := range ignore {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "$") {
+			// A bare field name matches that field anywhere
+			if strings.HasSuffix(path, "."+p) {
+				return true
+			}
+			continue
+		}
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func diffJSON(path string, a, b interface{}, ignore []string, diffs *[]Diff) {
+	if ignored(path, ignore) {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			child := path + "." + k
+			if ignored(child, ignore) {
+				continue
+			}
+			va, inA := av[k]
+			vb, inB := bv[k]
+			switch {
+			case !inA:
+				*diffs = append(*diffs, Diff{Path: child, Kind: "added", Candidate: vb})
+			case !inB:
+				*diffs = append(*diffs, Diff{Path: child, Kind: "removed", Primary: va})
+			default:
+				diffJSON(child, va, vb, ignore, diffs)
+			}
+		}
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			child := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(av):
+				*diffs = append(*diffs, Diff{Path: child, Kind: "added", Candidate: bv[i]})
+			case i >= len(bv):
+				*diffs = append(*diffs, Diff{Path: child, Kind: "removed", Primary: av[i]})
+			default:
+				diffJSON(child, av[i], bv[i], ignore, diffs)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*diffs = append(*diffs, Diff{Path: path, Kind: "changed", Primary: a, Candidate: b})
+	}
+}
+
+// WriteTable prints a human-readable summary of the report.
+func (r *Report) WriteTable(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RESULT\tMETHOD\tPATH\tSTATUS\tLATENCY (ms)\tDETAILS")
+
+	for _, res := range r.Results {
+		outcome, status, latency, details := "match", "", "", ""
+		switch {
+		case res.Skipped != "":
+			outcome, details = "skipped", res.Skipped
+		case res.Error != "":
+			outcome, details = "error", res.Error
+		default:
+			status = fmt.Sprintf("%d / %d", res.PrimaryStatus, res.CandidateStatus)
+			latency = fmt.Sprintf("%d / %d", res.PrimaryLatency, res.CandidateLatency)
+			if !res.Matches() {
+				outcome = "DIFF"
+				var parts []string
+				if res.StatusMismatch {
+					parts = append(parts, "status")
+				}
+				for _, d := range res.Diffs {
+					parts = append(parts, d.Kind+" "+d.Path)
+				}
+				if res.BodyMismatch && len(res.Diffs) == 0 {
+					parts = append(parts, "body")
+				}
+				details = strings.Join(parts, ", ")
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", outcome, res.Method, res.Path, status, latency, details)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\n%d matched, %d differed, %d skipped, %d errors\n", r.Matched, r.Differed, r.Skipped, r.Errors)
+}
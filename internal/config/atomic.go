@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CorruptSuffix starts the name a config that couldn't be parsed is moved
+// to, followed by when it was found.
+const CorruptSuffix = ".corrupt-"
+
+// writeFileAtomic writes data to path through a temporary file in the same
+// directory, synced and then renamed over path, so a crash or a concurrent
+// reader sees either the old content or the new, never part of it.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// The rename itself is durable once the directory is synced
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// setAsideCorrupt moves the unparseable config at path to
+// <path>.corrupt-<time>, where nothing reads, rewrites or deletes it, and
+// returns the new path.
+func setAsideCorrupt(path string) (string, error) {
+	aside := path + CorruptSuffix + time.Now().Format("20060102-150405")
+	if err := os.Rename(path, aside); err != nil {
+		return "", fmt.Errorf("move corrupt config aside: %w", err)
+	}
+	return aside, nil
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// secretRef is a "${secret:NAME}" reference, which is read from the
+// environment variable NAME.
+var secretRef = regexp.MustCompile(`\$\{secret:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ParseAuthQuery reads a comma-separated list of name=value query
+// parameters, e.g. "api_key=${secret:API_KEY}".
+func ParseAuthQuery(list string) (map[string]string, error) {
+	params := make(map[string]string)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w %q: want name=value", ErrInvalidAuthQuery, item)
+		}
+		params[name] = value
+	}
+	return params, nil
+}
+
+// ResolveSecrets replaces the ${secret:NAME} references in each value with
+// the environment variable NAME. It fails on the first unset one, so a
+// missing key shows up at startup rather than as rejected calls.
+func ResolveSecrets(params map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(params))
+	for name, value := range params {
+		var missing string
+		resolved[name] = secretRef.ReplaceAllStringFunc(value, func(ref string) string {
+			key := secretRef.FindStringSubmatch(ref)[1]
+			secret, ok := os.LookupEnv(key)
+			if !ok && missing == "" {
+				missing = key
+			}
+			return secret
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("%w: %s needs the environment variable %s", ErrSecretNotSet, name, missing)
+		}
+	}
+	return resolved, nil
+}
+
+// HeaderSecretRef is the ${secret:NAME} reference kept instead of the
+// value of the sensitive header name with --keep-auth: the name in upper
+// case with dashes as underscores, e.g. ${secret:X_API_KEY}.
+func HeaderSecretRef(name string) string {
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+	return "${secret:" + key + "}"
+}
+
+// ResolveHeaderSecrets replaces the ${secret:NAME} references in the
+// values of h with the environment variable NAME. Headers referring to an
+// unset variable are removed, and the first is reported.
+func ResolveHeaderSecrets(h http.Header) error {
+	var missing error
+	for name := range h {
+		value := h.Get(name)
+		if !secretRef.MatchString(value) {
+			continue
+		}
+		resolved, err := ResolveSecrets(map[string]string{name: value})
+		if err != nil {
+			h.Del(name)
+			if missing == nil {
+				missing = err
+			}
+			continue
+		}
+		h.Set(name, resolved[name])
+	}
+	return missing
+}
+
+// RedactQueryParams blanks the stored values of the query parameters
+// sensitive reports true for, including those in tool history, so keys
+// captured before they were known to be sensitive don't stay on disk. It
+// returns how many tools changed.
+func (c *Config) RedactQueryParams(sensitive func(name string) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	redacted := 0
+	for _, tool := range c.Tools {
+		changed := redactParams(tool.QueryParams, sensitive)
+		for i := range tool.History {
+			for j, change := range tool.History[i].Changes {
+				if change.Field != "query_params" {
+					continue
+				}
+				for _, value := range []*json.RawMessage{&tool.History[i].Changes[j].Old, &tool.History[i].Changes[j].New} {
+					var params map[string]string
+					if json.Unmarshal(*value, &params) != nil || !redactParams(params, sensitive) {
+						continue
+					}
+					*value, _ = json.Marshal(params)
+					changed = true
+				}
+			}
+		}
+		if changed {
+			redacted++
+		}
+	}
+	return redacted
+}
+
+func redactParams(params map[string]string, sensitive func(name string) bool) bool {
+	changed := false
+	for name, value := range params {
+		if value != "" && sensitive(name) {
+			params[name] = ""
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultBlobThreshold is the size above which request bodies and
+// response examples are kept as blobs rather than inline in config.json,
+// when the config doesn't set blob_threshold.
+const DefaultBlobThreshold = 1 << 10
+
+// BlobDir is the directory next to the config that DirBlobs keeps blobs in.
+const BlobDir = "blobs"
+
+const blobRefPrefix = "sha256:"
+
+var blobRefPattern = regexp.MustCompile(`sha256:[0-9a-f]{64}`)
+
+// BlobStore keeps content under a reference derived from it, so equal
+// bodies are stored once.
+type BlobStore interface {
+	Put(data []byte) (ref string, err error)
+	// Get fails with ErrBlobNotFound when ref isn't stored.
+	Get(ref string) ([]byte, error)
+	Refs() ([]string, error)
+	Delete(ref string) error
+}
+
+// BlobRef is the reference of data: "sha256:" and its hex digest.
+func BlobRef(data []byte) string {
+	sum := sha256.Sum256(data)
+	return blobRefPrefix + hex.EncodeToString(sum[:])
+}
+
+// DirBlobs stores each blob in a file of a directory, named by its digest.
+type DirBlobs struct {
+	dir string
+}
+
+func NewDirBlobs(dir string) *DirBlobs {
+	return &DirBlobs{dir: dir}
+}
+
+func (b *DirBlobs) path(ref string) (string, error) {
+	if !blobRefPattern.MatchString(ref) || len(ref) != len(blobRefPrefix)+sha256.Size*2 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidBlobRef, ref)
+	}
+	return filepath.Join(b.dir, strings.TrimPrefix(ref, blobRefPrefix)), nil
+}
+
+// Put stores data unless a blob with its digest exists. Blobs are written
+// to a temporary file first, so a crash never leaves a partial one.
+func (b *DirBlobs) Put(data []byte) (string, error) {
+	ref := BlobRef(data)
+	path, _ := b.path(ref)
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return "", fmt.Errorf("create blob directory: %w", err)
+	}
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	return ref, nil
+}
+
+// Get reads the blob ref, failing with ErrBlobNotFound when it is missing
+// and ErrBlobCorrupt when its content doesn't match its digest.
+func (b *DirBlobs) Get(ref string) ([]byte, error) {
+	path, err := b.path(ref)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, ref)
+	} else if err != nil {
+		return nil, err
+	}
+	if BlobRef(data) != ref {
+		return nil, fmt.Errorf("%w: %s", ErrBlobCorrupt, ref)
+	}
+	return data, nil
+}
+
+func (b *DirBlobs) Refs() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var refs []string
+	for _, entry := range entries {
+		if ref := blobRefPrefix + entry.Name(); entry.Type().IsRegular() && blobRefPattern.MatchString(ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+func (b *DirBlobs) Delete(ref string) error {
+	path, err := b.path(ref)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SetBlobStore replaces where large bodies and examples are kept. A nil
+// store keeps everything inline.
+func (c *Config) SetBlobStore(store BlobStore) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blobs = store
+}
+
+// BodyUnavailable reports whether the tool's body is kept as a blob that
+// couldn't be read when the config was loaded. The tool is sent without
+// a body until one is captured or set.
+func (t *Tool) BodyUnavailable() bool {
+	return t.Body == "" && t.BodyRef != ""
+}
+
+// BodyUnavailable reports whether the example is kept as a blob that
+// couldn't be read when the config was loaded.
+func (r *ResponseSample) BodyUnavailable() bool {
+	return r.Body == "" && r.BodyRef != ""
+}
+
+// The forms tools are written to config.json in. Their Body fields shadow
+// the embedded ones, leaving them out when the content is in a blob.
+type (
+	configFields   Config
+	toolFields     Tool
+	responseFields ResponseSample
+
+	storedConfig struct {
+		*configFields
+		// Every catalog is under Targets, the active one included
+		Tools   map[string]*storedTool     `json:"tools,omitempty"`
+		Groups  map[string]*Group          `json:"groups,omitempty"`
+		Targets map[string]json.RawMessage `json:"targets,omitempty"`
+	}
+	storedCatalog struct {
+		Tools  map[string]*storedTool `json:"tools"`
+		Groups map[string]*Group      `json:"groups,omitempty"`
+	}
+	storedTool struct {
+		*toolFields
+		Body     string          `json:"body"`
+		BodyRef  string          `json:"body_ref,omitempty"`
+		Response *storedResponse `json:"response,omitempty"`
+	}
+	storedResponse struct {
+		*responseFields
+		Body    string `json:"body,omitempty"`
+		BodyRef string `json:"body_ref,omitempty"`
+	}
+)
+
+func (c *Config) blobThreshold() int {
+	if c.BlobThreshold > 0 {
+		return c.BlobThreshold
+	}
+	return DefaultBlobThreshold
+}
+
+// stored returns c in the form written to disk, with the active catalog
+// under Targets next to the others. Bodies over the blob threshold go to
+// the blob store unless inline is set, and ones that were unavailable
+// keep their reference. c.mu must be held.
+func (c *Config) stored(inline bool) (*storedConfig, error) {
+	targets := c.Targets
+	if inline {
+		var err error
+		if targets, err = c.inlineTargets(); err != nil {
+			return nil, err
+		}
+	}
+	s := &storedConfig{configFields: (*configFields)(c), Targets: maps.Clone(targets)}
+	if len(c.Tools) > 0 || len(c.Groups) > 0 {
+		active, err := c.storedCatalog(inline)
+		if err != nil {
+			return nil, err
+		}
+		if s.Targets == nil {
+			s.Targets = make(map[string]json.RawMessage)
+		}
+		s.Targets[c.target] = active
+	}
+	return s, nil
+}
+
+// storedCatalog returns the active tools and groups in the form written
+// to disk. c.mu must be held.
+func (c *Config) storedCatalog(inline bool) (json.RawMessage, error) {
+	externalize := func(body, ref string) (string, string, error) {
+		switch {
+		case body == "" && ref != "" && !inline:
+			return "", ref, nil
+		case inline || c.blobs == nil || c.BlobThreshold < 0 || len(body) <= c.blobThreshold():
+			return body, "", nil
+		}
+		ref, err := c.blobs.Put([]byte(body))
+		if err != nil {
+			return "", "", err
+		}
+		return "", ref, nil
+	}
+
+	s := &storedCatalog{Tools: make(map[string]*storedTool, len(c.Tools)), Groups: c.Groups}
+	for id, tool := range c.Tools {
+		st := &storedTool{toolFields: (*toolFields)(tool)}
+		var err error
+		if st.Body, st.BodyRef, err = externalize(tool.Body, tool.BodyRef); err != nil {
+			return nil, fmt.Errorf("store body of %s: %w", tool.Name, err)
+		}
+		if tool.Response != nil {
+			st.Response = &storedResponse{responseFields: (*responseFields)(tool.Response)}
+			if st.Response.Body, st.Response.BodyRef, err = externalize(tool.Response.Body, tool.Response.BodyRef); err != nil {
+				return nil, fmt.Errorf("store response example of %s: %w", tool.Name, err)
+			}
+		}
+		s.Tools[id] = st
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("encode tools: %w", err)
+	}
+	return data, nil
+}
+
+// resolveBlobs reads the bodies and examples kept as blobs. Missing ones
+// are logged and left unavailable rather than failing the load. It
+// reports how many inline ones belong in blobs.
+func (c *Config) resolveBlobs() (inlined int) {
+	resolve := func(body, ref *string, what string) {
+		if *ref == "" {
+			if c.blobs != nil && c.BlobThreshold >= 0 && len(*body) > c.blobThreshold() {
+				inlined++
+			}
+			return
+		}
+		if c.blobs == nil {
+			return
+		}
+		data, err := c.blobs.Get(*ref)
+		if err != nil {
+			slog.Warn("Blob is unavailable", "blob", what, "error", err)
+			return
+		}
+		*body, *ref = string(data), ""
+	}
+
+	for _, tool := range c.Tools {
+		resolve(&tool.Body, &tool.BodyRef, "Body of "+tool.Name)
+		if tool.Response != nil {
+			resolve(&tool.Response.Body, &tool.Response.BodyRef, "Response example of "+tool.Name)
+		}
+	}
+	return inlined
+}
+
+// Export returns the config as written to disk. With inline set, every
+// body and example is included, making a portable file; otherwise large
+// ones are references into this config's blob store.
+func (c *Config) Export(inline bool) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.encode(inline)
+}
+
+// CollectBlobs deletes the blobs neither the catalog nor a backup of the
+// config (<config>.bak*, or <config>.corrupt-* set aside by LoadConfig)
+// refers to, and returns how many it deleted.
+func (c *Config) CollectBlobs() (int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.blobs == nil {
+		return 0, nil
+	}
+
+	// Every target's catalog, not just the active one
+	data, err := c.encode(false)
+	if err != nil {
+		return 0, err
+	}
+	referenced := make(map[string]bool)
+	for _, ref := range blobRefPattern.FindAll(data, -1) {
+		referenced[string(ref)] = true
+	}
+	backups, _ := filepath.Glob(c.Path + ".bak*")
+	corrupt, _ := filepath.Glob(c.Path + CorruptSuffix + "*")
+	backups = append(backups, corrupt...)
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			return 0, fmt.Errorf("read backup: %w", err)
+		}
+		for _, ref := range blobRefPattern.FindAll(data, -1) {
+			referenced[string(ref)] = true
+		}
+	}
+
+	refs, err := c.blobs.Refs()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, ref := range refs {
+		if referenced[ref] {
+			continue
+		}
+		if err := c.blobs.Delete(ref); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
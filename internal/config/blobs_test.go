@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// inlineCatalog writes a config with every body inline, as configs from
+// before blobs were, to a fresh directory and returns its path. Of its
+// tools, one in ten has a small body, and the large bodies and examples
+// repeat every 25 tools.
+func inlineCatalog(t *testing.T, tools int) string {
+	t.Helper()
+	src := DefaultConfig(filepath.Join(t.TempDir(), "config.json"))
+	src.LastTarget = "http://localhost:3000"
+	src.target = TargetKey(src.LastTarget)
+	src.names = make(map[string]string)
+	for i := range tools {
+		body := fmt.Sprintf(`{"n":%d}`, i)
+		if i%10 != 0 {
+			body = fmt.Sprintf(`{"payload":%q}`, strings.Repeat(fmt.Sprint(i%25), 4<<10))
+		}
+		src.AddTool(&Tool{
+			Name:      fmt.Sprintf("post_item%d", i),
+			Method:    "POST",
+			URL:       fmt.Sprintf("http://localhost:3000/items%d", i),
+			Body:      body,
+			CreatedAt: time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC),
+			Response: &ResponseSample{
+				Status:      200,
+				ContentType: "application/json",
+				Body:        fmt.Sprintf(`{"items":%q}`, strings.Repeat("r", 8<<10+i%25)),
+			},
+		})
+	}
+	data, err := src.Export(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInlineBodiesMoveToBlobs(t *testing.T) {
+	const tools = 300
+	path := inlineCatalog(t, tools)
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrated) > len(before)/10 {
+		t.Errorf("config.json is %d bytes after the move, was %d", len(migrated), len(before))
+	}
+
+	// 25 distinct large bodies and 25 distinct examples, each stored once
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(path), BlobDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 50 {
+		t.Errorf("got %d blobs, want 50", len(entries))
+	}
+
+	// Bodies read the same as before, and small ones stay inline
+	reloaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range cfg.ListTools() {
+		again := reloaded.GetTool(tool.Name)
+		if again == nil || again.Body != tool.Body || again.Response.Body != tool.Response.Body {
+			t.Fatalf("%s reads differently after reloading", tool.Name)
+		}
+		if quoted, _ := json.Marshal(tool.Body); len(tool.Body) < DefaultBlobThreshold && !strings.Contains(string(migrated), string(quoted)) {
+			t.Errorf("small body of %s isn't inline", tool.Name)
+		}
+	}
+	if len(cfg.ListTools()) != tools {
+		t.Errorf("got %d tools, want %d", len(cfg.ListTools()), tools)
+	}
+
+	// A second load has nothing left to move
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(migrated) {
+		t.Error("reloading the migrated config rewrote it")
+	}
+	if n, err := reloaded.CollectBlobs(); err != nil || n != 0 {
+		t.Errorf("CollectBlobs deleted %d blobs (%v), want none", n, err)
+	}
+}
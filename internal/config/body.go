@@ -0,0 +1,179 @@
+package config
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+)
+
+// BodySchema is the structure of a tool's JSON request bodies: field
+// names and value types, never values. Captured samples are merged, so a
+// field seen in any of them is listed.
+type BodySchema struct {
+	// Types holds the JSON Schema types seen, sorted. Whole numbers are
+	// "integer" until a fraction is seen.
+	Types      []string               `json:"type"`
+	Properties map[string]*BodySchema `json:"properties,omitempty"`
+	Items      *BodySchema            `json:"items,omitempty"`
+}
+
+// InferBodySchema returns the schema of body, or nil when body isn't a JSON
+// object.
+func InferBodySchema(body string) *BodySchema {
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return nil
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return nil
+	}
+	return schemaOf(v)
+}
+
+func schemaOf(v any) *BodySchema {
+	switch v := v.(type) {
+	case map[string]any:
+		s := &BodySchema{Types: []string{"object"}, Properties: make(map[string]*BodySchema, len(v))}
+		for name, child := range v {
+			s.Properties[name] = schemaOf(child)
+		}
+		return s
+	case []any:
+		s := &BodySchema{Types: []string{"array"}}
+		for _, child := range v {
+			s.Items, _ = MergeBodySchemas(s.Items, schemaOf(child))
+		}
+		return s
+	case string:
+		return &BodySchema{Types: []string{"string"}}
+	case float64:
+		if v == float64(int64(v)) {
+			return &BodySchema{Types: []string{"integer"}}
+		}
+		return &BodySchema{Types: []string{"number"}}
+	case bool:
+		return &BodySchema{Types: []string{"boolean"}}
+	default:
+		return &BodySchema{Types: []string{"null"}}
+	}
+}
+
+// MergeBodySchemas returns a schema allowing what either a or b does, and
+// whether it allows more than a. Neither argument is modified.
+func MergeBodySchemas(a, b *BodySchema) (*BodySchema, bool) {
+	switch {
+	case b == nil:
+		return a, false
+	case a == nil:
+		return b.clone(), true
+	}
+
+	merged := a.clone()
+	for _, t := range b.Types {
+		if !slices.Contains(merged.Types, t) {
+			merged.Types = append(merged.Types, t)
+		}
+	}
+	// A fraction widens integer to number
+	if i := slices.Index(merged.Types, "integer"); i >= 0 && slices.Contains(merged.Types, "number") {
+		merged.Types = slices.Delete(merged.Types, i, i+1)
+	}
+	slices.Sort(merged.Types)
+	grew := !slices.Equal(merged.Types, a.Types)
+
+	for name, child := range b.Properties {
+		if merged.Properties == nil {
+			merged.Properties = make(map[string]*BodySchema)
+		}
+		var childGrew bool
+		merged.Properties[name], childGrew = MergeBodySchemas(merged.Properties[name], child)
+		grew = grew || childGrew
+	}
+	var itemsGrew bool
+	merged.Items, itemsGrew = MergeBodySchemas(merged.Items, b.Items)
+	return merged, grew || itemsGrew
+}
+
+func (s *BodySchema) clone() *BodySchema {
+	if s == nil {
+		return nil
+	}
+	c := &BodySchema{Types: slices.Clone(s.Types), Items: s.Items.clone()}
+	if s.Properties != nil {
+		c.Properties = maps.Clone(s.Properties)
+		for name, child := range c.Properties {
+			c.Properties[name] = child.clone()
+		}
+	}
+	return c
+}
+
+// InputBodySchema is the schema calls to the tool build bodies from: the
+// one merged from captured samples, widened by the stored body, which may
+// have been edited or imported since.
+func (t *Tool) InputBodySchema() *BodySchema {
+	schema, _ := MergeBodySchemas(t.BodySchema, InferBodySchema(t.Body))
+	return schema
+}
+
+// MergeBodySchema widens the body schema of the tool matching method and
+// url with schema.
+func (c *Config) MergeBodySchema(method, url string, schema *BodySchema) (*Tool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tool := c.toolFor(method, url); tool != nil {
+		merged, grew := MergeBodySchemas(tool.BodySchema, schema)
+		if !grew {
+			return tool, false
+		}
+		tool.BodySchema = merged
+		return tool, true
+	}
+	return nil, false
+}
+
+// RedactBodies passes the stored request bodies, response samples and body
+// revisions in tool history through redact, so values captured before a
+// field was known to be sensitive don't stay on disk. It returns how many
+// tools changed.
+func (c *Config) RedactBodies(redact func(body string) string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	redacted := 0
+	for _, tool := range c.Tools {
+		changed := false
+		if body := redact(tool.Body); body != tool.Body {
+			tool.Body = body
+			changed = true
+		}
+		if tool.Response != nil {
+			if body := redact(tool.Response.Body); body != tool.Response.Body {
+				tool.Response.Body = body
+				changed = true
+			}
+		}
+		for i := range tool.History {
+			for j, change := range tool.History[i].Changes {
+				if change.Field != "body" {
+					continue
+				}
+				for _, value := range []*json.RawMessage{&tool.History[i].Changes[j].Old, &tool.History[i].Changes[j].New} {
+					var body string
+					if json.Unmarshal(*value, &body) != nil {
+						continue
+					}
+					if r := redact(body); r != body {
+						*value, _ = json.Marshal(r)
+						changed = true
+					}
+				}
+			}
+		}
+		if changed {
+			redacted++
+		}
+	}
+	return redacted
+}
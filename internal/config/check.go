@@ -0,0 +1,221 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severities of the problems Check finds.
+const (
+	// SeverityWarning is a leftover that changes nothing, like an empty
+	// group.
+	SeverityWarning = "warning"
+	// SeverityError is a reference that doesn't resolve, so some tool or
+	// group doesn't work as stored.
+	SeverityError = "error"
+	// SeverityFatal is a config mcpify can't run with: one that can't be
+	// parsed and can't be moved aside either, as LoadConfig reports.
+	SeverityFatal = "fatal"
+)
+
+// Problem is one inconsistency in the config.
+type Problem struct {
+	Severity string `json:"severity"`
+	// Kind identifies the check, e.g. "dangling_group_member".
+	Kind    string `json:"kind"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	// Fixable problems are repaired by Repair without losing anything
+	// that still works.
+	Fixable bool `json:"fixable"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.Severity, p.Subject, p.Message)
+}
+
+// CheckReport is the outcome of a consistency check, as /debug and
+// `mcpify status` show it.
+type CheckReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Problems  []Problem `json:"problems"`
+	// Fixed are the problems repaired when the check ran.
+	Fixed []Problem `json:"fixed,omitempty"`
+}
+
+// Check verifies that every reference in the config resolves: the name
+// index, group members, blob references and aliases. It changes nothing.
+func (c *Config) Check() []Problem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.check(false)
+}
+
+// Repair fixes the problems Check reports as fixable and returns them. It
+// drops references that don't resolve, renames tools sharing a name and
+// rebuilds the name index. Problems it can't fix safely are left for a
+// person.
+func (c *Config) Repair() []Problem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var fixed []Problem
+	for _, problem := range c.check(true) {
+		if problem.Fixable {
+			fixed = append(fixed, problem)
+		}
+	}
+	return fixed
+}
+
+// check runs every check, repairing what it can when fix is set. c.mu must
+// be held, for writing when fix is set.
+func (c *Config) check(fix bool) []Problem {
+	var problems []Problem
+	report := func(severity, kind, subject string, fixable bool, format string, args ...any) {
+		problems = append(problems, Problem{Severity: severity, Kind: kind, Subject: subject, Message: fmt.Sprintf(format, args...), Fixable: fixable})
+	}
+
+	if c.corrupt != "" {
+		report(SeverityError, "corrupt_config", c.Path, false, "the config couldn't be parsed and was moved to %s; this one started empty", c.corrupt)
+	}
+
+	// Tools, oldest first, so a duplicate name stays with the first tool
+	ids := slices.SortedFunc(maps.Keys(c.Tools), func(a, b string) int {
+		return c.Tools[a].CreatedAt.Compare(c.Tools[b].CreatedAt)
+	})
+	owners := make(map[string]string, len(ids))
+	duplicates := false
+	for _, id := range ids {
+		tool := c.Tools[id]
+		if tool.ID != id {
+			report(SeverityError, "tool_id_mismatch", tool.Name, true, "stored under %q but has ID %q", id, tool.ID)
+			if fix {
+				tool.ID = id
+			}
+		}
+		if tool.Name == "" {
+			report(SeverityError, "tool_without_name", id, true, "tool has no name; it can't be called")
+			if fix {
+				tool.Name = id
+			}
+		}
+		if owner, taken := owners[tool.Name]; taken {
+			duplicates = true
+			name := c.freeName(tool.Name, owners)
+			report(SeverityError, "duplicate_tool_name", tool.Name, true, "%s and %s share the name; only one can be called (fix renames the newer one %s)", owner, id, name)
+			if fix {
+				before := definitionOf(tool)
+				tool.Name = name
+				c.recordRevision(tool, HistoryRepair, before)
+			}
+		}
+		owners[tool.Name] = id
+
+		if u, err := url.Parse(tool.URL); tool.Method == "" || err != nil || u.Host == "" {
+			report(SeverityError, "invalid_tool_request", tool.Name, false, "method %q and URL %q can't be called; edit or remove the tool", tool.Method, tool.URL)
+		}
+		if tool.BodyUnavailable() {
+			report(SeverityWarning, "missing_blob", tool.Name, true, "body blob %s is missing; the tool is called without a body", tool.BodyRef)
+			if fix {
+				tool.BodyRef = ""
+			}
+		}
+		if tool.Response != nil && tool.Response.BodyUnavailable() {
+			report(SeverityWarning, "missing_blob", tool.Name, true, "response example blob %s is missing", tool.Response.BodyRef)
+			if fix {
+				tool.Response.BodyRef = ""
+			}
+		}
+	}
+
+	// The name index is derived, so it is always rebuilt. Duplicate names
+	// already explain an index that is off.
+	if !duplicates && !maps.Equal(owners, c.names) {
+		report(SeverityWarning, "stale_name_index", "catalog", true, "the tool name index is out of date")
+	}
+	if fix {
+		c.names = owners
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Groups)) {
+		group := c.Groups[name]
+		if group.Name != name {
+			report(SeverityError, "group_name_mismatch", name, true, "stored as %q but named %q", name, group.Name)
+			if fix {
+				group.Name = name
+			}
+		}
+		seen := make(map[string]bool, len(group.ToolIDs))
+		members := group.ToolIDs[:0:0]
+		for _, id := range group.ToolIDs {
+			switch {
+			case c.Tools[id] == nil:
+				report(SeverityError, "dangling_group_member", name, true, "member %s is not in the catalog", id)
+			case seen[id]:
+				report(SeverityWarning, "duplicate_group_member", name, true, "%s is listed twice", c.Tools[id].Name)
+			default:
+				members = append(members, id)
+			}
+			seen[id] = true
+		}
+		if len(group.ToolNames) > 0 {
+			report(SeverityWarning, "legacy_group_members", name, true, "members are still listed by name")
+			for _, toolName := range group.ToolNames {
+				if id, ok := owners[toolName]; ok && !seen[id] {
+					members = append(members, id)
+					seen[id] = true
+				}
+			}
+		}
+		if len(members) == 0 {
+			report(SeverityWarning, "empty_group", name, true, "group has no tools; fix removes it")
+		}
+		if fix {
+			group.ToolIDs, group.ToolNames = members, nil
+			if len(members) == 0 {
+				delete(c.Groups, name)
+			}
+		}
+	}
+
+	aliases := c.Aliases[:0:0]
+	for _, alias := range c.Aliases {
+		if !validAlias(alias) {
+			report(SeverityWarning, "invalid_alias", alias, true, "alias is not a host or host:port; requests can't match it")
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	if fix && len(aliases) != len(c.Aliases) {
+		c.Aliases = aliases
+	}
+
+	return problems
+}
+
+// freeName returns the first of name_2, name_3, ... no tool uses.
+func (c *Config) freeName(name string, owners map[string]string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s_%d", name, i)
+		if _, taken := owners[candidate]; !taken && c.names[candidate] == "" {
+			return candidate
+		}
+	}
+}
+
+func validAlias(alias string) bool {
+	if alias == "" || strings.ContainsAny(alias, "/ ?#@") {
+		return false
+	}
+	host := alias
+	if h, _, err := net.SplitHostPort(alias); err == nil {
+		host = h
+	}
+	return host != ""
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRepairFixesEachProblem(t *testing.T) {
+	tests := []struct {
+		kind string
+		// also are the other problems it causes
+		also []string
+		// breakConfig introduces the problem into a healthy catalog
+		breakConfig func(t *testing.T, c *Config)
+		// repaired checks what Repair did about it
+		repaired func(t *testing.T, c *Config)
+	}{
+		{
+			kind: "tool_id_mismatch",
+			breakConfig: func(t *testing.T, c *Config) {
+				c.GetTool("get_users").ID = "01JH0000000000000000000000"
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if tool := c.GetTool("get_users"); tool == nil || c.Tools[tool.ID] != tool {
+					t.Errorf("get_users = %+v, want it under its ID", tool)
+				}
+			},
+		},
+		{
+			kind: "tool_without_name",
+			also: []string{"stale_name_index"},
+			breakConfig: func(t *testing.T, c *Config) {
+				tool := c.GetTool("get_orders")
+				delete(c.names, tool.Name)
+				tool.Name = ""
+			},
+			repaired: func(t *testing.T, c *Config) {
+				for id, tool := range c.Tools {
+					if tool.Name == "" {
+						t.Errorf("tool %s still has no name", id)
+					}
+				}
+			},
+		},
+		{
+			kind: "duplicate_tool_name",
+			breakConfig: func(t *testing.T, c *Config) {
+				// create_user is newer than get_users in the fixture
+				c.GetTool("create_user").Name = "get_users"
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if tool := c.GetTool("get_users"); tool == nil || tool.Method != "GET" {
+					t.Errorf("get_users = %+v, want the older tool to keep the name", tool)
+				}
+				if tool := c.GetTool("get_users_2"); tool == nil || tool.Method != "POST" {
+					t.Errorf("get_users_2 = %+v, want the newer tool renamed", tool)
+				}
+			},
+		},
+		{
+			kind: "missing_blob",
+			breakConfig: func(t *testing.T, c *Config) {
+				tool := c.GetTool("create_user")
+				tool.Body, tool.BodyRef = "", BlobRef([]byte("gone"))
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if ref := c.GetTool("create_user").BodyRef; ref != "" {
+					t.Errorf("body ref %q kept", ref)
+				}
+			},
+		},
+		{
+			kind: "stale_name_index",
+			breakConfig: func(t *testing.T, c *Config) {
+				c.names["old_name"] = c.names["get_users"]
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if c.GetTool("old_name") != nil {
+					t.Error("index still has old_name")
+				}
+			},
+		},
+		{
+			kind: "group_name_mismatch",
+			breakConfig: func(t *testing.T, c *Config) {
+				c.Groups["users"].Name = "people"
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if name := c.Groups["users"].Name; name != "users" {
+					t.Errorf("group named %q, want users", name)
+				}
+			},
+		},
+		{
+			kind: "dangling_group_member",
+			breakConfig: func(t *testing.T, c *Config) {
+				users := c.Groups["users"]
+				users.ToolIDs = append(users.ToolIDs, "01JH0000000000000000000000")
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if n := len(c.Groups["users"].ToolIDs); n != 2 {
+					t.Errorf("users has %d members, want 2", n)
+				}
+			},
+		},
+		{
+			kind: "duplicate_group_member",
+			breakConfig: func(t *testing.T, c *Config) {
+				users := c.Groups["users"]
+				users.ToolIDs = append(users.ToolIDs, users.ToolIDs[0])
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if n := len(c.Groups["users"].ToolIDs); n != 2 {
+					t.Errorf("users has %d members, want 2", n)
+				}
+			},
+		},
+		{
+			kind: "legacy_group_members",
+			breakConfig: func(t *testing.T, c *Config) {
+				orders := c.Groups["orders"]
+				orders.ToolNames = []string{"get_orders", "get_users"}
+			},
+			repaired: func(t *testing.T, c *Config) {
+				orders := c.Groups["orders"]
+				want := []string{c.GetTool("get_orders").ID, c.GetTool("get_users").ID}
+				if orders.ToolNames != nil || !sameIDs(orders.ToolIDs, want) {
+					t.Errorf("orders = %+v, want members %v by ID", orders, want)
+				}
+			},
+		},
+		{
+			kind: "empty_group",
+			breakConfig: func(t *testing.T, c *Config) {
+				c.AddGroup(&Group{Name: "empty"})
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if c.GetGroup("empty") != nil {
+					t.Error("empty group kept")
+				}
+			},
+		},
+		{
+			kind: "invalid_alias",
+			breakConfig: func(t *testing.T, c *Config) {
+				c.Aliases = []string{"api.local", "http://bad/alias", "host.docker.internal:4000"}
+			},
+			repaired: func(t *testing.T, c *Config) {
+				if want := []string{"api.local", "host.docker.internal:4000"}; !slices.Equal(c.Aliases, want) {
+					t.Errorf("aliases %v, want %v", c.Aliases, want)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			cfg, _ := loadFixture(t, "v1-tools-by-name.json")
+			if problems := cfg.Check(); len(problems) > 0 {
+				t.Fatalf("fixture has problems: %v", problems)
+			}
+			tt.breakConfig(t, cfg)
+
+			problems := cfg.Check()
+			if !slices.ContainsFunc(problems, func(p Problem) bool { return p.Kind == tt.kind }) ||
+				slices.ContainsFunc(problems, func(p Problem) bool { return p.Kind != tt.kind && !slices.Contains(tt.also, p.Kind) }) {
+				t.Fatalf("Check() = %v, want only %s", problems, tt.kind)
+			}
+			fixed := cfg.Repair()
+			if len(fixed) != len(problems) {
+				t.Errorf("Repair() fixed %v, want %v", fixed, problems)
+			}
+			if left := cfg.Check(); len(left) > 0 {
+				t.Errorf("after Repair, Check() = %v", left)
+			}
+			tt.repaired(t, cfg)
+		})
+	}
+}
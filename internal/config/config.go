@@ -2,67 +2,277 @@ package config
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Config struct {
-	mu          sync.RWMutex
-	Path        string            `json:"-"`
-	MCPPort     string            `json:"mcp_port"`
-	MaxTools    int               `json:"max_tools"`
-	UseLLM      bool              `json:"use_llm"`
-	UseGrouping bool              `json:"use_grouping"`
-	LastTarget  string            `json:"last_target"`
-	Tools       map[string]*Tool  `json:"tools"`
-	Groups      map[string]*Group `json:"groups,omitempty"`
+	mu sync.RWMutex
+	// saveMu orders saves, so an older snapshot never replaces a newer
+	saveMu sync.Mutex
+	// SchemaVersion is the version of the format the config was written
+	// in; see the SchemaVersion constant.
+	SchemaVersion int    `json:"version"`
+	Path          string `json:"-"`
+	MCPPort       string `json:"mcp_port"`
+	MaxTools      int    `json:"max_tools"`
+	UseLLM        bool   `json:"use_llm"`
+	UseGrouping   bool   `json:"use_grouping"`
+	LastTarget    string `json:"last_target"`
+	CaptureMode   string `json:"capture_mode,omitempty"`
+	// Interface is the network interface packets are captured on; empty
+	// means loopback.
+	Interface string `json:"interface,omitempty"`
+	ToolView  string `json:"tool_view,omitempty"`
+	// IgnorePaths and IncludePaths are the path patterns picking the
+	// requests capture turns into tools; see capture.SetPathFilters.
+	IgnorePaths  []string `json:"ignore_paths,omitempty"`
+	IncludePaths []string `json:"include_paths,omitempty"`
+	// NoDefaultIgnores captures static assets too.
+	NoDefaultIgnores bool `json:"no_default_ignores,omitempty"`
+	// ResponseHeaders is the global response header allowlist; nil means
+	// DefaultResponseHeaders.
+	ResponseHeaders []string `json:"response_headers,omitempty"`
+	// VolatilePaths are response paths left out of response fingerprints.
+	VolatilePaths []string `json:"volatile_paths,omitempty"`
+	// UserAgent replaces mcpify's own User-Agent in tool calls.
+	UserAgent string `json:"user_agent,omitempty"`
+	// DiscoveryWebhook is the URL told about each endpoint capture
+	// registers a tool for.
+	DiscoveryWebhook string `json:"discovery_webhook,omitempty"`
+	// Aliases are other host names requests reach the target under, e.g.
+	// "api.local" or "host.docker.internal:4000". Loopback names are
+	// always equivalent for a loopback target.
+	Aliases []string `json:"aliases,omitempty"`
+	// AuthQuery are query parameters every tool call gets from mcpify
+	// rather than from the agent, e.g. {"api_key": "${secret:API_KEY}"}.
+	AuthQuery map[string]string `json:"auth_query,omitempty"`
+	// SensitiveHeaders are request headers whose values are never stored,
+	// in addition to DefaultSensitiveHeaders, e.g. "X-Internal-Token".
+	SensitiveHeaders []string `json:"sensitive_headers,omitempty"`
+	// SensitiveFields are the JSON body fields whose values are stored as
+	// "<redacted>", matched by substring. Unset means the defaults:
+	// password, token, secret, api_key and credit_card.
+	SensitiveFields []string `json:"sensitive_fields,omitempty"`
+	// Replicas are other instances of the target. Capture covers them,
+	// and tool calls are spread across them and the target.
+	Replicas []Replica `json:"replicas,omitempty"`
+	// ReplicaStrategy is "round-robin" (the default) or "weighted".
+	ReplicaStrategy string `json:"replica_strategy,omitempty"`
+	// StickyReplicas keeps each MCP session on one replica while it is up.
+	StickyReplicas bool `json:"sticky_replicas,omitempty"`
+	// BlobThreshold is the size in bytes above which bodies and response
+	// examples are kept in blobs next to the config; 0 means
+	// DefaultBlobThreshold and a negative value keeps them inline.
+	BlobThreshold int `json:"blob_threshold,omitempty"`
+	// HistoryLimit is how many revisions are kept per tool; 0 means
+	// DefaultHistoryLimit.
+	HistoryLimit int `json:"history_limit,omitempty"`
+	// MaxResponseSize is how much of a response body tool results
+	// include, a size such as "100KB"; "0" includes everything. Set with
+	// --max-response-size.
+	MaxResponseSize string `json:"max_response_size,omitempty"`
+	// Profiles are named sets of flag values selected with --profile.
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+	// NameCache holds the names the LLM gave endpoints, by method and
+	// templated path, so rediscovered ones aren't named again.
+	NameCache map[string]CachedName `json:"name_cache,omitempty"`
+	// Tools and Groups are the active target's; see SelectTarget.
+	Tools  map[string]*Tool  `json:"tools"`
+	Groups map[string]*Group `json:"groups,omitempty"`
+	// Targets holds the tools and groups of the other targets, by
+	// TargetKey, in their stored form. On disk the active target's are
+	// here too.
+	Targets map[string]json.RawMessage `json:"targets,omitempty"`
+
+	// names indexes Tools (keyed by ID) by tool name
+	names map[string]string
+	// blobs keeps large bodies and examples; nil keeps them inline
+	blobs BlobStore
+	// serialGroups remembers which groups were serialized when the groups
+	// were last cleared, so a regroup keeps the setting for groups that
+	// come back under the same name
+	serialGroups map[string]bool
+	// target is the TargetKey of the target Tools and Groups belong to
+	target string
+	// corrupt is where the unparseable config this one replaced was moved
+	corrupt string
+	// usageChanged is set when usage counts change, until SaveUsage saves
+	// them
+	usageChanged bool
+	// store is where Save writes the config when given Path; nil means
+	// the JSON file
+	store store
+	// saveErrors counts failed saves
+	saveErrors atomic.Int64
 }
 
 type Tool struct {
-	Name        string            `json:"name"`
-	Method      string            `json:"method"`
-	URL         string            `json:"url"`
-	Headers     map[string]string `json:"headers"`
-	Body        string            `json:"body"`
-	Description string            `json:"description"`
-	CreatedAt   time.Time         `json:"created_at"`
-	LastUsed    time.Time         `json:"last_used,omitempty"`
-	UseCount    int               `json:"use_count"`
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// BodyRef is set while Body is kept in a blob that couldn't be read.
+	BodyRef     string          `json:"body_ref,omitempty"`
+	Description string          `json:"description"`
+	CreatedAt   time.Time       `json:"created_at"`
+	LastUsed    time.Time       `json:"last_used,omitempty"`
+	UseCount    int             `json:"use_count"`
+	Assertions  *Assertions     `json:"assertions,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Response    *ResponseSample `json:"response,omitempty"`
+	// ErrorCount counts the calls that failed: no response, a rate limit,
+	// a failed assertion or an error status. LastError is the most recent
+	// failure's reason, at LastErrorAt.
+	ErrorCount  int       `json:"error_count,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+	// PathParams holds the captured value of each {param} in URL, used when
+	// a call doesn't supply one.
+	PathParams map[string]string `json:"path_params,omitempty"`
+	// QueryParams holds every query parameter seen for the tool with its
+	// captured value, sent when a call doesn't override it. An empty value
+	// marks a parameter whose value wasn't safe to keep.
+	QueryParams map[string]string `json:"query_params,omitempty"`
+	// ParamDescriptions documents path and query parameters, e.g. from an
+	// OpenAPI spec.
+	ParamDescriptions map[string]string `json:"param_descriptions,omitempty"`
+	// StrippedHeaders names the sensitive headers captured requests sent,
+	// whose values were never stored, e.g. Authorization.
+	StrippedHeaders []string `json:"stripped_headers,omitempty"`
+	// BodySchema is merged from the JSON bodies captured for the tool.
+	BodySchema *BodySchema `json:"body_schema,omitempty"`
+	// Spec is the API description the tool was imported from. Its {param}
+	// path segments match any captured value.
+	Spec string `json:"spec,omitempty"`
+	// ResponseHeaders overrides the global response header allowlist.
+	ResponseHeaders []string `json:"response_headers,omitempty"`
+	// ResponseShape is the fingerprint of the last successful JSON
+	// response, and ShapeChange the last time it lost paths.
+	ResponseShape []string     `json:"response_shape,omitempty"`
+	ShapeChange   *ShapeChange `json:"shape_change,omitempty"`
+	// UserAgent overrides the User-Agent calls to this tool are sent with.
+	UserAgent string `json:"user_agent,omitempty"`
+	// Serialize runs calls to the tool one at a time.
+	Serialize bool `json:"serialize,omitempty"`
+	// Timeout bounds each call to the tool, e.g. "2m", in place of
+	// --request-timeout.
+	Timeout string `json:"timeout,omitempty"`
+	// Retries replaces --retries for calls to the tool that fail with a
+	// connection error or a 502, 503 or 504.
+	Retries *int `json:"retries,omitempty"`
+	// RetryUnsafe lets calls that aren't idempotent, as POST and PATCH,
+	// be retried too.
+	RetryUnsafe bool `json:"retry_unsafe,omitempty"`
+	// AllowExecute, when set, decides whether calls to the tool are sent,
+	// overriding --read-only: true lets a POST through, false disables
+	// the tool whatever its method.
+	AllowExecute *bool `json:"allow_execute,omitempty"`
+	// Annotations overrides the hints about the tool's effects that are
+	// otherwise derived from its method, e.g. for a POST search.
+	Annotations *Annotations `json:"annotations,omitempty"`
+	// Provenance describes where the stored headers and body were
+	// captured.
+	Provenance *Provenance `json:"provenance,omitempty"`
+	// History holds the latest revisions of the tool's definition.
+	History []Revision `json:"history,omitempty"`
+}
+
+// Annotations tells MCP clients what calling a tool does. Fields left out
+// follow the tool's method.
+type Annotations struct {
+	// ReadOnly is whether calls leave the target as it was.
+	ReadOnly *bool `json:"read_only,omitempty"`
+	// Destructive is whether calls may delete or overwrite data, rather
+	// than only add to it.
+	Destructive *bool `json:"destructive,omitempty"`
+	// Idempotent is whether repeating a call has no further effect.
+	Idempotent *bool `json:"idempotent,omitempty"`
+}
+
+// ResponseSample describes what an endpoint returned when it was captured.
+type ResponseSample struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+	// BodyRef is set while Body is kept in a blob that couldn't be read.
+	BodyRef string            `json:"body_ref,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	SeenAt  time.Time         `json:"seen_at"`
+	// Shape is the fingerprint of a successful JSON body. It is folded
+	// into the tool's ResponseShape rather than stored with the sample.
+	Shape []string `json:"-"`
+}
+
+// Assertions are checks on a tool's response. They can be stored per tool
+// or passed with a single call.
+type Assertions struct {
+	ExpectStatus   int                    `json:"expect_status,omitempty"`
+	ExpectJSON     map[string]interface{} `json:"expect_json,omitempty"`
+	ExpectContains string                 `json:"expect_contains,omitempty"`
+}
+
+func (a *Assertions) IsEmpty() bool {
+	return a == nil || (a.ExpectStatus == 0 && len(a.ExpectJSON) == 0 && a.ExpectContains == "")
+}
+
+// Replica is another instance of the target. Weight is its share of
+// tool calls with the weighted strategy; 0 means 1.
+type Replica struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight,omitempty"`
 }
 
 type Group struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	ToolNames   []string  `json:"tool_names"`
+	ToolIDs     []string  `json:"tool_ids"`
+	ToolNames   []string  `json:"tool_names,omitempty"` // pre-ID configs only
 	CreatedAt   time.Time `json:"created_at"`
 	LastUsed    time.Time `json:"last_used,omitempty"`
 	UseCount    int       `json:"use_count"`
+	// Serialize runs calls to any of the group's tools one at a time.
+	Serialize bool `json:"serialize,omitempty"`
+	// GroupedBy is the prompt that made the group, or "path-prefix" for
+	// groups made without the LLM.
+	GroupedBy string `json:"grouped_by,omitempty"`
 }
 
 func DefaultConfig(configPath string) *Config {
 	return &Config{
-		Path:        configPath,
-		MCPPort:     "8081",
-		MaxTools:    100,
-		UseLLM:      true,
-		UseGrouping: false,
-		Tools:       make(map[string]*Tool),
-		Groups:      make(map[string]*Group),
+		SchemaVersion: SchemaVersion,
+		Path:          configPath,
+		MCPPort:       "8081",
+		MaxTools:      100,
+		UseLLM:        false,
+		UseGrouping:   false,
+		Tools:         make(map[string]*Tool),
+		Groups:        make(map[string]*Group),
+		names:         make(map[string]string),
+		blobs:         NewDirBlobs(filepath.Join(filepath.Dir(configPath), BlobDir)),
 	}
 }
 
-func GetConfigPath() string {
+func GetConfigPath() (string, error) {
 	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
-		log.Fatalf("Unsupported operating system: %s. Only macOS and Linux are supported.", runtime.GOOS)
+		return "", fmt.Errorf("%w: %s (only macOS and Linux are supported)", ErrUnsupportedOS, runtime.GOOS)
 	}
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Fatalf("Could not determine home directory: %v", err)
+		return "", fmt.Errorf("%w: %w", ErrNoHomeDir, err)
 	}
 
 	var configDir string
@@ -76,30 +286,55 @@ func GetConfigPath() string {
 		}
 	}
 
-	return filepath.Join(configDir, "config.json")
+	return filepath.Join(configDir, "config.json"), nil
 }
 
 func LoadConfig(configPath string) (*Config, error) {
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("create config directory: %w", err)
 	}
+	return load(fileStore{configPath}, configPath)
+}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+// load reads the config kept in st, which configPath names.
+func load(st store, configPath string) (*Config, error) {
+	data, err := st.read()
+	if errors.Is(err, fs.ErrNotExist) {
 		cfg := DefaultConfig(configPath)
+		cfg.store = st
 		if err := cfg.Save(configPath); err != nil {
 			return nil, err
 		}
 		return cfg, nil
 	}
-
-	data, err := os.ReadFile(configPath)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("read config: %w", err)
 	}
 
 	cfg := DefaultConfig(configPath)
-	if err := json.Unmarshal(data, cfg); err != nil {
-		return nil, err
+	cfg.store = st
+	version, err := versionOf(data)
+	if err == nil && version > SchemaVersion {
+		return nil, fmt.Errorf("%w: %s is version %d, and this mcpify (%s) reads up to version %d; upgrade mcpify with `mcpify self-update`", ErrConfigTooNew, configPath, version, Version, SchemaVersion)
+	}
+	if err == nil {
+		err = json.Unmarshal(data, cfg)
+	}
+	if err != nil {
+		// Set the file aside and start over rather than refuse to run;
+		// it is kept for the user to repair
+		aside, moveErr := st.setAside()
+		if moveErr != nil {
+			return nil, &ErrConfigCorrupt{Path: configPath, Cause: errors.Join(err, moveErr)}
+		}
+		slog.Error("Config is corrupt; moved it aside and starting with an empty config", "path", configPath, "error", err, "moved_to", aside)
+		cfg = DefaultConfig(configPath)
+		cfg.store = st
+		cfg.corrupt = aside
+		if err := cfg.Save(configPath); err != nil {
+			return nil, err
+		}
+		return cfg, nil
 	}
 
 	if cfg.Tools == nil {
@@ -109,41 +344,432 @@ func LoadConfig(configPath string) (*Config, error) {
 		cfg.Groups = make(map[string]*Group)
 	}
 
+	migrated := version < SchemaVersion
+	if migrated {
+		if err := cfg.migrate(configPath, data, version); err != nil {
+			return nil, err
+		}
+	}
+	cfg.target = TargetKey(cfg.LastTarget)
+	if err := cfg.activateTarget(cfg.target); err != nil {
+		return nil, &ErrConfigCorrupt{Path: configPath, Cause: err}
+	}
+	if inlined := cfg.resolveBlobs(); inlined > 0 {
+		slog.Info("Moving large bodies and response examples to blobs", "count", inlined, "dir", filepath.Join(filepath.Dir(configPath), BlobDir))
+		migrated = true
+	}
+	if migrated {
+		if err := cfg.Save(configPath); err != nil {
+			return nil, err
+		}
+	}
+
 	return cfg, nil
 }
 
+// migrateToolIDs re-keys tools by ID, assigning IDs to tools from configs
+// written before IDs existed, rebuilds the name index, converts group
+// membership from names to IDs and starts the history of tools that have
+// none. It reports whether anything changed.
+func (c *Config) migrateToolIDs() bool {
+	changed := false
+
+	tools := make(map[string]*Tool, len(c.Tools))
+	c.names = make(map[string]string, len(c.Tools))
+	for key, tool := range c.Tools {
+		if tool.Name == "" {
+			tool.Name = key
+		}
+		if tool.ID == "" {
+			tool.ID = NewID()
+		}
+		if key != tool.ID {
+			changed = true
+		}
+		if len(tool.History) == 0 {
+			c.appendRevision(tool, Revision{At: time.Now(), Source: HistoryMigration})
+			changed = true
+		}
+		tools[tool.ID] = tool
+		c.names[tool.Name] = tool.ID
+	}
+	c.Tools = tools
+
+	for _, group := range c.Groups {
+		if len(group.ToolNames) == 0 {
+			continue
+		}
+		for _, name := range group.ToolNames {
+			if id, ok := c.names[name]; ok {
+				group.ToolIDs = append(group.ToolIDs, id)
+			}
+		}
+		group.ToolNames = nil
+		changed = true
+	}
+
+	return changed
+}
+
+// Save writes the config to configPath atomically, into the store it was
+// loaded from when configPath is its path and as JSON otherwise. Saves
+// run one at a time, each writing the config as it was when the save
+// started.
 func (c *Config) Save(configPath string) error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	var st store = fileStore{configPath}
+	if c.store != nil && configPath == c.Path {
+		st = c.store
+	}
+	c.mu.RLock()
+	s, err := c.stored(false)
+	var write func() error
+	if err == nil {
+		write, err = st.prepare(s)
+	}
+	c.mu.RUnlock()
 	if err != nil {
+		c.saveErrors.Add(1)
 		return err
 	}
-	return os.WriteFile(configPath, data, 0644)
+	if err := write(); err != nil {
+		c.saveErrors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// SaveErrors returns how many saves have failed.
+func (c *Config) SaveErrors() int64 {
+	return c.saveErrors.Load()
+}
+
+// encode marshals the config as written to disk. c.mu must be held.
+func (c *Config) encode(inline bool) ([]byte, error) {
+	s, err := c.stored(inline)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return data, nil
 }
 
+// AddTool stores tool, assigning it an ID if it has none. A tool without an
+// ID that reuses an existing name replaces that tool and keeps its ID.
 func (c *Config) AddTool(tool *Tool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.Tools[tool.Name] = tool
+
+	if tool.ID == "" {
+		if id, ok := c.names[tool.Name]; ok {
+			tool.ID = id
+		} else {
+			tool.ID = NewID()
+		}
+	}
+	if old := c.Tools[tool.ID]; old != nil && c.names[old.Name] == tool.ID {
+		delete(c.names, old.Name)
+	}
+
+	c.Tools[tool.ID] = tool
+	c.names[tool.Name] = tool.ID
+	if len(tool.History) == 0 {
+		c.appendRevision(tool, Revision{At: time.Now(), Source: HistoryCreated})
+	}
 }
 
-func (c *Config) RemoveTool(name string) {
+// RemoveTool removes the tool called name from the catalog and from its
+// groups. Groups left without tools are removed too.
+func (c *Config) RemoveTool(name string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	delete(c.Tools, name)
+
+	id, ok := c.names[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrToolNotFound, name)
+	}
+	delete(c.Tools, id)
+	delete(c.names, name)
+	for groupName, group := range c.Groups {
+		group.ToolIDs = slices.DeleteFunc(group.ToolIDs, func(toolID string) bool { return toolID == id })
+		if len(group.ToolIDs) == 0 {
+			delete(c.Groups, groupName)
+		}
+	}
+	return nil
 }
 
+// GetTool returns the tool with the given name.
 func (c *Config) GetTool(name string) *Tool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.Tools[name]
+	return c.Tools[c.names[name]]
+}
+
+func (c *Config) GetToolByID(id string) *Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Tools[id]
+}
+
+// LookupTool accepts either a tool ID or a current tool name.
+func (c *Config) LookupTool(ref string) *Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lookupTool(ref)
+}
+
+// RenameTool changes a tool's name. References by ID, such as group
+// membership, are unaffected.
+func (c *Config) RenameTool(ref, newName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	if id, taken := c.names[newName]; taken && id != tool.ID {
+		return fmt.Errorf("%w: %q", ErrToolNameInUse, newName)
+	}
+
+	before := definitionOf(tool)
+	delete(c.names, tool.Name)
+	tool.Name = newName
+	c.names[newName] = tool.ID
+	c.recordRevision(tool, HistoryAdmin, before)
+	return nil
+}
+
+// ToolPatch holds the changes UpdateTool makes. Nil fields are kept, and
+// headers set to nil are removed.
+type ToolPatch struct {
+	Name        *string            `json:"name,omitempty"`
+	Description *string            `json:"description,omitempty"`
+	URL         *string            `json:"url,omitempty"`
+	Headers     map[string]*string `json:"headers,omitempty"`
+	Body        *string            `json:"body,omitempty"`
+	// Timeout is a duration such as "5s"; "" goes back to the global one.
+	Timeout *string `json:"timeout,omitempty"`
+	// Retries of -1 goes back to the global count.
+	Retries     *int  `json:"retries,omitempty"`
+	RetryUnsafe *bool `json:"retry_unsafe,omitempty"`
+	// AllowExecute is true or false, or null to follow --read-only.
+	AllowExecute NullableBool `json:"allow_execute,omitzero"`
+}
+
+// NullableBool is a patch value that tells null, which clears a setting,
+// from leaving the setting out.
+type NullableBool struct {
+	Set   bool
+	Value *bool
+}
+
+func (b *NullableBool) UnmarshalJSON(data []byte) error {
+	b.Set, b.Value = true, nil
+	if string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, &b.Value)
+}
+
+func (b NullableBool) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.Value)
+}
+
+// UpdateTool applies patch to the tool named ref, returning it and the
+// name it had before.
+func (c *Config) UpdateTool(ref string, patch ToolPatch) (*Tool, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return nil, "", fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	oldName := tool.Name
+	if patch.Name != nil {
+		if id, taken := c.names[*patch.Name]; taken && id != tool.ID {
+			return nil, "", fmt.Errorf("%w: %q", ErrToolNameInUse, *patch.Name)
+		}
+	}
+
+	if patch.Timeout != nil && *patch.Timeout != "" {
+		if d, err := time.ParseDuration(*patch.Timeout); err != nil || d <= 0 {
+			return nil, "", fmt.Errorf("%w %q: want a positive duration such as 30s", ErrInvalidTimeout, *patch.Timeout)
+		}
+	}
+	if patch.Retries != nil && *patch.Retries < -1 {
+		return nil, "", fmt.Errorf("%w %d: want a count, or -1 for --retries", ErrInvalidRetries, *patch.Retries)
+	}
+
+	before := definitionOf(tool)
+	if patch.Name != nil {
+		delete(c.names, tool.Name)
+		tool.Name = *patch.Name
+		c.names[tool.Name] = tool.ID
+	}
+	if patch.Description != nil {
+		tool.Description = *patch.Description
+	}
+	if patch.URL != nil {
+		tool.URL = *patch.URL
+	}
+	if patch.Body != nil {
+		tool.Body = *patch.Body
+	}
+	if patch.Timeout != nil {
+		tool.Timeout = *patch.Timeout
+	}
+	if patch.Retries != nil {
+		tool.Retries = patch.Retries
+		if *patch.Retries == -1 {
+			tool.Retries = nil
+		}
+	}
+	if patch.RetryUnsafe != nil {
+		tool.RetryUnsafe = *patch.RetryUnsafe
+	}
+	if patch.AllowExecute.Set {
+		tool.AllowExecute = patch.AllowExecute.Value
+	}
+	for name, value := range patch.Headers {
+		if value == nil {
+			delete(tool.Headers, name)
+			continue
+		}
+		if tool.Headers == nil {
+			tool.Headers = make(map[string]string)
+		}
+		tool.Headers[name] = *value
+	}
+	c.recordRevision(tool, HistoryAdmin, before)
+	return tool, oldName, nil
+}
+
+// ToolFor returns the tool calling method and url, or nil.
+func (c *Config) ToolFor(method, url string) *Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.toolFor(method, url)
+}
+
+// SetResponse stores sample on the tool matching method and url. To keep
+// saves and tool list updates rare, it only replaces a stored sample whose
+// status or content type differs, or that had no body or headers. The
+// sample's shape updates the tool's fingerprint either way.
+func (c *Config) SetResponse(method, url string, sample *ResponseSample) (*Tool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tool := c.toolFor(method, url); tool != nil {
+		reshaped := c.updateShape(tool, sample)
+		old := tool.Response
+		if old != nil && old.Status == sample.Status && old.ContentType == sample.ContentType &&
+			(old.Body != "" || sample.Body == "") && (len(old.Headers) > 0 || len(sample.Headers) == 0) {
+			return tool, reshaped
+		}
+		tool.Response = sample
+		return tool, true
+	}
+	return nil, false
+}
+
+// MergeQueryParams adds the query parameters in params that the tool
+// matching method and url hasn't seen yet. Known parameters keep their
+// captured value.
+func (c *Config) MergeQueryParams(method, url string, params map[string]string) (*Tool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tool := c.toolFor(method, url); tool != nil {
+		before := definitionOf(tool)
+		changed := false
+		for name, value := range params {
+			if _, ok := tool.QueryParams[name]; ok {
+				continue
+			}
+			if tool.QueryParams == nil {
+				tool.QueryParams = make(map[string]string)
+			}
+			tool.QueryParams[name] = value
+			changed = true
+		}
+		c.recordRevision(tool, HistoryCapture, before)
+		return tool, changed
+	}
+	return nil, false
+}
+
+func (c *Config) ListTools() []*Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tools := make([]*Tool, 0, len(c.Tools))
+	for _, tool := range c.Tools {
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// OldestTools returns the max earliest-created tools, or all of them when
+// max isn't positive. Tools past a limit lowered after capture are the
+// ones left out.
+func (c *Config) OldestTools(max int) []*Tool {
+	tools := c.ListTools()
+	slices.SortFunc(tools, func(a, b *Tool) int {
+		if n := a.CreatedAt.Compare(b.CreatedAt); n != 0 {
+			return n
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if max > 0 && len(tools) > max {
+		tools = tools[:max]
+	}
+	return tools
+}
+
+// FirstTool returns a copy of the tool, of those called one of names (all
+// of them when names is nil), that sorts first by cmp, or nil when there
+// is none. cmp runs with the catalog locked, so it may read usage counts.
+func (c *Config) FirstTool(names []string, cmp func(a, b *Tool) int) *Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var first *Tool
+	consider := func(tool *Tool) {
+		if tool != nil && (first == nil || cmp(tool, first) < 0) {
+			first = tool
+		}
+	}
+	if names == nil {
+		for _, tool := range c.Tools {
+			consider(tool)
+		}
+	} else {
+		for _, name := range names {
+			consider(c.Tools[c.names[name]])
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	copied := *first
+	return &copied
 }
 
 func (c *Config) AddGroup(group *Group) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.serialGroups[group.Name] {
+		group.Serialize = true
+	}
 	c.Groups[group.Name] = group
 }
 
@@ -159,9 +785,26 @@ func (c *Config) GetGroup(name string) *Group {
 	return c.Groups[name]
 }
 
+func (c *Config) ListGroups() []*Group {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	groups := make([]*Group, 0, len(c.Groups))
+	for _, group := range c.Groups {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
 func (c *Config) ClearGroups() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.serialGroups = make(map[string]bool)
+	for name, group := range c.Groups {
+		if group.Serialize {
+			c.serialGroups[name] = true
+		}
+	}
 	c.Groups = make(map[string]*Group)
 }
 
@@ -175,8 +818,8 @@ func (c *Config) GetToolsInGroup(groupName string) []*Tool {
 	}
 
 	var tools []*Tool
-	for _, toolName := range group.ToolNames {
-		if tool := c.Tools[toolName]; tool != nil {
+	for _, id := range group.ToolIDs {
+		if tool := c.Tools[id]; tool != nil {
 			tools = append(tools, tool)
 		}
 	}
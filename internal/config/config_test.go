@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// loadFixture loads a copy of testdata/name from a temporary directory,
+// returning the config and its path.
+func loadFixture(t *testing.T, name string) (*Config, string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, path
+}
+
+// toolIDs maps each tool's name to its ID.
+func toolIDs(c *Config) map[string]string {
+	ids := make(map[string]string)
+	for key, tool := range c.Tools {
+		ids[tool.Name] = key
+	}
+	return ids
+}
+
+func TestToolIDMigration(t *testing.T) {
+	cfg, path := loadFixture(t, "v1-tools-by-name.json")
+
+	ids := toolIDs(cfg)
+	for _, name := range []string{"get_users", "create_user", "get_orders"} {
+		id := ids[name]
+		if len(id) != 26 || cfg.Tools[id].ID != id {
+			t.Errorf("%s is keyed by %q, want its ULID", name, id)
+		}
+		if got := cfg.GetTool(name); got == nil || got.ID != id {
+			t.Errorf("GetTool(%q) = %+v", name, got)
+		}
+	}
+	if len(ids) != 3 {
+		t.Errorf("got tools %v, want 3", ids)
+	}
+
+	users := cfg.GetGroup("users")
+	if want := []string{ids["create_user"], ids["get_users"]}; users == nil || !sameIDs(users.ToolIDs, want) || users.ToolNames != nil {
+		t.Errorf("users group = %+v, want IDs %v", users, want)
+	}
+	// A member that no longer exists is dropped
+	if orders := cfg.GetGroup("orders"); orders == nil || !sameIDs(orders.ToolIDs, []string{ids["get_orders"]}) {
+		t.Errorf("orders group = %+v", orders)
+	}
+	if _, err := os.Stat(path + ".bak.v1"); err != nil {
+		t.Errorf("no backup of the old config: %v", err)
+	}
+
+	// Loading the migrated config keeps the IDs and saving it changes nothing
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := toolIDs(again); !maps.Equal(got, ids) {
+		t.Errorf("IDs changed on reload: %v, want %v", got, ids)
+	}
+	if err := again.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(saved, migrated) {
+		t.Errorf("saving the migrated config changed it:\n%s\nwant:\n%s", saved, migrated)
+	}
+}
+
+func sameIDs(got, want []string) bool {
+	got, want = slices.Clone(got), slices.Clone(want)
+	slices.Sort(got)
+	slices.Sort(want)
+	return slices.Equal(got, want)
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrToolNotFound    = errors.New("tool not found")
+	ErrGroupNotFound   = errors.New("group not found")
+	ErrToolNameInUse   = errors.New("tool name already in use")
+	ErrUnsupportedOS   = errors.New("unsupported operating system")
+	ErrNoHomeDir       = errors.New("could not determine home directory")
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrRevisionNotFound is returned for revisions that never existed or
+	// are past the history limit.
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrSecretNotSet is returned for ${secret:NAME} references to unset
+	// variables.
+	ErrSecretNotSet = errors.New("secret not set")
+	// ErrInvalidAuthQuery is returned for auth query parameters that are
+	// not name=value.
+	ErrInvalidAuthQuery = errors.New("invalid auth query parameter")
+	// ErrInvalidTimeout is returned for tool timeouts that aren't positive
+	// durations.
+	ErrInvalidTimeout = errors.New("invalid timeout")
+	ErrInvalidRetries = errors.New("invalid retry count")
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrBlobCorrupt    = errors.New("blob content doesn't match its digest")
+	ErrInvalidBlobRef = errors.New("invalid blob reference")
+	// ErrConfigTooNew is returned for configs written by a newer mcpify.
+	ErrConfigTooNew = errors.New("config was written by a newer mcpify")
+)
+
+// ErrConfigCorrupt reports a config file that exists but can't be parsed
+// and couldn't be moved aside either.
+type ErrConfigCorrupt struct {
+	Path  string
+	Cause error
+}
+
+func (e *ErrConfigCorrupt) Error() string {
+	return fmt.Sprintf("config file %s is corrupt: %v", e.Path, e.Cause)
+}
+
+func (e *ErrConfigCorrupt) Unwrap() error {
+	return e.Cause
+}
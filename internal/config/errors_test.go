@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOperationErrors(t *testing.T) {
+	negative, zero := -2, "0s"
+	taken := "get_users"
+
+	tests := []struct {
+		name string
+		op   func(t *testing.T, c *Config) error
+		want error
+	}{
+		{"remove a missing tool", func(t *testing.T, c *Config) error { return c.RemoveTool("nope") }, ErrToolNotFound},
+		{"rename a missing tool", func(t *testing.T, c *Config) error { return c.RenameTool("nope", "other") }, ErrToolNotFound},
+		{"rename onto a taken name", func(t *testing.T, c *Config) error { return c.RenameTool("create_user", taken) }, ErrToolNameInUse},
+		{"update a missing tool", func(t *testing.T, c *Config) error {
+			_, _, err := c.UpdateTool("nope", ToolPatch{})
+			return err
+		}, ErrToolNotFound},
+		{"update onto a taken name", func(t *testing.T, c *Config) error {
+			_, _, err := c.UpdateTool("create_user", ToolPatch{Name: &taken})
+			return err
+		}, ErrToolNameInUse},
+		{"update with a zero timeout", func(t *testing.T, c *Config) error {
+			_, _, err := c.UpdateTool("create_user", ToolPatch{Timeout: &zero})
+			return err
+		}, ErrInvalidTimeout},
+		{"update with negative retries", func(t *testing.T, c *Config) error {
+			_, _, err := c.UpdateTool("create_user", ToolPatch{Retries: &negative})
+			return err
+		}, ErrInvalidRetries},
+		{"history of a missing tool", func(t *testing.T, c *Config) error {
+			_, err := c.History("nope")
+			return err
+		}, ErrToolNotFound},
+		{"revert to a missing revision", func(t *testing.T, c *Config) error {
+			_, _, err := c.RevertTool("create_user", 99)
+			return err
+		}, ErrRevisionNotFound},
+		{"serialize a missing tool", func(t *testing.T, c *Config) error {
+			_, err := c.SetToolSerialize("nope", true)
+			return err
+		}, ErrToolNotFound},
+		{"serialize a missing group", func(t *testing.T, c *Config) error {
+			_, err := c.SetGroupSerialize("nope", true)
+			return err
+		}, ErrGroupNotFound},
+		{"missing profile", func(t *testing.T, c *Config) error {
+			_, err := c.Profile("nope")
+			return err
+		}, ErrProfileNotFound},
+		{"auth query without a value", func(t *testing.T, c *Config) error {
+			_, err := ParseAuthQuery("api_key")
+			return err
+		}, ErrInvalidAuthQuery},
+		{"unset secret", func(t *testing.T, c *Config) error {
+			_, err := ResolveSecrets(map[string]string{"api_key": "${secret:MCPIFY_TEST_UNSET}"})
+			return err
+		}, ErrSecretNotSet},
+		{"unknown store", func(t *testing.T, c *Config) error {
+			_, _, err := ParseStore("redis:localhost")
+			return err
+		}, ErrUnknownStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := loadFixture(t, "v1-tools-by-name.json")
+			err := tt.op(t, cfg)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlobErrors(t *testing.T) {
+	dir := t.TempDir()
+	blobs := NewDirBlobs(dir)
+	ref, err := blobs.Put([]byte("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ref[len("sha256:"):]), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref  string
+		want error
+	}{
+		{"md5:abc", ErrInvalidBlobRef},
+		{BlobRef([]byte("never stored")), ErrBlobNotFound},
+		{ref, ErrBlobCorrupt},
+	}
+	for _, tt := range tests {
+		if _, err := blobs.Get(tt.ref); !errors.Is(err, tt.want) {
+			t.Errorf("Get(%s) = %v, want %v", tt.ref, err, tt.want)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	target := "http://localhost:3000"
+	tests := []struct {
+		name string
+		data string
+		// check checks the error LoadConfig returned
+		check func(t *testing.T, err error, path string)
+	}{
+		{
+			name: "newer version",
+			data: fmt.Sprintf(`{"version":%d}`, SchemaVersion+1),
+			check: func(t *testing.T, err error, path string) {
+				if !errors.Is(err, ErrConfigTooNew) {
+					t.Errorf("err = %v, want ErrConfigTooNew", err)
+				}
+			},
+		},
+		{
+			name: "unreadable catalog",
+			data: fmt.Sprintf(`{"version":%d,"last_target":%q,"targets":{%q:42}}`, SchemaVersion, target, TargetKey(target)),
+			check: func(t *testing.T, err error, path string) {
+				var corrupt *ErrConfigCorrupt
+				if !errors.As(err, &corrupt) {
+					t.Fatalf("err = %v, want *ErrConfigCorrupt", err)
+				}
+				if corrupt.Path != path || corrupt.Cause == nil {
+					t.Errorf("corrupt config %+v, want path %s and a cause", corrupt, path)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadConfig(path)
+			tt.check(t, err, path)
+		})
+	}
+}
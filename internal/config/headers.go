@@ -0,0 +1,206 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// DefaultSensitiveHeaders are the request headers whose values are never
+// stored. sensitive_headers in the config adds to them.
+var DefaultSensitiveHeaders = []string{"Authorization", "Cookie", "X-Api-Key", "X-Auth-Token"}
+
+// DefaultResponseHeaders are the response headers kept when neither the
+// config nor the tool has its own list. A trailing * matches any suffix.
+var DefaultResponseHeaders = []string{"Location", "Content-Type", "X-Total-Count", "Link", "RateLimit-*"}
+
+// SensitiveHeaderNames returns DefaultSensitiveHeaders and the config's
+// sensitive_headers.
+func (c *Config) SensitiveHeaderNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sensitiveHeaderNames()
+}
+
+func (c *Config) sensitiveHeaderNames() []string {
+	return append(slices.Clone(DefaultSensitiveHeaders), c.SensitiveHeaders...)
+}
+
+// RedactHeaders removes the stored values of the headers in
+// SensitiveHeaderNames, including those in tool history, so values
+// captured before the header was known to be sensitive don't stay on
+// disk. The tools list them as stripped instead, or with keep set store
+// their HeaderSecretRef. It returns how many tools changed.
+func (c *Config) RedactHeaders(keep bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sensitiveNames := c.sensitiveHeaderNames()
+	sensitive := func(name string) bool {
+		return slices.ContainsFunc(sensitiveNames, func(s string) bool {
+			return strings.EqualFold(name, strings.TrimSpace(s))
+		})
+	}
+
+	redact := func(headers map[string]string) (names []string) {
+		for name, value := range headers {
+			if !sensitive(name) || value == HeaderSecretRef(name) {
+				continue
+			}
+			if keep {
+				headers[name] = HeaderSecretRef(name)
+			} else {
+				delete(headers, name)
+			}
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+		return names
+	}
+
+	redacted := 0
+	for _, tool := range c.Tools {
+		names := redact(tool.Headers)
+		changed := len(names) > 0
+		for i := range tool.History {
+			for j, change := range tool.History[i].Changes {
+				if change.Field != "headers" {
+					continue
+				}
+				for _, value := range []*json.RawMessage{&tool.History[i].Changes[j].Old, &tool.History[i].Changes[j].New} {
+					var headers map[string]string
+					if json.Unmarshal(*value, &headers) != nil {
+						continue
+					}
+					if found := redact(headers); len(found) > 0 {
+						*value, _ = json.Marshal(headers)
+						names = append(names, found...)
+						changed = true
+					}
+				}
+			}
+		}
+		if !keep {
+			for _, name := range names {
+				if !slices.Contains(tool.StrippedHeaders, name) {
+					tool.StrippedHeaders = append(tool.StrippedHeaders, name)
+				}
+			}
+			slices.Sort(tool.StrippedHeaders)
+		}
+		if changed {
+			redacted++
+		}
+	}
+	return redacted
+}
+
+// ResponseHeadersFor returns the response header allowlist for tool: its
+// own list, else the config's, else DefaultResponseHeaders.
+func (c *Config) ResponseHeadersFor(tool *Tool) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.responseHeadersFor(tool)
+}
+
+func (c *Config) responseHeadersFor(tool *Tool) []string {
+	switch {
+	case tool != nil && tool.ResponseHeaders != nil:
+		return tool.ResponseHeaders
+	case c.ResponseHeaders != nil:
+		return c.ResponseHeaders
+	}
+	return DefaultResponseHeaders
+}
+
+// ResponseHeadersForEndpoint is ResponseHeadersFor the tool matching
+// method and url, or the global list when there is none yet.
+func (c *Config) ResponseHeadersForEndpoint(method, url string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.responseHeadersFor(c.toolFor(method, url))
+}
+
+// SetToolResponseHeaders replaces the allowlist of the tool named ref. A
+// nil list makes the tool follow the global one again.
+func (c *Config) SetToolResponseHeaders(ref string, allow []string) (*Tool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return nil, fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	before := definitionOf(tool)
+	tool.ResponseHeaders = allow
+	c.recordRevision(tool, HistoryAdmin, before)
+	return tool, nil
+}
+
+// FilterHeaders keeps the headers in h matched by allow. Set-Cookie is only
+// kept when allow names it exactly, never through a wildcard.
+func FilterHeaders(h http.Header, allow []string) map[string]string {
+	var kept map[string]string
+	for name, values := range h {
+		if len(values) == 0 || !headerAllowed(name, allow) {
+			continue
+		}
+		if kept == nil {
+			kept = make(map[string]string)
+		}
+		kept[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+	}
+	return kept
+}
+
+func headerAllowed(name string, allow []string) bool {
+	cookie := strings.EqualFold(name, "Set-Cookie")
+	for _, pattern := range allow {
+		pattern = strings.TrimSpace(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if !cookie && len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(name, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// hopHeaders describe the connection or the framing of the captured
+// message rather than the request, so replaying them breaks calls whose
+// body differs. Host and Content-Length are set from the tool URL and the
+// body actually sent.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"Host",
+	"Content-Length",
+}
+
+// StripHopHeaders removes hopHeaders from a replayed request, along with
+// any header its Connection header names.
+func StripHopHeaders(h http.Header) {
+	for _, value := range h.Values("Connection") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
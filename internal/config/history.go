@@ -0,0 +1,232 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultHistoryLimit is how many revisions are kept per tool when the
+// config doesn't set history_limit.
+const DefaultHistoryLimit = 20
+
+// What made a revision.
+const (
+	HistoryCreated    = "created"
+	HistoryCapture    = "capture"
+	HistoryAdmin      = "admin"
+	HistoryEnrichment = "enrichment"
+	HistoryMigration  = "migration"
+	HistoryRevert     = "revert"
+	HistoryImport     = "import"
+	HistoryRepair     = "repair"
+)
+
+// Revision is one change to a tool's definition.
+type Revision struct {
+	Rev     int           `json:"rev"`
+	At      time.Time     `json:"at"`
+	Source  string        `json:"source"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a definition field's JSON value before and after a
+// revision. Values come from the catalog, so they are redacted the same
+// way.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// definition is the part of a tool that history tracks: what it calls
+// and how it is presented, not what was observed about it.
+type definition struct {
+	Name              string            `json:"name"`
+	Method            string            `json:"method"`
+	URL               string            `json:"url"`
+	Headers           map[string]string `json:"headers"`
+	Body              string            `json:"body"`
+	Description       string            `json:"description"`
+	PathParams        map[string]string `json:"path_params"`
+	QueryParams       map[string]string `json:"query_params"`
+	ParamDescriptions map[string]string `json:"param_descriptions"`
+	ResponseHeaders   []string          `json:"response_headers"`
+	UserAgent         string            `json:"user_agent"`
+	Serialize         bool              `json:"serialize"`
+	Timeout           string            `json:"timeout"`
+	Retries           *int              `json:"retries"`
+	RetryUnsafe       bool              `json:"retry_unsafe"`
+	AllowExecute      *bool             `json:"allow_execute"`
+}
+
+func definitionOf(t *Tool) definition {
+	return definition{
+		Name:              t.Name,
+		Method:            t.Method,
+		URL:               t.URL,
+		Headers:           cloneMap(t.Headers),
+		Body:              t.Body,
+		Description:       t.Description,
+		PathParams:        cloneMap(t.PathParams),
+		QueryParams:       cloneMap(t.QueryParams),
+		ParamDescriptions: cloneMap(t.ParamDescriptions),
+		ResponseHeaders:   slices.Clone(t.ResponseHeaders),
+		UserAgent:         t.UserAgent,
+		Serialize:         t.Serialize,
+		Timeout:           t.Timeout,
+		Retries:           t.Retries,
+		RetryUnsafe:       t.RetryUnsafe,
+		AllowExecute:      t.AllowExecute,
+	}
+}
+
+// apply sets t's definition fields to d, except the name, which goes
+// through the name index.
+func (d definition) apply(t *Tool) {
+	t.Method = d.Method
+	t.URL = d.URL
+	t.Headers = d.Headers
+	t.Body = d.Body
+	t.Description = d.Description
+	t.PathParams = d.PathParams
+	t.QueryParams = d.QueryParams
+	t.ParamDescriptions = d.ParamDescriptions
+	t.ResponseHeaders = d.ResponseHeaders
+	t.UserAgent = d.UserAgent
+	t.Serialize = d.Serialize
+	t.Timeout = d.Timeout
+	t.Retries = d.Retries
+	t.RetryUnsafe = d.RetryUnsafe
+	t.AllowExecute = d.AllowExecute
+}
+
+func (d definition) fields() map[string]json.RawMessage {
+	data, _ := json.Marshal(d)
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func cloneMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]string, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+// historyLimit returns the number of revisions kept per tool. c.mu must
+// be held.
+func (c *Config) historyLimit() int {
+	if c.HistoryLimit > 0 {
+		return c.HistoryLimit
+	}
+	return DefaultHistoryLimit
+}
+
+// recordRevision adds a revision to tool for whatever changed since
+// before. Nothing is recorded when nothing changed. c.mu must be held.
+func (c *Config) recordRevision(tool *Tool, source string, before definition) {
+	old, now := before.fields(), definitionOf(tool).fields()
+	var changes []FieldChange
+	for field, value := range now {
+		if !bytes.Equal(old[field], value) {
+			changes = append(changes, FieldChange{Field: field, Old: old[field], New: value})
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	slices.SortFunc(changes, func(a, b FieldChange) int {
+		switch {
+		case a.Field < b.Field:
+			return -1
+		case a.Field > b.Field:
+			return 1
+		}
+		return 0
+	})
+	c.appendRevision(tool, Revision{At: time.Now(), Source: source, Changes: changes})
+}
+
+func (c *Config) appendRevision(tool *Tool, rev Revision) {
+	rev.Rev = 1
+	if n := len(tool.History); n > 0 {
+		rev.Rev = tool.History[n-1].Rev + 1
+	}
+	tool.History = append(tool.History, rev)
+	if extra := len(tool.History) - c.historyLimit(); extra > 0 {
+		tool.History = slices.Delete(tool.History, 0, extra)
+	}
+}
+
+// History returns the kept revisions of the tool named ref, oldest first.
+func (c *Config) History(ref string) ([]Revision, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return nil, fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	return slices.Clone(tool.History), nil
+}
+
+// RevertTool restores the definition the tool named ref had right after
+// revision rev, by undoing the revisions since. The revert is itself
+// recorded as a revision. It returns the tool and its name before the
+// revert.
+func (c *Config) RevertTool(ref string, rev int) (*Tool, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return nil, "", fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	i := slices.IndexFunc(tool.History, func(r Revision) bool { return r.Rev == rev })
+	if i < 0 {
+		return nil, "", fmt.Errorf("%w: %s has no revision %d", ErrRevisionNotFound, tool.Name, rev)
+	}
+
+	before := definitionOf(tool)
+	fields := before.fields()
+	for j := len(tool.History) - 1; j > i; j-- {
+		for _, change := range tool.History[j].Changes {
+			fields[change.Field] = change.Old
+		}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, "", err
+	}
+	var restored definition
+	if err := json.Unmarshal(data, &restored); err != nil {
+		return nil, "", fmt.Errorf("restore revision %d: %w", rev, err)
+	}
+	if id, taken := c.names[restored.Name]; taken && id != tool.ID {
+		return nil, "", fmt.Errorf("%w: %q", ErrToolNameInUse, restored.Name)
+	}
+
+	oldName := tool.Name
+	restored.apply(tool)
+	delete(c.names, tool.Name)
+	tool.Name = restored.Name
+	c.names[tool.Name] = tool.ID
+	c.recordRevision(tool, HistoryRevert, before)
+	return tool, oldName, nil
+}
+
+// lookupTool is LookupTool for callers holding c.mu.
+func (c *Config) lookupTool(ref string) *Tool {
+	if tool := c.Tools[ref]; tool != nil {
+		return tool
+	}
+	return c.Tools[c.names[ref]]
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var (
+	idMu     sync.Mutex
+	lastTime uint64
+	lastRand [10]byte
+)
+
+// NewID returns a ULID: 48 bits of millisecond time followed by 80 random
+// bits, Crockford base32 encoded. IDs created within the same millisecond
+// increment the random part, so they stay sortable.
+func NewID() string {
+	idMu.Lock()
+	defer idMu.Unlock()
+
+	now := uint64(time.Now().UnixMilli())
+	if now == lastTime {
+		for i := len(lastRand) - 1; i >= 0; i-- {
+			lastRand[i]++
+			if lastRand[i] != 0 {
+				break
+			}
+		}
+	} else {
+		lastTime = now
+		rand.Read(lastRand[:])
+	}
+
+	var raw [16]byte
+	for i := 0; i < 6; i++ {
+		raw[i] = byte(now >> (40 - 8*i))
+	}
+	copy(raw[6:], lastRand[:])
+
+	return encodeULID(raw)
+}
+
+func encodeULID(raw [16]byte) string {
+	// 128 bits become 26 characters; the first holds only 3 bits
+	out := make([]byte, 26)
+	var acc uint64
+	bits := 0
+	pos := 25
+	for i := 15; i >= 0; i-- {
+		acc |= uint64(raw[i]) << bits
+		bits += 8
+		for bits >= 5 && pos >= 0 {
+			out[pos] = crockford[acc&31]
+			acc >>= 5
+			bits -= 5
+			pos--
+		}
+	}
+	if pos >= 0 {
+		out[pos] = crockford[acc&31]
+	}
+	return string(out)
+}
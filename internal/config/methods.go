@@ -0,0 +1,38 @@
+package config
+
+import "strings"
+
+// safeMethods only read. Besides the RFC 9110 ones, WebDAV's PROPFIND,
+// REPORT and SEARCH are defined as safe.
+var safeMethods = map[string]bool{
+	"GET": true, "HEAD": true, "OPTIONS": true, "TRACE": true,
+	"PROPFIND": true, "REPORT": true, "SEARCH": true,
+}
+
+// StandardMethods are the methods every HTTP tool understands. Tools may
+// use any other valid method too, e.g. WebDAV's.
+var StandardMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// ValidMethod reports whether method is an HTTP method token: one or more
+// tchar as RFC 7230 defines them.
+func ValidMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for i := 0; i < len(method); i++ {
+		c := method[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SafeMethod reports whether method is defined to only read. Methods
+// mcpify doesn't know are assumed to write.
+func SafeMethod(method string) bool {
+	return safeMethods[strings.ToUpper(method)]
+}
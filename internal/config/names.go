@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+// CachedName is a tool name the LLM gave an endpoint, kept so the endpoint
+// is named the same when it is discovered again.
+type CachedName struct {
+	Name string `json:"name"`
+	// NamedBy is the Ref of the prompt that made the name.
+	NamedBy string    `json:"named_by,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// CachedName returns the name cached for endpoint, a method and templated
+// path such as "GET /users/{id}".
+func (c *Config) CachedName(endpoint string) (CachedName, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.NameCache[endpoint]
+	return name, ok
+}
+
+// CacheName caches the name the LLM gave endpoint. It is saved with the
+// config.
+func (c *Config) CacheName(endpoint string, name CachedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.NameCache == nil {
+		c.NameCache = make(map[string]CachedName)
+	}
+	c.NameCache[endpoint] = name
+}
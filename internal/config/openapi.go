@@ -0,0 +1,108 @@
+package config
+
+import (
+	"maps"
+	"strings"
+	"time"
+)
+
+// ViaOpenAPI marks tools imported from an OpenAPI document. Their
+// headers and body are the document's examples, so any captured request
+// replaces them.
+const ViaOpenAPI = "openapi"
+
+// ToolDoc is what an API description says about a tool beyond the
+// request it makes.
+type ToolDoc struct {
+	// Spec is the document the tool was imported from.
+	Spec              string
+	QueryParams       map[string]string
+	ParamDescriptions map[string]string
+	// NamedBy records what the tool's name came from.
+	NamedBy string
+}
+
+// DocumentTool adds doc to the tool matching method and url. Query
+// parameters the tool already has keep their value. Tools that have no
+// provenance yet are marked as imported from doc.Spec.
+func (c *Config) DocumentTool(method, url string, doc ToolDoc) (*Tool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.toolFor(method, url)
+	if tool == nil {
+		return nil, false
+	}
+	before := definitionOf(tool)
+	changed := false
+	for name, value := range doc.QueryParams {
+		if _, ok := tool.QueryParams[name]; ok {
+			continue
+		}
+		if tool.QueryParams == nil {
+			tool.QueryParams = make(map[string]string)
+		}
+		tool.QueryParams[name] = value
+		changed = true
+	}
+	if len(doc.ParamDescriptions) > 0 && !maps.Equal(tool.ParamDescriptions, doc.ParamDescriptions) {
+		tool.ParamDescriptions = maps.Clone(doc.ParamDescriptions)
+		changed = true
+	}
+	if tool.Spec != doc.Spec {
+		tool.Spec = doc.Spec
+		changed = true
+	}
+	if tool.Provenance == nil {
+		tool.Provenance = &Provenance{Via: ViaOpenAPI, Interface: doc.Spec, CapturedAt: time.Now(), NamedBy: doc.NamedBy}
+		changed = true
+	}
+	c.recordRevision(tool, HistoryImport, before)
+	return tool, changed
+}
+
+// toolFor returns the tool calling method and url, or nil. An exact URL
+// wins; otherwise {param} segments match each other, and those of tools
+// imported from a spec match any value, so /users/42 finds the documented
+// /users/{id}. c.mu must be held.
+func (c *Config) toolFor(method, url string) *Tool {
+	var best *Tool
+	bestParams := 0
+	segments := strings.Split(url, "/")
+	for _, tool := range c.Tools {
+		if tool.Method != method {
+			continue
+		}
+		if tool.URL == url {
+			return tool
+		}
+		params, ok := matchTemplate(strings.Split(tool.URL, "/"), segments, tool.Spec != "")
+		if ok && (best == nil || params < bestParams) {
+			best, bestParams = tool, params
+		}
+	}
+	return best
+}
+
+// matchTemplate reports whether the tool URL segments match those of a
+// request, and how many placeholders that took.
+func matchTemplate(tool, request []string, documented bool) (int, bool) {
+	if len(tool) != len(request) {
+		return 0, false
+	}
+	params := 0
+	for i, segment := range tool {
+		switch {
+		case segment == request[i]:
+		case isPlaceholder(segment) && (documented || isPlaceholder(request[i])):
+			params++
+		default:
+			return 0, false
+		}
+	}
+	return params, true
+}
+
+func isPlaceholder(segment string) bool {
+	return len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ResolveURL fills the {param} placeholders in the tool's URL from
+// pathValues and appends its query parameters, with queryValues overriding
+// the captured ones. An empty query value drops the parameter.
+func (t *Tool) ResolveURL(pathValues, queryValues map[string]string) string {
+	resolved := t.resolvePath(pathValues)
+
+	query := make(url.Values)
+	for name, value := range t.QueryParams {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	for name, value := range queryValues {
+		if value == "" {
+			query.Del(name)
+		} else {
+			query.Set(name, value)
+		}
+	}
+	if len(query) == 0 {
+		return resolved
+	}
+
+	separator := "?"
+	if strings.Contains(resolved, "?") {
+		separator = "&"
+	}
+	return resolved + separator + query.Encode()
+}
+
+func (t *Tool) resolvePath(values map[string]string) string {
+	if !strings.Contains(t.URL, "{") {
+		return t.URL
+	}
+
+	var b strings.Builder
+	rest := t.URL
+	for {
+		start := strings.Index(rest, "{")
+		end := strings.Index(rest[max(start, 0):], "}")
+		if start < 0 || end < 0 {
+			b.WriteString(rest)
+			return b.String()
+		}
+		end += start
+
+		name := rest[start+1 : end]
+		value, ok := values[name]
+		if !ok {
+			value, ok = t.PathParams[name]
+		}
+		b.WriteString(rest[:start])
+		if ok {
+			b.WriteString(url.PathEscape(value))
+		} else {
+			b.WriteString(rest[start : end+1])
+		}
+		rest = rest[end+1:]
+	}
+}
+
+// PathParamNames lists the {param} placeholders in the tool's URL in
+// order.
+func (t *Tool) PathParamNames() []string {
+	var names []string
+	for _, segment := range strings.Split(t.RawPath(), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, segment[1:len(segment)-1])
+		}
+	}
+	return names
+}
+
+// QueryParamNames lists the tool's query parameters in name order.
+func (t *Tool) QueryParamNames() []string {
+	names := make([]string, 0, len(t.QueryParams))
+	for name := range t.QueryParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MatchPath reports whether the concrete path fits the tool's templated
+// path, returning the value of each placeholder.
+func (t *Tool) MatchPath(path string) (map[string]string, bool) {
+	want := strings.Split(t.RawPath(), "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+	for i, segment := range want {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && got[i] != "" {
+			// Echoing the placeholder back leaves the captured default
+			if got[i] == segment {
+				continue
+			}
+			value, err := url.PathUnescape(got[i])
+			if err != nil {
+				return nil, false
+			}
+			values[segment[1:len(segment)-1]] = value
+			continue
+		}
+		if !sameSegment(segment, got[i]) {
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// sameSegment compares static path segments by what they decode to, so
+// /files/my%20doc matches /files/my doc and %2f matches %2F.
+func sameSegment(a, b string) bool {
+	if a == b {
+		return true
+	}
+	da, errA := url.PathUnescape(a)
+	db, errB := url.PathUnescape(b)
+	return errA == nil && errB == nil && da == db
+}
+
+// RawPath returns the path of the tool's URL exactly as stored, with its
+// percent-encoding and {param} placeholders intact. url.URL can't give
+// that back: its Path is decoded and EscapedPath re-encodes the braces.
+func (t *Tool) RawPath() string {
+	rest := t.URL
+	if _, afterScheme, ok := strings.Cut(rest, "://"); ok {
+		i := strings.IndexByte(afterScheme, '/')
+		if i < 0 {
+			return ""
+		}
+		rest = afterScheme[i:]
+	}
+	path, _, _ := strings.Cut(rest, "?")
+	return path
+}
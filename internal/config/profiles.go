@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Profile is a named set of command line flag values keyed by flag name,
+// e.g. {"use-llm": true, "max-tools": 50}. Lists are joined with commas.
+type Profile map[string]interface{}
+
+// Profile returns the profile called name.
+func (c *Config) Profile(name string) (Profile, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrProfileNotFound, name)
+	}
+	return p, nil
+}
+
+// ProfileNames returns the names of all profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Sorted(maps.Keys(c.Profiles))
+}
+
+// Values returns the profile's settings in flag syntax.
+func (p Profile) Values() map[string]string {
+	values := make(map[string]string, len(p))
+	for name, v := range p {
+		values[name] = flagValue(v)
+	}
+	return values
+}
+
+func flagValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = flagValue(item)
+		}
+		return strings.Join(parts, ",")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
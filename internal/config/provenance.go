@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Ways a request can reach mcpify.
+const (
+	ViaPcap   = "pcap"
+	ViaProxy  = "proxy"
+	ViaIngest = "ingest"
+	ViaImport = "import"
+)
+
+// Provenance records where one captured request came from.
+type Provenance struct {
+	// Via is ViaPcap, ViaProxy, ViaIngest, ViaImport or ViaOpenAPI.
+	Via string `json:"via"`
+	// Interface is the capture interface for pcap, or the listener address
+	// for the proxy.
+	Interface string `json:"interface,omitempty"`
+	// Client is the address the request was sent from.
+	Client string `json:"client,omitempty"`
+	// Host is the Host header the request was sent with, which tells
+	// target aliases apart.
+	Host       string    `json:"host,omitempty"`
+	TLS        bool      `json:"tls"`
+	CapturedAt time.Time `json:"captured_at"`
+	// NamedBy is the prompt that named the tool, as prompts.Prompt.Ref
+	// gives it, or "heuristic" for names made from the path.
+	NamedBy string `json:"named_by,omitempty"`
+}
+
+// Trusted reports whether the request was handed to mcpify on purpose, by
+// ingestion or an import, rather than seen in ambient traffic.
+func (p *Provenance) Trusted() bool {
+	return p != nil && (p.Via == ViaIngest || p.Via == ViaImport)
+}
+
+func (p *Provenance) String() string {
+	if p == nil {
+		return "unknown"
+	}
+	s := p.Via
+	if p.Interface != "" {
+		s += " on " + p.Interface
+	}
+	if p.Host != "" {
+		s += " to " + p.Host
+	}
+	if p.Client != "" {
+		s += " from " + p.Client
+	}
+	if p.TLS {
+		s += " over TLS"
+	}
+	return fmt.Sprintf("%s at %s", s, p.CapturedAt.Format(time.RFC3339))
+}
+
+// RequestSample is the captured request a tool's headers and body come
+// from.
+type RequestSample struct {
+	Headers map[string]string
+	// StrippedHeaders names the sensitive headers left out of Headers.
+	StrippedHeaders []string
+	Body            string
+	Provenance      *Provenance
+}
+
+// SetRequestSample records where the tool matching method and url got its
+// headers and body. A trusted sample replaces what was captured from
+// ambient traffic, and any captured request replaces an OpenAPI
+// document's examples; otherwise the stored request is kept, and only gains a
+// provenance when sample is the request it was made from. Stripped header
+// names are added to the tool's either way.
+func (c *Config) SetRequestSample(method, url string, sample *RequestSample) (*Tool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tool := c.toolFor(method, url); tool != nil {
+		stripped := false
+		for _, name := range sample.StrippedHeaders {
+			if !slices.Contains(tool.StrippedHeaders, name) {
+				tool.StrippedHeaders = append(tool.StrippedHeaders, name)
+				stripped = true
+			}
+		}
+		slices.Sort(tool.StrippedHeaders)
+
+		switch {
+		case tool.Provenance == nil && tool.Body == sample.Body && maps.Equal(tool.Headers, sample.Headers):
+			tool.Provenance = sample.Provenance
+		case sample.Provenance.Trusted() && !tool.Provenance.Trusted(),
+			tool.Provenance != nil && tool.Provenance.Via == ViaOpenAPI && sample.Provenance != nil && sample.Provenance.Via != ViaOpenAPI:
+			before := definitionOf(tool)
+			// The sample's request didn't name the tool; keep what did
+			if sample.Provenance.NamedBy == "" && tool.Provenance != nil {
+				sample.Provenance.NamedBy = tool.Provenance.NamedBy
+			}
+			tool.Headers = sample.Headers
+			tool.Body = sample.Body
+			tool.Provenance = sample.Provenance
+			c.recordRevision(tool, HistoryCapture, before)
+		default:
+			return tool, stripped
+		}
+		return tool, true
+	}
+	return nil, false
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// SchemaVersion is the version of the config format this mcpify writes,
+// stored as "version". Older versions are upgraded on load by the
+// migrations below; newer ones are refused.
+//
+//  1. Tools keyed by name, groups listing tool names
+//  2. Tools keyed by ID, groups listing tool IDs
+//  3. Tools and groups kept per target, under "targets"
+//  4. mcp_port, max_tools and use_llm read back as flag defaults
+const SchemaVersion = 4
+
+// migrations[v-1] upgrades a config from version v to v+1.
+var migrations = []func(c *Config) error{
+	// 1 → 2
+	func(c *Config) error {
+		c.migrateToolIDs()
+		return nil
+	},
+	// 2 → 3
+	func(c *Config) error {
+		c.target = TargetKey(c.LastTarget)
+		moved, err := c.splitTargets()
+		if err != nil {
+			return err
+		}
+		slog.Info("Keeping tools per target", "target", c.target, "tools", len(c.Tools), "moved", moved)
+		return nil
+	},
+	// 3 → 4
+	func(c *Config) error {
+		// use_llm was written as true by every config and never read;
+		// read now, it would need an LLM nobody asked for
+		c.UseLLM = false
+		return nil
+	},
+}
+
+// versionOf returns the version of the config in data. Configs written
+// before versions were recorded are told apart by their shape.
+func versionOf(data []byte) (int, error) {
+	var shape struct {
+		Version int                        `json:"version"`
+		Targets map[string]json.RawMessage `json:"targets"`
+		Tools   map[string]struct {
+			ID string `json:"id"`
+		} `json:"tools"`
+		Groups map[string]struct {
+			ToolNames []string `json:"tool_names"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		return 0, err
+	}
+	switch {
+	case shape.Version != 0:
+		return shape.Version, nil
+	case shape.Targets != nil:
+		return 3, nil
+	}
+	for _, tool := range shape.Tools {
+		if tool.ID == "" {
+			return 1, nil
+		}
+	}
+	for _, group := range shape.Groups {
+		if len(group.ToolNames) > 0 {
+			return 1, nil
+		}
+	}
+	return 2, nil
+}
+
+// migrate upgrades c, loaded from a config of version from, to
+// SchemaVersion, after copying the file at path to <path>.bak.v<from>.
+func (c *Config) migrate(path string, data []byte, from int) error {
+	backup := fmt.Sprintf("%s.bak.v%d", path, from)
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("back up config before upgrading it: %w", err)
+	}
+	for v := from; v < SchemaVersion; v++ {
+		if err := migrations[v-1](c); err != nil {
+			return fmt.Errorf("upgrade config from version %d: %w", v, err)
+		}
+	}
+	c.SchemaVersion = SchemaVersion
+	slog.Info("Upgraded config", "from", from, "to", SchemaVersion, "backup", backup)
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestOlderConfigsUpgrade(t *testing.T) {
+	tests := []struct {
+		fixture string
+		version int
+		// tools and groups are the active target's after the upgrade
+		tools  []string
+		groups []string
+		// elsewhere are the tools kept for other targets
+		elsewhere map[string][]string
+	}{
+		{
+			fixture: "v1-tools-by-name.json",
+			version: 1,
+			tools:   []string{"create_user", "get_orders", "get_users"},
+			groups:  []string{"orders", "users"},
+		},
+		{
+			fixture:   "v2-tools-by-id.json",
+			version:   2,
+			tools:     []string{"create_user", "get_users"},
+			groups:    []string{"users"},
+			elsewhere: map[string][]string{"https://rates.example.com": {"get_rates"}},
+		},
+		{
+			fixture:   "v3-per-target.json",
+			version:   3,
+			tools:     []string{"create_user", "get_users"},
+			elsewhere: map[string][]string{"https://rates.example.com": {"get_rates"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/" + tt.fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := versionOf(data); err != nil || got != tt.version {
+				t.Fatalf("versionOf() = %d, %v, want %d", got, err, tt.version)
+			}
+
+			cfg, path := loadFixture(t, tt.fixture)
+			if cfg.SchemaVersion != SchemaVersion {
+				t.Errorf("version %d, want %d", cfg.SchemaVersion, SchemaVersion)
+			}
+			if cfg.UseLLM {
+				t.Error("use_llm carried over")
+			}
+			if backup, err := os.ReadFile(fmt.Sprintf("%s.bak.v%d", path, tt.version)); err != nil || string(backup) != string(data) {
+				t.Errorf("backup of the old config: %v", err)
+			}
+
+			var tools []string
+			for _, tool := range cfg.ListTools() {
+				tools = append(tools, tool.Name)
+				if tool.ID == "" || len(tool.History) == 0 {
+					t.Errorf("%s has ID %q and %d revisions", tool.Name, tool.ID, len(tool.History))
+				}
+			}
+			slices.Sort(tools)
+			if !slices.Equal(tools, tt.tools) {
+				t.Errorf("tools %v, want %v", tools, tt.tools)
+			}
+			var groups []string
+			for _, group := range cfg.ListGroups() {
+				groups = append(groups, group.Name)
+			}
+			slices.Sort(groups)
+			if !slices.Equal(groups, tt.groups) {
+				t.Errorf("groups %v, want %v", groups, tt.groups)
+			}
+			for key, want := range tt.elsewhere {
+				var catalog targetCatalog
+				if err := json.Unmarshal(cfg.Targets[key], &catalog); err != nil {
+					t.Fatalf("catalog of %s: %v", key, err)
+				}
+				var names []string
+				for _, tool := range catalog.Tools {
+					names = append(names, tool.Name)
+				}
+				if !slices.Equal(names, want) {
+					t.Errorf("%s has tools %v, want %v", key, names, want)
+				}
+			}
+
+			// The upgraded config loads as the current version
+			saved, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := versionOf(saved); err != nil || got != SchemaVersion {
+				t.Errorf("saved config is version %d, %v", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package config
+
+import "fmt"
+
+// SerialLane names the lane calls to tool must queue in, or "" when they
+// may run concurrently. A serialized group puts all of its tools in one
+// lane; otherwise a serialized tool has a lane of its own.
+func (c *Config) SerialLane(tool *Tool) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, group := range c.Groups {
+		if !group.Serialize {
+			continue
+		}
+		for _, id := range group.ToolIDs {
+			if id == tool.ID {
+				return "group:" + group.Name
+			}
+		}
+	}
+	if tool.Serialize {
+		return "tool:" + tool.ID
+	}
+	return ""
+}
+
+// SetToolSerialize turns serialization of the tool named ref on or off.
+func (c *Config) SetToolSerialize(ref string, on bool) (*Tool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tool := c.lookupTool(ref)
+	if tool == nil {
+		return nil, fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+	}
+	before := definitionOf(tool)
+	tool.Serialize = on
+	c.recordRevision(tool, HistoryAdmin, before)
+	return tool, nil
+}
+
+// SetGroupSerialize turns serialization of the group called name on or
+// off.
+func (c *Config) SetGroupSerialize(name string, on bool) (*Group, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	group := c.Groups[name]
+	if group == nil {
+		return nil, fmt.Errorf("%w: %q", ErrGroupNotFound, name)
+	}
+	group.Serialize = on
+	return group, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/fingerprint"
+)
+
+// ShapeChange is a breaking change in a tool's response structure: paths
+// that went missing or changed type, alongside the ones that appeared.
+type ShapeChange struct {
+	Added      []string  `json:"added,omitempty"`
+	Removed    []string  `json:"removed"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// ResponseChange is a tool whose response shape changed, as listed in
+// /debug.
+type ResponseChange struct {
+	Tool string `json:"tool"`
+	*ShapeChange
+}
+
+// updateShape folds sample.Shape into tool's fingerprint, recording a
+// ShapeChange when paths were lost. It reports whether the tool changed.
+func (c *Config) updateShape(tool *Tool, sample *ResponseSample) bool {
+	if sample.Shape == nil {
+		return false
+	}
+	shape := fingerprint.Filter(sample.Shape, c.VolatilePaths)
+	if tool.ResponseShape == nil {
+		tool.ResponseShape = shape
+		return true
+	}
+
+	added, removed := fingerprint.Diff(tool.ResponseShape, shape, c.VolatilePaths)
+	if len(added) == 0 && len(removed) == 0 {
+		return false
+	}
+	tool.ResponseShape = shape
+	if len(removed) > 0 {
+		tool.ShapeChange = &ShapeChange{Added: added, Removed: removed, DetectedAt: sample.SeenAt}
+		slog.Warn("Response schema changed", "tool", tool.Name, "removed", removed, "added", added)
+	}
+	return true
+}
+
+// ResponseChanges returns the tools whose response shape has changed,
+// by name.
+func (c *Config) ResponseChanges() []ResponseChange {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var changes []ResponseChange
+	for _, tool := range c.Tools {
+		if tool.ShapeChange != nil {
+			changes = append(changes, ResponseChange{Tool: tool.Name, ShapeChange: tool.ShapeChange})
+		}
+	}
+	slices.SortFunc(changes, func(a, b ResponseChange) int { return strings.Compare(a.Tool, b.Tool) })
+	return changes
+}
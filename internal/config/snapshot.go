@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Snapshot returns a copy of tool's definition as it is now, sharing
+// nothing with it. Tool calls run on a snapshot taken when they start, so
+// a rename, regroup or removal while one is in flight neither races with
+// it nor changes what it sends. The copy has no history.
+func (c *Config) Snapshot(tool *Tool) *Tool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return tool.clone()
+}
+
+func (t *Tool) clone() *Tool {
+	c := *t
+	c.Headers = maps.Clone(t.Headers)
+	c.Tags = slices.Clone(t.Tags)
+	c.PathParams = maps.Clone(t.PathParams)
+	c.QueryParams = maps.Clone(t.QueryParams)
+	c.ParamDescriptions = maps.Clone(t.ParamDescriptions)
+	c.StrippedHeaders = slices.Clone(t.StrippedHeaders)
+	c.BodySchema = t.BodySchema.clone()
+	c.ResponseHeaders = slices.Clone(t.ResponseHeaders)
+	c.ResponseShape = slices.Clone(t.ResponseShape)
+	c.History = nil
+	if t.Assertions != nil {
+		a := *t.Assertions
+		a.ExpectJSON = maps.Clone(t.Assertions.ExpectJSON)
+		c.Assertions = &a
+	}
+	if t.Response != nil {
+		r := *t.Response
+		r.Headers = maps.Clone(t.Response.Headers)
+		r.Shape = slices.Clone(t.Response.Shape)
+		c.Response = &r
+	}
+	if t.ShapeChange != nil {
+		s := *t.ShapeChange
+		s.Added = slices.Clone(t.ShapeChange.Added)
+		s.Removed = slices.Clone(t.ShapeChange.Removed)
+		c.ShapeChange = &s
+	}
+	if t.Provenance != nil {
+		p := *t.Provenance
+		c.Provenance = &p
+	}
+	return &c
+}
+
+// RecordUse counts a call of the tool with ID id, made through group when
+// it isn't empty. Tools and groups removed since the call started are
+// skipped.
+func (c *Config) RecordUse(id, group string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if tool := c.Tools[id]; tool != nil {
+		tool.UseCount++
+		tool.LastUsed = now
+	}
+	if g := c.Groups[group]; g != nil {
+		g.UseCount++
+		g.LastUsed = now
+	}
+	c.usageChanged = true
+}
+
+// RecordFailure counts a failed call of the tool with ID id, for reason.
+func (c *Config) RecordFailure(id, reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tool := c.Tools[id]; tool != nil {
+		tool.ErrorCount++
+		tool.LastError = reason
+		tool.LastErrorAt = time.Now()
+		c.usageChanged = true
+	}
+}
+
+// UsageSaveInterval is how often SaveUsage saves changed usage counts.
+const UsageSaveInterval = time.Minute
+
+// SaveUsage saves the config to its Path every interval while usage counts
+// have changed since, until ctx is done. Calls aren't saved one by one;
+// shutdown saves whatever the last interval missed.
+func (c *Config) SaveUsage(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		c.mu.Lock()
+		changed := c.usageChanged
+		c.usageChanged = false
+		c.mu.Unlock()
+		if !changed {
+			continue
+		}
+		if err := c.Save(c.Path); err != nil {
+			slog.Error("Failed to save usage counts", "error", err)
+			c.mu.Lock()
+			c.usageChanged = true
+			c.mu.Unlock()
+		}
+	}
+}
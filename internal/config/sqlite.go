@@ -0,0 +1,413 @@
+package config
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema keeps the config in rows, so a save writes only the tools,
+// groups and usage counts that changed rather than the whole config.
+// Definitions are kept in their JSON form, without their usage counts,
+// which change on every call and are in a table of their own.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS settings (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS tools (
+	target     TEXT NOT NULL,
+	id         TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	definition TEXT NOT NULL,
+	PRIMARY KEY (target, id)
+);
+CREATE TABLE IF NOT EXISTS groups (
+	target     TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	definition TEXT NOT NULL,
+	PRIMARY KEY (target, name)
+);
+CREATE TABLE IF NOT EXISTS usage (
+	target        TEXT NOT NULL,
+	tool_id       TEXT NOT NULL,
+	use_count     INTEGER NOT NULL,
+	last_used     TEXT NOT NULL,
+	error_count   INTEGER NOT NULL,
+	last_error    TEXT NOT NULL,
+	last_error_at TEXT NOT NULL,
+	PRIMARY KEY (target, tool_id)
+);`
+
+// usageFields are the Tool fields kept in the usage table.
+var usageFields = []string{"use_count", "last_used", "error_count", "last_error", "last_error_at"}
+
+// toolUsage is a tool's row of the usage table.
+type toolUsage struct {
+	UseCount    int       `json:"use_count"`
+	LastUsed    time.Time `json:"last_used"`
+	ErrorCount  int       `json:"error_count"`
+	LastError   string    `json:"last_error"`
+	LastErrorAt time.Time `json:"last_error_at"`
+}
+
+// sqliteRow is a row of the tools, groups or usage table, by table,
+// target and key (the tool ID or group name).
+type sqliteRow struct {
+	table, target, key string
+}
+
+// sqliteValues are the rows of a config: tool and group definitions, and
+// usage as JSON.
+type sqliteValues struct {
+	settings string
+	names    map[sqliteRow]string
+	rows     map[sqliteRow]string
+}
+
+// sqliteStore keeps the config in a SQLite database.
+type sqliteStore struct {
+	path string
+	db   *sql.DB
+	// written is what the database holds, as last read or written
+	written sqliteValues
+}
+
+// LoadSQLite loads the config kept in the SQLite database at dbPath,
+// creating it if need be. A new database starts with the tools and
+// settings of the JSON config at jsonPath, if there is one; the file is
+// left as it is.
+func LoadSQLite(dbPath, jsonPath string) (*Config, error) {
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return nil, fmt.Errorf("create config directory: %w", err)
+	}
+	st, err := openSQLite(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if st.written.settings != "" || jsonPath == "" {
+		return load(st, dbPath)
+	}
+	if _, err := os.Stat(jsonPath); err != nil {
+		return load(st, dbPath)
+	}
+
+	cfg, err := LoadConfig(jsonPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.mu.Lock()
+	cfg.Path = dbPath
+	cfg.store = st
+	if cfg.blobs != nil {
+		cfg.blobs = NewDirBlobs(filepath.Join(filepath.Dir(dbPath), BlobDir))
+	}
+	cfg.mu.Unlock()
+	if err := cfg.Save(dbPath); err != nil {
+		return nil, err
+	}
+	slog.Info("Imported JSON config into SQLite store", "from", jsonPath, "to", dbPath, "tools", len(cfg.ListTools()))
+	return cfg, nil
+}
+
+func openSQLite(path string) (*sqliteStore, error) {
+	// The default rollback journal rather than WAL leaves every commit in
+	// the database file itself, so copying it, as backups do, copies the
+	// whole config
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("open SQLite store: %w", err)
+	}
+	// One connection, so saves never wait on each other's locks
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open SQLite store %s: %w", path, err)
+	}
+	st := &sqliteStore{path: path, db: db}
+	if st.written, err = st.load(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("read SQLite store %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// load reads every row of the database.
+func (st *sqliteStore) load() (sqliteValues, error) {
+	v := sqliteValues{names: make(map[sqliteRow]string), rows: make(map[sqliteRow]string)}
+	err := st.db.QueryRow(`SELECT value FROM settings WHERE key = 'config'`).Scan(&v.settings)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return v, err
+	}
+
+	rows, err := st.db.Query(`SELECT target, id, name, definition FROM tools`)
+	if err != nil {
+		return v, err
+	}
+	for rows.Next() {
+		row := sqliteRow{table: "tools"}
+		var name, definition string
+		if err := rows.Scan(&row.target, &row.key, &name, &definition); err != nil {
+			rows.Close()
+			return v, err
+		}
+		v.names[row], v.rows[row] = name, definition
+	}
+	if err := rows.Close(); err != nil {
+		return v, err
+	}
+
+	if rows, err = st.db.Query(`SELECT target, name, definition FROM groups`); err != nil {
+		return v, err
+	}
+	for rows.Next() {
+		row := sqliteRow{table: "groups"}
+		var definition string
+		if err := rows.Scan(&row.target, &row.key, &definition); err != nil {
+			rows.Close()
+			return v, err
+		}
+		v.rows[row] = definition
+	}
+	if err := rows.Close(); err != nil {
+		return v, err
+	}
+
+	if rows, err = st.db.Query(`SELECT target, tool_id, use_count, last_used, error_count, last_error, last_error_at FROM usage`); err != nil {
+		return v, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		row := sqliteRow{table: "usage"}
+		var u toolUsage
+		var lastUsed, lastErrorAt string
+		if err := rows.Scan(&row.target, &row.key, &u.UseCount, &lastUsed, &u.ErrorCount, &u.LastError, &lastErrorAt); err != nil {
+			return v, err
+		}
+		u.LastUsed, _ = time.Parse(time.RFC3339Nano, lastUsed)
+		u.LastErrorAt, _ = time.Parse(time.RFC3339Nano, lastErrorAt)
+		data, err := json.Marshal(u)
+		if err != nil {
+			return v, err
+		}
+		v.rows[row] = string(data)
+	}
+	return v, rows.Err()
+}
+
+// read puts the rows back together into the JSON document a config file
+// would hold.
+func (st *sqliteStore) read() ([]byte, error) {
+	if st.written.settings == "" {
+		return nil, fs.ErrNotExist
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(st.written.settings), &doc); err != nil {
+		return nil, fmt.Errorf("settings: %w", err)
+	}
+
+	type catalog struct {
+		Tools  map[string]map[string]json.RawMessage `json:"tools"`
+		Groups map[string]json.RawMessage            `json:"groups,omitempty"`
+	}
+	targets := make(map[string]*catalog)
+	catalogOf := func(target string) *catalog {
+		if targets[target] == nil {
+			targets[target] = &catalog{Tools: make(map[string]map[string]json.RawMessage)}
+		}
+		return targets[target]
+	}
+	for row, value := range st.written.rows {
+		switch row.table {
+		case "tools":
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal([]byte(value), &fields); err != nil {
+				return nil, fmt.Errorf("tool %s: %w", st.written.names[row], err)
+			}
+			usage := st.written.rows[sqliteRow{"usage", row.target, row.key}]
+			if usage != "" {
+				if err := json.Unmarshal([]byte(usage), &fields); err != nil {
+					return nil, fmt.Errorf("usage of %s: %w", st.written.names[row], err)
+				}
+			}
+			catalogOf(row.target).Tools[row.key] = fields
+		case "groups":
+			c := catalogOf(row.target)
+			if c.Groups == nil {
+				c.Groups = make(map[string]json.RawMessage)
+			}
+			c.Groups[row.key] = json.RawMessage(value)
+		}
+	}
+	if len(targets) > 0 {
+		data, err := json.Marshal(targets)
+		if err != nil {
+			return nil, err
+		}
+		doc["targets"] = data
+	}
+	return json.Marshal(doc)
+}
+
+// prepare splits s into rows and returns what writes the ones that
+// changed since the last save.
+func (st *sqliteStore) prepare(s *storedConfig) (func() error, error) {
+	v, err := sqliteValuesOf(s)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return func() error {
+		if err := st.write(v); err != nil {
+			return fmt.Errorf("write SQLite store %s: %w", st.path, err)
+		}
+		st.written = v
+		return nil
+	}, nil
+}
+
+// sqliteValuesOf splits the stored form of a config into rows.
+func sqliteValuesOf(s *storedConfig) (sqliteValues, error) {
+	v := sqliteValues{names: make(map[sqliteRow]string), rows: make(map[sqliteRow]string)}
+	settings := *s
+	settings.Targets = nil
+	data, err := json.Marshal(&settings)
+	if err != nil {
+		return v, err
+	}
+	v.settings = string(data)
+
+	for target, raw := range s.Targets {
+		var c struct {
+			Tools  map[string]map[string]json.RawMessage `json:"tools"`
+			Groups map[string]json.RawMessage            `json:"groups"`
+		}
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return v, fmt.Errorf("catalog of %s: %w", target, err)
+		}
+		for id, fields := range c.Tools {
+			usage := make(map[string]json.RawMessage)
+			for _, field := range usageFields {
+				if value, ok := fields[field]; ok {
+					usage[field] = value
+					delete(fields, field)
+				}
+			}
+			var u toolUsage
+			if data, err = json.Marshal(usage); err == nil {
+				err = json.Unmarshal(data, &u)
+			}
+			if err == nil {
+				data, err = json.Marshal(u)
+			}
+			if err != nil {
+				return v, fmt.Errorf("usage of %s: %w", id, err)
+			}
+			v.rows[sqliteRow{"usage", target, id}] = string(data)
+
+			// Maps are encoded in key order, so an unchanged tool always
+			// encodes the same
+			definition, err := json.Marshal(fields)
+			if err != nil {
+				return v, err
+			}
+			row := sqliteRow{"tools", target, id}
+			var name string
+			json.Unmarshal(fields["name"], &name)
+			v.names[row], v.rows[row] = name, string(definition)
+		}
+		for name, group := range c.Groups {
+			v.rows[sqliteRow{"groups", target, name}] = string(group)
+		}
+	}
+	return v, nil
+}
+
+// write upserts the rows of v that differ from those written before and
+// deletes the ones v no longer has, in one transaction.
+func (st *sqliteStore) write(v sqliteValues) error {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if v.settings != st.written.settings {
+		if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES ('config', ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value`, v.settings); err != nil {
+			return err
+		}
+	}
+	for row, value := range v.rows {
+		if st.written.rows[row] == value && st.written.names[row] == v.names[row] {
+			continue
+		}
+		if err := upsertRow(tx, row, v.names[row], value); err != nil {
+			return err
+		}
+	}
+	for row := range st.written.rows {
+		if _, ok := v.rows[row]; ok {
+			continue
+		}
+		key := "id"
+		switch row.table {
+		case "groups":
+			key = "name"
+		case "usage":
+			key = "tool_id"
+		}
+		if _, err := tx.Exec(`DELETE FROM `+row.table+` WHERE target = ? AND `+key+` = ?`, row.target, row.key); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func upsertRow(tx *sql.Tx, row sqliteRow, name, value string) error {
+	var err error
+	switch row.table {
+	case "tools":
+		_, err = tx.Exec(`INSERT INTO tools (target, id, name, definition) VALUES (?, ?, ?, ?)
+			ON CONFLICT (target, id) DO UPDATE SET name = excluded.name, definition = excluded.definition`,
+			row.target, row.key, name, value)
+	case "groups":
+		_, err = tx.Exec(`INSERT INTO groups (target, name, definition) VALUES (?, ?, ?)
+			ON CONFLICT (target, name) DO UPDATE SET definition = excluded.definition`,
+			row.target, row.key, value)
+	case "usage":
+		var u toolUsage
+		if err := json.Unmarshal([]byte(value), &u); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO usage (target, tool_id, use_count, last_used, error_count, last_error, last_error_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (target, tool_id) DO UPDATE SET use_count = excluded.use_count, last_used = excluded.last_used,
+				error_count = excluded.error_count, last_error = excluded.last_error, last_error_at = excluded.last_error_at`,
+			row.target, row.key, u.UseCount, u.LastUsed.Format(time.RFC3339Nano), u.ErrorCount, u.LastError, u.LastErrorAt.Format(time.RFC3339Nano))
+	}
+	return err
+}
+
+// setAside moves the database out of the way and starts an empty one in
+// its place.
+func (st *sqliteStore) setAside() (string, error) {
+	st.db.Close()
+	aside, err := setAsideCorrupt(st.path)
+	if err != nil {
+		return "", err
+	}
+	fresh, err := openSQLite(st.path)
+	if err != nil {
+		return "", err
+	}
+	st.db, st.written = fresh.db, fresh.written
+	return aside, nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var ErrUnknownStore = errors.New("unknown store")
+
+// Store kinds of --store.
+const (
+	StoreJSON   = "json"
+	StoreSQLite = "sqlite"
+)
+
+// ParseStore parses a --store value: "json", the config file, or
+// "sqlite:PATH", a SQLite database at PATH. It returns the kind and, for
+// sqlite, the path.
+func ParseStore(spec string) (kind, path string, err error) {
+	kind, path, _ = strings.Cut(spec, ":")
+	switch {
+	case kind == StoreJSON && path == "":
+		return kind, "", nil
+	case kind == StoreSQLite && path != "":
+		return kind, path, nil
+	}
+	return "", "", fmt.Errorf("%w %q (want json or sqlite:PATH)", ErrUnknownStore, spec)
+}
+
+// store is where a config is kept between runs.
+type store interface {
+	// read returns the config as a JSON document, failing with
+	// fs.ErrNotExist when none was stored yet.
+	read() ([]byte, error)
+	// prepare encodes s, while the config is locked, and returns what
+	// writes it once the lock is released.
+	prepare(s *storedConfig) (write func() error, err error)
+	// setAside moves a config that couldn't be parsed out of the way and
+	// returns where to.
+	setAside() (string, error)
+}
+
+// fileStore keeps the config in a JSON file, rewritten whole on each save.
+type fileStore struct {
+	path string
+}
+
+func (f fileStore) read() ([]byte, error) {
+	return os.ReadFile(f.path)
+}
+
+func (f fileStore) prepare(s *storedConfig) (func() error, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return func() error {
+		if err := writeFileAtomic(f.path, data, 0644); err != nil {
+			return fmt.Errorf("write config: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+func (f fileStore) setAside() (string, error) {
+	return setAsideCorrupt(f.path)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Subset returns a standalone copy of the config holding only the tools
+// keep reports true for, to run on another machine. Groups keep only
+// those tools, and groups left without any are dropped. Bodies and
+// examples are inline, and other targets' tools, profiles, usage counts
+// and history are left out: each tool starts a history of its own with an
+// import revision.
+func (c *Config) Subset(keep func(*Tool) bool) (*Config, error) {
+	data, err := c.Export(true)
+	if err != nil {
+		return nil, err
+	}
+	sub := &Config{}
+	if err := json.Unmarshal(data, sub); err != nil {
+		return nil, err
+	}
+	// Only the active target's tools are picked from
+	if err := sub.activateTarget(c.ActiveTarget()); err != nil {
+		return nil, err
+	}
+	sub.Targets = nil
+
+	now := time.Now()
+	sub.Profiles = nil
+	sub.names = make(map[string]string)
+	for id, tool := range sub.Tools {
+		if !keep(tool) {
+			delete(sub.Tools, id)
+			continue
+		}
+		tool.UseCount = 0
+		tool.LastUsed = time.Time{}
+		tool.ErrorCount = 0
+		tool.LastError = ""
+		tool.LastErrorAt = time.Time{}
+		tool.History = nil
+		sub.appendRevision(tool, Revision{At: now, Source: HistoryImport})
+		sub.names[tool.Name] = id
+	}
+	for name, group := range sub.Groups {
+		group.ToolIDs = slices.DeleteFunc(group.ToolIDs, func(id string) bool { return sub.Tools[id] == nil })
+		if len(group.ToolIDs) == 0 {
+			delete(sub.Groups, name)
+			continue
+		}
+		group.UseCount = 0
+		group.LastUsed = time.Time{}
+	}
+	return sub, nil
+}
+
+// SecretRefs returns the environment variables the config's
+// ${secret:NAME} references read, sorted: those in auth_query and in the
+// tools' headers, query parameters and bodies.
+func (c *Config) SecretRefs() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := make(map[string]bool)
+	add := func(value string) {
+		for _, match := range secretRef.FindAllStringSubmatch(value, -1) {
+			names[match[1]] = true
+		}
+	}
+	for _, value := range c.AuthQuery {
+		add(value)
+	}
+	for _, tool := range c.Tools {
+		for _, value := range tool.Headers {
+			add(value)
+		}
+		for _, value := range tool.QueryParams {
+			add(value)
+		}
+		add(tool.Body)
+	}
+	return slices.Sorted(maps.Keys(names))
+}
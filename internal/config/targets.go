@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// TargetKey returns the key the tools captured from target are kept
+// under: its scheme and host, lowercased, without a default port. A
+// target that isn't a URL is its own key.
+func TargetKey(target string) string {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(target))
+	}
+	scheme, host := strings.ToLower(u.Scheme), strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return scheme + "://" + host
+}
+
+// targetCatalog is one target's tools and groups, as stored in Targets.
+type targetCatalog struct {
+	Tools  map[string]*Tool  `json:"tools"`
+	Groups map[string]*Group `json:"groups,omitempty"`
+}
+
+// TargetInfo describes the active target for /debug.
+type TargetInfo struct {
+	Active string `json:"active"`
+	Tools  int    `json:"tools"`
+	// Others are the targets whose tools are kept but not served.
+	Others []string `json:"others,omitempty"`
+}
+
+// ActiveTarget returns the key of the target whose tools Tools holds.
+func (c *Config) ActiveTarget() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.target
+}
+
+// TargetInfo returns the active target and the others kept.
+func (c *Config) TargetInfo() TargetInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return TargetInfo{Active: c.target, Tools: len(c.Tools), Others: slices.Sorted(maps.Keys(c.Targets))}
+}
+
+// SelectTarget makes target's tools and groups the ones Tools and Groups
+// hold, keeping the current ones under their own target. Tools kept
+// without a target, as from a config that never had one, are adopted by
+// the first target selected. It reports whether the active target
+// changed.
+func (c *Config) SelectTarget(target string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := TargetKey(target)
+	if key == c.target {
+		return false, nil
+	}
+	if _, known := c.Targets[key]; c.target == "" && !known {
+		c.target = key
+		return true, nil
+	}
+	if err := c.stashTarget(); err != nil {
+		return false, err
+	}
+	if err := c.activateTarget(key); err != nil {
+		return false, err
+	}
+	c.resolveBlobs()
+	return true, nil
+}
+
+// stashTarget moves the active catalog to Targets, in its stored form.
+// c.mu must be held for writing.
+func (c *Config) stashTarget() error {
+	if len(c.Tools) == 0 && len(c.Groups) == 0 {
+		return nil
+	}
+	data, err := c.storedCatalog(false)
+	if err != nil {
+		return err
+	}
+	if c.Targets == nil {
+		c.Targets = make(map[string]json.RawMessage)
+	}
+	c.Targets[c.target] = data
+	c.Tools, c.Groups = make(map[string]*Tool), make(map[string]*Group)
+	c.names = make(map[string]string)
+	return nil
+}
+
+// activateTarget loads key's catalog from Targets into Tools and Groups,
+// which must be empty. c.mu must be held for writing, or c not be shared
+// yet.
+func (c *Config) activateTarget(key string) error {
+	c.target = key
+	if data, ok := c.Targets[key]; ok {
+		var catalog targetCatalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return fmt.Errorf("load tools of %s: %w", key, err)
+		}
+		delete(c.Targets, key)
+		if catalog.Tools != nil {
+			c.Tools = catalog.Tools
+		}
+		if catalog.Groups != nil {
+			c.Groups = catalog.Groups
+		}
+	}
+	c.migrateToolIDs()
+	return nil
+}
+
+// splitTargets moves the tools of a config written before tools were kept
+// per target to the catalog of the target their URL points at. Tools of
+// the active target, and ones without a URL, stay. Groups go with their
+// tools, into every catalog holding one. It returns how many tools moved.
+// c.mu must be held for writing, or c not be shared yet.
+func (c *Config) splitTargets() (int, error) {
+	catalogs := make(map[string]*targetCatalog)
+	for id, tool := range c.Tools {
+		key := TargetKey(tool.URL)
+		if key == "" || key == c.target || !strings.Contains(key, "://") {
+			continue
+		}
+		if catalogs[key] == nil {
+			catalogs[key] = &targetCatalog{Tools: make(map[string]*Tool), Groups: make(map[string]*Group)}
+		}
+		catalogs[key].Tools[id] = tool
+	}
+	if len(catalogs) == 0 {
+		return 0, nil
+	}
+
+	moved := 0
+	for key, catalog := range catalogs {
+		for name, group := range c.Groups {
+			var members []string
+			for _, id := range group.ToolIDs {
+				if catalog.Tools[id] != nil {
+					members = append(members, id)
+				}
+			}
+			if len(members) > 0 {
+				copied := *group
+				copied.ToolIDs = members
+				catalog.Groups[name] = &copied
+			}
+		}
+		for id := range catalog.Tools {
+			delete(c.Tools, id)
+			moved++
+		}
+
+		// Stored through the active catalog, so bodies go to blobs the
+		// same way
+		tools, groups := c.Tools, c.Groups
+		c.Tools, c.Groups = catalog.Tools, catalog.Groups
+		data, err := c.storedCatalog(false)
+		c.Tools, c.Groups = tools, groups
+		if err != nil {
+			return 0, err
+		}
+		if c.Targets == nil {
+			c.Targets = make(map[string]json.RawMessage)
+		}
+		c.Targets[key] = data
+	}
+	for name, group := range c.Groups {
+		group.ToolIDs = slices.DeleteFunc(group.ToolIDs, func(id string) bool { return c.Tools[id] == nil })
+		if len(group.ToolIDs) == 0 {
+			delete(c.Groups, name)
+		}
+	}
+	c.migrateToolIDs()
+	return moved, nil
+}
+
+// inlineTargets returns Targets with every body and example read back
+// from the blob store, for a portable export. c.mu must be held.
+func (c *Config) inlineTargets() (map[string]json.RawMessage, error) {
+	if c.blobs == nil || len(c.Targets) == 0 {
+		return c.Targets, nil
+	}
+	targets := make(map[string]json.RawMessage, len(c.Targets))
+	for key, data := range c.Targets {
+		var catalog targetCatalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("export tools of %s: %w", key, err)
+		}
+		for _, tool := range catalog.Tools {
+			inline(c.blobs, &tool.Body, &tool.BodyRef)
+			if tool.Response != nil {
+				inline(c.blobs, &tool.Response.Body, &tool.Response.BodyRef)
+			}
+		}
+		inlined, err := json.Marshal(catalog)
+		if err != nil {
+			return nil, fmt.Errorf("export tools of %s: %w", key, err)
+		}
+		targets[key] = inlined
+	}
+	return targets, nil
+}
+
+// inline replaces a readable blob reference with the blob's content.
+func inline(blobs BlobStore, body, ref *string) {
+	if *ref == "" {
+		return
+	}
+	if data, err := blobs.Get(*ref); err == nil {
+		*body, *ref = string(data), ""
+	}
+}
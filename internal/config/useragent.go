@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Version is the mcpify version reported to MCP clients and targets.
+// Release builds set it with -ldflags "-X ...config.Version=1.2.3".
+var Version = "1.0.0"
+
+// MarkerHeader is set on every request mcpify sends itself: tool calls,
+// verification probes and the capture self-test. Its value says what sent
+// the request, e.g. "tool=list_users". Targets can use it to tell mcpify
+// traffic apart, and capture never records requests carrying it.
+const MarkerHeader = "X-Mcpify"
+
+// DefaultUserAgent is the User-Agent mcpify calls the named tool with.
+func DefaultUserAgent(tool string) string {
+	return fmt.Sprintf("mcpify/%s (+tool:%s)", Version, tool)
+}
+
+// UserAgentFor returns the User-Agent calls to tool are sent with: the
+// tool's override, then the config's, then the captured one if preserve
+// is set, and DefaultUserAgent otherwise.
+func (c *Config) UserAgentFor(tool *Tool, preserve bool) string {
+	if tool.UserAgent != "" {
+		return tool.UserAgent
+	}
+	if c.UserAgent != "" {
+		return c.UserAgent
+	}
+	if preserve {
+		for name, value := range tool.Headers {
+			if strings.EqualFold(name, "User-Agent") && value != "" {
+				return value
+			}
+		}
+	}
+	return DefaultUserAgent(tool.Name)
+}
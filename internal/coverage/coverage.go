@@ -0,0 +1,298 @@
+// Package coverage measures how much of the catalog tool calls exercise
+// within named scenarios, such as one run of an agent evaluation.
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+var (
+	ErrScenarioNotFound = errors.New("scenario not found")
+	ErrInvalidAction    = errors.New("invalid scenario action")
+)
+
+// Tracker records tool calls in every open scenario. Scenarios only live
+// in memory. A nil *Tracker records nothing.
+type Tracker struct {
+	mu        sync.Mutex
+	scenarios map[string]*scenario
+}
+
+type scenario struct {
+	started time.Time
+	stopped time.Time
+	tools   map[string]*toolCalls // by tool ID
+}
+
+type toolCalls struct {
+	calls     int
+	successes int
+	endpoints map[string]bool
+	params    map[string]bool
+}
+
+// Scenario is a scenario's window as the API reports it.
+type Scenario struct {
+	Name    string     `json:"name"`
+	Started time.Time  `json:"started"`
+	Stopped *time.Time `json:"stopped,omitempty"`
+	Active  bool       `json:"active"`
+	Calls   int        `json:"calls"`
+}
+
+func New() *Tracker {
+	return &Tracker{scenarios: make(map[string]*scenario)}
+}
+
+// Start opens the scenario name, discarding the calls of an earlier
+// scenario of that name.
+func (t *Tracker) Start(name string) Scenario {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &scenario{started: time.Now(), tools: make(map[string]*toolCalls)}
+	t.scenarios[name] = s
+	return s.summary(name)
+}
+
+// Stop closes the scenario name. Its calls are kept for reports.
+func (t *Tracker) Stop(name string) (Scenario, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.scenarios[name]
+	if s == nil {
+		return Scenario{}, fmt.Errorf("%w: %s", ErrScenarioNotFound, name)
+	}
+	if s.stopped.IsZero() {
+		s.stopped = time.Now()
+	}
+	return s.summary(name), nil
+}
+
+// Scenarios lists every scenario, most recently started first.
+func (t *Tracker) Scenarios() []Scenario {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]Scenario, 0, len(t.scenarios))
+	for name, s := range t.scenarios {
+		list = append(list, s.summary(name))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Started.Equal(list[j].Started) {
+			return list[i].Started.After(list[j].Started)
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func (s *scenario) summary(name string) Scenario {
+	summary := Scenario{Name: name, Started: s.started, Active: s.stopped.IsZero()}
+	if !summary.Active {
+		stopped := s.stopped
+		summary.Stopped = &stopped
+	}
+	for _, calls := range s.tools {
+		summary.Calls += calls.calls
+	}
+	return summary
+}
+
+// Record counts a call of the tool with ID toolID in every open scenario.
+// endpoint is the method and path called, and params the names of the
+// path and query parameters it was called with.
+func (t *Tracker) Record(toolID, endpoint string, params []string, ok bool) {
+	if t == nil {
+		return
+	}
+	sorted := append([]string(nil), params...)
+	sort.Strings(sorted)
+	combination, _ := json.Marshal(sorted)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.scenarios {
+		if !s.stopped.IsZero() {
+			continue
+		}
+		calls := s.tools[toolID]
+		if calls == nil {
+			calls = &toolCalls{endpoints: make(map[string]bool), params: make(map[string]bool)}
+			s.tools[toolID] = calls
+		}
+		calls.calls++
+		if ok {
+			calls.successes++
+		}
+		calls.endpoints[endpoint] = true
+		calls.params[string(combination)] = true
+	}
+}
+
+// Report is a scenario's coverage of the catalog. Lists are sorted and
+// never null, so thresholds can be asserted on the JSON.
+type Report struct {
+	Scenario
+	Summary   Summary         `json:"summary"`
+	Groups    []GroupCoverage `json:"groups"`
+	Tools     []ToolCoverage  `json:"tools"`
+	Untouched []string        `json:"untouched"`
+}
+
+type Summary struct {
+	Tools             int     `json:"tools"`
+	ToolsCalled       int     `json:"tools_called"`
+	CoveragePercent   float64 `json:"coverage_percent"`
+	Endpoints         int     `json:"endpoints"`
+	ParamCombinations int     `json:"param_combinations"`
+	Calls             int     `json:"calls"`
+	Successes         int     `json:"successes"`
+	SuccessPercent    float64 `json:"success_percent"`
+}
+
+type GroupCoverage struct {
+	Name            string   `json:"name"`
+	Tools           int      `json:"tools"`
+	ToolsCalled     int      `json:"tools_called"`
+	CoveragePercent float64  `json:"coverage_percent"`
+	Untouched       []string `json:"untouched"`
+}
+
+// ToolCoverage covers a tool called in the scenario.
+type ToolCoverage struct {
+	Tool              string  `json:"tool"`
+	Calls             int     `json:"calls"`
+	Successes         int     `json:"successes"`
+	SuccessPercent    float64 `json:"success_percent"`
+	Endpoints         int     `json:"endpoints"`
+	ParamCombinations int     `json:"param_combinations"`
+}
+
+// Report compares the calls of scenario name with the catalog in cfg.
+// Calls of tools removed since are left out.
+func (t *Tracker) Report(cfg *config.Config, name string) (*Report, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.scenarios[name]
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s", ErrScenarioNotFound, name)
+	}
+
+	report := &Report{
+		Scenario:  s.summary(name),
+		Groups:    []GroupCoverage{},
+		Tools:     []ToolCoverage{},
+		Untouched: []string{},
+	}
+	for _, tool := range cfg.ListTools() {
+		report.Summary.Tools++
+		calls := s.tools[tool.ID]
+		if calls == nil {
+			report.Untouched = append(report.Untouched, tool.Name)
+			continue
+		}
+		report.Summary.ToolsCalled++
+		report.Summary.Endpoints += len(calls.endpoints)
+		report.Summary.ParamCombinations += len(calls.params)
+		report.Summary.Calls += calls.calls
+		report.Summary.Successes += calls.successes
+		report.Tools = append(report.Tools, ToolCoverage{
+			Tool:              tool.Name,
+			Calls:             calls.calls,
+			Successes:         calls.successes,
+			SuccessPercent:    percent(calls.successes, calls.calls),
+			Endpoints:         len(calls.endpoints),
+			ParamCombinations: len(calls.params),
+		})
+	}
+	report.Calls = report.Summary.Calls
+	report.Summary.CoveragePercent = percent(report.Summary.ToolsCalled, report.Summary.Tools)
+	report.Summary.SuccessPercent = percent(report.Summary.Successes, report.Summary.Calls)
+	sort.Strings(report.Untouched)
+	sort.Slice(report.Tools, func(i, j int) bool { return report.Tools[i].Tool < report.Tools[j].Tool })
+
+	for _, group := range cfg.ListGroups() {
+		coverage := GroupCoverage{Name: group.Name, Untouched: []string{}}
+		for _, tool := range cfg.GetToolsInGroup(group.Name) {
+			coverage.Tools++
+			if s.tools[tool.ID] != nil {
+				coverage.ToolsCalled++
+			} else {
+				coverage.Untouched = append(coverage.Untouched, tool.Name)
+			}
+		}
+		coverage.CoveragePercent = percent(coverage.ToolsCalled, coverage.Tools)
+		sort.Strings(coverage.Untouched)
+		report.Groups = append(report.Groups, coverage)
+	}
+	sort.Slice(report.Groups, func(i, j int) bool { return report.Groups[i].Name < report.Groups[j].Name })
+	return report, nil
+}
+
+// percent is n of total as a percentage with one decimal, and 0 when
+// total is.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(n)*1000/float64(total)) / 10
+}
+
+// ScenariosHandler serves GET /api/scenarios, and POST
+// /api/scenarios/{name}/{action} with action start or stop.
+func (t *Tracker) ScenariosHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusOK, t.Scenarios())
+			return
+		}
+
+		name := r.PathValue("name")
+		switch action := r.PathValue("action"); action {
+		case "start":
+			writeJSON(w, http.StatusOK, t.Start(name))
+		case "stop":
+			s, err := t.Stop(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, s)
+		default:
+			http.Error(w, fmt.Sprintf("%v %q (want start or stop)", ErrInvalidAction, action), http.StatusBadRequest)
+		}
+	})
+}
+
+// Handler serves GET /api/coverage?scenario=name.
+func (t *Tracker) Handler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("scenario")
+		if name == "" {
+			http.Error(w, "missing scenario parameter", http.StatusBadRequest)
+			return
+		}
+		report, err := t.Report(cfg, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, report)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
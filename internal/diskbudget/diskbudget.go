@@ -0,0 +1,376 @@
+// Package diskbudget keeps the files mcpify writes next to its config
+// within a size budget, deleting the oldest expendable ones first.
+package diskbudget
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultTotal    = 256 << 20
+	DefaultInterval = 10 * time.Minute
+
+	// warnRatio is the share of the budget above which every check warns
+	// that cleanup is coming.
+	warnRatio = 0.9
+)
+
+var ErrInvalidBudget = errors.New("invalid disk budget")
+
+// Categories of files under the config directory.
+const (
+	Config  = "config"
+	State   = "state"
+	Backups = "backups"
+	Audit   = "audit"
+	Cache   = "cache"
+	// Blobs are the bodies and examples the config refers to. Unreferenced
+	// ones are deleted by the collector, never by the budget.
+	Blobs = "blobs"
+)
+
+// cleanupOrder lists the categories files may be deleted from, in the
+// order they are deleted. The live config, its blobs and state are never
+// deleted.
+var cleanupOrder = []string{Backups, Audit, Cache}
+
+// stateFiles are the small files mcpify keeps next to the config: the
+// update-check state and the capture proxy's self-signed certificate.
+var stateFiles = map[string]bool{
+	"update-check.json": true,
+	"proxy-cert.pem":    true,
+	"proxy-key.pem":     true,
+}
+
+// Budget is the total cap and the optional per-category caps, in bytes.
+// A zero Total disables cleanup; usage is still accounted.
+type Budget struct {
+	Total int64
+	Caps  map[string]int64
+}
+
+// Parse parses a total like "256MB" and caps like "backups=20MB,cache=100MB".
+func Parse(total, caps string) (Budget, error) {
+	b := Budget{Caps: make(map[string]int64)}
+	var err error
+	if b.Total, err = ParseSize(total); err != nil {
+		return Budget{}, err
+	}
+	for _, part := range strings.Split(caps, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Budget{}, fmt.Errorf("%w: expected category=size, got %q", ErrInvalidBudget, part)
+		}
+		name = strings.TrimSpace(name)
+		if !deletable(name) {
+			return Budget{}, fmt.Errorf("%w: unknown category %q (want %s)", ErrInvalidBudget, name, strings.Join(cleanupOrder, ", "))
+		}
+		if b.Caps[name], err = ParseSize(value); err != nil {
+			return Budget{}, err
+		}
+	}
+	return b, nil
+}
+
+var units = []struct {
+	suffix string
+	size   float64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size such as "512KB", "256MB" or "1.5GB". Units are
+// binary; a bare number is bytes.
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			multiplier = u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: size %q", ErrInvalidBudget, s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// FormatSize formats n bytes with the largest unit it fills.
+func FormatSize(n int64) string {
+	for _, u := range units {
+		if float64(n) >= u.size && u.size > 1 {
+			return strconv.FormatFloat(float64(n)/u.size, 'f', 1, 64) + " " + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10) + " B"
+}
+
+func deletable(category string) bool {
+	for _, name := range cleanupOrder {
+		if name == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is the accounting of the last check.
+type Usage struct {
+	Dir        string          `json:"dir"`
+	Bytes      int64           `json:"bytes"`
+	Budget     int64           `json:"budget_bytes"`
+	Categories []CategoryUsage `json:"categories"`
+	CheckedAt  time.Time       `json:"checked_at"`
+	// Deleted counts the files deleted since startup.
+	Deleted int `json:"deleted_files"`
+}
+
+type CategoryUsage struct {
+	Name      string `json:"name"`
+	Bytes     int64  `json:"bytes"`
+	Files     int    `json:"files"`
+	Cap       int64  `json:"cap_bytes,omitempty"`
+	Protected bool   `json:"protected,omitempty"`
+}
+
+type file struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Manager accounts for and trims the directory of one config file. Only
+// files it recognises are counted or deleted; anything else in the
+// directory is left alone.
+type Manager struct {
+	dir        string
+	configName string
+	budget     Budget
+
+	mu      sync.Mutex
+	usage   Usage
+	deleted int
+	// collect deletes unreferenced blobs before each check
+	collect func() (int, error)
+}
+
+func New(configPath string, budget Budget) *Manager {
+	return &Manager{
+		dir:        filepath.Dir(configPath),
+		configName: filepath.Base(configPath),
+		budget:     budget,
+	}
+}
+
+// SetCollector has every check call collect first, to delete blobs
+// nothing refers to any more, e.g. after their backups were deleted.
+func (m *Manager) SetCollector(collect func() (int, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collect = collect
+}
+
+// category returns the category of the file at rel, a slash-separated
+// path relative to the directory, or "" when mcpify didn't write it.
+func (m *Manager) category(rel string) string {
+	top, _, nested := strings.Cut(rel, "/")
+	switch {
+	case !nested && rel == m.configName:
+		return Config
+	case !nested && strings.HasPrefix(rel, m.configName+".bak"):
+		return Backups
+	case !nested && stateFiles[rel]:
+		return State
+	case nested && (top == Backups || top == Audit || top == Cache || top == Blobs):
+		return top
+	}
+	return ""
+}
+
+// scan lists the recognised files by category, oldest first. Symlinks
+// are neither followed nor counted.
+func (m *Manager) scan() (map[string][]file, error) {
+	files := make(map[string][]file)
+	err := filepath.WalkDir(m.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == m.dir {
+				return err
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(m.dir, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Only the category directories are descended into
+			if top, _, _ := strings.Cut(rel, "/"); path != m.dir && !deletable(top) && top != Blobs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		category := m.category(rel)
+		if category == "" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[category] = append(files[category], file{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, list := range files {
+		sort.Slice(list, func(i, j int) bool {
+			if !list[i].modTime.Equal(list[j].modTime) {
+				return list[i].modTime.Before(list[j].modTime)
+			}
+			return list[i].path < list[j].path
+		})
+	}
+	return files, nil
+}
+
+// Check accounts for the directory and, when a cap is exceeded, deletes
+// the oldest files of the category over its cap, then the oldest
+// backups, audit segments and cache entries in turn until the total fits.
+func (m *Manager) Check() (Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.collect != nil {
+		if n, err := m.collect(); err != nil {
+			slog.Error("Disk budget: failed to delete unreferenced blobs", "error", err)
+		} else if n > 0 {
+			slog.Info("Disk budget: deleted unreferenced blobs", "count", n)
+		}
+	}
+
+	files, err := m.scan()
+	if err != nil {
+		return m.usage, err
+	}
+	total := int64(0)
+	bytes := make(map[string]int64)
+	for category, list := range files {
+		for _, f := range list {
+			bytes[category] += f.size
+		}
+		total += bytes[category]
+	}
+
+	trim := func(category string, over int64, reason string) {
+		var victims []file
+		for _, f := range files[category] {
+			if over <= 0 {
+				break
+			}
+			victims = append(victims, f)
+			over -= f.size
+		}
+		if len(victims) == 0 {
+			return
+		}
+		size := int64(0)
+		for _, f := range victims {
+			size += f.size
+		}
+		slog.Warn("Disk budget exceeded; deleting the oldest files", "reason", reason, "files", len(victims), "category", category, "size", FormatSize(size), "dir", m.dir)
+		for _, f := range victims {
+			slog.Warn("Disk budget: deleting file", "path", f.path, "size", FormatSize(f.size), "modified", f.modTime.Format(time.DateTime))
+			if err := os.Remove(f.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				slog.Error("Disk budget: failed to delete file", "path", f.path, "error", err)
+				continue
+			}
+			files[category] = files[category][1:]
+			bytes[category] -= f.size
+			total -= f.size
+			m.deleted++
+		}
+	}
+
+	if m.budget.Total > 0 {
+		for _, category := range cleanupOrder {
+			if limit := m.budget.Caps[category]; limit > 0 && bytes[category] > limit {
+				trim(category, bytes[category]-limit, fmt.Sprintf("%s uses %s of its %s cap", category, FormatSize(bytes[category]), FormatSize(limit)))
+			}
+		}
+		for _, category := range cleanupOrder {
+			if total <= m.budget.Total {
+				break
+			}
+			trim(category, total-m.budget.Total, fmt.Sprintf("%s uses %s of its %s budget", m.dir, FormatSize(total), FormatSize(m.budget.Total)))
+		}
+		switch {
+		case total > m.budget.Total:
+			slog.Warn("Disk budget still exceeded; only the live config, its blobs and state are left, which are never deleted", "dir", m.dir, "used", FormatSize(total), "budget", FormatSize(m.budget.Total))
+		case float64(total) >= warnRatio*float64(m.budget.Total):
+			slog.Warn("Disk budget nearly used; the oldest backups, audit segments and cache entries are deleted once it is exceeded", "dir", m.dir, "used", FormatSize(total), "budget", FormatSize(m.budget.Total))
+		}
+	}
+
+	usage := Usage{Dir: m.dir, Bytes: total, Budget: m.budget.Total, CheckedAt: time.Now(), Deleted: m.deleted}
+	for _, category := range []string{Config, State, Blobs, Backups, Audit, Cache} {
+		usage.Categories = append(usage.Categories, CategoryUsage{
+			Name:      category,
+			Bytes:     bytes[category],
+			Files:     len(files[category]),
+			Cap:       m.budget.Caps[category],
+			Protected: !deletable(category),
+		})
+	}
+	m.usage = usage
+	return usage, nil
+}
+
+// Usage returns the accounting of the last check.
+func (m *Manager) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage
+}
+
+// Run checks the directory now and every interval until ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if _, err := m.Check(); err != nil {
+		slog.Error("Disk budget check failed", "error", err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Check(); err != nil {
+				slog.Error("Disk budget check failed", "error", err)
+			}
+		}
+	}
+}
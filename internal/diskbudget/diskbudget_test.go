@@ -0,0 +1,118 @@
+package diskbudget
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFiles make up a config directory of 1KB files, oldest first; the
+// live config is the newest. notes.txt isn't mcpify's.
+var testFiles = []string{
+	"audit/2025-01-01.jsonl",
+	"config.json.bak.v1",
+	"cache/names.json",
+	"backups/config-1.json",
+	"audit/2025-01-02.jsonl",
+	"backups/config-2.json",
+	"cache/specs.json",
+	"blobs/ab/abcdef",
+	"update-check.json",
+	"proxy-cert.pem",
+	"notes.txt",
+	"config.json",
+}
+
+func writeTestDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	for i, name := range testFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 1<<10)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		at := start.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// remaining lists the test files still in dir.
+func remaining(dir string) []string {
+	var left []string
+	for _, name := range testFiles {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			left = append(left, name)
+		}
+	}
+	return left
+}
+
+func TestCheckDeletesInCleanupOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  Budget
+		deleted []string
+	}{
+		{
+			name:   "within budget",
+			budget: Budget{Total: 11 << 10},
+		},
+		{
+			name:    "oldest backups first",
+			budget:  Budget{Total: 9 << 10},
+			deleted: []string{"config.json.bak.v1", "backups/config-1.json"},
+		},
+		{
+			name:    "all backups, then the oldest audit segment",
+			budget:  Budget{Total: 7 << 10},
+			deleted: []string{"config.json.bak.v1", "backups/config-1.json", "backups/config-2.json", "audit/2025-01-01.jsonl"},
+		},
+		{
+			name:    "category cap before the total",
+			budget:  Budget{Total: 100 << 10, Caps: map[string]int64{Cache: 1 << 10}},
+			deleted: []string{"cache/names.json"},
+		},
+		{
+			name:    "budget below the live catalog",
+			budget:  Budget{Total: 1},
+			deleted: []string{"audit/2025-01-01.jsonl", "config.json.bak.v1", "cache/names.json", "backups/config-1.json", "audit/2025-01-02.jsonl", "backups/config-2.json", "cache/specs.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTestDir(t)
+			m := New(filepath.Join(dir, "config.json"), tt.budget)
+			usage, err := m.Check()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := slices.DeleteFunc(slices.Clone(testFiles), func(name string) bool {
+				return slices.Contains(tt.deleted, name)
+			})
+			if got := remaining(dir); !slices.Equal(got, want) {
+				t.Errorf("left %v, want %v", got, want)
+			}
+			if usage.Deleted != len(tt.deleted) {
+				t.Errorf("usage counts %d deleted files, want %d", usage.Deleted, len(tt.deleted))
+			}
+			// The live config, its blobs, state and unknown files are untouched
+			for _, name := range []string{"config.json", "blobs/ab/abcdef", "update-check.json", "proxy-cert.pem", "notes.txt"} {
+				if !slices.Contains(remaining(dir), name) {
+					t.Errorf("%s was deleted", name)
+				}
+			}
+		})
+	}
+}
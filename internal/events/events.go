@@ -0,0 +1,197 @@
+// Package events is an in-process bus of server activity. Capture,
+// registration, grouping and tool execution publish to it, and clients
+// follow it as a JSON-lines stream at /api/events.
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Version is the event format version. Within a version, types and
+// payload fields are only ever added, never renamed or removed.
+const Version = 1
+
+// Event types and their payload fields.
+const (
+	// ServerStarted: transport, addr, tools
+	ServerStarted = "server.started"
+	// ServerStopping: no payload
+	ServerStopping = "server.stopping"
+	// EndpointDiscovered: method, path
+	EndpointDiscovered = "endpoint.discovered"
+	// EndpointHostConflict: method, path, hosts - the endpoint was
+	// requested under more than one host name
+	EndpointHostConflict = "endpoint.host_conflict"
+	// ToolRegistered: tool, method, url
+	ToolRegistered = "tool.registered"
+	// ToolRegistrationFailed: tool, method, url, error
+	ToolRegistrationFailed = "tool.registration_failed"
+	// GroupsRebuilt: groups, tools
+	GroupsRebuilt = "groups.rebuilt"
+	// ToolCallFailed: tool, status (0 without a response), error, and
+	// replica when the call was routed to one
+	ToolCallFailed = "tool.call_failed"
+	// ReplicaDown: replica, error - the replica kept failing and was
+	// taken out of rotation
+	ReplicaDown = "replica.down"
+	// ReplicaUp: replica - a replica out of rotation answered again
+	ReplicaUp = "replica.up"
+	// EventsDropped: after, before - events between those IDs were
+	// evicted before a replaying client asked for them
+	EventsDropped = "events.dropped"
+)
+
+// DefaultHistory is how many events are kept for replay.
+const DefaultHistory = 1024
+
+// subscriberBuffer is how far a client may fall behind before it is
+// disconnected; it reconnects with ?since= to catch up.
+const subscriberBuffer = 256
+
+type Event struct {
+	ID      uint64    `json:"id"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Version int       `json:"version"`
+	Payload any       `json:"payload,omitempty"`
+}
+
+// Bus fans events out to subscribers and keeps the latest ones for
+// replay. A nil *Bus drops everything published to it.
+type Bus struct {
+	mu      sync.Mutex
+	history []Event
+	size    int
+	lastID  uint64
+	subs    map[chan Event]struct{}
+}
+
+func NewBus(size int) *Bus {
+	return &Bus{
+		size: size,
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish records an event and passes it to every subscriber. It never
+// blocks: subscribers that can't keep up are disconnected.
+func (b *Bus) Publish(typ string, payload any) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	event := Event{ID: b.lastID, Type: typ, Time: time.Now(), Version: Version, Payload: payload}
+	b.history = append(b.history, event)
+	if len(b.history) > b.size {
+		b.history = b.history[len(b.history)-b.size:]
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the kept events after since and a channel of the
+// ones that follow. The channel is closed when the subscriber falls too
+// far behind or cancel is called.
+func (b *Bus) Subscribe(since uint64) (backlog []Event, ch <-chan Event, cancel func()) {
+	return b.subscribe(since, true)
+}
+
+func (b *Bus) subscribe(since uint64, replay bool) (backlog []Event, ch <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !replay {
+		since = b.lastID
+	}
+	if since > 0 && len(b.history) > 0 && b.history[0].ID > since+1 {
+		backlog = append(backlog, Event{
+			Type:    EventsDropped,
+			Time:    time.Now(),
+			Version: Version,
+			Payload: map[string]uint64{"after": since, "before": b.history[0].ID},
+		})
+	}
+	for _, event := range b.history {
+		if event.ID > since {
+			backlog = append(backlog, event)
+		}
+	}
+
+	sub := make(chan Event, subscriberBuffer)
+	b.subs[sub] = struct{}{}
+	return backlog, sub, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[sub]; ok {
+			delete(b.subs, sub)
+			close(sub)
+		}
+	}
+}
+
+// Handler streams events as JSON lines until the client disconnects.
+// ?since=ID replays the kept events after ID first; without it the stream
+// starts with the next event.
+func (b *Bus) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var since uint64
+		s := r.URL.Query().Get("since")
+		if s != "" {
+			var err error
+			if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+				http.Error(w, "since must be an event ID", http.StatusBadRequest)
+				return
+			}
+		}
+
+		backlog, events, cancel := b.subscribe(since, s != "")
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Cache-Control", "no-cache")
+		flusher, _ := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+		for _, event := range backlog {
+			if enc.Encode(event) != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok || enc.Encode(event) != nil {
+					return
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+		}
+	})
+}
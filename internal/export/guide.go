@@ -0,0 +1,270 @@
+// Package export renders the tool catalog in formats meant for people and
+// other tools.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/redact"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatLLMsTxt  = "llms-txt"
+)
+
+type section struct {
+	title       string
+	description string
+	tools       []*config.Tool
+}
+
+// Guide renders the catalog as a markdown API guide, or in the compact
+// llms.txt convention. The output only depends on the catalog.
+func Guide(cfg *config.Config, name, format string) (string, error) {
+	sections := buildSections(cfg)
+
+	switch format {
+	case FormatMarkdown, "":
+		return markdownGuide(name, cfg.LastTarget, sections), nil
+	case FormatLLMsTxt:
+		return llmsTxt(name, cfg.LastTarget, sections), nil
+	}
+	return "", fmt.Errorf("unknown guide format %q (want %s or %s)", format, FormatMarkdown, FormatLLMsTxt)
+}
+
+// buildSections uses groups when the catalog has them and otherwise groups
+// endpoints by their first meaningful path segment.
+func buildSections(cfg *config.Config) []section {
+	var sections []section
+	grouped := make(map[string]bool)
+
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	for _, name := range groupNames {
+		tools := cfg.GetToolsInGroup(name)
+		if len(tools) == 0 {
+			continue
+		}
+		for _, tool := range tools {
+			grouped[tool.ID] = true
+		}
+		sections = append(sections, section{
+			title:       name,
+			description: cfg.GetGroup(name).Description,
+			tools:       sortTools(tools),
+		})
+	}
+
+	byPrefix := make(map[string][]*config.Tool)
+	for _, tool := range cfg.ListTools() {
+		if !grouped[tool.ID] {
+			prefix := resourcePrefix(toolPath(tool))
+			byPrefix[prefix] = append(byPrefix[prefix], tool)
+		}
+	}
+
+	prefixes := make([]string, 0, len(byPrefix))
+	for prefix := range byPrefix {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		sections = append(sections, section{
+			title: prefix,
+			tools: sortTools(byPrefix[prefix]),
+		})
+	}
+	return sections
+}
+
+func sortTools(tools []*config.Tool) []*config.Tool {
+	sorted := append([]*config.Tool(nil), tools...)
+	sort.Slice(sorted, func(i, j int) bool {
+		pi, pj := toolPath(sorted[i]), toolPath(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+		if sorted[i].Method != sorted[j].Method {
+			return sorted[i].Method < sorted[j].Method
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func toolPath(tool *config.Tool) string {
+	if u, err := url.Parse(tool.URL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return "/"
+}
+
+// resourcePrefix skips version segments, so /v1/users/42 files under
+// "/users".
+func resourcePrefix(path string) string {
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" || segment == "api" || isVersion(segment) {
+			continue
+		}
+		return "/" + segment
+	}
+	return "/"
+}
+
+func isVersion(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == ""
+}
+
+type param struct {
+	name     string
+	location string
+	example  string
+}
+
+// toolParams lists path parameters from the templated path and top-level
+// fields of a captured JSON body.
+func toolParams(tool *config.Tool, body string) []param {
+	var params []param
+
+	_, pathParams := observed.Template(toolPath(tool))
+	names := make([]string, 0, len(pathParams))
+	for name := range pathParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		params = append(params, param{name: name, location: "path", example: pathParams[name]})
+	}
+
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(body), &fields) == nil {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			params = append(params, param{name: key, location: "body", example: jsonExample(fields[key])})
+		}
+	}
+	return params
+}
+
+func jsonExample(v interface{}) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func markdownGuide(name, target string, sections []section) string {
+	secrets := redact.NewDetector()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s API Guide\n\n", name)
+	if target != "" {
+		fmt.Fprintf(&b, "Base URL: `%s`\n\n", target)
+	}
+	b.WriteString("Generated by mcpify from observed traffic. Credentials (Authorization, Cookie,\n")
+	b.WriteString("X-API-Key, X-Auth-Token) are never captured; send your own with each request.\n")
+
+	for _, sec := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", sec.title)
+		if sec.description != "" {
+			fmt.Fprintf(&b, "%s\n\n", sec.description)
+		}
+
+		for _, tool := range sec.tools {
+			template, _ := observed.Template(toolPath(tool))
+			body := secrets.Body(tool.Body)
+
+			fmt.Fprintf(&b, "### `%s %s`\n\n", tool.Method, template)
+			fmt.Fprintf(&b, "Tool: `%s`\n\n", tool.Name)
+			if tool.Description != "" {
+				fmt.Fprintf(&b, "%s\n\n", tool.Description)
+			}
+
+			if params := toolParams(tool, body); len(params) > 0 {
+				b.WriteString("| Parameter | In | Example |\n|-----------|----|---------|\n")
+				for _, p := range params {
+					fmt.Fprintf(&b, "| `%s` | %s | `%s` |\n", p.name, p.location, strings.ReplaceAll(p.example, "|", "\\|"))
+				}
+				b.WriteString("\n")
+			}
+
+			b.WriteString("Example request:\n\n```http\n")
+			fmt.Fprintf(&b, "%s %s\n", tool.Method, toolPath(tool))
+			headerNames := make([]string, 0, len(tool.Headers))
+			for k := range tool.Headers {
+				headerNames = append(headerNames, k)
+			}
+			sort.Strings(headerNames)
+			for _, k := range headerNames {
+				fmt.Fprintf(&b, "%s: %s\n", k, secrets.Text(tool.Headers[k]))
+			}
+			if body != "" {
+				fmt.Fprintf(&b, "\n%s\n", body)
+			}
+			b.WriteString("```\n\n")
+		}
+	}
+
+	return b.String()
+}
+
+func llmsTxt(name, target string, sections []section) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	summary := "HTTP API discovered by mcpify from observed traffic"
+	if target != "" {
+		summary += ", served at " + target
+	}
+	fmt.Fprintf(&b, "> %s.\n", summary)
+
+	for _, sec := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", sec.title)
+		for _, tool := range sec.tools {
+			template, _ := observed.Template(toolPath(tool))
+			line := fmt.Sprintf("- %s %s: %s", tool.Method, template, tool.Name)
+			if tool.Description != "" {
+				line += " — " + strings.ReplaceAll(tool.Description, "\n", " ")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// GuideHandler serves GET /export/guide, with ?format=llms-txt for the
+// compact variant.
+func GuideHandler(cfg *config.Config, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		guide, err := Guide(cfg, name, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		if format == FormatLLMsTxt {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		}
+		w.Write([]byte(guide))
+	}
+}
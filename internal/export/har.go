@@ -0,0 +1,257 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/redact"
+	"github.com/NilayYadav/mcpify/internal/session"
+)
+
+// HARVersion is the HTTP Archive format version HAR writes.
+const HARVersion = "1.2"
+
+type harLog struct {
+	Log harContent `json:"log"`
+}
+
+type harContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harBody        `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harBody struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR renders captured traffic as an HTTP Archive 1.2: one entry per tool,
+// with its captured request and response example, or, given the entries
+// of a session file, one per recorded exchange. Sensitive headers are left
+// out, as they are from tools. Response bodies are the samples kept, and
+// their full size isn't known.
+func HAR(cfg *config.Config, exchanges []session.Entry) ([]byte, error) {
+	h := &harLog{Log: harContent{
+		Version: HARVersion,
+		Creator: harCreator{Name: "mcpify", Version: config.Version},
+		Entries: []harEntry{},
+	}}
+
+	b := &harBuilder{secrets: redact.NewDetector(), sensitive: cfg.SensitiveHeaderNames()}
+	if exchanges != nil {
+		baseURL := strings.TrimSuffix(cfg.LastTarget, "/")
+		for _, e := range exchanges {
+			h.Log.Entries = append(h.Log.Entries, b.exchangeEntry(e, baseURL))
+		}
+	} else {
+		for _, tool := range sortTools(cfg.ListTools()) {
+			h.Log.Entries = append(h.Log.Entries, b.toolEntry(tool))
+		}
+	}
+
+	data, err := encodeJSON(h, "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode HAR: %w", err)
+	}
+	return data, nil
+}
+
+type harBuilder struct {
+	secrets   *redact.Detector
+	sensitive []string
+}
+
+// toolEntry is the request stored for tool, with its path parameters and
+// query parameters filled in from the captured values.
+func (b *harBuilder) toolEntry(tool *config.Tool) harEntry {
+	rawURL, _, _ := strings.Cut(tool.URL, "?")
+	for name, value := range tool.PathParams {
+		if value != "" {
+			rawURL = strings.ReplaceAll(rawURL, "{"+name+"}", url.PathEscape(value))
+		}
+	}
+	started := tool.CreatedAt
+	if tool.Provenance != nil && !tool.Provenance.CapturedAt.IsZero() {
+		started = tool.Provenance.CapturedAt
+	}
+
+	entry := harEntry{
+		StartedDateTime: started.Format(time.RFC3339Nano),
+		Request:         b.request(tool.Method, rawURL, tool.QueryParams, tool.Headers, tool.Body),
+		Response:        noHARResponse(),
+		Comment:         tool.Name,
+	}
+	if sample := tool.Response; sample != nil {
+		entry.Response = b.response(sample.Status, sample.Headers, sample.ContentType, sample.Body)
+	}
+	return entry
+}
+
+// exchangeEntry is a request recorded in a session file, against baseURL.
+func (b *harBuilder) exchangeEntry(e session.Entry, baseURL string) harEntry {
+	entry := harEntry{
+		StartedDateTime: e.Time.Format(time.RFC3339Nano),
+		Request:         b.request(e.Method, baseURL+e.Path, e.Query, e.Headers, e.Body),
+		Response:        noHARResponse(),
+		Comment:         e.Endpoint,
+	}
+	if resp := e.Response; resp != nil {
+		entry.Response = b.response(resp.Status, resp.Headers, headerValue(resp.Headers, "Content-Type"), resp.Body)
+		entry.Time = float64(resp.Elapsed)
+		entry.Timings.Wait = float64(resp.Elapsed)
+	}
+	return entry
+}
+
+func (b *harBuilder) request(method, rawURL string, query, headers map[string]string, body string) harRequest {
+	req := harRequest{
+		Method:      method,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     b.headers(headers),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+	}
+	values := url.Values{}
+	for _, name := range mapKeys(query) {
+		req.QueryString = append(req.QueryString, harNameValue{Name: name, Value: query[name]})
+		values.Set(name, query[name])
+	}
+	req.URL = rawURL
+	if len(values) > 0 {
+		req.URL += "?" + values.Encode()
+	}
+	if body != "" {
+		body = b.secrets.Body(body)
+		mimeType := headerValue(headers, "Content-Type")
+		if mimeType == "" && json.Valid([]byte(body)) {
+			mimeType = "application/json"
+		}
+		req.PostData = &harPostData{MimeType: mimeType, Text: body}
+		req.BodySize = len(body)
+	}
+	return req
+}
+
+func (b *harBuilder) response(status int, headers map[string]string, contentType, body string) harResponse {
+	return harResponse{
+		Status:      status,
+		StatusText:  http.StatusText(status),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []harNameValue{},
+		Headers:     b.headers(headers),
+		Content:     harBody{Size: len(body), MimeType: contentType, Text: body},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// noHARResponse stands in for a response that wasn't seen, as browsers
+// record requests that failed.
+func noHARResponse() harResponse {
+	return harResponse{
+		Cookies:     []harNameValue{},
+		Headers:     []harNameValue{},
+		HTTPVersion: "HTTP/1.1",
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// headers lists headers by name, leaving out sensitive ones and the
+// ${secret:NAME} references --keep-auth stores in their place.
+func (b *harBuilder) headers(headers map[string]string) []harNameValue {
+	list := []harNameValue{}
+	redacted := b.secrets.Headers(headers)
+	for _, name := range mapKeys(redacted) {
+		value := redacted[name]
+		if strings.Contains(value, "${secret:") || slices.ContainsFunc(b.sensitive, func(s string) bool { return strings.EqualFold(s, name) }) {
+			continue
+		}
+		list = append(list, harNameValue{Name: name, Value: value})
+	}
+	return list
+}
+
+func headerValue(headers map[string]string, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// mapKeys returns the keys of m in order.
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/session"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// harSchema is the HAR 1.2 schema in testdata.
+func harSchema(t *testing.T) *jsonschema.Resolved {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "har-1.2.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestHARMatchesSchema(t *testing.T) {
+	at := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	exchanges := []session.Entry{
+		{
+			Time: at, Endpoint: "GET /users", Method: "GET", Path: "/users",
+			Query:   map[string]string{"page": "2"},
+			Headers: map[string]string{"Accept": "application/json", "Cookie": "session=abc"},
+			Response: &session.Response{
+				Status: 200, Headers: map[string]string{"Content-Type": "application/json"},
+				Body: `[{"id":42}]`, Elapsed: 12,
+			},
+		},
+		{
+			Time: at.Add(time.Second), Endpoint: "POST /users", Method: "POST", Path: "/users",
+			Headers: map[string]string{"Content-Type": "application/json"},
+			Body:    `{"name":"Ada","password":"hunter2"}`,
+		},
+		{
+			Time: at.Add(2 * time.Second), Endpoint: "GET /logo.png", Method: "GET", Path: "/logo.png",
+			Response: &session.Response{Status: 304},
+		},
+	}
+
+	schema := harSchema(t)
+	tests := []struct {
+		name      string
+		exchanges []session.Entry
+		entries   int
+	}{
+		{name: "tools", entries: 5},
+		{name: "session", exchanges: exchanges, entries: len(exchanges)},
+		{name: "empty session", exchanges: []session.Entry{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := HAR(testCatalog(t), tt.exchanges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc map[string]any
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if err := schema.Validate(doc); err != nil {
+				t.Errorf("not HAR 1.2: %v\n%s", err, data)
+			}
+			if n := len(doc["log"].(map[string]any)["entries"].([]any)); n != tt.entries {
+				t.Errorf("got %d entries, want %d", n, tt.entries)
+			}
+		})
+	}
+
+	// The schema catches what it should
+	data, err := HAR(testCatalog(t), exchanges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	entry := doc["log"].(map[string]any)["entries"].([]any)[0].(map[string]any)
+	delete(entry["request"].(map[string]any), "queryString")
+	if schema.Validate(doc) == nil {
+		t.Error("a request without queryString passes the schema")
+	}
+}
@@ -0,0 +1,388 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/redact"
+)
+
+// OpenAPIVersion is the version of the documents OpenAPI exports.
+const OpenAPIVersion = "3.1.0"
+
+// MethodsExtension is the path item extension holding operations whose
+// method OpenAPI has no field for, e.g. WebDAV's PROPFIND, by method.
+const MethodsExtension = "x-mcpify-methods"
+
+// ignoredHeaders are header parameters OpenAPI says to ignore: they are
+// described by the request body and security schemes instead.
+var ignoredHeaders = map[string]bool{"accept": true, "content-type": true, "authorization": true}
+
+type apiDocument struct {
+	OpenAPI string               `json:"openapi"`
+	Info    apiInfo              `json:"info"`
+	Servers []apiServer          `json:"servers,omitempty"`
+	Tags    []apiTag             `json:"tags,omitempty"`
+	Paths   map[string]*pathItem `json:"paths"`
+}
+
+type apiInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type apiServer struct {
+	URL string `json:"url"`
+}
+
+type apiTag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// pathItem holds a path's operations by lowercase method, and those
+// OpenAPI has no field for by method as sent. Servers is set for tools
+// calling another host than the target.
+type pathItem struct {
+	Servers    []apiServer
+	Operations map[string]*operation
+	Extended   map[string]*operation
+}
+
+// MarshalJSON inlines the operations next to the servers.
+func (p *pathItem) MarshalJSON() ([]byte, error) {
+	fields := make(map[string]any, len(p.Operations)+2)
+	for method, op := range p.Operations {
+		fields[method] = op
+	}
+	if len(p.Extended) > 0 {
+		fields[MethodsExtension] = p.Extended
+	}
+	if len(p.Servers) > 0 {
+		fields["servers"] = p.Servers
+	}
+	return encodeJSON(fields, "")
+}
+
+// encodeJSON is json.MarshalIndent without escaping HTML, which example
+// bodies are full of.
+func encodeJSON(v any, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type operation struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Parameters  []*apiParameter         `json:"parameters,omitempty"`
+	RequestBody *apiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*apiResponse `json:"responses"`
+}
+
+type apiParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required"`
+	Schema      *apiSchema `json:"schema"`
+	Example     any        `json:"example,omitempty"`
+}
+
+type apiRequestBody struct {
+	Content map[string]*mediaType `json:"content"`
+}
+
+type apiResponse struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema  *apiSchema `json:"schema,omitempty"`
+	Example any        `json:"example,omitempty"`
+}
+
+type apiSchema struct {
+	Type       string                `json:"type,omitempty"`
+	Properties map[string]*apiSchema `json:"properties,omitempty"`
+	Items      *apiSchema            `json:"items,omitempty"`
+}
+
+// OpenAPI describes the catalog as an OpenAPI 3.1 JSON document: one
+// operation per tool, under its templated path, with the captured request
+// and response as examples. Values that look like secrets are redacted,
+// and the output only depends on the catalog.
+func OpenAPI(cfg *config.Config, name string) ([]byte, error) {
+	secrets := redact.NewDetector()
+	doc := &apiDocument{
+		OpenAPI: OpenAPIVersion,
+		Info: apiInfo{
+			Title:       name,
+			Description: "Generated by mcpify from observed traffic.",
+			Version:     config.Version,
+		},
+		Paths: make(map[string]*pathItem),
+	}
+
+	origin := ""
+	if target, err := url.Parse(cfg.LastTarget); err == nil && target.Host != "" {
+		origin = target.Scheme + "://" + target.Host
+		doc.Servers = []apiServer{{URL: origin}}
+	}
+
+	// Tags are the groups, and each tool is tagged with the groups it is in
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for groupName := range cfg.Groups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+	tags := make(map[string][]string)
+	for _, groupName := range groupNames {
+		tools := cfg.GetToolsInGroup(groupName)
+		if len(tools) == 0 {
+			continue
+		}
+		doc.Tags = append(doc.Tags, apiTag{Name: groupName, Description: cfg.GetGroup(groupName).Description})
+		for _, tool := range tools {
+			tags[tool.ID] = append(tags[tool.ID], groupName)
+		}
+	}
+
+	// Paths differing only in parameter names are the same path to
+	// OpenAPI, so tools share the first one's
+	keys := make(map[string]string)
+	for _, tool := range sortTools(cfg.ListTools()) {
+		template, values := observed.Template(toolPath(tool))
+		signature := pathSignature(template)
+		key, ok := keys[signature]
+		if !ok {
+			key = template
+			keys[signature] = key
+			doc.Paths[key] = &pathItem{Operations: make(map[string]*operation)}
+			if u, err := url.Parse(tool.URL); err == nil && u.Host != "" && u.Scheme+"://"+u.Host != origin {
+				doc.Paths[key].Servers = []apiServer{{URL: u.Scheme + "://" + u.Host}}
+			}
+		}
+		item := doc.Paths[key]
+		method, operations := strings.ToLower(tool.Method), item.Operations
+		if !isOperationMethod(method) {
+			if item.Extended == nil {
+				item.Extended = make(map[string]*operation)
+			}
+			method, operations = strings.ToUpper(tool.Method), item.Extended
+		}
+		if _, taken := operations[method]; taken {
+			continue
+		}
+
+		op := &operation{
+			OperationID: tool.Name,
+			Summary:     tool.Description,
+			Tags:        tags[tool.ID],
+			Responses:   map[string]*apiResponse{},
+		}
+		op.Parameters = append(op.Parameters, pathParameters(tool, key, template, values)...)
+		op.Parameters = append(op.Parameters, queryParameters(tool, secrets)...)
+		op.Parameters = append(op.Parameters, headerParameters(tool, secrets)...)
+
+		if tool.Body != "" {
+			contentType := tool.Headers["Content-Type"]
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			op.RequestBody = &apiRequestBody{Content: map[string]*mediaType{
+				mediaTypeOf(contentType): exampleMedia(secrets.Body(tool.Body)),
+			}}
+		}
+
+		if resp := tool.Response; resp != nil && resp.Status > 0 {
+			response := &apiResponse{Description: http.StatusText(resp.Status)}
+			if response.Description == "" {
+				response.Description = "Captured response"
+			}
+			if resp.ContentType != "" && resp.Body != "" {
+				response.Content = map[string]*mediaType{
+					mediaTypeOf(resp.ContentType): exampleMedia(secrets.Body(resp.Body)),
+				}
+			}
+			op.Responses[strconv.Itoa(resp.Status)] = response
+		} else {
+			op.Responses["default"] = &apiResponse{Description: "No response was captured"}
+		}
+		operations[method] = op
+	}
+
+	data, err := encodeJSON(doc, "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode OpenAPI document: %w", err)
+	}
+	return data, nil
+}
+
+func isOperationMethod(method string) bool {
+	switch method {
+	case "get", "put", "post", "delete", "options", "head", "patch", "trace":
+		return true
+	}
+	return false
+}
+
+// pathSignature is template with its parameter names left out.
+func pathSignature(template string) string {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pathParameters describes the parameters of the path key, which the
+// tool's own template names the same or, for a shared path, differently.
+func pathParameters(tool *config.Tool, key, template string, values map[string]string) []*apiParameter {
+	var params []*apiParameter
+	own := strings.Split(template, "/")
+	for i, segment := range strings.Split(key, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		ownName := strings.TrimSuffix(strings.TrimPrefix(own[i], "{"), "}")
+		param := &apiParameter{
+			Name:        name,
+			In:          "path",
+			Description: tool.ParamDescriptions[ownName],
+			Required:    true,
+			Schema:      &apiSchema{Type: "string"},
+		}
+		if value, ok := tool.PathParams[ownName]; ok {
+			param.Example = value
+		} else if value, ok := values[ownName]; ok {
+			param.Example = value
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func queryParameters(tool *config.Tool, secrets *redact.Detector) []*apiParameter {
+	names := make([]string, 0, len(tool.QueryParams))
+	for name := range tool.QueryParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]*apiParameter, 0, len(names))
+	for _, name := range names {
+		param := &apiParameter{
+			Name:        name,
+			In:          "query",
+			Description: tool.ParamDescriptions[name],
+			Schema:      &apiSchema{Type: "string"},
+		}
+		if value := tool.QueryParams[name]; value != "" {
+			param.Example = secrets.Text(value)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func headerParameters(tool *config.Tool, secrets *redact.Detector) []*apiParameter {
+	names := make([]string, 0, len(tool.Headers))
+	for name := range tool.Headers {
+		if !ignoredHeaders[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	params := make([]*apiParameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, &apiParameter{
+			Name:    name,
+			In:      "header",
+			Schema:  &apiSchema{Type: "string"},
+			Example: secrets.Text(tool.Headers[name]),
+		})
+	}
+	return params
+}
+
+// mediaTypeOf drops parameters such as charset from a Content-Type.
+func mediaTypeOf(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+// exampleMedia uses body as the example, with a schema inferred from it
+// when it is JSON.
+func exampleMedia(body string) *mediaType {
+	var v any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		return &mediaType{Schema: &apiSchema{Type: "string"}, Example: body}
+	}
+	return &mediaType{Schema: schemaOf(v), Example: v}
+}
+
+func schemaOf(v any) *apiSchema {
+	switch v := v.(type) {
+	case map[string]any:
+		s := &apiSchema{Type: "object", Properties: make(map[string]*apiSchema, len(v))}
+		for name, value := range v {
+			s.Properties[name] = schemaOf(value)
+		}
+		return s
+	case []any:
+		s := &apiSchema{Type: "array"}
+		if len(v) > 0 {
+			s.Items = schemaOf(v[0])
+		}
+		return s
+	case string:
+		return &apiSchema{Type: "string"}
+	case float64:
+		if v == math.Trunc(v) {
+			return &apiSchema{Type: "integer"}
+		}
+		return &apiSchema{Type: "number"}
+	case bool:
+		return &apiSchema{Type: "boolean"}
+	}
+	return &apiSchema{Type: "null"}
+}
+
+// OpenAPIHandler serves GET /export/openapi.
+func OpenAPIHandler(cfg *config.Config, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := OpenAPI(cfg, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(doc)
+	}
+}
@@ -0,0 +1,258 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/redact"
+)
+
+// PostmanSchema is the collection format Postman exports.
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// credentialHeaders are the headers capture never records. Requests get
+// them as disabled headers taking their value from a variable.
+var credentialHeaders = []string{"Authorization", "Cookie", "X-API-Key", "X-Auth-Token"}
+
+type postmanCollection struct {
+	Info     postmanInfo       `json:"info"`
+	Item     []*postmanItem    `json:"item"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// postmanItem is a folder when it has items, and a request otherwise.
+type postmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []*postmanItem  `json:"item,omitempty"`
+	Request     *postmanRequest `json:"request,omitempty"`
+}
+
+type postmanRequest struct {
+	Method      string          `json:"method"`
+	Header      []postmanHeader `json:"header"`
+	Body        *postmanBody    `json:"body,omitempty"`
+	URL         postmanURL      `json:"url"`
+	Description string          `json:"description,omitempty"`
+}
+
+type postmanHeader struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanBody struct {
+	Mode    string          `json:"mode"`
+	Raw     string          `json:"raw"`
+	Options *postmanOptions `json:"options,omitempty"`
+}
+
+type postmanOptions struct {
+	Raw struct {
+		Language string `json:"language"`
+	} `json:"raw"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path,omitempty"`
+	Query    []postmanQuery    `json:"query,omitempty"`
+	Variable []postmanVariable `json:"variable,omitempty"`
+}
+
+type postmanQuery struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+type postmanVariable struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Postman renders the catalog as a Postman Collection v2.1. Groups become
+// folders. Header values that were redacted, and the credential headers
+// capture never records, are {{variable}} placeholders to fill in from a
+// Postman environment. The output only depends on the catalog.
+func Postman(cfg *config.Config, name string) ([]byte, error) {
+	secrets := redact.NewDetector()
+	collection := &postmanCollection{
+		Info: postmanInfo{
+			Name:        name,
+			Description: "Generated by mcpify from observed traffic.",
+			Schema:      PostmanSchema,
+		},
+		Item: []*postmanItem{},
+	}
+
+	baseURL := strings.TrimSuffix(cfg.LastTarget, "/")
+	variables := make(map[string]bool)
+	item := func(tool *config.Tool) *postmanItem {
+		return &postmanItem{Name: tool.Name, Request: postmanRequestFor(tool, baseURL, secrets, variables)}
+	}
+
+	grouped := make(map[string]bool)
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for groupName := range cfg.Groups {
+		groupNames = append(groupNames, groupName)
+	}
+	sort.Strings(groupNames)
+	for _, groupName := range groupNames {
+		tools := cfg.GetToolsInGroup(groupName)
+		if len(tools) == 0 {
+			continue
+		}
+		folder := &postmanItem{Name: groupName, Description: cfg.GetGroup(groupName).Description}
+		for _, tool := range sortTools(tools) {
+			grouped[tool.ID] = true
+			folder.Item = append(folder.Item, item(tool))
+		}
+		collection.Item = append(collection.Item, folder)
+	}
+	for _, tool := range sortTools(cfg.ListTools()) {
+		if !grouped[tool.ID] {
+			collection.Item = append(collection.Item, item(tool))
+		}
+	}
+
+	if baseURL != "" {
+		collection.Variable = append(collection.Variable, postmanVariable{Key: "baseUrl", Value: baseURL})
+	}
+	names := make([]string, 0, len(variables))
+	for variable := range variables {
+		names = append(names, variable)
+	}
+	sort.Strings(names)
+	for _, variable := range names {
+		collection.Variable = append(collection.Variable, postmanVariable{Key: variable})
+	}
+
+	data, err := encodeJSON(collection, "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode Postman collection: %w", err)
+	}
+	return data, nil
+}
+
+func postmanRequestFor(tool *config.Tool, baseURL string, secrets *redact.Detector, variables map[string]bool) *postmanRequest {
+	req := &postmanRequest{
+		Method:      tool.Method,
+		Header:      []postmanHeader{},
+		URL:         postmanURLFor(tool, baseURL, secrets),
+		Description: tool.Description,
+	}
+
+	headerNames := make([]string, 0, len(tool.Headers))
+	for k := range tool.Headers {
+		headerNames = append(headerNames, k)
+	}
+	sort.Strings(headerNames)
+	for _, k := range headerNames {
+		value := secrets.Text(tool.Headers[k])
+		if strings.Contains(value, "<redacted") {
+			value = "{{" + variableName(k) + "}}"
+			variables[variableName(k)] = true
+		}
+		req.Header = append(req.Header, postmanHeader{Key: k, Value: value})
+	}
+	for _, k := range credentialHeaders {
+		if slices.ContainsFunc(headerNames, func(name string) bool { return strings.EqualFold(name, k) }) {
+			continue
+		}
+		req.Header = append(req.Header, postmanHeader{Key: k, Value: "{{" + variableName(k) + "}}", Disabled: true})
+		variables[variableName(k)] = true
+	}
+
+	if tool.Body != "" {
+		req.Body = &postmanBody{Mode: "raw", Raw: secrets.Body(tool.Body)}
+		if json.Valid([]byte(tool.Body)) {
+			req.Body.Options = &postmanOptions{}
+			req.Body.Options.Raw.Language = "json"
+		}
+	}
+	return req
+}
+
+// postmanURLFor uses {{baseUrl}} for the target, and Postman's :name
+// path variables for {name} segments.
+func postmanURLFor(tool *config.Tool, baseURL string, secrets *redact.Detector) postmanURL {
+	rawURL, _, _ := strings.Cut(tool.URL, "?")
+	var u postmanURL
+	rest, ok := strings.CutPrefix(rawURL, baseURL)
+	if baseURL != "" && ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		u.Host = []string{"{{baseUrl}}"}
+	} else {
+		scheme, hostAndPath, _ := strings.Cut(rawURL, "://")
+		host, path, _ := strings.Cut(hostAndPath, "/")
+		u.Host = []string{scheme + "://" + host}
+		rest = "/" + path
+	}
+
+	for _, segment := range strings.Split(strings.Trim(rest, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(segment, "{"); ok && strings.HasSuffix(name, "}") {
+			name = strings.TrimSuffix(name, "}")
+			segment = ":" + name
+			u.Variable = append(u.Variable, postmanVariable{Key: name, Value: tool.PathParams[name]})
+		}
+		u.Path = append(u.Path, segment)
+	}
+	u.Raw = u.Host[0]
+	if len(u.Path) > 0 {
+		u.Raw += "/" + strings.Join(u.Path, "/")
+	}
+
+	names := make([]string, 0, len(tool.QueryParams))
+	for name := range tool.QueryParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var query []string
+	for _, name := range names {
+		value := secrets.Text(tool.QueryParams[name])
+		// Parameters without a captured value are there to fill in
+		u.Query = append(u.Query, postmanQuery{Key: name, Value: value, Disabled: value == ""})
+		if value != "" {
+			query = append(query, name+"="+value)
+		}
+	}
+	if len(query) > 0 {
+		u.Raw += "?" + strings.Join(query, "&")
+	}
+	return u
+}
+
+// variableName turns a header name into a Postman variable, e.g.
+// x_api_key for X-API-Key.
+func variableName(header string) string {
+	return strings.ReplaceAll(strings.ToLower(header), "-", "_")
+}
+
+// PostmanHandler serves GET /export/postman.
+func PostmanHandler(cfg *config.Config, name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		collection, err := Postman(cfg, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(collection)
+	}
+}
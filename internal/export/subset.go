@@ -0,0 +1,100 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/redact"
+)
+
+// Selection picks the tools of a subset. A tool is picked when it matches
+// every kind of selector given, and one value of each kind: it is in one
+// of Groups, has one of Tags, and calls one of Targets.
+type Selection struct {
+	Groups  []string
+	Tags    []string
+	Targets []string
+}
+
+// Subset renders the tools sel picks as a standalone config, which
+// `mcpify serve --config` runs on another machine. Captured values pass
+// through secret redaction. The warnings name what the recipient has to
+// provide, like the environment variables of ${secret:NAME} references.
+func Subset(cfg *config.Config, sel Selection) ([]byte, []string, error) {
+	if len(sel.Groups) == 0 && len(sel.Tags) == 0 && len(sel.Targets) == 0 {
+		return nil, nil, fmt.Errorf("a subset needs --group, --tag or --target")
+	}
+
+	inGroups := make(map[string]bool)
+	for _, name := range sel.Groups {
+		if cfg.GetGroup(name) == nil {
+			return nil, nil, fmt.Errorf("%w: %q", config.ErrGroupNotFound, name)
+		}
+		for _, tool := range cfg.GetToolsInGroup(name) {
+			inGroups[tool.ID] = true
+		}
+	}
+	targets := make([]*url.URL, 0, len(sel.Targets))
+	for _, target := range sel.Targets {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return nil, nil, fmt.Errorf("invalid --target %q: want a URL like http://localhost:3000", target)
+		}
+		targets = append(targets, u)
+	}
+
+	sub, err := cfg.Subset(func(tool *config.Tool) bool {
+		return (len(sel.Groups) == 0 || inGroups[tool.ID]) &&
+			(len(sel.Tags) == 0 || slices.ContainsFunc(tool.Tags, func(tag string) bool { return slices.Contains(sel.Tags, tag) })) &&
+			(len(targets) == 0 || slices.ContainsFunc(targets, func(u *url.URL) bool { return calls(tool, u) }))
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sub.Tools) == 0 {
+		return nil, nil, fmt.Errorf("no tools match the selection")
+	}
+
+	secrets := redact.NewDetector()
+	if sub.SensitiveFields != nil {
+		secrets.SensitiveFields = sub.SensitiveFields
+	}
+	var warnings []string
+	for _, tool := range sub.Tools {
+		tool.Body = secrets.Body(tool.Body)
+		for name, value := range tool.Headers {
+			tool.Headers[name] = secrets.Text(value)
+		}
+		for name, value := range tool.QueryParams {
+			tool.QueryParams[name] = secrets.Text(value)
+		}
+		if tool.Response != nil {
+			tool.Response.Body = secrets.Body(tool.Response.Body)
+		}
+		if len(tool.StrippedHeaders) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s needs %s from the agent; it was never stored", tool.Name, strings.Join(tool.StrippedHeaders, ", ")))
+		}
+	}
+	for _, name := range sub.SecretRefs() {
+		warnings = append(warnings, fmt.Sprintf("the config refers to ${secret:%s}; the recipient must set the environment variable %s", name, name))
+	}
+	slices.Sort(warnings)
+
+	data, err := sub.Export(true)
+	return data, warnings, err
+}
+
+// calls reports whether tool calls the target at u: the same scheme and
+// host, under u's path.
+func calls(tool *config.Tool, u *url.URL) bool {
+	t, err := url.Parse(tool.URL)
+	if err != nil {
+		return false
+	}
+	prefix := strings.TrimSuffix(u.Path, "/")
+	return t.Scheme == u.Scheme && strings.EqualFold(t.Host, u.Host) &&
+		(t.Path == prefix || strings.HasPrefix(t.Path, prefix+"/"))
+}
@@ -0,0 +1,168 @@
+// Package fingerprint reduces JSON responses to their structure - key
+// paths and value types, never values - so changes in what an API returns
+// can be spotted without keeping its data.
+package fingerprint
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var dynamicKey = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{16,}|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|\d{4}-\d{2}-\d{2})$`)
+
+// Of returns the sorted "path:type" entries of the JSON document in body,
+// or false when body isn't JSON. Paths use the "$.a.b" form, array
+// elements share one "[]" path, and object keys that look like IDs or
+// dates collapse to "*" so maps keyed by them keep a single shape.
+func Of(body []byte) ([]string, bool) {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, false
+	}
+	seen := make(map[string]bool)
+	walk("$", v, seen)
+
+	shape := make([]string, 0, len(seen))
+	for entry := range seen {
+		shape = append(shape, entry)
+	}
+	slices.Sort(shape)
+	return shape, true
+}
+
+func walk(path string, v interface{}, seen map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		seen[path+":object"] = true
+		for k, child := range v {
+			if dynamicKey.MatchString(k) {
+				k = "*"
+			}
+			walk(path+"."+k, child, seen)
+		}
+	case []interface{}:
+		seen[path+":array"] = true
+		for _, child := range v {
+			walk(path+"[]", child, seen)
+		}
+	case string:
+		seen[path+":string"] = true
+	case float64:
+		seen[path+":number"] = true
+	case bool:
+		seen[path+":boolean"] = true
+	default:
+		seen[path+":null"] = true
+	}
+}
+
+// Filter returns shape without the entries matched by ignore.
+func Filter(shape, ignore []string) []string {
+	if len(ignore) == 0 {
+		return shape
+	}
+	kept := make([]string, 0, len(shape))
+	for _, entry := range shape {
+		if path, _ := split(entry); !Ignored(path, ignore) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// Ignored reports whether path is matched by ignore, which lists JSON
+// paths ("$.meta", "$.items[].etag") covering everything below them or
+// bare field names matching that field anywhere.
+func Ignored(path string, ignore []string) bool {
+	for _, p := range ignore {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "$") {
+			if strings.HasSuffix(path, "."+p) || strings.Contains(path, "."+p+".") || strings.Contains(path, "."+p+"[") {
+				return true
+			}
+			continue
+		}
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the entries only in b (added) and only in a (removed),
+// skipping paths matched by ignore. A null or an empty array says nothing
+// about what it would contain, so neither counts against the paths it
+// stands in for.
+func Diff(a, b, ignore []string) (added, removed []string) {
+	ta, tb := types(Filter(a, ignore)), types(Filter(b, ignore))
+	return missing(tb, ta), missing(ta, tb)
+}
+
+// missing returns the entries of from that to doesn't account for.
+func missing(from, to map[string][]string) []string {
+	open := openPaths(to)
+	var out []string
+	for path, kinds := range from {
+		if under(path, open) {
+			continue
+		}
+		other := to[path]
+		if len(other) == 1 && other[0] == "null" {
+			continue
+		}
+		for _, kind := range kinds {
+			if kind != "null" && !slices.Contains(other, kind) {
+				out = append(out, path+":"+kind)
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// openPaths returns the paths in t whose contents are unknown: nulls and
+// arrays without elements.
+func openPaths(t map[string][]string) []string {
+	var open []string
+	for path, kinds := range t {
+		if slices.Contains(kinds, "null") {
+			open = append(open, path)
+			continue
+		}
+		if slices.Contains(kinds, "array") && t[path+"[]"] == nil {
+			open = append(open, path)
+		}
+	}
+	return open
+}
+
+func under(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+func types(shape []string) map[string][]string {
+	t := make(map[string][]string, len(shape))
+	for _, entry := range shape {
+		path, kind := split(entry)
+		t[path] = append(t[path], kind)
+	}
+	return t
+}
+
+func split(entry string) (path, kind string) {
+	i := strings.LastIndexByte(entry, ':')
+	if i < 0 {
+		return entry, ""
+	}
+	return entry[:i], entry[i+1:]
+}
@@ -3,13 +3,16 @@ package grouping
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/llm"
+	"github.com/NilayYadav/mcpify/internal/prompts"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 )
@@ -17,6 +20,16 @@ import (
 type LLMGrouper struct {
 	llmClient *openai.Client
 	llmModel  string
+	health    *llm.Breaker
+	limiter   *llm.Limiter
+	prompts   *prompts.Set
+	maxTools  int
+}
+
+// GroupingInput is what the grouping prompt is rendered with.
+type GroupingInput struct {
+	// Tools has the name, method, path and description of each tool
+	Tools []map[string]interface{}
 }
 
 func NewLLMGrouper(llmKey, llmEndpoint, llmModel string) *LLMGrouper {
@@ -31,19 +44,54 @@ func NewLLMGrouper(llmKey, llmEndpoint, llmModel string) *LLMGrouper {
 	}
 }
 
-func (lg *LLMGrouper) GroupToolsInConfig(cfg *config.Config) error {
-	cfg.ClearGroups()
+// SetHealth makes grouping report to b and skip the LLM while b is open.
+func (lg *LLMGrouper) SetHealth(b *llm.Breaker) {
+	lg.health = b
+}
+
+// SetLimiter makes grouping calls wait behind interactive LLM work.
+func (lg *LLMGrouper) SetLimiter(l *llm.Limiter) {
+	lg.limiter = l
+}
+
+// SetPrompts makes grouping use p instead of the embedded prompts.
+func (lg *LLMGrouper) SetPrompts(p *prompts.Set) {
+	lg.prompts = p
+}
+
+func (lg *LLMGrouper) prompt() *prompts.Prompt {
+	set := lg.prompts
+	if set == nil {
+		set = prompts.Default()
+	}
+	return set.MustGet(prompts.Grouping)
+}
+
+// SetMaxTools limits grouping to the max oldest tools; 0 means no limit.
+func (lg *LLMGrouper) SetMaxTools(max int) {
+	lg.maxTools = max
+}
 
-	tools := make([]*config.Tool, 0, len(cfg.Tools))
-	for _, tool := range cfg.Tools {
-		tools = append(tools, tool)
+// GroupToolsInConfig replaces the config's groups with LLM-made ones.
+// While the provider is unhealthy existing groups are kept, and a config
+// without any is grouped by path prefix instead.
+func (lg *LLMGrouper) GroupToolsInConfig(cfg *config.Config) error {
+	if !lg.health.Allow() {
+		if len(cfg.ListGroups()) > 0 {
+			slog.Warn("LLM unavailable; keeping existing groups")
+			return nil
+		}
+		slog.Warn("LLM unavailable; grouping tools by path prefix")
+		return GroupByPrefix(cfg, lg.maxTools)
 	}
 
+	tools := cfg.OldestTools(lg.maxTools)
+
 	if len(tools) == 0 {
 		return nil
 	}
 
-	log.Printf("Analyzing %d tools for intelligent grouping...", len(tools))
+	slog.Info("Grouping tools with the LLM", "tools", len(tools))
 
 	// Prepare tools data for LLM analysis
 	toolsData := make([]map[string]interface{}, len(tools))
@@ -56,79 +104,61 @@ func (lg *LLMGrouper) GroupToolsInConfig(cfg *config.Config) error {
 		}
 	}
 
-	toolsJSON, _ := json.MarshalIndent(toolsData, "", "  ")
-
-	systemPrompt := `You are an API analysis expert. Group related API endpoints into logical, workflow-oriented tools.
-
-Rules:
-1. Create 3-7 groups maximum, regardless of API size
-2. Group by business function/capability, not technical patterns  
-3. Each group should represent what a user wants to accomplish
-4. Prefer fewer, more powerful groups over many small ones
-5. Important standalone endpoints (health, webhooks) can be their own group
-
-Output ONLY valid JSON in this exact format:
-{
-  "groups": [
-    {
-      "name": "user_management", 
-      "description": "Complete user lifecycle operations including creation, updates, and deletion",
-      "tool_names": ["create_user", "get_user", "update_user", "delete_user", "list_users", "search_users"]
-    }
-  ]
-}
-
-Group names should be snake_case. Use the exact tool names from the input.`
-
-	prompt := fmt.Sprintf("Analyze and group these API tools:\n%s", string(toolsJSON))
-
-	chatCompletion, err := lg.llmClient.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(prompt),
-		},
-		Model:       lg.llmModel,
-		Temperature: openai.Float(0.1),
-	})
-
+	prompt := lg.prompt()
+	systemPrompt, userPrompt, err := prompt.Render(GroupingInput{Tools: toolsData})
 	if err != nil {
-		return fmt.Errorf("LLM grouping failed: %w", err)
+		return err
 	}
 
-	response := chatCompletion.Choices[0].Message.Content
-	log.Printf("LLM grouping response received")
-
-	var result struct {
-		Groups []struct {
-			Name        string   `json:"name"`
-			Description string   `json:"description"`
-			ToolNames   []string `json:"tool_names"`
-		} `json:"groups"`
+	messages := []openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage(systemPrompt),
+		openai.UserMessage(userPrompt),
 	}
-
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		return fmt.Errorf("failed to parse LLM response: %w", err)
+	response, err := lg.complete(messages)
+	if err != nil {
+		return err
+	}
+	slog.Debug("LLM grouping response received")
+
+	var result groupingResult
+	if err := parseGroupingResponse(response, &result); err != nil {
+		// One more try, telling the model what was wrong
+		slog.Warn("LLM grouping response wasn't valid JSON; asking again", "error", err)
+		messages = append(messages,
+			openai.AssistantMessage(response),
+			openai.UserMessage(fmt.Sprintf("That response could not be parsed (%v). Reply with only the JSON object, no markdown or explanation.", err)),
+		)
+		if response, err = lg.complete(messages); err != nil {
+			return err
+		}
+		if err := parseGroupingResponse(response, &result); err != nil {
+			return fmt.Errorf("failed to parse LLM response: %w", err)
+		}
 	}
 
+	// Existing groups only go once there are new ones to replace them
+	cfg.ClearGroups()
+
 	// Add groups to config
 	for _, llmGroup := range result.Groups {
-		// Validate tool names exist
-		validToolNames := []string{}
+		// Validate tool names exist; groups reference tools by ID
+		toolIDs := []string{}
 		for _, toolName := range llmGroup.ToolNames {
-			if cfg.GetTool(toolName) != nil {
-				validToolNames = append(validToolNames, toolName)
+			if tool := cfg.GetTool(toolName); tool != nil {
+				toolIDs = append(toolIDs, tool.ID)
 			}
 		}
 
-		if len(validToolNames) > 0 {
+		if len(toolIDs) > 0 {
 			group := &config.Group{
 				Name:        llmGroup.Name,
 				Description: llmGroup.Description,
-				ToolNames:   validToolNames,
+				ToolIDs:     toolIDs,
 				CreatedAt:   time.Now(),
+				GroupedBy:   prompt.Ref(),
 			}
 			cfg.AddGroup(group)
-			log.Printf("Created group '%s' with %d tools", group.Name, len(group.ToolNames))
+			slog.Info("Created group", "group", group.Name, "tools", len(group.ToolIDs))
 		}
 	}
 
@@ -136,6 +166,48 @@ Group names should be snake_case. Use the exact tool names from the input.`
 	return cfg.Save(cfg.Path)
 }
 
+type groupingResult struct {
+	Groups []struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		ToolNames   []string `json:"tool_names"`
+	} `json:"groups"`
+}
+
+// complete sends messages to the LLM and returns its answer.
+func (lg *LLMGrouper) complete(messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+	release, err := lg.limiter.Acquire(context.TODO(), llm.Background)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	chatCompletion, err := lg.llmClient.Chat.Completions.New(context.TODO(), openai.ChatCompletionNewParams{
+		Messages:    messages,
+		Model:       lg.llmModel,
+		Temperature: openai.Float(0.1),
+	})
+	if err != nil {
+		lg.health.Failure(err)
+		return "", fmt.Errorf("LLM grouping failed: %w", err)
+	}
+	lg.health.Success()
+
+	if len(chatCompletion.Choices) == 0 {
+		return "", fmt.Errorf("LLM grouping returned no choices")
+	}
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// parseGroupingResponse decodes the JSON object in response into result.
+func parseGroupingResponse(response string, result *groupingResult) error {
+	object, ok := extractJSON(response)
+	if !ok {
+		return errors.New("no JSON object found")
+	}
+	return json.Unmarshal([]byte(object), result)
+}
+
 func (lg *LLMGrouper) extractPath(fullURL string) string {
 	if !strings.Contains(fullURL, "://") {
 		return fullURL
@@ -0,0 +1,179 @@
+package grouping
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// Grouper replaces a config's groups. GroupedMCPServer serves whatever
+// groups it writes, so groupers are interchangeable.
+type Grouper interface {
+	GroupToolsInConfig(cfg *config.Config) error
+}
+
+// PrefixGrouper groups tools by path prefix without an LLM, so no API
+// shapes leave the machine.
+type PrefixGrouper struct {
+	maxTools int
+}
+
+// NewPrefixGrouper groups the maxTools oldest tools; 0 means all.
+func NewPrefixGrouper(maxTools int) *PrefixGrouper {
+	return &PrefixGrouper{maxTools: maxTools}
+}
+
+func (pg *PrefixGrouper) GroupToolsInConfig(cfg *config.Config) error {
+	return GroupByPrefix(cfg, pg.maxTools)
+}
+
+// methodOrder is how methods are listed in group descriptions.
+// PrefixGrouping is recorded as what made groups built from path
+// prefixes.
+const PrefixGrouping = "path-prefix"
+
+var methodOrder = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// GroupByPrefix groups tools by their first meaningful path segment, so
+// everything under /users lands in a users group. A first segment that is
+// only a namespace, like /admin when every path continues with a static
+// segment, is grouped by two segments instead: /admin/reports becomes
+// admin_reports. A prefix with a single tool gets a group of its own named
+// after the tool, so it stays a standalone tool. It needs no LLM. Only the
+// maxTools oldest tools are grouped; 0 means all.
+func GroupByPrefix(cfg *config.Config, maxTools int) error {
+	tools := cfg.OldestTools(maxTools)
+
+	segments := make(map[*config.Tool][]string, len(tools))
+	namespace := make(map[string]bool)
+	for _, tool := range tools {
+		segs := pathSegments(tool.RawPath())
+		segments[tool] = segs
+		if len(segs) == 0 {
+			continue
+		}
+		isNamespace, seen := namespace[segs[0]]
+		if !seen {
+			isNamespace = true
+		}
+		namespace[segs[0]] = isNamespace && len(segs) > 1 && !strings.HasPrefix(segs[1], "{")
+	}
+
+	byPrefix := make(map[string][]*config.Tool)
+	for _, tool := range tools {
+		prefix := ""
+		if segs := segments[tool]; len(segs) > 0 {
+			prefix = segs[0]
+			if namespace[segs[0]] {
+				prefix += "/" + segs[1]
+			}
+		}
+		byPrefix[prefix] = append(byPrefix[prefix], tool)
+	}
+
+	prefixes := make([]string, 0, len(byPrefix))
+	for prefix := range byPrefix {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	cfg.ClearGroups()
+	for _, prefix := range prefixes {
+		tools := byPrefix[prefix]
+		group := &config.Group{
+			Name:        groupName(prefix),
+			Description: prefixDescription(prefix, tools),
+			CreatedAt:   time.Now(),
+			GroupedBy:   PrefixGrouping,
+		}
+		if len(tools) == 1 && cfg.GetGroup(tools[0].Name) == nil {
+			group.Name = tools[0].Name
+			if tools[0].Description != "" {
+				group.Description = tools[0].Description
+			}
+		}
+		for _, tool := range tools {
+			group.ToolIDs = append(group.ToolIDs, tool.ID)
+		}
+		cfg.AddGroup(group)
+		slog.Info("Created group", "group", group.Name, "tools", len(group.ToolIDs))
+	}
+
+	cfg.UseGrouping = true
+	return cfg.Save(cfg.Path)
+}
+
+// pathSegments splits path into its segments, dropping a leading "api"
+// and version segments like v1.
+func pathSegments(path string) []string {
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" || (len(segments) == 0 && (segment == "api" || isVersion(segment))) {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	// A path starting with a parameter has no prefix to group by
+	if len(segments) > 0 && strings.HasPrefix(segments[0], "{") {
+		return nil
+	}
+	return segments
+}
+
+func isVersion(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && strings.Trim(segment[1:], "0123456789") == ""
+}
+
+// prefixDescription summarises the methods and paths of a prefix group,
+// e.g. "Endpoints under /users: GET, POST, DELETE across 4 paths".
+func prefixDescription(prefix string, tools []*config.Tool) string {
+	var methods []string
+	paths := make(map[string]bool)
+	for _, tool := range tools {
+		if !slices.Contains(methods, tool.Method) {
+			methods = append(methods, tool.Method)
+		}
+		paths[tool.RawPath()] = true
+	}
+	slices.SortFunc(methods, func(a, b string) int {
+		ia, ib := slices.Index(methodOrder, a), slices.Index(methodOrder, b)
+		if ia < 0 {
+			ia = len(methodOrder)
+		}
+		if ib < 0 {
+			ib = len(methodOrder)
+		}
+		if ia != ib {
+			return ia - ib
+		}
+		return strings.Compare(a, b)
+	})
+
+	description := fmt.Sprintf("Endpoints under /%s: %s", prefix, strings.Join(methods, ", "))
+	if len(paths) > 1 {
+		description += fmt.Sprintf(" across %d paths", len(paths))
+	}
+	return description
+}
+
+// groupName turns a path prefix into a snake_case group name.
+func groupName(prefix string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, prefix)
+	if name = strings.Trim(name, "_"); name == "" {
+		return "root"
+	}
+	return name
+}
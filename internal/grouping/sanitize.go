@@ -0,0 +1,57 @@
+package grouping
+
+import "strings"
+
+// extractJSON returns the first JSON object in an LLM response, ignoring
+// markdown fences and prose around it and dropping trailing commas that
+// models like to leave before a closing bracket.
+func extractJSON(text string) (string, bool) {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", false
+	}
+
+	var out strings.Builder
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			dropTrailingComma(&out)
+			depth--
+		}
+		out.WriteByte(c)
+		if depth == 0 {
+			return out.String(), true
+		}
+	}
+	return "", false
+}
+
+// dropTrailingComma removes a comma, and the whitespace after it, from
+// the end of b.
+func dropTrailingComma(b *strings.Builder) {
+	s := strings.TrimRight(b.String(), " \t\r\n")
+	if strings.HasSuffix(s, ",") {
+		b.Reset()
+		b.WriteString(s[:len(s)-1])
+	}
+}
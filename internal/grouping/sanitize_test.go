@@ -0,0 +1,95 @@
+package grouping
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupingResponse(t *testing.T) {
+	users := map[string][]string{"users": {"get_users", "create_user"}}
+	tests := []struct {
+		name     string
+		response string
+		want     map[string][]string
+		wantErr  bool
+	}{
+		{
+			name:     "bare object",
+			response: `{"groups":[{"name":"users","description":"Users","tool_names":["get_users","create_user"]}]}`,
+			want:     users,
+		},
+		{
+			name:     "json code fence",
+			response: "```json\n{\"groups\":[{\"name\":\"users\",\"tool_names\":[\"get_users\",\"create_user\"]}]}\n```",
+			want:     users,
+		},
+		{
+			name:     "plain code fence",
+			response: "```\n{\"groups\":[{\"name\":\"users\",\"tool_names\":[\"get_users\",\"create_user\"]}]}\n```",
+			want:     users,
+		},
+		{
+			name:     "surrounding prose",
+			response: "Sure! Here are the groups:\n{\"groups\":[{\"name\":\"users\",\"tool_names\":[\"get_users\",\"create_user\"]}]}\nLet me know if you need more.",
+			want:     users,
+		},
+		{
+			name:     "trailing commas",
+			response: "{\"groups\":[{\"name\":\"users\",\"tool_names\":[\"get_users\",\"create_user\",],},\n],}",
+			want:     users,
+		},
+		{
+			name:     "braces and commas inside strings",
+			response: `{"groups":[{"name":"users","description":"Handles {user}, and \"more\",]","tool_names":["get_users","create_user"]}]}`,
+			want:     users,
+		},
+		{
+			name:     "second object ignored",
+			response: `{"groups":[{"name":"users","tool_names":["get_users","create_user"]}]} {"groups":[]}`,
+			want:     users,
+		},
+		{
+			name:     "empty output",
+			response: "",
+			wantErr:  true,
+		},
+		{
+			name:     "whitespace only",
+			response: " \n\t",
+			wantErr:  true,
+		},
+		{
+			name:     "prose without JSON",
+			response: "I could not group these tools.",
+			wantErr:  true,
+		},
+		{
+			name:     "truncated object",
+			response: `{"groups":[{"name":"users","tool_names":["get_users"`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result groupingResult
+			err := parseGroupingResponse(tt.response, &result)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %+v, want an error", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][]string{}
+			for _, group := range result.Groups {
+				got[group.Name] = group.ToolNames
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
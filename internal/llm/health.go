@@ -0,0 +1,197 @@
+// Package llm tracks whether the LLM provider is usable, so naming and
+// grouping can fall back to heuristics instead of queueing doomed calls.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+const (
+	// DefaultFailureThreshold is how many consecutive failures open the
+	// breaker.
+	DefaultFailureThreshold = 3
+	// DefaultCooldown is how long the breaker stays open before a single
+	// call is let through to probe the provider.
+	DefaultCooldown = 30 * time.Second
+)
+
+// Status is the provider health shown in /debug and `mcpify status`.
+type Status struct {
+	Provider            string    `json:"provider"`
+	Model               string    `json:"model"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Fallback            bool      `json:"fallback"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+// Breaker is a circuit breaker around LLM calls. After threshold
+// consecutive failures it opens and callers use their heuristics; once the
+// cooldown has passed one call probes the provider and closes it again on
+// success. A nil *Breaker allows every call.
+type Breaker struct {
+	mu        sync.Mutex
+	provider  string
+	model     string
+	threshold int
+	cooldown  time.Duration
+
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+	failures    int
+	open        bool
+	retryAt     time.Time
+	// calls and failed count every call reported, for /metrics
+	calls  int64
+	failed int64
+}
+
+func NewBreaker(provider, model string) *Breaker {
+	return &Breaker{
+		provider:  provider,
+		model:     model,
+		threshold: DefaultFailureThreshold,
+		cooldown:  DefaultCooldown,
+	}
+}
+
+// Allow reports whether an LLM call should be attempted now. While the
+// breaker is open only one probe is allowed per cooldown.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if now := time.Now(); now.After(b.retryAt) {
+		b.retryAt = now.Add(b.cooldown)
+		return true
+	}
+	return false
+}
+
+// Success records a successful call, closing the breaker if it was open.
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.calls++
+	b.lastSuccess = time.Now()
+	b.failures = 0
+	if b.open {
+		b.open = false
+		b.retryAt = time.Time{}
+		slog.Info("LLM provider is reachable again; leaving heuristic fallback", "provider", b.provider)
+	}
+}
+
+// Failure records a failed call and opens the breaker once the threshold
+// is reached.
+func (b *Breaker) Failure(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.record(err)
+	if !b.open && b.failures >= b.threshold {
+		b.trip()
+	}
+}
+
+// Trip opens the breaker immediately, e.g. when the startup check fails.
+func (b *Breaker) Trip(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.record(err)
+	if !b.open {
+		b.trip()
+	}
+}
+
+func (b *Breaker) record(err error) {
+	b.calls++
+	b.failed++
+	b.lastFailure = time.Now()
+	b.lastError = err.Error()
+	b.failures++
+}
+
+func (b *Breaker) trip() {
+	b.open = true
+	b.retryAt = time.Now().Add(b.cooldown)
+	slog.Error("LLM provider failing; using heuristic naming and grouping",
+		"provider", b.provider, "failures", b.failures, "last_error", b.lastError, "retry_in", b.cooldown)
+}
+
+// Counts returns how many calls have been reported, and how many of them
+// failed.
+func (b *Breaker) Counts() (calls, failed int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.calls, b.failed
+}
+
+func (b *Breaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := Status{
+		Provider:            b.provider,
+		Model:               b.model,
+		LastSuccess:         b.lastSuccess,
+		LastFailure:         b.lastFailure,
+		LastError:           b.lastError,
+		ConsecutiveFailures: b.failures,
+		Fallback:            b.open,
+	}
+	if b.open {
+		status.RetryAt = b.retryAt
+	}
+	return status
+}
+
+// Check validates the provider, key and model with a one-token completion.
+func Check(ctx context.Context, endpoint, key, model string) error {
+	client := openai.NewClient(
+		option.WithBaseURL(endpoint),
+		option.WithAPIKey(key),
+		option.WithMaxRetries(0),
+	)
+
+	completion, err := client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
+		Messages:  []openai.ChatCompletionMessageParamUnion{openai.UserMessage("ping")},
+		Model:     model,
+		MaxTokens: openai.Int(1),
+	})
+	if err != nil {
+		return fmt.Errorf("LLM check against %s failed: %w", endpoint, err)
+	}
+	if len(completion.Choices) == 0 {
+		return errors.New("LLM check returned no choices")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Priority orders LLM calls waiting for the provider.
+type Priority int
+
+const (
+	// Background work, like grouping, waits for everything else and is
+	// spaced out.
+	Background Priority = iota
+	// Interactive work, like naming a tool that was just captured, goes
+	// first.
+	Interactive
+)
+
+const (
+	// DefaultConcurrency is how many LLM calls run at once.
+	DefaultConcurrency = 2
+	// DefaultBackgroundInterval is the minimum gap between the starts of
+	// background calls.
+	DefaultBackgroundInterval = 5 * time.Second
+)
+
+// QueueStatus is the limiter state shown in /debug.
+type QueueStatus struct {
+	Running            int `json:"running"`
+	WaitingInteractive int `json:"waiting_interactive"`
+	WaitingBackground  int `json:"waiting_background"`
+}
+
+// Limiter bounds concurrent LLM calls so startup work and live capture
+// don't saturate the provider together. A nil *Limiter doesn't limit.
+type Limiter struct {
+	mu       sync.Mutex
+	slots    int
+	running  int
+	waiting  [2][]chan struct{}
+	interval time.Duration
+	// nextBackground is the earliest start of the next background call
+	nextBackground time.Time
+}
+
+func NewLimiter(concurrency int, backgroundInterval time.Duration) *Limiter {
+	return &Limiter{
+		slots:    max(concurrency, 1),
+		interval: backgroundInterval,
+	}
+}
+
+// Acquire waits for a slot and returns the function that gives it back.
+// It fails only when ctx ends first.
+func (l *Limiter) Acquire(ctx context.Context, p Priority) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if p == Background {
+		if err := l.pace(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	l.mu.Lock()
+	if l.running < l.slots && len(l.waiting[Interactive]) == 0 && (p == Interactive || len(l.waiting[Background]) == 0) {
+		l.running++
+		l.mu.Unlock()
+		return l.release, nil
+	}
+	ready := make(chan struct{})
+	l.waiting[p] = append(l.waiting[p], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.release, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if i := slices.Index(l.waiting[p], ready); i >= 0 {
+			l.waiting[p] = slices.Delete(l.waiting[p], i, i+1)
+			return nil, ctx.Err()
+		}
+		// The slot was handed over as ctx ended; pass it on
+		l.handOff()
+		return nil, ctx.Err()
+	}
+}
+
+// pace reserves the next background start time and sleeps until then.
+func (l *Limiter) pace(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.nextBackground
+	if start.Before(now) {
+		start = now
+	}
+	l.nextBackground = start.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (l *Limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handOff()
+}
+
+// handOff gives a finished call's slot to the next waiter, interactive
+// ones first. l.mu must be held.
+func (l *Limiter) handOff() {
+	for _, p := range []Priority{Interactive, Background} {
+		if len(l.waiting[p]) > 0 {
+			close(l.waiting[p][0])
+			l.waiting[p] = l.waiting[p][1:]
+			return
+		}
+	}
+	l.running--
+}
+
+func (l *Limiter) Status() QueueStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return QueueStatus{
+		Running:            l.running,
+		WaitingInteractive: len(l.waiting[Interactive]),
+		WaitingBackground:  len(l.waiting[Background]),
+	}
+}
@@ -0,0 +1,99 @@
+// Package metrics exposes mcpify's activity to Prometheus at /metrics:
+// capture, tool registration, tool calls, LLM calls and config saves.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds mcpify's collectors in a registry of its own. A nil
+// *Metrics records nothing, so components work the same without
+// --metrics.
+type Metrics struct {
+	registry    *prometheus.Registry
+	registered  prometheus.Counter
+	evicted     prometheus.Counter
+	toolCalls   *prometheus.CounterVec
+	toolLatency *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		registered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcpify_tools_registered_total",
+			Help: "Tools registered, from capture, the admin API or an OpenAPI import.",
+		}),
+		evicted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mcpify_tools_evicted_total",
+			Help: "Tools evicted to make room for new ones at --max-tools.",
+		}),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mcpify_tool_calls_total",
+			Help: `MCP tool calls sent upstream, by tool and response status code ("none" without a response).`,
+		}, []string{"tool", "status"}),
+		toolLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mcpify_tool_call_duration_seconds",
+			Help:    "Time from sending a tool call upstream to reading its response, retries included.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"tool"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.registered, m.evicted, m.toolCalls, m.toolLatency,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// CounterFunc exposes a total kept elsewhere, read by fn on each scrape,
+// as the counter name.
+func (m *Metrics) CounterFunc(name, help string, fn func() int64) {
+	if m == nil {
+		return
+	}
+	m.registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+		return float64(fn())
+	}))
+}
+
+// ToolRegistered counts a registered tool.
+func (m *Metrics) ToolRegistered() {
+	if m == nil {
+		return
+	}
+	m.registered.Inc()
+}
+
+// ToolEvicted counts an evicted tool.
+func (m *Metrics) ToolEvicted() {
+	if m == nil {
+		return
+	}
+	m.evicted.Inc()
+}
+
+// ToolCall counts a call of tool that got status, 0 without a response,
+// after elapsed.
+func (m *Metrics) ToolCall(tool string, status int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	label := "none"
+	if status != 0 {
+		label = strconv.Itoa(status)
+	}
+	m.toolCalls.WithLabelValues(tool, label).Inc()
+	m.toolLatency.WithLabelValues(tool).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,212 @@
+// Package observed remembers real parameter values seen in captured
+// traffic so agents can be offered IDs that actually exist.
+package observed
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// MaxValues is how many distinct values are kept per parameter.
+	MaxValues = 20
+	// DefaultTTL is how long a value is remembered after it was last seen.
+	DefaultTTL = 24 * time.Hour
+)
+
+var (
+	uuidSegment = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexSegment  = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	dateSegment = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	slugSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{4,}$`)
+	nonNameChar = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// Templater turns concrete paths into templates. The zero value collapses
+// numbers, UUIDs, long hex strings and redacted tokens; dates and slugs
+// often name distinct endpoints, so collapsing them is opt-in.
+type Templater struct {
+	// Dates also collapses ISO dates like 2024-01-01.
+	Dates bool
+	// Slugs also collapses segments mixing letters and digits, like a1b2c3.
+	Slugs bool
+}
+
+// Template replaces identifier-like path segments with named parameters
+// using the default Templater.
+func Template(path string) (string, map[string]string) {
+	return Templater{}.Template(path)
+}
+
+// Template replaces identifier-like path segments with named parameters,
+// e.g. /orders/42 becomes /orders/{order_id}. It also returns the value of
+// each parameter.
+func (t Templater) Template(path string) (string, map[string]string) {
+	segments := strings.Split(path, "/")
+	params := make(map[string]string)
+
+	for i, segment := range segments {
+		suffix := "_id"
+		switch {
+		case t.Dates && dateSegment.MatchString(segment):
+			suffix = "_date"
+		case isIdentifier(segment), t.Slugs && isSlug(segment):
+		default:
+			continue
+		}
+
+		name := strings.TrimPrefix(suffix, "_")
+		if i > 0 {
+			if noun := paramNoun(segments[i-1]); noun != "" {
+				name = noun + suffix
+			}
+		}
+		base := name
+		for n := 2; params[name] != ""; n++ {
+			name = base + strconv.Itoa(n)
+		}
+
+		params[name] = segment
+		segments[i] = "{" + name + "}"
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// paramNoun makes the start of a parameter's name from the segment before
+// it, e.g. order for orders. Matrix parameters and percent-encoding are
+// dropped, and characters argument names can't hold are replaced, so
+// /cars;color=red/1 gets car_id rather than a name with ; and = in it.
+func paramNoun(segment string) string {
+	if strings.HasPrefix(segment, "{") {
+		return ""
+	}
+	segment, _, _ = strings.Cut(segment, ";")
+	if decoded, err := url.PathUnescape(segment); err == nil {
+		segment = decoded
+	}
+	noun := strings.Trim(nonNameChar.ReplaceAllString(strings.ToLower(segment), "_"), "_")
+	return strings.TrimSuffix(noun, "s")
+}
+
+func isIdentifier(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if segment == "{token}" || uuidSegment.MatchString(segment) || hexSegment.MatchString(segment) {
+		return true
+	}
+	_, err := strconv.ParseUint(segment, 10, 64)
+	return err == nil
+}
+
+func isSlug(segment string) bool {
+	return slugSegment.MatchString(segment) &&
+		strings.ContainsAny(segment, "0123456789") &&
+		strings.ContainsFunc(segment, unicode.IsLetter)
+}
+
+// Value is one observed parameter value.
+type Value struct {
+	Value    string    `json:"value"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Tracker keeps a bounded, expiring set of values per endpoint parameter.
+type Tracker struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	optOut map[string]bool
+	// endpoint ("GET /orders/{order_id}") → parameter → value → entry
+	values map[string]map[string]map[string]*Value
+}
+
+// NewTracker creates a tracker that never records the parameters named in
+// optOut.
+func NewTracker(ttl time.Duration, optOut []string) *Tracker {
+	t := &Tracker{
+		ttl:    ttl,
+		optOut: make(map[string]bool),
+		values: make(map[string]map[string]map[string]*Value),
+	}
+	for _, name := range optOut {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			t.optOut[name] = true
+		}
+	}
+	return t
+}
+
+// Observe records value for param of endpoint. Redacted values are ignored.
+func (t *Tracker) Observe(endpoint, param, value string, at time.Time) {
+	if value == "" || t.optOut[strings.ToLower(param)] ||
+		value == "{token}" || strings.HasPrefix(value, "<redacted:") {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	params := t.values[endpoint]
+	if params == nil {
+		params = make(map[string]map[string]*Value)
+		t.values[endpoint] = params
+	}
+	values := params[param]
+	if values == nil {
+		values = make(map[string]*Value)
+		params[param] = values
+	}
+
+	if v := values[value]; v != nil {
+		v.Count++
+		v.LastSeen = at
+		return
+	}
+
+	values[value] = &Value{Value: value, Count: 1, LastSeen: at}
+	if len(values) > MaxValues {
+		var oldest *Value
+		for _, v := range values {
+			if oldest == nil || v.LastSeen.Before(oldest.LastSeen) {
+				oldest = v
+			}
+		}
+		delete(values, oldest.Value)
+	}
+}
+
+// Values returns the live values for each parameter of endpoint, most
+// recently seen first.
+func (t *Tracker) Values(endpoint string) map[string][]Value {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	result := make(map[string][]Value)
+	for param, values := range t.values[endpoint] {
+		var list []Value
+		for key, v := range values {
+			if now.Sub(v.LastSeen) > t.ttl {
+				delete(values, key)
+				continue
+			}
+			list = append(list, *v)
+		}
+		if len(list) == 0 {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].LastSeen.After(list[j].LastSeen)
+		})
+		result[param] = list
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+// Package openapi reads OpenAPI 3.x documents, so documented operations
+// can become tools without being captured first.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxDocumentSize bounds a downloaded or read document.
+const maxDocumentSize = 32 << 20
+
+var (
+	// ErrUnsupportedFormat is returned for documents that aren't JSON, such
+	// as YAML ones.
+	ErrUnsupportedFormat = errors.New("unsupported OpenAPI document format")
+	// ErrUnsupportedVersion is returned for Swagger 2.0 and other non-3.x
+	// documents.
+	ErrUnsupportedVersion = errors.New("unsupported OpenAPI version")
+)
+
+// Document is the part of an OpenAPI 3.x document operations are read
+// from.
+type Document struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       Info                       `json:"info"`
+	Servers    []Server                   `json:"servers,omitempty"`
+	Paths      map[string]json.RawMessage `json:"paths"`
+	Components Components                 `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string          `json:"$ref,omitempty"`
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Schema      *Schema         `json:"schema,omitempty"`
+	Example     json.RawMessage `json:"example,omitempty"`
+}
+
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema   *Schema                    `json:"schema,omitempty"`
+	Example  json.RawMessage            `json:"example,omitempty"`
+	Examples map[string]json.RawMessage `json:"examples,omitempty"`
+}
+
+// Schema is the subset of JSON Schema needed to make example values.
+// Type is a string in 3.0 and may be a list in 3.1.
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       json.RawMessage    `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Example    json.RawMessage    `json:"example,omitempty"`
+	Default    json.RawMessage    `json:"default,omitempty"`
+	Enum       []json.RawMessage  `json:"enum,omitempty"`
+	AllOf      []*Schema          `json:"allOf,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty"`
+	AnyOf      []*Schema          `json:"anyOf,omitempty"`
+}
+
+// Load reads a JSON OpenAPI 3.x document from a file or an http(s) URL.
+func Load(ctx context.Context, source string) (*Document, error) {
+	data, err := read(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes a JSON OpenAPI 3.x document.
+func Parse(data []byte) (*Document, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, fmt.Errorf("%w: only JSON documents are supported; convert YAML to JSON first", ErrUnsupportedFormat)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode OpenAPI document: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		version := doc.OpenAPI
+		if version == "" {
+			version = "missing"
+		}
+		return nil, fmt.Errorf("%w: openapi is %s, want 3.x", ErrUnsupportedVersion, version)
+	}
+	return &doc, nil
+}
+
+func read(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxDocumentSize))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+}
@@ -0,0 +1,419 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// methods are the operations a path item can have that mcpify can call.
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// methodsExtension holds operations with methods OpenAPI has no field
+// for, by method, as mcpify's own export writes them.
+const methodsExtension = "x-mcpify-methods"
+
+// maxExampleDepth bounds example generation for recursive schemas.
+const maxExampleDepth = 8
+
+// Endpoint is one documented operation, described the way tools are.
+type Endpoint struct {
+	Method string
+	// Path is the documented path, with {param} placeholders, below the
+	// server's base path.
+	Path string
+	Name string
+	// NamedByID reports whether Name is the operationId.
+	NamedByID   bool
+	Description string
+	// PathParams holds the path parameters with an example.
+	PathParams map[string]string
+	// QueryParams holds every query parameter, with its example or "".
+	QueryParams map[string]string
+	// Headers holds header parameters with an example, and the body's
+	// Content-Type.
+	Headers map[string]string
+	// ParamDescriptions are the documented descriptions of path and query
+	// parameters.
+	ParamDescriptions map[string]string
+	Body              string
+}
+
+// ServerPath is the base path of the document's first server, e.g. "/v1"
+// for "https://api.example.com/v1". Operation paths are relative to it.
+func (d *Document) ServerPath() string {
+	if len(d.Servers) == 0 {
+		return ""
+	}
+	u, err := url.Parse(d.Servers[0].URL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// Endpoints returns the document's operations ordered by path and
+// method.
+func (d *Document) Endpoints() ([]Endpoint, error) {
+	paths := make([]string, 0, len(d.Paths))
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	var endpoints []Endpoint
+	for _, path := range paths {
+		var item map[string]json.RawMessage
+		if err := json.Unmarshal(d.Paths[path], &item); err != nil {
+			return nil, fmt.Errorf("path %s: %w", path, err)
+		}
+		var shared []*Parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("path %s: parameters: %w", path, err)
+			}
+		}
+		for _, method := range methods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+			var op Operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+			}
+			endpoints = append(endpoints, d.endpoint(strings.ToUpper(method), path, &op, shared))
+		}
+		if raw, ok := item[methodsExtension]; ok {
+			var extended map[string]*Operation
+			if err := json.Unmarshal(raw, &extended); err != nil {
+				return nil, fmt.Errorf("path %s: %s: %w", path, methodsExtension, err)
+			}
+			names := make([]string, 0, len(extended))
+			for method := range extended {
+				names = append(names, method)
+			}
+			slices.Sort(names)
+			for _, method := range names {
+				if extended[method] != nil {
+					endpoints = append(endpoints, d.endpoint(strings.ToUpper(method), path, extended[method], shared))
+				}
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+func (d *Document) endpoint(method, path string, op *Operation, shared []*Parameter) Endpoint {
+	e := Endpoint{
+		Method:            method,
+		Path:              path,
+		Name:              toolName(op.OperationID),
+		NamedByID:         op.OperationID != "",
+		Description:       op.Summary,
+		PathParams:        make(map[string]string),
+		QueryParams:       make(map[string]string),
+		Headers:           make(map[string]string),
+		ParamDescriptions: make(map[string]string),
+	}
+	if e.Name == "" {
+		e.Name, e.NamedByID = pathName(method, path), false
+	}
+	if e.Description == "" {
+		e.Description = op.Description
+	}
+	if e.Description == "" {
+		e.Description = fmt.Sprintf("Imported from OpenAPI: %s %s", method, path)
+	}
+
+	for _, param := range d.parameters(shared, op.Parameters) {
+		example, ok := d.paramExample(param)
+		switch param.In {
+		case "path":
+			if ok {
+				e.PathParams[param.Name] = example
+			}
+		case "query":
+			e.QueryParams[param.Name] = example
+		case "header":
+			if ok {
+				e.Headers[param.Name] = example
+			}
+			continue
+		default:
+			continue
+		}
+		if param.Description != "" {
+			e.ParamDescriptions[param.Name] = param.Description
+		}
+	}
+
+	if body := d.requestBody(op.RequestBody); body != nil {
+		contentType, media := pickMediaType(body.Content)
+		if contentType != "" {
+			e.Headers["Content-Type"] = contentType
+			if isJSON(contentType) {
+				e.Body = d.bodyExample(media)
+			}
+		}
+	}
+	return e
+}
+
+// parameters merges path-level parameters with the operation's, which
+// override them by name and location.
+func (d *Document) parameters(shared, own []*Parameter) []*Parameter {
+	var params []*Parameter
+	for _, list := range [][]*Parameter{own, shared} {
+		for _, param := range list {
+			param = d.parameter(param)
+			if param == nil || param.Name == "" {
+				continue
+			}
+			if !slices.ContainsFunc(params, func(p *Parameter) bool { return p.Name == param.Name && p.In == param.In }) {
+				params = append(params, param)
+			}
+		}
+	}
+	return params
+}
+
+func (d *Document) parameter(p *Parameter) *Parameter {
+	if p == nil || p.Ref == "" {
+		return p
+	}
+	name, ok := strings.CutPrefix(p.Ref, "#/components/parameters/")
+	if !ok {
+		return nil
+	}
+	return d.Components.Parameters[name]
+}
+
+func (d *Document) requestBody(b *RequestBody) *RequestBody {
+	if b == nil || b.Ref == "" {
+		return b
+	}
+	name, ok := strings.CutPrefix(b.Ref, "#/components/requestBodies/")
+	if !ok {
+		return nil
+	}
+	return d.Components.RequestBodies[name]
+}
+
+func (d *Document) schema(s *Schema) *Schema {
+	for depth := 0; s != nil && s.Ref != "" && depth < maxExampleDepth; depth++ {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return nil
+		}
+		s = d.Components.Schemas[name]
+	}
+	return s
+}
+
+// paramExample returns param's example as it would be sent, and whether
+// the document gives one.
+func (d *Document) paramExample(param *Parameter) (string, bool) {
+	raw := param.Example
+	if len(raw) == 0 {
+		if s := d.schema(param.Schema); s != nil {
+			switch {
+			case len(s.Example) > 0:
+				raw = s.Example
+			case len(s.Default) > 0:
+				raw = s.Default
+			case len(s.Enum) > 0:
+				raw = s.Enum[0]
+			}
+		}
+	}
+	if len(raw) == 0 {
+		return "", false
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, true
+	}
+	return string(raw), true
+}
+
+// pickMediaType prefers JSON request bodies.
+func pickMediaType(content map[string]*MediaType) (string, *MediaType) {
+	types := make([]string, 0, len(content))
+	for contentType := range content {
+		types = append(types, contentType)
+	}
+	slices.Sort(types)
+	for _, contentType := range types {
+		if isJSON(contentType) {
+			return contentType, content[contentType]
+		}
+	}
+	if len(types) == 0 {
+		return "", nil
+	}
+	return types[0], content[types[0]]
+}
+
+func isJSON(contentType string) bool {
+	return contentType == "application/json" || strings.HasSuffix(contentType, "+json")
+}
+
+// bodyExample is the documented example body, or one made up from the
+// schema.
+func (d *Document) bodyExample(media *MediaType) string {
+	if media == nil {
+		return ""
+	}
+	if len(media.Example) > 0 {
+		return compact(media.Example)
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		var example struct {
+			Value json.RawMessage `json:"value"`
+		}
+		if json.Unmarshal(media.Examples[name], &example) == nil && len(example.Value) > 0 {
+			return compact(example.Value)
+		}
+	}
+	if media.Schema == nil {
+		return ""
+	}
+	data, err := json.Marshal(d.example(media.Schema, 0))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// example makes a value matching s, preferring documented examples.
+func (d *Document) example(s *Schema, depth int) any {
+	s = d.schema(s)
+	if s == nil || depth > maxExampleDepth {
+		return nil
+	}
+	for _, raw := range [][]byte{s.Example, s.Default} {
+		var v any
+		if len(raw) > 0 && json.Unmarshal(raw, &v) == nil {
+			return v
+		}
+	}
+	if len(s.Enum) > 0 {
+		var v any
+		json.Unmarshal(s.Enum[0], &v)
+		return v
+	}
+	if len(s.AllOf) > 0 {
+		merged := make(map[string]any)
+		for _, part := range s.AllOf {
+			if object, ok := d.example(part, depth+1).(map[string]any); ok {
+				for k, v := range object {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, choices := range [][]*Schema{s.OneOf, s.AnyOf} {
+		if len(choices) > 0 {
+			return d.example(choices[0], depth+1)
+		}
+	}
+
+	switch schemaType(s) {
+	case "object":
+		object := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			object[name] = d.example(prop, depth+1)
+		}
+		return object
+	case "array":
+		return []any{d.example(s.Items, depth+1)}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// schemaType is s's type, the first non-null one for 3.1 type lists.
+func schemaType(s *Schema) string {
+	var single string
+	if json.Unmarshal(s.Type, &single) == nil && single != "" {
+		return single
+	}
+	var list []string
+	json.Unmarshal(s.Type, &list)
+	for _, t := range list {
+		if t != "null" {
+			return t
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+func compact(raw json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	// A string example holds the body as text
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+var (
+	wordBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	nonNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// toolName turns an operationId like "getUserById" into get_user_by_id.
+func toolName(operationID string) string {
+	name := strings.ToLower(wordBoundary.ReplaceAllString(operationID, "${1}_${2}"))
+	name = strings.Trim(nonNameChars.ReplaceAllString(name, "_"), "_")
+	if len(name) > 64 {
+		name = strings.TrimRight(name[:64], "_")
+	}
+	return name
+}
+
+// pathName names operations without an operationId, e.g.
+// get_users_by_id for GET /users/{id}.
+func pathName(method, path string) string {
+	words := []string{strings.ToLower(method)}
+	for _, segment := range strings.Split(path, "/") {
+		if param, ok := strings.CutPrefix(segment, "{"); ok {
+			words = append(words, "by", strings.TrimSuffix(param, "}"))
+		} else if segment != "" {
+			words = append(words, segment)
+		}
+	}
+	return toolName(strings.Join(words, "_"))
+}
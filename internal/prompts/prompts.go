@@ -0,0 +1,183 @@
+// Package prompts holds the LLM prompts used to name and group tools.
+// They are versioned templates embedded in the binary, and each can be
+// replaced by a file of the same name in a prompt directory.
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+)
+
+// Prompt names, which are also their file names without ".tmpl".
+const (
+	Naming   = "naming"
+	Grouping = "grouping"
+)
+
+// Names lists the prompts in the order `mcpify prompts` shows them.
+var Names = []string{Naming, Grouping}
+
+var (
+	ErrUnknownPrompt = errors.New("unknown prompt")
+	ErrInvalidPrompt = errors.New("invalid prompt template")
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// versionComment is the "{{/* version: N */}}" a template starts with.
+// Templates without one are reported as version "custom".
+var versionComment = regexp.MustCompile(`^\{\{/\* version: (\S+) \*/\}\}`)
+
+// Prompt is one parsed template, which defines a "system" and a "user"
+// message.
+type Prompt struct {
+	Name    string
+	Version string
+	// Hash is the start of the template's SHA-256, so edits show up even
+	// when the version wasn't bumped.
+	Hash string
+	// Source is the override file, or "" for the embedded template.
+	Source string
+	Text   string
+	tmpl   *template.Template
+}
+
+// Ref identifies the prompt in provenance, e.g. "naming v1 sha256:0123abcd4567".
+func (p *Prompt) Ref() string {
+	version := p.Version
+	if version != "custom" {
+		version = "v" + version
+	}
+	return fmt.Sprintf("%s %s sha256:%s", p.Name, version, p.Hash)
+}
+
+// Render executes the prompt with data and returns its system and user
+// messages.
+func (p *Prompt) Render(data any) (system, user string, err error) {
+	var buf bytes.Buffer
+	if err := p.tmpl.ExecuteTemplate(&buf, "system", data); err != nil {
+		return "", "", fmt.Errorf("render %s prompt: %w", p.Name, err)
+	}
+	system = buf.String()
+	buf.Reset()
+	if err := p.tmpl.ExecuteTemplate(&buf, "user", data); err != nil {
+		return "", "", fmt.Errorf("render %s prompt: %w", p.Name, err)
+	}
+	return system, buf.String(), nil
+}
+
+// Set is the effective prompts: the embedded ones, less any overridden.
+type Set struct {
+	prompts map[string]*Prompt
+}
+
+var defaults = mustLoad("")
+
+// Default returns the embedded prompts.
+func Default() *Set {
+	return defaults
+}
+
+// Load returns the embedded prompts, with those that have a <name>.tmpl
+// file in dir replaced by it. An empty dir means no overrides.
+func Load(dir string) (*Set, error) {
+	set := &Set{prompts: make(map[string]*Prompt, len(Names))}
+	for _, name := range Names {
+		file := name + ".tmpl"
+		source := ""
+		text, err := fs.ReadFile(embedded, "templates/"+file)
+		if err != nil {
+			return nil, err
+		}
+		if dir != "" {
+			path := filepath.Join(dir, file)
+			override, err := os.ReadFile(path)
+			switch {
+			case err == nil:
+				text, source = override, path
+			case !errors.Is(err, fs.ErrNotExist):
+				return nil, err
+			}
+		}
+
+		prompt, err := parse(name, string(text))
+		if err != nil {
+			if source != "" {
+				return nil, fmt.Errorf("%s: %w", source, err)
+			}
+			return nil, err
+		}
+		prompt.Source = source
+		set.prompts[name] = prompt
+	}
+	return set, nil
+}
+
+func mustLoad(dir string) *Set {
+	set, err := Load(dir)
+	if err != nil {
+		panic(err)
+	}
+	return set
+}
+
+// Get returns the prompt called name.
+func (s *Set) Get(name string) (*Prompt, error) {
+	prompt := s.prompts[name]
+	if prompt == nil {
+		return nil, fmt.Errorf("%w: %q (want naming or grouping)", ErrUnknownPrompt, name)
+	}
+	return prompt, nil
+}
+
+// MustGet is Get for the names this package defines.
+func (s *Set) MustGet(name string) *Prompt {
+	prompt, err := s.Get(name)
+	if err != nil {
+		panic(err)
+	}
+	return prompt
+}
+
+func parse(name, text string) (*Prompt, error) {
+	tmpl, err := template.New(name).Funcs(template.FuncMap{"json": toJSON}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPrompt, err)
+	}
+	for _, part := range []string{"system", "user"} {
+		if tmpl.Lookup(part) == nil {
+			return nil, fmt.Errorf("%w: %s doesn't define %q", ErrInvalidPrompt, name, part)
+		}
+	}
+
+	version := "custom"
+	if m := versionComment.FindStringSubmatch(text); m != nil {
+		version = m[1]
+	}
+	sum := sha256.Sum256([]byte(text))
+	return &Prompt{
+		Name:    name,
+		Version: version,
+		Hash:    hex.EncodeToString(sum[:6]),
+		Text:    text,
+		tmpl:    tmpl,
+	}, nil
+}
+
+// toJSON is the templates' "json" function, which indents like the
+// prompts always have.
+func toJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	return string(data), err
+}
@@ -0,0 +1,159 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// namingInput and groupingInput have the fields of capture.NamingInput and
+// grouping.GroupingInput, which can't be imported here.
+type namingInput struct {
+	Method, Path, Body, Headers, Suggested string
+}
+
+type groupingInput struct {
+	Tools []map[string]interface{}
+}
+
+// checkGolden compares got with testdata/name, or rewrites it with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs; rerun with -update and review the diff:\n%s", name, got)
+	}
+}
+
+// mustGet is MustGet, failing the test rather than panicking.
+func mustGet(t *testing.T, set *Set, name string) (prompt *Prompt) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("MustGet(%q) panicked: %v", name, r)
+		}
+	}()
+	return set.MustGet(name)
+}
+
+func TestEmbeddedPromptsLoad(t *testing.T) {
+	set, err := Load("")
+	if err != nil {
+		t.Fatalf("the embedded prompts don't load, so mustLoad panics at startup: %v", err)
+	}
+	files, err := fs.Glob(embedded, "templates/*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if name := strings.TrimSuffix(filepath.Base(file), ".tmpl"); !slices.Contains(Names, name) {
+			t.Errorf("%s isn't in Names", file)
+		}
+	}
+	for _, name := range Names {
+		prompt := mustGet(t, set, name)
+		if prompt.Version == "custom" || prompt.Source != "" {
+			t.Errorf("%s: version %q from %q, want a numbered embedded template", name, prompt.Version, prompt.Source)
+		}
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		data any
+	}{
+		{
+			name: Naming,
+			data: namingInput{
+				Method:    "POST",
+				Path:      "/users/{id}/orders",
+				Body:      `{"sku":"A-100","quantity":2}`,
+				Headers:   "Content-Type: application/json\nAccept: application/json",
+				Suggested: "create_user_order",
+			},
+		},
+		{
+			name: Grouping,
+			data: groupingInput{Tools: []map[string]interface{}{
+				{"name": "list_users", "method": "GET", "path": "/users", "description": "List users"},
+				{"name": "create_user_order", "method": "POST", "path": "/users/{id}/orders", "description": "Order for a user"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			system, user, err := mustGet(t, Default(), tt.name).Render(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name+".golden", []byte("--- system ---\n"+system+"\n--- user ---\n"+user+"\n"))
+		})
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		version  string
+		err      error
+	}{
+		{name: "versioned", template: `{{/* version: 2 */}}{{define "system"}}Name it.{{end}}{{define "user"}}{{.Path}}{{end}}`, version: "2"},
+		{name: "unversioned", template: `{{define "system"}}Name it.{{end}}{{define "user"}}{{.Path}}{{end}}`, version: "custom"},
+		{name: "no user message", template: `{{define "system"}}Name it.{{end}}`, err: ErrInvalidPrompt},
+		{name: "unparseable", template: `{{define "system"}}Name it.`, err: ErrInvalidPrompt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, Naming+".tmpl")
+			if err := os.WriteFile(path, []byte(tt.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+			set, err := Load(dir)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) || !strings.Contains(err.Error(), path) {
+					t.Errorf("err = %v, want %v naming %s", err, tt.err, path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			prompt := mustGet(t, set, Naming)
+			if prompt.Version != tt.version || prompt.Source != path {
+				t.Errorf("version %q from %q, want %q from %s", prompt.Version, prompt.Source, tt.version, path)
+			}
+			if _, user, err := prompt.Render(namingInput{Path: "/users"}); err != nil || user != "/users" {
+				t.Errorf("Render = %q, %v", user, err)
+			}
+			// Prompts without an override stay embedded
+			if grouping := mustGet(t, set, Grouping); grouping.Source != "" {
+				t.Errorf("grouping loaded from %q", grouping.Source)
+			}
+		})
+	}
+
+	if _, err := Default().Get("summary"); !errors.Is(err, ErrUnknownPrompt) {
+		t.Errorf("Get(summary) = %v, want ErrUnknownPrompt", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Package redact detects secrets by their content rather than by the name
+// of the header or field that carries them.
+package redact
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+)
+
+const (
+	DefaultMinLength = 20
+	DefaultEntropy   = 4.0
+)
+
+// DefaultSensitiveParams are the query parameters whose values are never
+// kept, however they look.
+var DefaultSensitiveParams = []string{"api_key", "token", "signature"}
+
+// DefaultSensitiveFields are the JSON body fields whose values are never
+// kept, however they look.
+var DefaultSensitiveFields = []string{"password", "token", "secret", "api_key", "credit_card"}
+
+// Redacted replaces the values of sensitive body fields.
+const Redacted = "<redacted>"
+
+var (
+	jwtPattern  = regexp.MustCompile(`^eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+	awsPattern  = regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`)
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	// candidatePattern finds token-like runs inside free text. '=' ends a
+	// run, so the name of a name=value pair isn't judged with its value;
+	// base64 padding is left behind.
+	candidatePattern = regexp.MustCompile(`[A-Za-z0-9_\-.~+]{16,}`)
+
+	prefixes = []struct {
+		prefix string
+		kind   string
+	}{
+		{"sk-", "api_key"},
+		{"pk_live_", "api_key"},
+		{"sk_live_", "api_key"},
+		{"ghp_", "github_token"},
+		{"gho_", "github_token"},
+		{"ghs_", "github_token"},
+		{"github_pat_", "github_token"},
+		{"xoxb-", "slack_token"},
+		{"xoxp-", "slack_token"},
+		{"glpat-", "gitlab_token"},
+	}
+)
+
+// Detector replaces secret-looking values with "<redacted:kind>".
+type Detector struct {
+	// MinLength is the shortest value considered by the entropy check.
+	MinLength int
+	// Entropy is the Shannon entropy (bits per character) above which a
+	// value is treated as a secret.
+	Entropy float64
+	// Allow lists field, header and query names whose values are never
+	// redacted, compared case-insensitively.
+	Allow []string
+	// SensitiveParams lists query parameters whose values are always
+	// redacted, such as API keys, compared case-insensitively.
+	SensitiveParams []string
+	// SensitiveFields lists JSON body fields whose values are always
+	// replaced with Redacted. A field matches when its name contains one,
+	// ignoring case, '_' and '-', so "password" covers "newPassword".
+	SensitiveFields []string
+}
+
+func NewDetector() *Detector {
+	return &Detector{
+		MinLength:       DefaultMinLength,
+		Entropy:         DefaultEntropy,
+		SensitiveParams: DefaultSensitiveParams,
+		SensitiveFields: DefaultSensitiveFields,
+	}
+}
+
+// SensitiveParam reports whether the query parameter name always carries
+// a secret.
+func (d *Detector) SensitiveParam(name string) bool {
+	for _, s := range d.SensitiveParams {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SensitiveField reports whether the JSON body field name always carries
+// a secret.
+func (d *Detector) SensitiveField(name string) bool {
+	name = fieldKey(name)
+	for _, s := range d.SensitiveFields {
+		if s := fieldKey(s); s != "" && strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(strings.TrimSpace(name)))
+}
+
+// Allowed reports whether name is exempt from redaction.
+func (d *Detector) Allowed(name string) bool {
+	for _, a := range d.Allow {
+		if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect reports whether value as a whole looks like a secret, and what
+// kind.
+func (d *Detector) Detect(value string) (string, bool) {
+	if uuidPattern.MatchString(value) {
+		return "", false
+	}
+	if jwtPattern.MatchString(value) {
+		return "jwt", true
+	}
+	if awsPattern.MatchString(value) {
+		return "aws_key", true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(value, p.prefix) && len(value) >= len(p.prefix)+16 {
+			return p.kind, true
+		}
+	}
+
+	if len(value) >= d.MinLength && mixedClasses(value) && entropy(value) >= d.Entropy {
+		return "token", true
+	}
+	return "", false
+}
+
+// Value redacts value if it looks like a secret.
+func (d *Detector) Value(value string) string {
+	if kind, ok := d.Detect(value); ok {
+		return placeholder(kind)
+	}
+	return value
+}
+
+// Text redacts every secret-looking run inside free text.
+func (d *Detector) Text(text string) string {
+	// Only token-like runs are replaced, so "Bearer <token>" keeps its scheme
+	return candidatePattern.ReplaceAllStringFunc(text, d.Value)
+}
+
+// Headers redacts header values unless the header name is allowlisted.
+func (d *Detector) Headers(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if d.Allowed(k) {
+			redacted[k] = v
+		} else {
+			redacted[k] = d.Text(v)
+		}
+	}
+	return redacted
+}
+
+// Path replaces secret-looking path segments with a {token} parameter so
+// the path still works as a template.
+func (d *Detector) Path(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, ok := d.Detect(segment); ok {
+			segments[i] = "{token}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Body redacts secrets in a request body. JSON bodies are walked so that
+// sensitive fields are redacted whatever they hold and allowlisted fields
+// are left alone; anything else is treated as text.
+func (d *Detector) Body(body string) string {
+	return d.body(body, true)
+}
+
+// Fields redacts only the sensitive fields of a JSON body, leaving other
+// bodies as they are.
+func (d *Detector) Fields(body string) string {
+	return d.body(body, false)
+}
+
+// body redacts body, and secret-looking values too when detect is set.
+func (d *Detector) body(body string, detect bool) string {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		if !detect {
+			return body
+		}
+		return d.Text(body)
+	}
+
+	changed := false
+	doc = d.walk("", doc, detect, &changed)
+	if !changed {
+		return body
+	}
+
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return d.Text(body)
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// decodeJSON decodes a whole JSON document, keeping numbers as
+// json.Number so integers past 2^53 are written back as they came.
+func decodeJSON(body string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("data after the JSON value")
+	}
+	return doc, nil
+}
+
+func (d *Detector) walk(field string, v interface{}, detect bool, changed *bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if child != nil && child != Redacted && !d.Allowed(k) && d.SensitiveField(k) {
+				val[k] = Redacted
+				*changed = true
+				continue
+			}
+			val[k] = d.walk(k, child, detect, changed)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = d.walk(field, child, detect, changed)
+		}
+	case string:
+		if !detect || field != "" && d.Allowed(field) {
+			return val
+		}
+		if redacted := d.Text(val); redacted != val {
+			*changed = true
+			return redacted
+		}
+	}
+	return v
+}
+
+func placeholder(kind string) string {
+	return "<redacted:" + kind + ">"
+}
+
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	n := float64(len([]rune(s)))
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// mixedClasses filters out long words and plain numbers, which can have
+// high entropy without being secrets.
+func mixedClasses(s string) bool {
+	var lower, upper, digit bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit} {
+		if present {
+			classes++
+		}
+	}
+	return classes >= 2 && digit
+}
@@ -0,0 +1,329 @@
+// Package replica spreads tool calls across several instances of the
+// target, round-robin or by weight, and stops sending calls to instances
+// that keep failing.
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// Strategies for picking a replica.
+const (
+	RoundRobin = "round-robin"
+	Weighted   = "weighted"
+)
+
+const (
+	// FailureThreshold is how many consecutive failures take a replica
+	// out of rotation.
+	FailureThreshold = 3
+	// Cooldown is how long a failing replica is left alone before one
+	// call probes it again.
+	Cooldown = 30 * time.Second
+)
+
+var (
+	ErrInvalidReplica  = errors.New("invalid replica")
+	ErrUnknownStrategy = errors.New("unknown replica strategy")
+	// ErrNoReplica is returned when every replica is out of rotation.
+	ErrNoReplica = errors.New("no replica available")
+)
+
+// Parse reads a comma-separated list of base URLs, each optionally
+// followed by "=weight", e.g. "http://localhost:3002,http://localhost:3003=2".
+func Parse(list string) ([]config.Replica, error) {
+	var replicas []config.Replica
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		r := config.Replica{URL: item}
+		if base, weight, ok := strings.Cut(item, "="); ok {
+			n, err := strconv.Atoi(weight)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: weight of %s must be a positive integer", ErrInvalidReplica, base)
+			}
+			r.URL, r.Weight = base, n
+		}
+		replicas = append(replicas, r)
+	}
+	return replicas, nil
+}
+
+// Replica is one instance of the target.
+type Replica struct {
+	// Base is the replica's base URL, without a trailing slash.
+	Base   string
+	Host   string
+	Weight int
+
+	mu          sync.Mutex
+	current     int // smooth weighted round-robin state
+	failures    int
+	open        bool
+	retryAt     time.Time
+	lastError   string
+	lastFailure time.Time
+	calls       int64
+}
+
+// Status is a replica's health as shown in /debug.
+type Status struct {
+	URL                 string    `json:"url"`
+	Weight              int       `json:"weight"`
+	Calls               int64     `json:"calls"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Down                bool      `json:"down"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastFailure         time.Time `json:"last_failure,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+// allow reports whether r can take a call now. A replica out of rotation
+// gets one probe per cooldown. r.mu must be held.
+func (r *Replica) allow(now time.Time) bool {
+	if !r.open {
+		return true
+	}
+	if now.After(r.retryAt) {
+		r.retryAt = now.Add(Cooldown)
+		return true
+	}
+	return false
+}
+
+// Pool picks the replica each tool call goes to. A nil *Pool leaves calls
+// on the target.
+type Pool struct {
+	target   string
+	strategy string
+	sticky   bool
+	replicas []*Replica
+
+	mu       sync.Mutex
+	next     int
+	sessions map[string]*Replica
+}
+
+// New makes a pool of the target and replicas. The target is the first
+// replica, with weight 1 unless it is listed among replicas with another.
+// With sticky set, each MCP session keeps its replica while it is up.
+func New(target *url.URL, replicas []config.Replica, strategy string, sticky bool) (*Pool, error) {
+	switch strategy {
+	case "":
+		strategy = RoundRobin
+	case RoundRobin, Weighted:
+	default:
+		return nil, fmt.Errorf("%w %q (want %s or %s)", ErrUnknownStrategy, strategy, RoundRobin, Weighted)
+	}
+
+	p := &Pool{
+		target:   baseOf(target),
+		strategy: strategy,
+		sticky:   sticky,
+		sessions: make(map[string]*Replica),
+	}
+	p.replicas = append(p.replicas, &Replica{Base: p.target, Host: target.Host, Weight: 1})
+	for _, r := range replicas {
+		u, err := url.Parse(r.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("%w: %q is not an http(s) URL", ErrInvalidReplica, r.URL)
+		}
+		weight := r.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		if base := baseOf(u); base == p.target {
+			p.replicas[0].Weight = weight
+		} else {
+			p.replicas = append(p.replicas, &Replica{Base: base, Host: u.Host, Weight: weight})
+		}
+	}
+	return p, nil
+}
+
+func baseOf(u *url.URL) string {
+	base := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	return base.String() + strings.TrimSuffix(u.EscapedPath(), "/")
+}
+
+// Hosts returns the host:port of every replica but the target, which
+// capture treats as the target too.
+func (p *Pool) Hosts() []string {
+	if p == nil {
+		return nil
+	}
+	hosts := make([]string, 0, len(p.replicas)-1)
+	for _, r := range p.replicas[1:] {
+		hosts = append(hosts, r.Host)
+	}
+	return hosts
+}
+
+// Route points rawURL at the replica picked for session, returning the
+// new URL and the replica. URLs outside the target, such as those of
+// extra ports, are left alone and get a nil replica.
+func (p *Pool) Route(rawURL, session string) (string, *Replica, error) {
+	if p == nil {
+		return rawURL, nil, nil
+	}
+	rest, ok := strings.CutPrefix(rawURL, p.target)
+	if !ok || (rest != "" && !strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "?")) {
+		return rawURL, nil, nil
+	}
+	r := p.pick(session)
+	if r == nil {
+		return "", nil, fmt.Errorf("%w: all %d replicas are failing", ErrNoReplica, len(p.replicas))
+	}
+	return r.Base + rest, r, nil
+}
+
+func (p *Pool) pick(session string) *Replica {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.sticky && session != "" {
+		if r := p.sessions[session]; r != nil && p.take(r, now) {
+			return r
+		}
+	}
+
+	var r *Replica
+	if p.strategy == Weighted {
+		r = p.pickWeighted(now)
+	} else {
+		for i := range p.replicas {
+			candidate := p.replicas[(p.next+i)%len(p.replicas)]
+			if p.take(candidate, now) {
+				p.next = (p.next + i + 1) % len(p.replicas)
+				r = candidate
+				break
+			}
+		}
+	}
+	if r != nil && p.sticky && session != "" {
+		p.sessions[session] = r
+	}
+	return r
+}
+
+// pickWeighted is smooth weighted round-robin over the replicas in
+// rotation: each gains its weight, and the one with the most is picked
+// and loses the total.
+func (p *Pool) pickWeighted(now time.Time) *Replica {
+	var best *Replica
+	total := 0
+	for _, r := range p.replicas {
+		r.mu.Lock()
+		if !r.open || now.After(r.retryAt) {
+			r.current += r.Weight
+			total += r.Weight
+			if best == nil || r.current > best.current {
+				best = r
+			}
+		}
+		r.mu.Unlock()
+	}
+	if best == nil {
+		return nil
+	}
+	best.mu.Lock()
+	defer best.mu.Unlock()
+	if !best.allow(now) {
+		return nil
+	}
+	best.current -= total
+	best.calls++
+	return best
+}
+
+// take claims a call on r if it is in rotation.
+func (p *Pool) take(r *Replica, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.allow(now) {
+		return false
+	}
+	r.calls++
+	return true
+}
+
+// Done records how a call to r went: err is the transport error, and
+// status the response status otherwise. Transport errors and 5xx
+// responses count as failures. It reports whether r went down or came
+// back up.
+func (r *Replica) Done(status int, err error) (changed bool) {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil && status < 500 {
+		r.failures = 0
+		if !r.open {
+			return false
+		}
+		r.open = false
+		r.retryAt = time.Time{}
+		slog.Info("Replica is answering again; back in rotation", "replica", r.Base)
+		return true
+	}
+
+	if err != nil {
+		r.lastError = err.Error()
+	} else {
+		r.lastError = fmt.Sprintf("status %d", status)
+	}
+	r.lastFailure = time.Now()
+	r.failures++
+	if r.open || r.failures < FailureThreshold {
+		return false
+	}
+	r.open = true
+	r.retryAt = time.Now().Add(Cooldown)
+	slog.Error("Replica failing; out of rotation",
+		"replica", r.Base, "failures", r.failures, "last_error", r.lastError, "retry_in", Cooldown)
+	return true
+}
+
+// Down reports whether r is out of rotation.
+func (r *Replica) Down() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open
+}
+
+// Status reports every replica's health, the target first.
+func (p *Pool) Status() []Status {
+	statuses := make([]Status, 0, len(p.replicas))
+	for _, r := range p.replicas {
+		r.mu.Lock()
+		status := Status{
+			URL:                 r.Base,
+			Weight:              r.Weight,
+			Calls:               r.calls,
+			ConsecutiveFailures: r.failures,
+			Down:                r.open,
+			LastError:           r.lastError,
+			LastFailure:         r.lastFailure,
+		}
+		if r.open {
+			status.RetryAt = r.retryAt
+		}
+		r.mu.Unlock()
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
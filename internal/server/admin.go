@@ -0,0 +1,151 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// ToolHandler serves GET /api/tools/{name}: the tool as stored in the
+// config, including where its headers and body were captured. The name
+// may also be a tool ID.
+func ToolHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tool := cfg.LookupTool(r.PathValue("name"))
+		if tool == nil {
+			err := fmt.Errorf("%w: %q", ErrToolNotFound, r.PathValue("name"))
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tool)
+	})
+}
+
+// NewTool is the body of POST /admin/tools: what RegisterTool takes.
+type NewTool struct {
+	Name        string            `json:"name"`
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	PathParams  map[string]string `json:"path_params,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+// registerFunc is RegisterTool of whichever server is running.
+type registerFunc func(name, method, url string, pathParams, headers map[string]string, body []byte, description string) error
+
+// AddToolHandler serves POST /admin/tools, adding a tool by hand through
+// register. It answers 201 with the tool as stored.
+func AddToolHandler(cfg *config.Config, register registerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in NewTool
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		in.Method = strings.ToUpper(in.Method)
+
+		err := validateTool(in.Name, in.Method, in.URL)
+		switch {
+		case err != nil:
+		case cfg.GetTool(in.Name) != nil:
+			err = fmt.Errorf("%w: %q", config.ErrToolNameInUse, in.Name)
+		case cfg.ToolFor(in.Method, in.URL) != nil:
+			err = fmt.Errorf("%w: %s %s is %s", ErrEndpointExists, in.Method, in.URL, cfg.ToolFor(in.Method, in.URL).Name)
+		default:
+			err = register(in.Name, in.Method, in.URL, in.PathParams, in.Headers, []byte(in.Body), in.Description)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		tool := cfg.GetTool(in.Name)
+		if tool == nil {
+			err := fmt.Errorf("%w: %s %s", ErrEndpointExists, in.Method, in.URL)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		slog.Info("Added tool", "tool", tool.Name, "method", tool.Method, "url", tool.URL)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tool)
+	})
+}
+
+// UpdateToolHandler serves PATCH /admin/tools/{name} with a
+// config.ToolPatch, e.g. {"description": "...", "headers": {"X-Old": null}}.
+// onChange republishes the tool; oldName is the name it had before.
+func UpdateToolHandler(cfg *config.Config, onChange func(tool *config.Tool, oldName string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var patch config.ToolPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		if current := cfg.LookupTool(r.PathValue("name")); current != nil {
+			name, url := current.Name, current.URL
+			if patch.Name != nil {
+				name = *patch.Name
+			}
+			if patch.URL != nil {
+				url = *patch.URL
+			}
+			if err := validateTool(name, current.Method, url); err != nil {
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+		}
+
+		tool, oldName, err := cfg.UpdateTool(r.PathValue("name"), patch)
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		if err := cfg.Save(cfg.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+		onChange(tool, oldName)
+		slog.Info("Updated tool", "tool", tool.Name)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tool)
+	})
+}
+
+// RemoveToolHandler serves DELETE /admin/tools/{name} through remove,
+// answering 204.
+func RemoveToolHandler(remove func(name string) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := remove(r.PathValue("name")); err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		slog.Info("Removed tool", "tool", r.PathValue("name"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// validateTool checks what a hand-made tool needs: a name, a method token
+// and an absolute http(s) URL.
+func validateTool(name, method, rawURL string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTool)
+	}
+	if !config.ValidMethod(method) {
+		return fmt.Errorf("%w: invalid method %q", ErrInvalidTool, method)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%w: %q is not an absolute http(s) URL", ErrInvalidTool, rawURL)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// toolAnnotations tells clients what calling tool does, so they can ask
+// before calls that change things: GET and HEAD are read-only, DELETE is
+// destructive, PUT and DELETE are idempotent and POST is none of these.
+// The tool's annotations in the config override any of it.
+func toolAnnotations(tool *config.Tool) *mcp.ToolAnnotations {
+	method := strings.ToUpper(tool.Method)
+	readOnly := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	destructive := method == http.MethodDelete
+	idempotent := readOnly || method == http.MethodPut || method == http.MethodDelete
+
+	if override := tool.Annotations; override != nil {
+		if override.ReadOnly != nil {
+			readOnly = *override.ReadOnly
+		}
+		if override.Destructive != nil {
+			destructive = *override.Destructive
+		}
+		if override.Idempotent != nil {
+			idempotent = *override.Idempotent
+		}
+	}
+	return annotations(readOnly, destructive, idempotent)
+}
+
+// groupAnnotations combines the annotations of a group's tools: the group
+// is read-only or idempotent if all of them are, and destructive if any
+// is.
+func groupAnnotations(tools []*config.Tool) *mcp.ToolAnnotations {
+	readOnly, destructive, idempotent := true, false, true
+	for _, tool := range tools {
+		a := toolAnnotations(tool)
+		readOnly = readOnly && a.ReadOnlyHint
+		destructive = destructive || !a.ReadOnlyHint && *a.DestructiveHint
+		idempotent = idempotent && (a.ReadOnlyHint || a.IdempotentHint)
+	}
+	return annotations(readOnly, destructive, idempotent)
+}
+
+// annotations builds the MCP form, in which a read-only tool's other
+// hints mean nothing and a missing destructive hint means true.
+func annotations(readOnly, destructive, idempotent bool) *mcp.ToolAnnotations {
+	if readOnly {
+		return &mcp.ToolAnnotations{ReadOnlyHint: true}
+	}
+	return &mcp.ToolAnnotations{DestructiveHint: &destructive, IdempotentHint: idempotent}
+}
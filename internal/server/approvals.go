@@ -0,0 +1,16 @@
+package server
+
+import (
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// blockedResult reports a call that was not approved. It is a tool error
+// rather than a protocol error so the agent can tell the user why.
+func blockedResult(err error) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: err.Error()},
+		},
+	}
+}
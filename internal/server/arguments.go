@@ -0,0 +1,301 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/jsonschema"
+)
+
+// idAlias is accepted in place of the only path parameter of a tool, since
+// agents reach for "id" before reading the schema.
+const idAlias = "id"
+
+// callParamNames are the argument names CallParams already uses.
+var callParamNames = func() map[string]bool {
+	names := make(map[string]bool)
+	if schema, err := jsonschema.For[CallParams](); err == nil {
+		for name := range schema.Properties {
+			names[name] = true
+		}
+	}
+	return names
+}()
+
+// toolArguments names the arguments a tool takes on top of CallParams.
+type toolArguments struct {
+	path  []string
+	query []string
+	// body are the top-level fields of the tool's JSON body
+	body []string
+	// alias stands in for the only path parameter, or is empty
+	alias string
+}
+
+// argumentsOf works out tool's extra arguments. Path parameters win over
+// query parameters, which win over body fields of the same name, and none
+// may shadow CallParams. Shadowed body fields are still set through
+// override_body.
+func argumentsOf(tool *config.Tool) toolArguments {
+	var args toolArguments
+	taken := make(map[string]bool)
+	for name := range callParamNames {
+		taken[name] = true
+	}
+
+	for _, name := range tool.PathParamNames() {
+		if !taken[name] {
+			args.path = append(args.path, name)
+			taken[name] = true
+		}
+	}
+	for _, name := range tool.QueryParamNames() {
+		if !taken[name] {
+			args.query = append(args.query, name)
+			taken[name] = true
+		}
+	}
+	if schema := tool.InputBodySchema(); schema != nil {
+		for _, name := range slices.Sorted(maps.Keys(schema.Properties)) {
+			if !taken[name] {
+				args.body = append(args.body, name)
+				taken[name] = true
+			}
+		}
+	}
+	if len(args.path) == 1 && !taken[idAlias] {
+		args.alias = idAlias
+	}
+	return args
+}
+
+// toolInputSchema extends the CallParams schema with one property per path
+// and query parameter of tool. Query parameters in serverProvided are
+// marked read-only, as the server sets them.
+func toolInputSchema(tool *config.Tool, serverProvided map[string]string) (*jsonschema.Schema, error) {
+	schema, err := jsonschema.For[CallParams]()
+	if err != nil {
+		return nil, err
+	}
+
+	args := argumentsOf(tool)
+	for _, name := range args.path {
+		description := fmt.Sprintf("Value for {%s} in the path.", name)
+		if value, ok := tool.PathParams[name]; ok {
+			description += fmt.Sprintf(" Defaults to %q, the captured value.", value)
+		}
+		if doc := tool.ParamDescriptions[name]; doc != "" {
+			description = doc + " " + description
+		}
+		schema.Properties[name] = &jsonschema.Schema{
+			Types:       []string{"string", "integer"},
+			Description: description,
+		}
+	}
+	if args.alias != "" {
+		schema.Properties[args.alias] = &jsonschema.Schema{
+			Types:       []string{"string", "integer"},
+			Description: fmt.Sprintf("Alias for %s.", args.path[0]),
+		}
+	}
+	for _, name := range args.query {
+		if _, ok := serverProvided[name]; ok {
+			schema.Properties[name] = &jsonschema.Schema{
+				Types:       []string{"string"},
+				Description: serverProvidedHint,
+				ReadOnly:    true,
+			}
+			continue
+		}
+		description := fmt.Sprintf("Query parameter %s. Pass an empty string to leave it out.", name)
+		if value := tool.QueryParams[name]; value != "" {
+			description += fmt.Sprintf(" Defaults to %q, the captured value.", value)
+		}
+		if doc := tool.ParamDescriptions[name]; doc != "" {
+			description = doc + " " + description
+		}
+		schema.Properties[name] = &jsonschema.Schema{
+			Types:       []string{"string", "integer", "number", "boolean"},
+			Description: description,
+		}
+	}
+
+	if len(args.body) > 0 {
+		fields := tool.InputBodySchema().Properties
+		example, _ := decodeCapturedBody(tool.Body)
+		for _, name := range args.body {
+			field := bodyFieldSchema(fields[name])
+			field.Description = fmt.Sprintf("Field %s of the JSON request body.", name)
+			if value, ok := example[name]; ok {
+				if encoded, err := json.Marshal(value); err == nil && len(encoded) <= maxExampleLength {
+					field.Description += fmt.Sprintf(" Defaults to %s, the captured value.", encoded)
+				} else {
+					field.Description += " Defaults to the captured value."
+				}
+			}
+			if slices.Contains(fields[name].Types, "object") {
+				field.Description += " Objects are merged into the captured one field by field."
+			}
+			schema.Properties[name] = field
+		}
+	}
+	return schema, nil
+}
+
+// maxExampleLength caps captured body values quoted in field descriptions.
+const maxExampleLength = 80
+
+func bodyFieldSchema(s *config.BodySchema) *jsonschema.Schema {
+	field := &jsonschema.Schema{Types: slices.Clone(s.Types)}
+	if len(field.Types) == 1 {
+		field.Type, field.Types = field.Types[0], nil
+	}
+	if s.Items != nil {
+		field.Items = bodyFieldSchema(s.Items)
+	}
+	if len(s.Properties) > 0 {
+		field.Properties = make(map[string]*jsonschema.Schema, len(s.Properties))
+		for name, child := range s.Properties {
+			field.Properties[name] = bodyFieldSchema(child)
+		}
+	}
+	return field
+}
+
+// splitArguments separates the path and query parameter values and body
+// fields in args from the remaining arguments, which are decoded into
+// CallParams. It fails when a path parameter has neither a value nor a
+// captured default.
+func splitArguments(tool *config.Tool, args map[string]any) (pathValues, queryValues map[string]string, bodyValues map[string]any, params CallParams, err error) {
+	names := argumentsOf(tool)
+
+	rest := make(map[string]any, len(args))
+	for k, v := range args {
+		rest[k] = v
+	}
+
+	pathValues = make(map[string]string)
+	for _, name := range names.path {
+		v, ok := rest[name]
+		if !ok && names.alias != "" {
+			v, ok = rest[names.alias]
+		}
+		if ok {
+			if pathValues[name], err = argumentString(name, v); err != nil {
+				return nil, nil, nil, params, err
+			}
+		} else if _, ok := tool.PathParams[name]; !ok {
+			return nil, nil, nil, params, fmt.Errorf("missing path parameter %q", name)
+		}
+		delete(rest, name)
+	}
+	if names.alias != "" {
+		delete(rest, names.alias)
+	}
+
+	queryValues = make(map[string]string)
+	for _, name := range names.query {
+		if v, ok := rest[name]; ok {
+			if queryValues[name], err = argumentString(name, v); err != nil {
+				return nil, nil, nil, params, err
+			}
+			delete(rest, name)
+		}
+	}
+
+	bodyValues = make(map[string]any)
+	for _, name := range names.body {
+		if v, ok := rest[name]; ok {
+			bodyValues[name] = v
+			delete(rest, name)
+		}
+	}
+
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return nil, nil, nil, params, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, nil, nil, params, fmt.Errorf("invalid arguments: %w", err)
+	}
+	return pathValues, queryValues, bodyValues, params, nil
+}
+
+// buildBody merges fields over the JSON object in captured, the tool's
+// example body. Objects merge field by field; other values replace what was
+// captured.
+func buildBody(captured string, fields map[string]any) ([]byte, error) {
+	if len(fields) == 0 {
+		return []byte(captured), nil
+	}
+	body := make(map[string]any)
+	if captured != "" {
+		var err error
+		if body, err = decodeCapturedBody(captured); err != nil {
+			return nil, fmt.Errorf("captured body is not a JSON object; use override_body: %w", err)
+		}
+	}
+	mergeFields(body, fields)
+	return json.Marshal(body)
+}
+
+// decodeCapturedBody decodes a JSON object body, keeping its numbers as
+// json.Number so IDs past 2^53 are sent as they were captured.
+func decodeCapturedBody(body string) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("data after the JSON object")
+	}
+	if obj == nil {
+		return nil, errors.New("body is null")
+	}
+	return obj, nil
+}
+
+func mergeFields(dst, src map[string]any) {
+	for name, value := range src {
+		if obj, ok := value.(map[string]any); ok {
+			if existing, ok := dst[name].(map[string]any); ok {
+				mergeFields(existing, obj)
+				continue
+			}
+		}
+		dst[name] = value
+	}
+}
+
+// maxSafeInteger is the largest integer a float64, and so a JSON number
+// decoded by the MCP SDK, can't have been rounded to.
+const maxSafeInteger = 1<<53 - 1
+
+// argumentString formats the value of the path or query parameter name.
+// Integers past 2^53 have lost digits by the time they arrive as numbers,
+// so they are refused rather than sent wrong.
+func argumentString(name string, v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case float64:
+		if math.Abs(v) > maxSafeInteger {
+			return "", fmt.Errorf("%s: %v is too large to pass exactly as a number; pass it as a string", name, v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
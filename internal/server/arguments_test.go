@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestBodyFieldsKeepLargeIDs(t *testing.T) {
+	got := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- string(body)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+	captured := `{"order_id":9007199254740993,"customer":{"id":1234567890123456789,"name":"Ada"},"quantity":1}`
+	headers := map[string]string{"Content-Type": "application/json"}
+	if err := s.RegisterTool("post_orders", "POST", api.URL+"/orders", nil, headers, []byte(captured), "Create an order"); err != nil {
+		t.Fatal(err)
+	}
+	client, _ := connect(t, s.mcpServer)
+
+	result, err := client.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "post_orders",
+		Arguments: map[string]any{"quantity": 3, "customer": map[string]any{"name": "Grace"}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool: %v %+v", err, result)
+	}
+
+	want := `{"customer":{"id":1234567890123456789,"name":"Grace"},"order_id":9007199254740993,"quantity":3}`
+	if body := <-got; body != want {
+		t.Errorf("target got %s, want %s", body, want)
+	}
+}
+
+func TestLargeParameterValues(t *testing.T) {
+	tool := &config.Tool{
+		Name:        "get_order",
+		Method:      "GET",
+		URL:         "http://localhost:3000/orders/{order_id}",
+		PathParams:  map[string]string{"order_id": "1"},
+		QueryParams: map[string]string{"after": "0"},
+	}
+	tests := []struct {
+		name  string
+		value any
+		want  string
+		err   bool
+	}{
+		{name: "string", value: "9007199254740993", want: "9007199254740993"},
+		{name: "json.Number", value: json.Number("9007199254740993"), want: "9007199254740993"},
+		{name: "small integer", value: float64(42), want: "42"},
+		{name: "largest safe integer", value: float64(1<<53 - 1), want: "9007199254740991"},
+		{name: "fraction", value: 2.5, want: "2.5"},
+		{name: "past 2^53", value: float64(9007199254740993), err: true},
+		{name: "negative past 2^53", value: -1e20, err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, param := range []string{"order_id", "after"} {
+				path, query, _, _, err := splitArguments(tool, map[string]any{param: tt.value})
+				if tt.err {
+					if err == nil || !strings.Contains(err.Error(), param) {
+						t.Errorf("%s: err = %v, want one naming the parameter", param, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", param, err)
+				}
+				if got := path[param] + query[param]; got != tt.want {
+					t.Errorf("%s = %q, want %q", param, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// effectiveAssertions prefers assertions passed with the call over the
+// ones stored on the tool.
+func effectiveAssertions(call *config.Assertions, tool *config.Tool) *config.Assertions {
+	if !call.IsEmpty() {
+		return call
+	}
+	return tool.Assertions
+}
+
+// checkAssertions returns one message per failed assertion.
+func checkAssertions(a *config.Assertions, status int, body []byte) []string {
+	if a.IsEmpty() {
+		return nil
+	}
+
+	var failures []string
+	if a.ExpectStatus != 0 && status != a.ExpectStatus {
+		failures = append(failures, fmt.Sprintf("expect_status: want %d, got %d", a.ExpectStatus, status))
+	}
+
+	if a.ExpectContains != "" && !strings.Contains(string(body), a.ExpectContains) {
+		failures = append(failures, fmt.Sprintf("expect_contains: body does not contain %q", a.ExpectContains))
+	}
+
+	if len(a.ExpectJSON) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			failures = append(failures, fmt.Sprintf("expect_json: response is not JSON: %v", err))
+			return failures
+		}
+
+		paths := make([]string, 0, len(a.ExpectJSON))
+		for path := range a.ExpectJSON {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			want := a.ExpectJSON[path]
+			got, ok := lookupJSONPath(doc, path)
+			if !ok {
+				failures = append(failures, fmt.Sprintf("expect_json: %s not found", path))
+			} else if !jsonEqual(got, want) {
+				failures = append(failures, fmt.Sprintf("expect_json: %s: want %v, got %v", path, want, got))
+			}
+		}
+	}
+
+	return failures
+}
+
+// lookupJSONPath resolves simple JSONPath expressions such as
+// "$.data.items[0].id" or "status".
+func lookupJSONPath(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, true
+	}
+
+	current := doc
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name != "" {
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[name]; !ok {
+				return nil, false
+			}
+		}
+
+		for rest != "" {
+			idxStr, after, found := strings.Cut(rest, "]")
+			if !found {
+				return nil, false
+			}
+			idx, err := strconv.Atoi(idxStr)
+			arr, ok := current.([]interface{})
+			if err != nil || !ok || idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			current = arr[idx]
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return current, true
+}
+
+// jsonEqual compares values after a JSON round trip, so 1 and 1.0 match.
+func jsonEqual(a, b interface{}) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var na, nb interface{}
+	json.Unmarshal(ja, &na)
+	json.Unmarshal(jb, &nb)
+	return reflect.DeepEqual(na, nb)
+}
+
+func assertionFailureResult(failures []string, status int, meta *ResultMeta, content *responseContent, body []byte) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Assertions failed:\n- %s\n\n%s", strings.Join(failures, "\n- "), resultText(status, nil, meta, content, body)),
+			},
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// serverProvidedHint describes query parameters that come from --auth-query.
+const serverProvidedHint = "Provided by the server for authentication; don't pass it."
+
+// SetAuthQuery makes every tool call send the query parameters in params,
+// whatever the agent passed. Tool schemas mark them as provided by the
+// server. Values are resolved already and are never stored.
+func (e *extensions) SetAuthQuery(params map[string]string) {
+	e.authQuery = params
+}
+
+// addAuthQuery sets the auth query parameters in queryValues, the
+// parameters a call resolves its URL with.
+func (e *extensions) addAuthQuery(queryValues map[string]string) {
+	for name, value := range e.authQuery {
+		queryValues[name] = value
+	}
+}
+
+// withoutAuthQuery is u with the values of the auth query parameters
+// redacted.
+func (e *extensions) withoutAuthQuery(u *url.URL) string {
+	if len(e.authQuery) == 0 {
+		return u.String()
+	}
+	redactedURL := *u
+	query := redactedURL.Query()
+	for name := range e.authQuery {
+		if query.Has(name) {
+			query.Set(name, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	return redactedURL.String()
+}
+
+// hideAuthQuery redacts the auth query from the URL a failed request's
+// error names, as the error is shown to the agent and saved as the
+// tool's last error. The error still unwraps to err.
+func (e *extensions) hideAuthQuery(err error) error {
+	var urlErr *url.Error
+	if len(e.authQuery) == 0 || !errors.As(err, &urlErr) {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	return &redactedError{msg: strings.ReplaceAll(err.Error(), urlErr.URL, e.withoutAuthQuery(u)), err: err}
+}
+
+// redactedError is err with a message that leaves out secrets.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
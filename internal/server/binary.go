@@ -0,0 +1,161 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// BinaryMode is how tool results carry a binary response body, such as an
+// image or a PDF.
+type BinaryMode string
+
+const (
+	// BinaryResource attaches the body, base64-encoded, as image, audio
+	// or embedded resource content.
+	BinaryResource BinaryMode = "resource"
+	// BinaryFile saves the whole body to a file in a directory of the
+	// run's own and names it; RemoveBinaryFiles deletes them.
+	BinaryFile BinaryMode = "file"
+	// BinarySummary only describes the body.
+	BinarySummary BinaryMode = "summary"
+)
+
+// maxBinaryFile bounds the bodies BinaryFile saves.
+const maxBinaryFile = 256 << 20
+
+func ParseBinaryMode(s string) (BinaryMode, error) {
+	switch BinaryMode(strings.ToLower(strings.TrimSpace(s))) {
+	case BinaryResource, "":
+		return BinaryResource, nil
+	case BinaryFile:
+		return BinaryFile, nil
+	case BinarySummary:
+		return BinarySummary, nil
+	}
+	return "", fmt.Errorf("%w %q (want resource, file or summary)", ErrUnknownBinaryMode, s)
+}
+
+// SetBinaryMode sets how tool results carry binary response bodies.
+func (e *extensions) SetBinaryMode(mode BinaryMode) {
+	e.binaryMode = mode
+}
+
+// isBinaryContent reports whether a response body is binary: by its
+// content type, as for images and PDFs, or by its bytes.
+func isBinaryContent(content *responseContent, body []byte) bool {
+	if content == nil {
+		return isBinary(body)
+	}
+	if content.Encoding != "" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(content.Type)
+	switch {
+	case strings.HasSuffix(mediaType, "+xml"), strings.HasSuffix(mediaType, "+json"):
+		return isBinary(body)
+	case strings.HasPrefix(mediaType, "image/"), strings.HasPrefix(mediaType, "audio/"), strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/pdf", mediaType == "application/octet-stream", mediaType == "application/zip":
+		return true
+	}
+	return isBinary(body)
+}
+
+// binaryResult carries a binary body as the binary mode says: as content
+// to attach to the result, or as a note on where it was saved or why it
+// wasn't attached. It does nothing for bodies that aren't binary, or are
+// in an encoding mcpify couldn't decode.
+func (e *extensions) binaryResult(content *responseContent, body []byte) (mcp.Content, string) {
+	if content == nil || !isBinaryContent(content, body) || content.Encoding != "" {
+		return nil, ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(content.Type)
+
+	switch e.binaryMode {
+	case BinaryFile:
+		path, err := e.saveBinary(mediaType, body, content.rest)
+		if err != nil {
+			return nil, fmt.Sprintf("[the body couldn't be saved: %v]", err)
+		}
+		return nil, "[body saved to " + path + "]"
+	case BinarySummary:
+		return nil, ""
+	}
+
+	// The size limit applies before base64, so a cut-off body isn't sent
+	if content.Truncated {
+		return nil, fmt.Sprintf("[not attached: over the %s response size limit]", diskbudget.FormatSize(content.Limit))
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		return &mcp.ImageContent{Data: body, MIMEType: mediaType}, ""
+	case strings.HasPrefix(mediaType, "audio/"):
+		return &mcp.AudioContent{Data: body, MIMEType: mediaType}, ""
+	}
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{URI: content.URL, MIMEType: mediaType, Blob: body}}, ""
+}
+
+// saveBinary writes body and what is left of the response after it, up to
+// maxBinaryFile, to a file named for mediaType in the run's binary
+// directory, and returns its path.
+func (e *extensions) saveBinary(mediaType string, body []byte, rest io.Reader) (string, error) {
+	dir, err := e.binaryFileDir()
+	if err != nil {
+		return "", err
+	}
+	ext := ""
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	f, err := os.CreateTemp(dir, "body-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(body); err != nil {
+		return "", err
+	}
+	if rest != nil {
+		if _, err := io.Copy(f, io.LimitReader(rest, maxBinaryFile-int64(len(body)))); err != nil {
+			return "", err
+		}
+	}
+	return f.Name(), f.Close()
+}
+
+// binaryFileDir returns the directory BinaryFile saves bodies to, making
+// it on first use.
+func (e *extensions) binaryFileDir() (string, error) {
+	e.binaryMu.Lock()
+	defer e.binaryMu.Unlock()
+	if e.binaryDir == "" {
+		dir, err := os.MkdirTemp("", "mcpify-bodies-*")
+		if err != nil {
+			return "", err
+		}
+		e.binaryDir = dir
+	}
+	return e.binaryDir, nil
+}
+
+// RemoveBinaryFiles deletes the bodies BinaryFile saved this run. They
+// only need to outlive the calls that named them, not the run.
+func (e *extensions) RemoveBinaryFiles() error {
+	e.binaryMu.Lock()
+	defer e.binaryMu.Unlock()
+	if e.binaryDir == "" {
+		return nil
+	}
+	dir := e.binaryDir
+	e.binaryDir = ""
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// testPNG is the 8-byte PNG signature and 200 bytes of image data.
+var testPNG = append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7}, 25)...)
+
+func TestImageResponse(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(testPNG)
+	}))
+	defer api.Close()
+
+	tests := []struct {
+		mode BinaryMode
+		// attached is the content expected after the text, nil for none
+		attached func(t *testing.T, c mcp.Content)
+		// file is whether the text names a saved file
+		file bool
+	}{
+		{
+			mode: BinaryResource,
+			attached: func(t *testing.T, c mcp.Content) {
+				image, ok := c.(*mcp.ImageContent)
+				if !ok {
+					t.Fatalf("attached %T, want image content", c)
+				}
+				if image.MIMEType != "image/png" || !bytes.Equal(image.Data, testPNG) {
+					t.Errorf("image is %s of %d bytes, want the %d byte PNG", image.MIMEType, len(image.Data), len(testPNG))
+				}
+			},
+		},
+		{mode: BinaryFile, file: true},
+		{mode: BinarySummary},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+			s.SetBinaryMode(tt.mode)
+			if err := s.RegisterTool("get_logo", "GET", api.URL+"/logo.png", nil, nil, nil, "Get the logo"); err != nil {
+				t.Fatal(err)
+			}
+			client, _ := connect(t, s.mcpServer)
+
+			result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: "get_logo", Arguments: map[string]any{}})
+			if err != nil || result.IsError {
+				t.Fatalf("CallTool: %v %+v", err, result)
+			}
+			text, ok := result.Content[0].(*mcp.TextContent)
+			if !ok {
+				t.Fatalf("first content is %T, want text", result.Content[0])
+			}
+			// The body is summarized, never dumped into the text
+			if !strings.Contains(text.Text, "[binary body: 208 B, image/png, starts 89504e470d0a1a0a") || strings.Contains(text.Text, "PNG") {
+				t.Errorf("text doesn't summarize the PNG:\n%s", text.Text)
+			}
+
+			if tt.attached != nil {
+				if len(result.Content) != 2 {
+					t.Fatalf("got %d contents, want text and the image", len(result.Content))
+				}
+				tt.attached(t, result.Content[1])
+			} else if len(result.Content) != 1 {
+				t.Errorf("got %d contents, want only text", len(result.Content))
+			}
+
+			_, path, saved := strings.Cut(text.Text, "[body saved to ")
+			if saved != tt.file {
+				t.Fatalf("text names a saved file: %v, want %v:\n%s", saved, tt.file, text.Text)
+			}
+			if !saved {
+				return
+			}
+			path, _, _ = strings.Cut(path, "]")
+			if filepath.Ext(path) != ".png" {
+				t.Errorf("saved to %s, want a .png file", path)
+			}
+			if data, err := os.ReadFile(path); err != nil || !bytes.Equal(data, testPNG) {
+				t.Errorf("saved file holds %d bytes (%v), want the PNG", len(data), err)
+			}
+
+			if err := s.RemoveBinaryFiles(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+				t.Errorf("%s is left after RemoveBinaryFiles (%v)", filepath.Dir(path), err)
+			}
+		})
+	}
+}
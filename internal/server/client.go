@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Transport settings of the client tool calls share. Calls are bounded by
+// their context, so the client itself has no timeout.
+const (
+	dialTimeout         = 10 * time.Second
+	maxIdleConnsPerHost = 32
+	idleConnTimeout     = 90 * time.Second
+)
+
+// defaultToolClient is the client of servers that keep connections alive,
+// as they do unless SetKeepAlive turns it off.
+var defaultToolClient = newToolClient(true)
+
+// newToolClient returns a client that reuses connections to the target
+// across tool calls, or opens one per call when keepAlive is false.
+func newToolClient(keepAlive bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.DisableKeepAlives = !keepAlive
+	return &http.Client{Transport: transport}
+}
+
+// SetKeepAlive sets whether tool calls reuse connections to the target.
+// Targets that mishandle reused connections can get a fresh one per call.
+func (e *extensions) SetKeepAlive(on bool) {
+	e.client = nil
+	if !on {
+		e.client = newToolClient(false)
+	}
+}
+
+// toolClient returns the client tool calls are sent with.
+func (e *extensions) toolClient() *http.Client {
+	if e.client == nil {
+		return defaultToolClient
+	}
+	return e.client
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultConfirm are the calls that need "confirm": true by default.
+const DefaultConfirm = "DELETE"
+
+// ConfirmRule matches calls that are only sent with "confirm": true: by
+// method, * for any, and optionally by path, in which * matches anything.
+type ConfirmRule struct {
+	Method string
+	Path   string
+	path   *regexp.Regexp
+}
+
+// ParseConfirmRules parses comma-separated rules such as
+// "DELETE,POST */purge".
+func ParseConfirmRules(s string) ([]ConfirmRule, error) {
+	var rules []ConfirmRule
+	for _, spec := range strings.Split(s, ",") {
+		fields := strings.Fields(spec)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%w %q (want METHOD or METHOD /path, e.g. 'POST */purge')", ErrInvalidConfirmRule, strings.TrimSpace(spec))
+		}
+		rule := ConfirmRule{Method: strings.ToUpper(fields[0])}
+		if len(fields) == 2 {
+			rule.Path = fields[1]
+			if !strings.HasPrefix(rule.Path, "/") && !strings.HasPrefix(rule.Path, "*") {
+				return nil, fmt.Errorf("%w %q: the path must start with / or *", ErrInvalidConfirmRule, strings.TrimSpace(spec))
+			}
+			rule.path = regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(rule.Path), `\*`, ".*") + "$")
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r ConfirmRule) String() string {
+	if r.Path == "" {
+		return r.Method
+	}
+	return r.Method + " " + r.Path
+}
+
+// matches reports whether a call with method to path needs confirming.
+func (r ConfirmRule) matches(method, path string) bool {
+	if r.Method != "*" && !strings.EqualFold(r.Method, method) {
+		return false
+	}
+	return r.path == nil || r.path.MatchString(path)
+}
+
+// SetConfirmRules sets the calls that are only sent with "confirm": true.
+// Without it they return a preview of the request instead.
+func (e *extensions) SetConfirmRules(rules []ConfirmRule) {
+	e.confirm = rules
+}
+
+// needsConfirmation reports whether req is only sent once confirmed.
+func (e *extensions) needsConfirmation(req *http.Request) bool {
+	return slices.ContainsFunc(e.confirm, func(r ConfirmRule) bool { return r.matches(req.Method, req.URL.Path) })
+}
+
+// confirmHint tells agents up front that calls to tool need confirming.
+// Rules with paths are matched against the tool's templated path.
+func (e *extensions) confirmHint(tool *config.Tool) string {
+	path := tool.RawPath()
+	if !slices.ContainsFunc(e.confirm, func(r ConfirmRule) bool { return r.matches(tool.Method, path) }) {
+		return ""
+	}
+	return `Calls must pass "confirm": true; without it the request is only previewed, not sent.`
+}
+
+// confirmationResult shows the request a call to tool would send and asks
+// for it to be confirmed.
+func (e *extensions) confirmationResult(cfg *config.Config, tool *config.Tool, req *http.Request, body []byte) *mcp.CallToolResultFor[any] {
+	text := fmt.Sprintf("%v: %s %s needs \"confirm\": true. Nothing was sent; call again with \"confirm\": true to send this request:\n\n", ErrConfirmationRequired, tool.Method, tool.Name)
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text + e.previewRequest(cfg, tool, req, body).String()}},
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+)
+
+// SetCoverage records tool calls in the scenarios t has open.
+func (e *extensions) SetCoverage(t *coverage.Tracker) {
+	e.coverage = t
+}
+
+// recordCall returns the function reporting the status a call of tool
+// with these path and query values got, 0 without a response, and whether
+// it succeeded. It is taken before the auth query parameters are added,
+// which the caller didn't pick, and times the call from then on.
+func (e *extensions) recordCall(tool *config.Tool, pathValues, queryValues map[string]string) func(status int, ok bool) {
+	start := time.Now()
+	if e.coverage == nil {
+		return func(status int, ok bool) {
+			e.metrics.ToolCall(tool.Name, status, time.Since(start))
+		}
+	}
+	endpoint := tool.Method + " " + toolPathOf(tool)
+	if u, err := url.Parse(tool.ResolveURL(pathValues, nil)); err == nil && u.Path != "" {
+		endpoint = tool.Method + " " + u.Path
+	}
+	params := make([]string, 0, len(pathValues)+len(queryValues))
+	for name := range pathValues {
+		params = append(params, name)
+	}
+	for name := range queryValues {
+		params = append(params, name)
+	}
+	return func(status int, ok bool) {
+		e.metrics.ToolCall(tool.Name, status, time.Since(start))
+		e.coverage.Record(tool.ID, endpoint, params, ok)
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+)
+
+// acceptEncoding replaces the Accept-Encoding of tool calls, captured or
+// passed by the agent: the codings decodeBody can undo. A captured "br"
+// would otherwise come back as bytes nobody can read.
+const acceptEncoding = "gzip, deflate"
+
+// binaryPreview is how many leading bytes of a binary body results show.
+const binaryPreview = 16
+
+// decodeBody replaces resp's body with its decoded content, as listed in
+// Content-Encoding, and drops the headers describing the encoded one. It
+// returns the coding it couldn't decode, if any, leaving the body as sent.
+func decodeBody(resp *http.Response) (string, error) {
+	var codings []string
+	for _, value := range resp.Header.Values("Content-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	if len(codings) == 0 {
+		return "", nil
+	}
+
+	// Codings are listed in the order they were applied; an empty body,
+	// as of a 204, has nothing to decode
+	buf := bufio.NewReader(resp.Body)
+	resp.Body = readCloser{buf, resp.Body}
+	if _, err := buf.Peek(1); err == io.EOF {
+		codings = nil
+	}
+	var body io.Reader = buf
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+		switch codings[i] {
+		case "gzip", "x-gzip":
+			body, err = gzip.NewReader(body)
+		case "deflate":
+			body, err = inflate(body)
+		default:
+			if i != len(codings)-1 {
+				return "", fmt.Errorf("can't decode %s under %s", codings[i], strings.Join(codings[i+1:], ", "))
+			}
+			return codings[i], nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("decode %s response: %w", codings[i], err)
+		}
+	}
+
+	resp.Body = readCloser{body, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	return "", nil
+}
+
+// readCloser reads a decoded body and closes the one it decodes.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// inflate reads a deflate body, which servers send both zlib-wrapped, as
+// the spec says, and raw.
+func inflate(r io.Reader) (io.Reader, error) {
+	buf := bufio.NewReader(r)
+	header, err := buf.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buf)
+	}
+	return flate.NewReader(buf), nil
+}
+
+// isBinary reports whether body is no text an agent could read: not UTF-8,
+// or holding NUL bytes.
+func isBinary(body []byte) bool {
+	return !utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0
+}
+
+// binarySummary stands in for a binary body in a result: its size, type
+// and first bytes in hex.
+func binarySummary(content *responseContent, body []byte) string {
+	size := int64(len(body))
+	if content != nil && content.Length >= 0 {
+		size = content.Length
+	}
+	contentType := "unknown type"
+	if content != nil && content.Type != "" {
+		contentType = content.Type
+	}
+	summary := fmt.Sprintf("[binary body: %s, %s", diskbudget.FormatSize(size), contentType)
+	if content != nil && content.Encoding != "" {
+		summary += ", " + content.Encoding + "-encoded, which mcpify can't decode"
+	}
+	return summary + ", starts " + hex.EncodeToString(body[:min(len(body), binaryPreview)]) + "]"
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+var (
+	// ErrToolNotFound is the catalog's error, re-exported so callers of
+	// this package can match it without importing config.
+	ErrToolNotFound      = config.ErrToolNotFound
+	ErrToolLimitReached  = errors.New("tool limit reached")
+	ErrUnknownToolView   = errors.New("unknown tool view")
+	ErrUnknownEviction   = errors.New("unknown eviction policy")
+	ErrUnknownBinaryMode = errors.New("unknown binary mode")
+	ErrRequestTimeout    = errors.New("request timed out")
+	// ErrConfirmationRequired starts the preview returned for calls
+	// that need "confirm": true.
+	ErrConfirmationRequired = errors.New("confirmation_required")
+	ErrInvalidConfirmRule   = errors.New("invalid confirm rule")
+	ErrToolUnavailable      = errors.New("tool not available in this view")
+	// ErrQueueFull is returned when too many calls wait for a serialized
+	// tool.
+	ErrQueueFull = errors.New("too many calls queued")
+	// ErrEndpointExists is returned when adding a tool for an endpoint
+	// that already has one.
+	ErrEndpointExists = errors.New("endpoint already has a tool")
+	ErrInvalidTool    = errors.New("invalid tool")
+)
+
+// errorStatus maps errors to HTTP statuses for the admin endpoints.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrToolNotFound), errors.Is(err, config.ErrGroupNotFound),
+		errors.Is(err, config.ErrRevisionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, config.ErrToolNameInUse), errors.Is(err, ErrToolLimitReached), errors.Is(err, ErrEndpointExists):
+		return http.StatusConflict
+	case errors.Is(err, ErrUnknownToolView), errors.Is(err, ErrInvalidTool), errors.Is(err, config.ErrInvalidTimeout),
+		errors.Is(err, config.ErrInvalidRetries):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrToolUnavailable):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
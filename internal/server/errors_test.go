@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrToolNotFound, http.StatusNotFound},
+		{config.ErrGroupNotFound, http.StatusNotFound},
+		{config.ErrRevisionNotFound, http.StatusNotFound},
+		{config.ErrToolNameInUse, http.StatusConflict},
+		{ErrToolLimitReached, http.StatusConflict},
+		{ErrEndpointExists, http.StatusConflict},
+		{ErrUnknownToolView, http.StatusBadRequest},
+		{ErrInvalidTool, http.StatusBadRequest},
+		{config.ErrInvalidTimeout, http.StatusBadRequest},
+		{config.ErrInvalidRetries, http.StatusBadRequest},
+		{ErrToolUnavailable, http.StatusForbidden},
+		{errors.New("disk full"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		// Handlers wrap the sentinels with detail
+		err := fmt.Errorf("%w: detail", tt.err)
+		if got := errorStatus(err); got != tt.want {
+			t.Errorf("errorStatus(%v) = %d, want %d", err, got, tt.want)
+		}
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		op   func() error
+		want error
+	}{
+		{"tool view", func() error { _, err := ParseToolView("flat"); return err }, ErrUnknownToolView},
+		{"eviction", func() error { _, err := ParseEviction("random"); return err }, ErrUnknownEviction},
+		{"binary mode", func() error { _, err := ParseBinaryMode("hex"); return err }, ErrUnknownBinaryMode},
+		{"confirm rule", func() error { _, err := ParseConfirmRules("POST users"); return err }, ErrInvalidConfirmRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolErrors(t *testing.T) {
+	s := NewMCPServer("test", "v0", 1, newTestConfig(t))
+	if err := s.RegisterTool("get_users", "GET", "http://localhost:3000/users", nil, nil, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.RegisterTool("get_orders", "GET", "http://localhost:3000/orders", nil, nil, nil, "")
+	if !errors.Is(err, ErrToolLimitReached) {
+		t.Errorf("registering past the limit: err = %v, want ErrToolLimitReached", err)
+	}
+	if err := s.RemoveTool("get_orders"); !errors.Is(err, ErrToolNotFound) {
+		t.Errorf("removing a missing tool: err = %v, want ErrToolNotFound", err)
+	}
+}
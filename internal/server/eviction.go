@@ -0,0 +1,88 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// Eviction is what happens when a new endpoint is captured at the tool
+// limit.
+type Eviction string
+
+const (
+	// EvictReject keeps the tools there are and drops the new one.
+	EvictReject Eviction = "reject"
+	// EvictLRU removes the tool called least recently, never-called ones
+	// first, then the one called least often.
+	EvictLRU Eviction = "lru"
+	// EvictFIFO removes the oldest tool.
+	EvictFIFO Eviction = "fifo"
+)
+
+func ParseEviction(s string) (Eviction, error) {
+	switch Eviction(strings.ToLower(strings.TrimSpace(s))) {
+	case EvictReject, "":
+		return EvictReject, nil
+	case EvictLRU:
+		return EvictLRU, nil
+	case EvictFIFO:
+		return EvictFIFO, nil
+	}
+	return "", fmt.Errorf("%w %q (want reject, lru or fifo)", ErrUnknownEviction, s)
+}
+
+// SetEviction sets how room is made for a tool captured at the tool limit.
+func (e *extensions) SetEviction(policy Eviction) {
+	e.eviction = policy
+}
+
+// compare orders tools by which policy evicts first. Ties go to the
+// oldest tool, then by name.
+func (policy Eviction) compare(a, b *config.Tool) int {
+	if policy == EvictLRU {
+		if n := a.LastUsed.Compare(b.LastUsed); n != 0 {
+			return n
+		}
+		if n := cmp.Compare(a.UseCount, b.UseCount); n != 0 {
+			return n
+		}
+	}
+	if n := a.CreatedAt.Compare(b.CreatedAt); n != 0 {
+		return n
+	}
+	return strings.Compare(a.Name, b.Name)
+}
+
+// evict makes room for the tool called name by removing, of the tools
+// called one of names (all of them when names is nil), the one the policy
+// evicts first. It fails with ErrToolLimitReached when the policy is
+// reject. It returns the evicted tool's name; the caller unpublishes it.
+func (e *extensions) evict(cfg *config.Config, names []string, name string, max int) (string, error) {
+	policy := e.eviction
+	if policy == "" || policy == EvictReject {
+		return "", fmt.Errorf("%w: maximum is %d", ErrToolLimitReached, max)
+	}
+	victim := cfg.FirstTool(names, policy.compare)
+	if victim == nil {
+		return "", fmt.Errorf("%w: maximum is %d", ErrToolLimitReached, max)
+	}
+	if err := cfg.RemoveTool(victim.Name); err != nil {
+		return "", err
+	}
+
+	reason := "created " + victim.CreatedAt.Format(time.RFC3339)
+	if policy == EvictLRU {
+		reason = "never called"
+		if victim.UseCount > 0 {
+			reason = fmt.Sprintf("last called %s, %d calls", victim.LastUsed.Format(time.RFC3339), victim.UseCount)
+		}
+	}
+	slog.Info("Evicted tool to make room at the tool limit", "tool", victim.Name, "policy", policy, "reason", reason, "for", name, "max_tools", max)
+	e.metrics.ToolEvicted()
+	return victim.Name, nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+	"github.com/NilayYadav/mcpify/internal/events"
+	"github.com/NilayYadav/mcpify/internal/metrics"
+	"github.com/NilayYadav/mcpify/internal/replica"
+)
+
+// extensions lets other components contribute sections to /debug and
+// extra handlers to the server mux, and carries the event bus.
+type extensions struct {
+	extrasMu sync.RWMutex
+	extras   map[string]func() any
+	handlers map[string]http.Handler
+	events   *events.Bus
+	// noResultMeta leaves the Meta line out of tool results
+	noResultMeta bool
+	// preserveUserAgent resends captured User-Agents
+	preserveUserAgent bool
+	// replicas spreads calls across instances of the target
+	replicas *replica.Pool
+	// authQuery are query parameters every call gets from mcpify
+	authQuery map[string]string
+	// coverage records calls for scenario coverage reports
+	coverage *coverage.Tracker
+	// metrics counts registrations, evictions and calls for /metrics
+	metrics *metrics.Metrics
+	// eviction makes room for tools captured at the tool limit
+	eviction Eviction
+	// maxResponseSize bounds the response body in tool results; 0 is
+	// unlimited
+	maxResponseSize int64
+	// plainResults returns every result as text, without isError for
+	// 4xx and 5xx
+	plainResults bool
+	// binaryMode is how results carry binary bodies
+	binaryMode BinaryMode
+	// binaryDir holds the bodies BinaryFile saved, "" until the first
+	binaryMu  sync.Mutex
+	binaryDir string
+	// requestTimeout bounds calls to tools without a timeout of their own
+	requestTimeout time.Duration
+	// client sends tool calls; nil is defaultToolClient
+	client *http.Client
+	// readOnly refuses calls to tools with methods other than GET and
+	// HEAD
+	readOnly bool
+	// confirm are the calls only sent with "confirm": true
+	confirm []ConfirmRule
+}
+
+// SetEvents makes the server publish registrations, regroups and failed
+// calls to b.
+func (e *extensions) SetEvents(b *events.Bus) {
+	e.events = b
+}
+
+// SetMetrics makes the server count registered and evicted tools and
+// tool calls in m.
+func (e *extensions) SetMetrics(m *metrics.Metrics) {
+	e.metrics = m
+}
+
+func (e *extensions) AddDebugInfo(key string, fn func() any) {
+	e.extrasMu.Lock()
+	defer e.extrasMu.Unlock()
+	if e.extras == nil {
+		e.extras = make(map[string]func() any)
+	}
+	e.extras[key] = fn
+}
+
+// Handle registers an extra handler; it takes effect when Start is called.
+func (e *extensions) Handle(pattern string, handler http.Handler) {
+	e.extrasMu.Lock()
+	defer e.extrasMu.Unlock()
+	if e.handlers == nil {
+		e.handlers = make(map[string]http.Handler)
+	}
+	e.handlers[pattern] = handler
+}
+
+func (e *extensions) addDebugInfoTo(info map[string]interface{}) map[string]interface{} {
+	e.extrasMu.RLock()
+	defer e.extrasMu.RUnlock()
+	for key, fn := range e.extras {
+		info[key] = fn()
+	}
+	return info
+}
+
+func (e *extensions) registerHandlers(mux *http.ServeMux) {
+	e.extrasMu.RLock()
+	defer e.extrasMu.RUnlock()
+	for pattern, handler := range e.handlers {
+		mux.Handle(pattern, handler)
+	}
+}
+
+// callFailed counts a failed call of tool in cfg and publishes it. status
+// is 0 when there was no response, and upstream nil when the call wasn't
+// routed to a replica.
+func (e *extensions) callFailed(cfg *config.Config, tool *config.Tool, upstream *replica.Replica, status int, reason string) {
+	cfg.RecordFailure(tool.ID, reason)
+	payload := map[string]any{"tool": tool.Name, "status": status, "error": reason}
+	if upstream != nil {
+		payload["replica"] = upstream.Base
+	}
+	e.events.Publish(events.ToolCallFailed, payload)
+}
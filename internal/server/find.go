@@ -0,0 +1,309 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const findEndpointName = "find_endpoint"
+
+// defaultFindLimit is how many matches find_endpoint returns by default.
+const defaultFindLimit = 5
+
+type FindEndpointParams struct {
+	Query  string `json:"query"`
+	Method string `json:"method,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Group  string `json:"group,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+type endpointMatch struct {
+	Tool        string   `json:"tool"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Description string   `json:"description,omitempty"`
+	Group       string   `json:"group,omitempty"`
+	PathParams  []string `json:"path_params,omitempty"`
+	BodyFields  []string `json:"body_fields,omitempty"`
+	Exposed     bool     `json:"exposed"`
+	Score       float64  `json:"score"`
+}
+
+// methodWords maps verbs agents tend to use onto the HTTP methods that
+// usually implement them.
+var methodWords = map[string][]string{
+	"get":     {"GET"},
+	"fetch":   {"GET"},
+	"show":    {"GET"},
+	"list":    {"GET"},
+	"read":    {"GET"},
+	"find":    {"GET"},
+	"search":  {"GET"},
+	"create":  {"POST"},
+	"add":     {"POST"},
+	"new":     {"POST"},
+	"make":    {"POST"},
+	"send":    {"POST"},
+	"update":  {"PUT", "PATCH"},
+	"change":  {"PUT", "PATCH"},
+	"edit":    {"PUT", "PATCH"},
+	"modify":  {"PUT", "PATCH"},
+	"set":     {"PUT", "PATCH"},
+	"replace": {"PUT"},
+	"delete":  {"DELETE"},
+	"remove":  {"DELETE"},
+	"destroy": {"DELETE"},
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "for": true, "to": true,
+	"by": true, "in": true, "on": true, "that": true, "which": true, "endpoint": true,
+	"api": true, "with": true, "my": true, "s": true, "and": true, "or": true,
+}
+
+// searchTokens lowercases text, splits it on anything that isn't a letter
+// or digit and drops plural suffixes, so "userEmails" matches "user email".
+func searchTokens(text string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			current = append(current, unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current = append(current, unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	tokens := words[:0]
+	for _, w := range words {
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// tokenScore rates how well a query token matches a document token: exact
+// matches count fully, prefixes and single typos partially.
+func tokenScore(query, doc string) float64 {
+	switch {
+	case query == doc:
+		return 1
+	case len(query) >= 3 && (strings.HasPrefix(doc, query) || strings.HasPrefix(query, doc) && len(doc) >= 3):
+		return 0.6
+	case len(query) >= 4 && editDistance(query, doc) <= 1:
+		return 0.5
+	}
+	return 0
+}
+
+func editDistance(a, b string) int {
+	if d := len(a) - len(b); d > 1 || d < -1 {
+		return 2
+	}
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// fieldScore sums, for each query token, its best match among tokens.
+func fieldScore(query, tokens []string) float64 {
+	var score float64
+	for _, q := range query {
+		best := 0.0
+		for _, t := range tokens {
+			best = max(best, tokenScore(q, t))
+		}
+		score += best
+	}
+	return score
+}
+
+func toolPathOf(tool *config.Tool) string {
+	if u, err := url.Parse(tool.URL); err == nil && u.Path != "" {
+		return u.Path
+	}
+	return "/"
+}
+
+// scoreTool ranks tool against the query tokens. Names and paths weigh
+// more than descriptions, and verbs in the query favour matching methods
+// and, a little, tools that use the same verb.
+func scoreTool(tool *config.Tool, template string, query []string) float64 {
+	var nouns, verbs []string
+	var methods []string
+	for _, q := range query {
+		if m, ok := methodWords[q]; ok {
+			verbs = append(verbs, q)
+			methods = append(methods, m...)
+			continue
+		}
+		if !stopWords[q] {
+			nouns = append(nouns, q)
+		}
+	}
+
+	nameTokens := searchTokens(tool.Name)
+	descriptionTokens := searchTokens(tool.Description)
+	pathTokens := searchTokens(strings.NewReplacer("{", " ", "}", " ", "_id", " id").Replace(template))
+	score := 3*fieldScore(nouns, nameTokens) +
+		2*fieldScore(nouns, pathTokens) +
+		fieldScore(nouns, descriptionTokens) +
+		// "list" tells a listing from a lookup when both are GETs
+		0.5*fieldScore(verbs, slices.Concat(nameTokens, descriptionTokens))
+
+	if len(methods) > 0 {
+		if slices.Contains(methods, strings.ToUpper(tool.Method)) {
+			score += 2
+		} else {
+			score -= 1
+		}
+	}
+	return score
+}
+
+// searchCatalog returns the best matches for params, most relevant first.
+// exposed reports whether a tool, in the given group, can currently be
+// called.
+func searchCatalog(cfg *config.Config, params FindEndpointParams, exposed func(tool *config.Tool, group string) bool) []endpointMatch {
+	groupOf := make(map[string]string)
+	for _, group := range cfg.ListGroups() {
+		for _, id := range group.ToolIDs {
+			groupOf[id] = group.Name
+		}
+	}
+
+	query := searchTokens(params.Query)
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultFindLimit
+	}
+
+	var matches []endpointMatch
+	for _, tool := range cfg.ListTools() {
+		if params.Method != "" && !strings.EqualFold(tool.Method, params.Method) {
+			continue
+		}
+		if params.Tag != "" && !slices.Contains(tool.Tags, params.Tag) {
+			continue
+		}
+		if params.Group != "" && groupOf[tool.ID] != params.Group {
+			continue
+		}
+
+		template, pathParams := observed.Template(toolPathOf(tool))
+		score := scoreTool(tool, template, query)
+		if len(query) > 0 && score <= 0 {
+			continue
+		}
+
+		match := endpointMatch{
+			Tool:        tool.Name,
+			Method:      tool.Method,
+			Path:        template,
+			Description: tool.Description,
+			Group:       groupOf[tool.ID],
+			BodyFields:  bodyFields(tool.Body),
+			Exposed:     exposed(tool, groupOf[tool.ID]),
+			Score:       float64(int(score*100)) / 100,
+		}
+		for name := range pathParams {
+			match.PathParams = append(match.PathParams, name)
+		}
+		sort.Strings(match.PathParams)
+		matches = append(matches, match)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Tool < matches[j].Tool
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+func bodyFields(body string) []string {
+	var fields map[string]interface{}
+	if json.Unmarshal([]byte(body), &fields) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addFindEndpoint registers the find_endpoint meta-tool. It is always
+// present, whatever the view, so agents can search the whole catalog.
+func addFindEndpoint(mcpServer *mcp.Server, cfg *config.Config, exposed func(tool *config.Tool, group string) bool) {
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name: findEndpointName,
+		Description: "Search all captured endpoints by free text (e.g. \"change a user's email\"), " +
+			"optionally filtered by method, tag or group. Returns the best matches with their tool " +
+			"name, group, templated path, parameters and whether they can be called right now. " +
+			"Call a match by its tool name, or through its group with the returned method and path.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[FindEndpointParams]) (*mcp.CallToolResultFor[any], error) {
+		matches := searchCatalog(cfg, params.Arguments, exposed)
+
+		text := "No matching endpoints found."
+		if len(matches) > 0 {
+			data, err := json.MarshalIndent(matches, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode matches: %w", err)
+			}
+			text = string(data)
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: text},
+			},
+		}, nil
+	})
+}
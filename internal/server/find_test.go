@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// catalogTools is a small API to search.
+var catalogTools = []*config.Tool{
+	{Name: "get_users", Method: "GET", URL: "http://localhost:3000/users", Description: "List all users"},
+	{Name: "get_user", Method: "GET", URL: "http://localhost:3000/users/42", Description: "Get a user by ID"},
+	{Name: "create_user", Method: "POST", URL: "http://localhost:3000/users", Body: `{"name":"ada","email":"ada@example.com"}`, Description: "Create a user"},
+	{Name: "update_user_email", Method: "PATCH", URL: "http://localhost:3000/users/42/email", Body: `{"email":"new@example.com"}`, Description: "Change a user's email address"},
+	{Name: "delete_user", Method: "DELETE", URL: "http://localhost:3000/users/42", Description: "Delete a user account"},
+	{Name: "get_orders", Method: "GET", URL: "http://localhost:3000/orders", Description: "List orders"},
+	{Name: "create_order", Method: "POST", URL: "http://localhost:3000/orders", Body: `{"item":1}`, Description: "Place an order"},
+	{Name: "cancel_order", Method: "POST", URL: "http://localhost:3000/orders/7/cancel", Description: "Cancel an order before it ships"},
+	{Name: "get_invoice_pdf", Method: "GET", URL: "http://localhost:3000/invoices/9/pdf", Description: "Download an invoice as PDF"},
+	{Name: "search_products", Method: "GET", URL: "http://localhost:3000/products/search?q=lamp", Description: "Search the product catalog"},
+	{Name: "reset_password", Method: "POST", URL: "http://localhost:3000/auth/password-reset", Body: `{"email":"ada@example.com"}`, Description: "Send a password reset link"},
+	{Name: "get_health", Method: "GET", URL: "http://localhost:3000/healthz", Description: "Service health check"},
+}
+
+func TestSearchCatalogRanking(t *testing.T) {
+	cfg := newTestConfig(t)
+	for _, tool := range catalogTools {
+		cfg.AddTool(tool)
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"list users", "get_users"},
+		{"get a user", "get_user"},
+		{"create a new user", "create_user"},
+		{"change a user's email", "update_user_email"},
+		{"remove user", "delete_user"},
+		{"list orders", "get_orders"},
+		{"place an order", "create_order"},
+		{"cancel order", "cancel_order"},
+		{"download invoice", "get_invoice_pdf"},
+		{"search products", "search_products"},
+		{"forgot password", "reset_password"},
+		{"health", "get_health"},
+		// Typos and plurals still match
+		{"ordr cancelation", "cancel_order"},
+		{"invoices", "get_invoice_pdf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			matches := searchCatalog(cfg, FindEndpointParams{Query: tt.query}, func(*config.Tool, string) bool { return true })
+			if len(matches) == 0 {
+				t.Fatalf("no matches, want %s", tt.want)
+			}
+			if matches[0].Tool != tt.want {
+				t.Errorf("top match %s (%.2f), want %s; got %+v", matches[0].Tool, matches[0].Score, tt.want, matches)
+			}
+		})
+	}
+}
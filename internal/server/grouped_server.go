@@ -1,56 +1,146 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/NilayYadav/mcpify/internal/approval"
+	"github.com/NilayYadav/mcpify/internal/chaos"
 	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/events"
 	"github.com/NilayYadav/mcpify/internal/grouping"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/workflow"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type GroupedMCPServer struct {
 	mcpServer *mcp.Server
-	grouper   *grouping.LLMGrouper
+	grouper   grouping.Grouper
 	config    *config.Config
 	mu        sync.RWMutex
+	workflows *workflow.Miner
+	observed  *observed.Tracker
+	verifier  *toolVerifier
+	chaos     *chaos.Chaos
+	approvals *approval.Gate
+	serial    *serializer
+	limits    *rateLimits
+	maxTools  int
+	// rebuild wakes the rebuild worker; published maps each group tool
+	// on the MCP server to its current description.
+	rebuild   chan struct{}
+	published map[string]string
+	statusMu  sync.Mutex
+	status    GroupingStatus
+	extensions
 }
 
+// GroupingStatus is the grouping progress shown in /debug.
+type GroupingStatus struct {
+	// State is idle, pending (waiting for discovery to go quiet) or
+	// running.
+	State string `json:"state"`
+	// Provisional is set while path-prefix groups stand in for the ones
+	// the grouper is still working on.
+	Provisional bool      `json:"provisional"`
+	Groups      int       `json:"groups"`
+	Tools       int       `json:"tools"`
+	LastRebuild time.Time `json:"last_rebuild,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+const (
+	// rebuildQuiet is how long discovery has to pause before groups are
+	// rebuilt, so a burst of new tools costs one grouping call.
+	rebuildQuiet = 2 * time.Second
+	// rebuildMaxWait bounds the wait under constant discovery.
+	rebuildMaxWait = 30 * time.Second
+)
+
 type GroupCallParams struct {
-	Method      string            `json:"method"`
-	Path        string            `json:"path,omitempty"`
-	RequestBody string            `json:"request_body,omitempty"`
-	Headers     map[string]string `json:"headers,omitempty"`
+	Method         string                 `json:"method"`
+	Path           string                 `json:"path,omitempty"`
+	Query          map[string]string      `json:"query,omitempty"`
+	RequestBody    string                 `json:"request_body,omitempty"`
+	Confirm        bool                   `json:"confirm,omitempty"`
+	DryRun         bool                   `json:"dry_run,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"`
+	ExpectStatus   int                    `json:"expect_status,omitempty"`
+	ExpectJSON     map[string]interface{} `json:"expect_json,omitempty"`
+	ExpectContains string                 `json:"expect_contains,omitempty"`
 }
 
-func NewGroupedMCPServer(name, version string, cfg *config.Config, llmKey, llmEndpoint, llmModel string) *GroupedMCPServer {
+// NewGroupedMCPServer builds a server whose tools are the groups grouper
+// writes to the config.
+func NewGroupedMCPServer(name, version string, maxTools int, cfg *config.Config, grouper grouping.Grouper) *GroupedMCPServer {
+	server := newGroupedMCPServerOn(mcp.NewServer(&mcp.Implementation{
+		Name:    name,
+		Version: version,
+	}, nil), maxTools, cfg, grouper)
+	server.AddDebugInfo("grouping", func() any { return server.GroupingStatus() })
+	return server
+}
+
+// newGroupedMCPServerOn builds the grouped tool view on an existing MCP
+// server, so it can share one server instance with other views.
+func newGroupedMCPServerOn(mcpServer *mcp.Server, maxTools int, cfg *config.Config, grouper grouping.Grouper) *GroupedMCPServer {
 	server := &GroupedMCPServer{
-		mcpServer: mcp.NewServer(&mcp.Implementation{
-			Name:    name,
-			Version: version,
-		}, nil),
-		grouper: grouping.NewLLMGrouper(llmKey, llmEndpoint, llmModel),
-		config:  cfg,
+		mcpServer: mcpServer,
+		grouper:   grouper,
+		config:    cfg,
+		verifier:  newToolVerifier(),
+		serial:    newSerializer(),
+		limits:    newRateLimits(),
+		maxTools:  maxTools,
+		rebuild:   make(chan struct{}, 1),
+		published: make(map[string]string),
+		status:    GroupingStatus{State: "idle"},
+	}
+
+	if extra := len(cfg.Tools) - maxTools; extra > 0 {
+		slog.Warn("Config has more tools than --max-tools; leaving the newest out of groups", "max_tools", maxTools, "left_out", extra)
 	}
 
 	// Load existing groups or create them
 	server.setupGroups()
+	go server.rebuildLoop()
+	addFindEndpoint(mcpServer, cfg, server.exposes)
+	addListEndpoints(mcpServer, cfg)
+	addRemoveTool(mcpServer, server.RemoveTool)
 	return server
 }
 
-func (s *GroupedMCPServer) RegisterTool(name string, method, url string, headers map[string]string, body []byte, description string) error {
+func (s *GroupedMCPServer) RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.HasTool(method, url) {
+		return nil
+	}
+	var evicted string
+	if len(s.config.ListTools()) >= s.maxTools {
+		var err error
+		if evicted, err = s.evict(s.config, nil, name, s.maxTools); err != nil {
+			return err
+		}
+	}
+
 	tool := &config.Tool{
 		Name:        name,
 		Method:      method,
 		URL:         url,
+		PathParams:  pathParams,
 		Headers:     headers,
 		Body:        string(body),
 		Description: description,
@@ -59,124 +149,487 @@ func (s *GroupedMCPServer) RegisterTool(name string, method, url string, headers
 
 	s.config.AddTool(tool)
 
-	if err := s.config.Save(s.config.Path); err != nil {
-		log.Printf("Failed to save config: %v", err)
+	if evicted != "" {
+		if err := saveAndCollect(s.config); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+		// The evicted tool's groups are republished without it
+		s.requestRebuild()
+	} else if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
 	}
 
+	s.toolAdded()
+	s.metrics.ToolRegistered()
+
+	return nil
+}
+
+// HasTool reports whether the endpoint method and url already has a tool.
+func (s *GroupedMCPServer) HasTool(method, url string) bool {
+	return s.config.ToolFor(method, url) != nil
+}
+
+// SetResultMeta turns the Meta line of tool results on or off and
+// republishes the group descriptions that mention it.
+func (s *GroupedMCPServer) SetResultMeta(on bool) {
+	s.extensions.SetResultMeta(on)
+	s.loadGroupsFromConfig()
+}
+
+// SetAuthQuery sets the query parameters every call sends and
+// republishes the group descriptions that list them.
+func (s *GroupedMCPServer) SetAuthQuery(params map[string]string) {
+	s.extensions.SetAuthQuery(params)
+	s.loadGroupsFromConfig()
+}
+
+// HasToolName reports whether a tool is called name.
+func (s *GroupedMCPServer) HasToolName(name string) bool {
+	return s.config.GetTool(name) != nil
+}
+
+// toolAdded schedules a regroup after a tool has been added to the config.
+func (s *GroupedMCPServer) toolAdded() {
 	// Trigger regrouping in background (only if we have enough tools)
-	if len(s.config.Tools) >= 5 { // Only regroup when we have enough tools
-		go s.rebuildGroups()
+	if len(s.config.ListTools()) >= 5 { // Only regroup when we have enough tools
+		s.requestRebuild()
 	}
+}
 
-	return nil
+// requestRebuild asks the rebuild worker for a regroup.
+func (s *GroupedMCPServer) requestRebuild() {
+	s.statusMu.Lock()
+	if s.status.State == "idle" {
+		s.status.State = "pending"
+	}
+	s.statusMu.Unlock()
+
+	select {
+	case s.rebuild <- struct{}{}:
+	default:
+		// A rebuild is pending already
+	}
+}
+
+// GroupingStatus reports how far grouping has got.
+func (s *GroupedMCPServer) GroupingStatus() GroupingStatus {
+	s.statusMu.Lock()
+	status := s.status
+	s.statusMu.Unlock()
+
+	status.Groups = len(s.config.ListGroups())
+	status.Tools = len(s.config.ListTools())
+	return status
+}
+
+// rebuildLoop is the only place groups are rebuilt after startup. It
+// waits for discovery to go quiet, and a request arriving mid-rebuild
+// causes exactly one more.
+func (s *GroupedMCPServer) rebuildLoop() {
+	for range s.rebuild {
+		quiet := time.NewTimer(rebuildQuiet)
+		deadline := time.NewTimer(rebuildMaxWait)
+	wait:
+		for {
+			select {
+			case <-s.rebuild:
+				quiet.Reset(rebuildQuiet)
+			case <-quiet.C:
+				break wait
+			case <-deadline.C:
+				break wait
+			}
+		}
+		quiet.Stop()
+		deadline.Stop()
+
+		s.rebuildGroups()
+	}
+}
+
+func (s *GroupedMCPServer) hasGroup(name string) bool {
+	return s.config.GetGroup(name) != nil
+}
+
+// exposes reports whether tool can be called through its group right now.
+func (s *GroupedMCPServer) exposes(tool *config.Tool, group string) bool {
+	return group != "" && !s.verifier.isHidden(tool.Name)
 }
 
 func (s *GroupedMCPServer) setupGroups() {
 	if s.config.UseGrouping && len(s.config.Groups) > 0 {
 		// Load existing groups from config
 		s.loadGroupsFromConfig()
-	} else if len(s.config.Tools) >= 3 {
-		// Create initial groups
+		return
+	}
+	if len(s.config.Tools) < 3 {
+		return
+	}
+	if _, local := s.grouper.(*grouping.PrefixGrouper); local {
 		s.rebuildGroups()
+		return
+	}
+
+	// Grouping a large catalog with the LLM takes a while. Serve path
+	// prefix groups right away and let the worker replace them; clients
+	// get a list_changed when it does.
+	if err := grouping.GroupByPrefix(s.config, s.maxTools); err != nil {
+		slog.Error("Failed to save provisional groups", "error", err)
 	}
+	s.loadGroupsFromConfig()
+	s.statusMu.Lock()
+	s.status.Provisional = true
+	s.statusMu.Unlock()
+	s.requestRebuild()
 }
 
+// loadGroupsFromConfig publishes the config's groups as MCP tools. Only
+// new groups and changed descriptions are (re)added, and groups that are
+// gone are removed, so clients see one list change per real difference.
 func (s *GroupedMCPServer) loadGroupsFromConfig() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, group := range s.config.Groups {
+	current := make(map[string]bool)
+	for _, group := range s.config.ListGroups() {
 		tools := s.config.GetToolsInGroup(group.Name)
-		if len(tools) > 0 {
-			handler := s.createGroupHandler(group.Name)
-			mcp.AddTool(s.mcpServer, &mcp.Tool{
-				Name:        group.Name,
-				Description: s.generateToolDescription(group, tools),
-			}, handler)
+		if len(tools) == 0 {
+			continue
+		}
+		current[group.Name] = true
+		description := s.generateToolDescription(group, tools)
+		if published, ok := s.published[group.Name]; ok && published == description {
+			continue
+		}
+
+		handler := s.createGroupHandler(group.Name)
+		mcp.AddTool(s.mcpServer, &mcp.Tool{
+			Name:        group.Name,
+			Description: description,
+			Annotations: groupAnnotations(tools),
+		}, handler)
+		s.published[group.Name] = description
 
-			log.Printf("Loaded group: %s with %d tools", group.Name, len(tools))
+		slog.Debug("Loaded group", "group", group.Name, "tools", len(tools))
+	}
+
+	var stale []string
+	for name := range s.published {
+		if !current[name] {
+			stale = append(stale, name)
+			delete(s.published, name)
 		}
 	}
+	if len(stale) > 0 {
+		s.mcpServer.RemoveTools(stale...)
+		slog.Info("Removed groups", "groups", strings.Join(stale, ","))
+	}
 }
 
 func (s *GroupedMCPServer) rebuildGroups() {
-	if err := s.grouper.GroupToolsInConfig(s.config); err != nil {
-		log.Printf("Failed to group tools: %v", err)
+	s.statusMu.Lock()
+	s.status.State = "running"
+	s.statusMu.Unlock()
+
+	err := s.grouper.GroupToolsInConfig(s.config)
+
+	s.statusMu.Lock()
+	s.status.State = "idle"
+	if len(s.rebuild) > 0 {
+		s.status.State = "pending"
+	}
+	if err != nil {
+		s.status.LastError = err.Error()
+	} else {
+		s.status.LastError = ""
+		s.status.Provisional = false
+		s.status.LastRebuild = time.Now()
+	}
+	s.statusMu.Unlock()
+
+	if err != nil {
+		slog.Error("Failed to group tools", "error", err)
 		return
 	}
 
 	// Reload groups from config
 	s.loadGroupsFromConfig()
+	s.events.Publish(events.GroupsRebuilt, map[string]int{"groups": len(s.config.ListGroups()), "tools": len(s.config.ListTools())})
 }
 
 func (s *GroupedMCPServer) generateToolDescription(group *config.Group, tools []*config.Tool) string {
 	description := group.Description + "\n\n"
 	description += "Available endpoints:\n"
 
+	names := toolNamesByEndpoint(s.config)
 	for _, tool := range tools {
 		description += fmt.Sprintf("- %s %s\n", tool.Method, tool.URL)
+		var params, provided []string
+		for _, name := range tool.QueryParamNames() {
+			if _, ok := s.authQuery[name]; ok {
+				provided = append(provided, name)
+			} else {
+				params = append(params, name)
+			}
+		}
+		if len(params) > 0 {
+			description += fmt.Sprintf("  Query parameters: %s\n", strings.Join(params, ", "))
+		}
+		if len(provided) > 0 {
+			description += fmt.Sprintf("  Provided by the server for authentication: %s\n", strings.Join(provided, ", "))
+		}
+		if hint := s.refusalHint(tool); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		} else if hint := s.confirmHint(tool); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		}
+		if hint := responseHint(tool); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		}
+		if hint := strippedHeadersHint(tool); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		}
+		if hint := followedByHint(s.workflows, tool, names); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		}
+		if hint := observedHint(s.observed, tool); hint != "" {
+			description += fmt.Sprintf("  %s\n", hint)
+		}
+		if !group.Serialize && tool.Serialize {
+			description += fmt.Sprintf("  %s\n", serializeHint)
+		}
+	}
+	if group.Serialize {
+		description += "\nCalls to this group run one at a time, whatever the endpoint; concurrent calls queue.\n"
 	}
 
-	description += "\nUsage: Specify 'method' (GET/POST/PUT/DELETE) and optionally 'path' for specific endpoint. "
-	description += "Include 'request_body' and 'headers' as needed."
+	// Groups may hold any method, e.g. WebDAV's PROPFIND, so list theirs
+	var methods []string
+	for _, tool := range tools {
+		if method := strings.ToUpper(tool.Method); !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	description += fmt.Sprintf("\nUsage: Specify 'method' (%s) and optionally 'path' for specific endpoint. ", strings.Join(methods, "/"))
+	description += "Fill {placeholders} with real values, e.g. /users/42 for /users/{user_id}; omitted ones use the captured value. "
+	description += "Pass query parameters as 'query' (name → value) or in the path; captured ones are sent unless set to an empty string. "
+	description += "Include 'request_body' and 'headers' as needed; an empty header value removes a captured header. "
+	description += "Optionally pass 'expect_status', 'expect_json' (JSONPath → value) or 'expect_contains' to fail the call when the response doesn't match. "
+	description += "'dry_run': true returns the request instead of sending it."
+	if hint := s.metaHint(); hint != "" {
+		description += "\n" + hint
+	}
 
 	return description
 }
 
+// VerifyTools stops routing group calls to endpoints the target doesn't
+// serve for this run and keeps re-checking them in the background.
+func (s *GroupedMCPServer) VerifyTools(ctx context.Context) {
+	s.verifier.start(ctx, s.config, &s.extensions)
+}
+
+// SetChaos enables fault injection for group calls.
+func (s *GroupedMCPServer) SetChaos(c *chaos.Chaos) {
+	s.chaos = c
+}
+
+// SetApprovals holds calls g needs approved, DELETE by default.
+func (s *GroupedMCPServer) SetApprovals(g *approval.Gate) {
+	s.approvals = g
+}
+
+// SetRetryAfterMax sets the longest rate limit a call waits out before
+// retrying by itself. Longer ones are returned as rate_limited errors.
+func (s *GroupedMCPServer) SetRetryAfterMax(d time.Duration) {
+	s.limits.SetMaxWait(d)
+}
+
+// SetRetries sets how often calls that fail with a connection error or a
+// 502, 503 or 504 are resent, unless their tool says otherwise.
+func (s *GroupedMCPServer) SetRetries(n int) {
+	s.limits.SetRetries(n)
+}
+
+// RecordResponse stores what a captured endpoint returned. Group
+// descriptions pick it up on the next rebuild.
+func (s *GroupedMCPServer) RecordResponse(method, url string, sample *config.ResponseSample) {
+	if _, changed := s.config.SetResponse(method, url, sample); changed {
+		if err := s.config.Save(s.config.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+}
+
+func (s *GroupedMCPServer) RecordQueryParams(method, url string, params map[string]string) {
+	if _, changed := s.config.MergeQueryParams(method, url, params); changed {
+		if err := s.config.Save(s.config.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+}
+
+func (s *GroupedMCPServer) RecordBodySchema(method, url string, schema *config.BodySchema) {
+	if _, changed := s.config.MergeBodySchema(method, url, schema); changed {
+		if err := s.config.Save(s.config.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+}
+
+func (s *GroupedMCPServer) RecordRequestSample(method, url string, sample *config.RequestSample) {
+	if _, changed := s.config.SetRequestSample(method, url, sample); changed {
+		if err := s.config.Save(s.config.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	}
+}
+
+// ToolChanged republishes the groups, whose descriptions list their
+// tools.
+func (s *GroupedMCPServer) ToolChanged(tool *config.Tool, oldName string) {
+	s.loadGroupsFromConfig()
+}
+
+// SetObservedValues adds real parameter values to group descriptions on
+// the next rebuild and serves them at /api/tools/{name}/observed-values.
+func (s *GroupedMCPServer) SetObservedValues(t *observed.Tracker) {
+	s.mu.Lock()
+	s.observed = t
+	s.mu.Unlock()
+
+	s.Handle("GET /api/tools/{name}/observed-values", observedValuesHandler(s.config, t))
+}
+
+// SetWorkflows adds workflow hints to group descriptions on the next
+// rebuild and exposes the workflow prompt.
+func (s *GroupedMCPServer) SetWorkflows(m *workflow.Miner) {
+	s.mu.Lock()
+	s.workflows = m
+	s.mu.Unlock()
+
+	addWorkflowPrompt(s.mcpServer, m, s.config)
+}
+
 func (s *GroupedMCPServer) createGroupHandler(groupName string) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[GroupCallParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[GroupCallParams]) (*mcp.CallToolResultFor[any], error) {
 
 		// Find the right tool
-		tool, err := s.selectTool(groupName, params.Arguments)
+		tool, pathValues, err := s.selectTool(groupName, params.Arguments)
 		if err != nil {
 			return nil, fmt.Errorf("tool selection failed: %w", err)
 		}
+		// The call runs as the tool is defined now, whatever regrouping
+		// or edits do meanwhile
+		tool = s.config.Snapshot(tool)
+		if err := s.refusal(tool); err != nil {
+			return blockedResult(err), nil
+		}
 
-		// Execute the request
-		result, err := s.executeRequest(ctx, tool, params.Arguments)
+		body := params.Arguments.RequestBody
+		if body == "" {
+			body = tool.Body
+		}
+		preview, err := s.newToolRequest(ctx, s.config, tool, pathValues, groupQueryValues(params.Arguments), []byte(body), params.Arguments.Headers, false)
 		if err != nil {
 			return nil, err
 		}
+		if params.Arguments.DryRun {
+			return s.dryRunResult(s.config, tool, preview, []byte(body)), nil
+		}
+		if s.needsConfirmation(preview) && !params.Arguments.Confirm {
+			return s.confirmationResult(s.config, tool, preview, []byte(body)), nil
+		}
+		if err := s.approvals.Wait(ctx, tool, body, session.ID()); err != nil {
+			return blockedResult(err), nil
+		}
+
+		release, waited, err := s.serial.acquire(ctx, s.config, tool)
+		if errors.Is(err, ErrQueueFull) {
+			return blockedResult(err), nil
+		} else if err != nil {
+			return nil, err
+		}
+		defer release()
 
-		// Update usage stats
-		s.updateUsageStats(groupName, tool)
+		// Update usage stats, failed calls included
+		s.config.RecordUse(tool.ID, groupName)
 
-		return result, nil
+		// Execute the request
+		result, err := s.executeRequest(ctx, session.ID(), tool, pathValues, params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+		return queuedNote(result, waited), nil
 	}
 }
 
-func (s *GroupedMCPServer) selectTool(groupName string, params GroupCallParams) (*config.Tool, error) {
-	tools := s.config.GetToolsInGroup(groupName)
+// selectTool picks the group's tool for params, along with the path
+// parameter values taken from a concrete params.Path.
+func (s *GroupedMCPServer) selectTool(groupName string, params GroupCallParams) (*config.Tool, map[string]string, error) {
+	var tools []*config.Tool
+	for _, tool := range s.config.GetToolsInGroup(groupName) {
+		if !s.verifier.isHidden(tool.Name) {
+			tools = append(tools, tool)
+		}
+	}
 	if len(tools) == 0 {
-		return nil, fmt.Errorf("no tools found in group %s", groupName)
+		return nil, nil, fmt.Errorf("%w: group %s has no tools", ErrToolNotFound, groupName)
 	}
 
 	// Method is required
 	if params.Method == "" {
-		return nil, fmt.Errorf("method parameter is required")
+		return nil, nil, fmt.Errorf("method parameter is required")
 	}
 
 	// If path is specified, find exact match
-	if params.Path != "" {
+	if path, _, _ := strings.Cut(params.Path, "?"); path != "" {
 		for _, tool := range tools {
-			if strings.EqualFold(tool.Method, params.Method) && strings.Contains(tool.URL, params.Path) {
-				return tool, nil
+			if !strings.EqualFold(tool.Method, params.Method) {
+				continue
+			}
+			if values, ok := tool.MatchPath(path); ok {
+				return tool, values, nil
 			}
 		}
-		return nil, fmt.Errorf("no tool found for method %s and path %s", params.Method, params.Path)
+		for _, tool := range tools {
+			if strings.EqualFold(tool.Method, params.Method) && strings.Contains(tool.URL, path) {
+				return tool, nil, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("%w: no %s endpoint matches path %s", ErrToolNotFound, params.Method, params.Path)
 	}
 
 	// Find first tool with matching method
 	for _, tool := range tools {
 		if strings.EqualFold(tool.Method, params.Method) {
-			return tool, nil
+			return tool, nil, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no tool found for method %s", params.Method)
+	return nil, nil, fmt.Errorf("%w: no %s endpoint in group %s", ErrToolNotFound, params.Method, groupName)
+}
+
+// groupQueryValues returns the query values of a group call: those in
+// the path's query string, overridden by the query argument.
+func groupQueryValues(params GroupCallParams) map[string]string {
+	queryValues := make(map[string]string)
+	if _, rawQuery, ok := strings.Cut(params.Path, "?"); ok {
+		if query, err := url.ParseQuery(rawQuery); err == nil {
+			for name := range query {
+				queryValues[name] = query.Get(name)
+			}
+		}
+	}
+	for name, value := range params.Query {
+		queryValues[name] = value
+	}
+	return queryValues
 }
 
-func (s *GroupedMCPServer) executeRequest(ctx context.Context, tool *config.Tool, params GroupCallParams) (*mcp.CallToolResultFor[any], error) {
+func (s *GroupedMCPServer) executeRequest(ctx context.Context, sessionID string, tool *config.Tool, pathValues map[string]string, params GroupCallParams) (result *mcp.CallToolResultFor[any], err error) {
 	// Prepare request body
 	var body []byte
 	if params.RequestBody != "" {
@@ -185,55 +638,93 @@ func (s *GroupedMCPServer) executeRequest(ctx context.Context, tool *config.Tool
 		body = []byte(tool.Body)
 	}
 
+	plan := s.chaos.Plan(tool.Name)
+	if err := plan.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if plan.Fail {
+		return s.toolResult(plan.Status, nil, nil, nil, plan.FailureBody()), nil
+	}
+
 	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, tool.Method, tool.URL, bytes.NewReader(body))
+	queryValues := groupQueryValues(params)
+	ctx, cancel := s.withTimeout(ctx, tool)
+	defer cancel()
+	called := s.recordCall(tool, pathValues, queryValues)
+	httpReq, err := s.newToolRequest(ctx, s.config, tool, pathValues, queryValues, body, params.Headers, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-
-	// Set headers (tool defaults, then override with params)
-	for k, v := range tool.Headers {
-		httpReq.Header.Set(k, v)
+	if err := config.ResolveHeaderSecrets(httpReq.Header); err != nil {
+		return nil, err
 	}
-	for k, v := range params.Headers {
-		httpReq.Header.Set(k, v)
+	upstream, err := s.route(httpReq, sessionID)
+	if err != nil {
+		s.callFailed(s.config, tool, nil, 0, err.Error())
+		called(0, false)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	// Execute request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(httpReq)
+	// Execute request, within the tool's timeout
+	start := time.Now()
+	resp, retries, err := s.limits.do(ctx, s.toolClient(), tool, httpReq)
+	err = s.hideAuthQuery(err)
+	var limited *RateLimitedError
+	if errors.As(err, &limited) {
+		// Settle the call on the replica like any other answer
+		s.replicaDone(upstream, limited.Status, nil)
+		s.callFailed(s.config, tool, upstream, limited.Status, err.Error())
+		called(0, false)
+		return limited.result(""), nil
+	}
 	if err != nil {
+		err = s.timedOut(ctx, tool, err)
+		s.replicaDone(upstream, 0, err)
+		s.callFailed(s.config, tool, upstream, 0, err.Error())
+		called(0, false)
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
+	defer func() { result = retriedNote(result, retries) }()
+	s.replicaDone(upstream, resp.StatusCode, nil)
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, content, err := s.readResponse(resp)
 	if err != nil {
+		err = s.timedOut(ctx, tool, err)
+		s.callFailed(s.config, tool, upstream, resp.StatusCode, err.Error())
+		called(resp.StatusCode, false)
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-
-	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{
-			&mcp.TextContent{
-				Text: fmt.Sprintf("Status: %d\nResponse: %s", resp.StatusCode, string(respBody)),
-			},
-		},
-	}, nil
-}
-
-func (s *GroupedMCPServer) updateUsageStats(groupName string, tool *config.Tool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if configTool := s.config.Tools[tool.Name]; configTool != nil {
-		configTool.UseCount++
-		configTool.LastUsed = time.Now()
+	meta := s.resultMeta(start, resp, respBody, upstream)
+	respBody = plan.Apply(respBody)
+	if meta != nil {
+		meta.Retries = retries
+		if content.Length >= 0 {
+			meta.Bytes = int(content.Length)
+		}
+	}
+	if limited := rateLimitedBy(tool, resp, time.Now()); limited != nil {
+		s.callFailed(s.config, tool, upstream, resp.StatusCode, limited.Error())
+		called(resp.StatusCode, false)
+		return limited.result(resultText(resp.StatusCode, config.FilterHeaders(resp.Header, s.config.ResponseHeadersFor(tool)), meta, content, respBody)), nil
 	}
 
-	if configGroup := s.config.Groups[groupName]; configGroup != nil {
-		configGroup.UseCount++
-		configGroup.LastUsed = time.Now()
+	assertions := effectiveAssertions(&config.Assertions{
+		ExpectStatus:   params.ExpectStatus,
+		ExpectJSON:     params.ExpectJSON,
+		ExpectContains: params.ExpectContains,
+	}, tool)
+	if failures := checkAssertions(assertions, resp.StatusCode, respBody); len(failures) > 0 {
+		s.callFailed(s.config, tool, upstream, resp.StatusCode, "assertion failed: "+failures[0])
+		called(resp.StatusCode, false)
+		return assertionFailureResult(failures, resp.StatusCode, meta, content, respBody), nil
 	}
+	if resp.StatusCode >= 400 {
+		s.callFailed(s.config, tool, upstream, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	called(resp.StatusCode, resp.StatusCode < 400)
+
+	return s.toolResult(resp.StatusCode, config.FilterHeaders(resp.Header, s.config.ResponseHeadersFor(tool)), meta, content, respBody), nil
 }
 
 func (s *GroupedMCPServer) Start(ctx context.Context, addr string) error {
@@ -248,33 +739,38 @@ func (s *GroupedMCPServer) Start(ctx context.Context, addr string) error {
 		s.mu.RUnlock()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
+		json.NewEncoder(w).Encode(s.addDebugInfoTo(map[string]interface{}{
 			"group_count": len(groups),
 			"groups":      groups,
-			"tools_count": len(s.config.Tools),
-		})
+			"tools_count": len(s.config.ListTools()),
+		}))
 	})
 
+	if s.workflows != nil {
+		mux.HandleFunc("/api/workflows", workflowsHandler(s.workflows))
+	}
+
 	mcpHandler := mcp.NewSSEHandler(func(request *http.Request) *mcp.Server {
-		log.Printf("🔗 MCP connection from %s", request.RemoteAddr)
+		slog.Info("MCP connection", "remote", request.RemoteAddr)
 		return s.mcpServer
 	})
 
 	mux.Handle("/mcp", mcpHandler)
+	s.registerHandlers(mux)
 
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
 
-	go func() {
-		<-ctx.Done()
-		log.Println("Shutting down server...")
-		srv.Shutdown(context.Background())
-	}()
+	slog.Info("MCP server with grouping listening", "mcp", "http://localhost"+addr+"/mcp", "debug", "http://localhost"+addr+"/debug")
 
-	log.Printf("MCP server with grouping on http://localhost%s", addr)
-	log.Printf("Debug: http://localhost%s/debug", addr)
+	return serve(ctx, srv)
+}
 
-	return srv.ListenAndServe()
+// ServeStdio serves MCP over stdin/stdout until the client disconnects or
+// ctx is cancelled.
+func (s *GroupedMCPServer) ServeStdio(ctx context.Context) error {
+	slog.Info("MCP server with grouping on stdio")
+	return s.mcpServer.Run(ctx, mcp.NewStdioTransport())
 }
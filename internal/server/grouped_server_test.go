@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// countingGrouper counts grouping calls and leaves the groups alone.
+type countingGrouper struct {
+	calls atomic.Int32
+}
+
+func (g *countingGrouper) GroupToolsInConfig(*config.Config) error {
+	g.calls.Add(1)
+	return nil
+}
+
+func TestToolBurstCostsOneGroupingCall(t *testing.T) {
+	grouper := &countingGrouper{}
+	s := NewGroupedMCPServer("test", "v0", 100, newTestConfig(t), grouper)
+
+	for i := range 50 {
+		url := fmt.Sprintf("http://localhost:3000/resource%d", i)
+		if err := s.RegisterTool(fmt.Sprintf("get_resource%d", i), "GET", url, nil, nil, nil, "Get a resource"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(rebuildQuiet + 5*time.Second)
+	for grouper.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// Give a second rebuild the chance to run, were one pending
+	time.Sleep(rebuildQuiet / 2)
+	if got := grouper.calls.Load(); got != 1 {
+		t.Errorf("50 tools cost %d grouping calls, want 1", got)
+	}
+	if state := s.GroupingStatus().State; state != "idle" {
+		t.Errorf("grouping state %q, want idle", state)
+	}
+}
+
+func TestLRUEvictionAtMaxTools(t *testing.T) {
+	const maxTools = 10
+	cfg := newTestConfig(t)
+	s := NewGroupedMCPServer("test", "v0", maxTools, cfg, &countingGrouper{})
+	s.SetEviction(EvictLRU)
+
+	base := time.Now().Add(-time.Hour)
+	register := func(i int) {
+		t.Helper()
+		name := fmt.Sprintf("get_resource%d", i)
+		if err := s.RegisterTool(name, "GET", fmt.Sprintf("http://localhost:3000/resource%d", i), nil, nil, nil, "Get a resource"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	called := func(i int, minute int) {
+		tool := cfg.GetTool(fmt.Sprintf("get_resource%d", i))
+		tool.UseCount++
+		tool.LastUsed = base.Add(time.Duration(minute) * time.Minute)
+	}
+
+	for i := range maxTools {
+		register(i)
+	}
+	// 3 and 7 are never called; the rest are, in this order
+	for minute, i := range []int{5, 0, 8, 2, 9, 1, 6, 4} {
+		called(i, minute)
+	}
+	// Each new tool is called right after it is captured
+	for i := maxTools; i < maxTools+5; i++ {
+		register(i)
+		called(i, 100+i)
+	}
+
+	if got := len(cfg.ListTools()); got != maxTools {
+		t.Errorf("%d tools, want %d", got, maxTools)
+	}
+	for _, i := range []int{3, 7, 5, 0, 8} {
+		if cfg.GetTool(fmt.Sprintf("get_resource%d", i)) != nil {
+			t.Errorf("get_resource%d kept, want it evicted", i)
+		}
+	}
+	for _, i := range []int{2, 9, 1, 6, 4, 10, 11, 12, 13, 14} {
+		if cfg.GetTool(fmt.Sprintf("get_resource%d", i)) == nil {
+			t.Errorf("get_resource%d evicted, want it kept", i)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// strippedHeadersHint names the sensitive headers captured requests to
+// tool carried, which were never stored, so the agent knows to supply them.
+func strippedHeadersHint(tool *config.Tool) string {
+	if len(tool.StrippedHeaders) == 0 {
+		return ""
+	}
+	pronoun := "it"
+	if len(tool.StrippedHeaders) > 1 {
+		pronoun = "them"
+	}
+	return fmt.Sprintf("Captured requests sent %s, which mcpify doesn't store; pass %s in 'headers' (name → value) if the call needs %[2]s.",
+		strings.Join(tool.StrippedHeaders, ", "), pronoun)
+}
+
+// applyHeaders sets the headers a call passed over the stored ones. An
+// empty value removes the header, e.g. a captured X-Request-Id.
+func applyHeaders(h http.Header, overrides map[string]string) {
+	for k, v := range overrides {
+		if v == "" {
+			h.Del(k)
+		} else {
+			h.Set(k, v)
+		}
+	}
+}
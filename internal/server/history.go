@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// HistoryHandler serves GET /api/tools/{name}/history: the kept revisions
+// of the tool's definition, oldest first.
+func HistoryHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		history, err := cfg.History(r.PathValue("name"))
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		if history == nil {
+			history = []config.Revision{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(history)
+	})
+}
+
+// RevertHandler serves POST /api/tools/{name}/revert with {"to": <rev>},
+// restoring the tool as it was after that revision. onChange republishes
+// the tool; oldName is the name it had before.
+func RevertHandler(cfg *config.Config, onChange func(tool *config.Tool, oldName string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var in struct {
+			To *int `json:"to"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		if in.To == nil {
+			http.Error(w, `"to" is required`, http.StatusBadRequest)
+			return
+		}
+
+		tool, oldName, err := cfg.RevertTool(r.PathValue("name"), *in.To)
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		if err := cfg.Save(cfg.Path); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+		onChange(tool, oldName)
+		slog.Info("Reverted tool", "tool", tool.Name, "revision", *in.To)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tool)
+	})
+}
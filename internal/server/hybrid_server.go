@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/approval"
+	"github.com/NilayYadav/mcpify/internal/chaos"
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/coverage"
+	"github.com/NilayYadav/mcpify/internal/events"
+	"github.com/NilayYadav/mcpify/internal/grouping"
+	"github.com/NilayYadav/mcpify/internal/metrics"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/replica"
+	"github.com/NilayYadav/mcpify/internal/workflow"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HybridMCPServer serves both the individual and the grouped projection of
+// the catalog from a single MCP server. Each session chooses what it sees.
+type HybridMCPServer struct {
+	mcpServer  *mcp.Server
+	individual *MCPServer
+	grouped    *GroupedMCPServer
+	router     *viewRouter
+	config     *config.Config
+	extensions
+}
+
+type SetToolViewParams struct {
+	View string `json:"view"`
+}
+
+func NewHybridMCPServer(name, version string, maxTools int, cfg *config.Config, defaultView ToolView, grouper grouping.Grouper) *HybridMCPServer {
+	mcpServer := mcp.NewServer(&mcp.Implementation{
+		Name:    name,
+		Version: version,
+	}, nil)
+
+	server := &HybridMCPServer{
+		mcpServer:  mcpServer,
+		individual: newMCPServerOn(mcpServer, maxTools, cfg),
+		grouped:    newGroupedMCPServerOn(mcpServer, maxTools, cfg, grouper),
+		config:     cfg,
+	}
+
+	// Both views hide the same tools
+	server.grouped.verifier = server.individual.verifier
+	// and a serialized tool runs one call at a time across both
+	server.grouped.serial = server.individual.serial
+	// and a rate limit the target sets holds calls back in both
+	server.grouped.limits = server.individual.limits
+
+	server.router = newViewRouter(defaultView, server.individual.hasTool, server.grouped.hasGroup)
+	mcpServer.AddReceivingMiddleware(server.router.middleware(server.sessions))
+	mcpServer.AddSendingMiddleware(notifyMiddleware)
+	server.addSetToolView()
+	server.AddDebugInfo("grouping", func() any { return server.grouped.GroupingStatus() })
+	addFindEndpoint(mcpServer, cfg, func(tool *config.Tool, group string) bool {
+		return server.individual.exposes(tool, group) || server.grouped.exposes(tool, group)
+	})
+	addRemoveTool(mcpServer, server.RemoveTool)
+
+	return server
+}
+
+func (s *HybridMCPServer) RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error {
+	evicted, err := s.individual.registerTool(name, method, url, pathParams, headers, body, description)
+	if err != nil {
+		return err
+	}
+	if evicted != "" {
+		s.grouped.requestRebuild()
+	}
+	s.grouped.toolAdded()
+	return nil
+}
+
+// SetEviction sets the policy of the individual view, which registers
+// tools for both.
+func (s *HybridMCPServer) SetEviction(policy Eviction) {
+	s.individual.SetEviction(policy)
+}
+
+func (s *HybridMCPServer) SetEvents(b *events.Bus) {
+	s.individual.SetEvents(b)
+	s.grouped.SetEvents(b)
+}
+
+func (s *HybridMCPServer) HasTool(method, url string) bool {
+	return s.individual.HasTool(method, url)
+}
+
+func (s *HybridMCPServer) SetResultMeta(on bool) {
+	s.individual.SetResultMeta(on)
+	s.grouped.SetResultMeta(on)
+}
+
+func (s *HybridMCPServer) SetMaxResponseSize(n int64) {
+	s.individual.SetMaxResponseSize(n)
+	s.grouped.SetMaxResponseSize(n)
+}
+
+func (s *HybridMCPServer) SetStructuredResults(on bool) {
+	s.individual.SetStructuredResults(on)
+	s.grouped.SetStructuredResults(on)
+}
+
+func (s *HybridMCPServer) SetBinaryMode(mode BinaryMode) {
+	s.individual.SetBinaryMode(mode)
+	s.grouped.SetBinaryMode(mode)
+}
+
+func (s *HybridMCPServer) RemoveBinaryFiles() error {
+	return errors.Join(s.individual.RemoveBinaryFiles(), s.grouped.RemoveBinaryFiles())
+}
+
+func (s *HybridMCPServer) SetRequestTimeout(d time.Duration) {
+	s.individual.SetRequestTimeout(d)
+	s.grouped.SetRequestTimeout(d)
+}
+
+func (s *HybridMCPServer) SetAuthQuery(params map[string]string) {
+	s.individual.SetAuthQuery(params)
+	s.grouped.SetAuthQuery(params)
+}
+
+func (s *HybridMCPServer) SetReplicas(p *replica.Pool) {
+	s.individual.SetReplicas(p)
+	s.grouped.SetReplicas(p)
+}
+
+func (s *HybridMCPServer) SetCoverage(t *coverage.Tracker) {
+	s.individual.SetCoverage(t)
+	s.grouped.SetCoverage(t)
+}
+
+func (s *HybridMCPServer) SetMetrics(m *metrics.Metrics) {
+	s.individual.SetMetrics(m)
+	s.grouped.SetMetrics(m)
+}
+
+func (s *HybridMCPServer) SetPreserveUserAgent(on bool) {
+	s.individual.SetPreserveUserAgent(on)
+	s.grouped.SetPreserveUserAgent(on)
+}
+
+func (s *HybridMCPServer) SetReadOnly(on bool) {
+	s.individual.SetReadOnly(on)
+	s.grouped.SetReadOnly(on)
+}
+
+func (s *HybridMCPServer) SetConfirmRules(rules []ConfirmRule) {
+	s.individual.SetConfirmRules(rules)
+	s.grouped.SetConfirmRules(rules)
+}
+
+func (s *HybridMCPServer) SetKeepAlive(on bool) {
+	s.individual.SetKeepAlive(on)
+	s.grouped.SetKeepAlive(on)
+	// One connection pool for both views
+	s.grouped.client = s.individual.client
+}
+
+func (s *HybridMCPServer) HasToolName(name string) bool {
+	return s.individual.HasToolName(name)
+}
+
+func (s *HybridMCPServer) VerifyTools(ctx context.Context) {
+	s.individual.verifier.start(ctx, s.config, &s.extensions)
+}
+
+func (s *HybridMCPServer) SetChaos(c *chaos.Chaos) {
+	s.individual.SetChaos(c)
+	s.grouped.SetChaos(c)
+}
+
+func (s *HybridMCPServer) SetApprovals(g *approval.Gate) {
+	s.individual.SetApprovals(g)
+	s.grouped.SetApprovals(g)
+}
+
+// SetRetryAfterMax sets the wait limit of the rate limits both views share.
+func (s *HybridMCPServer) SetRetryAfterMax(d time.Duration) {
+	s.individual.SetRetryAfterMax(d)
+}
+
+// SetRetries sets the retry count of the rate limits both views share.
+func (s *HybridMCPServer) SetRetries(n int) {
+	s.individual.SetRetries(n)
+}
+
+// RecordResponse goes through the individual view, which shares the
+// catalog with the grouped one.
+func (s *HybridMCPServer) RecordResponse(method, url string, sample *config.ResponseSample) {
+	s.individual.RecordResponse(method, url, sample)
+}
+
+func (s *HybridMCPServer) RecordQueryParams(method, url string, params map[string]string) {
+	s.individual.RecordQueryParams(method, url, params)
+}
+
+func (s *HybridMCPServer) RecordBodySchema(method, url string, schema *config.BodySchema) {
+	s.individual.RecordBodySchema(method, url, schema)
+}
+
+func (s *HybridMCPServer) RecordRequestSample(method, url string, sample *config.RequestSample) {
+	s.individual.RecordRequestSample(method, url, sample)
+}
+
+func (s *HybridMCPServer) ToolChanged(tool *config.Tool, oldName string) {
+	s.individual.ToolChanged(tool, oldName)
+	s.grouped.ToolChanged(tool, oldName)
+}
+
+func (s *HybridMCPServer) SetObservedValues(t *observed.Tracker) {
+	s.individual.SetObservedValues(t)
+	s.grouped.SetObservedValues(t)
+	s.Handle("GET /api/tools/{name}/observed-values", observedValuesHandler(s.config, t))
+}
+
+func (s *HybridMCPServer) SetWorkflows(m *workflow.Miner) {
+	s.individual.SetWorkflows(m)
+	s.grouped.SetWorkflows(m)
+}
+
+func (s *HybridMCPServer) sessions() []*mcp.ServerSession {
+	return slices.Collect(s.mcpServer.Sessions())
+}
+
+func (s *HybridMCPServer) addSetToolView() {
+	mcp.AddTool(s.mcpServer, &mcp.Tool{
+		Name:        setToolViewName,
+		Description: "Switch which tools this session sees: 'individual' (one tool per endpoint), 'grouped' (one tool per endpoint group) or 'both'.",
+	}, s.handleSetToolView)
+}
+
+func (s *HybridMCPServer) handleSetToolView(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[SetToolViewParams]) (*mcp.CallToolResultFor[any], error) {
+	view, err := ParseToolView(params.Arguments.View)
+	if err != nil {
+		return nil, err
+	}
+
+	s.router.setView(session, view, s.sessions)
+	slog.Info("Session switched tool view", "session", session.ID(), "view", view)
+
+	// Only this session's tool list changed, so only its client refetches
+	if err := session.NotifyProgress(ctx, &mcp.ProgressNotificationParams{ProgressToken: viewChangedToken}); err != nil {
+		slog.Warn("Failed to notify session of its new tool view", "session", session.ID(), "error", err)
+	}
+
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Tool view set to %s", view),
+			},
+		},
+	}, nil
+}
+
+func (s *HybridMCPServer) Start(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug", func(w http.ResponseWriter, r *http.Request) {
+		views := make(map[string]ToolView)
+		for _, session := range s.sessions() {
+			views[session.ID()] = s.router.view(session)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.addDebugInfoTo(map[string]interface{}{
+			"default_view":  s.router.defaultView,
+			"session_views": views,
+			"tools_count":   len(s.config.ListTools()),
+			"group_count":   len(s.config.ListGroups()),
+		}))
+	})
+
+	if s.individual.workflows != nil {
+		mux.HandleFunc("/api/workflows", workflowsHandler(s.individual.workflows))
+	}
+	if s.individual.workflows != nil || s.individual.observed != nil {
+		go s.individual.refreshHints(ctx)
+	}
+
+	mcpHandler := mcp.NewSSEHandler(func(request *http.Request) *mcp.Server {
+		slog.Info("MCP connection", "remote", request.RemoteAddr)
+		return s.mcpServer
+	})
+
+	mux.Handle("/mcp", mcpHandler)
+	s.registerHandlers(mux)
+
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	slog.Info("MCP server with per-session tool views listening", "mcp", "http://localhost"+addr+"/mcp", "debug", "http://localhost"+addr+"/debug", "default_view", s.router.defaultView)
+
+	return serve(ctx, srv)
+}
+
+// ServeStdio serves MCP over stdin/stdout until the client disconnects or
+// ctx is cancelled. The single stdio session starts in the default view.
+func (s *HybridMCPServer) ServeStdio(ctx context.Context) error {
+	slog.Info("MCP server with per-session tool views on stdio", "default_view", s.router.defaultView)
+	return s.mcpServer.Run(ctx, mcp.NewStdioTransport())
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const listEndpointsName = "mcpify_list_endpoints"
+
+type ListEndpointsParams struct {
+	Filter string `json:"filter,omitempty"`
+}
+
+type endpointListing struct {
+	Tool   string `json:"tool"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Calls counts the tool calls made through mcpify, and Errors those
+	// that failed, most recently with LastError.
+	Calls     int       `json:"calls"`
+	LastUsed  time.Time `json:"last_used,omitzero"`
+	Errors    int       `json:"errors"`
+	LastError string    `json:"last_error,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	// LastSeen is when the endpoint was last seen in captured traffic.
+	LastSeen      time.Time `json:"last_seen"`
+	Groups        []string  `json:"groups,omitempty"`
+	HasSampleBody bool      `json:"has_sample_body"`
+}
+
+// listCatalog lists every captured endpoint matching filter, a
+// case-insensitive substring of the method or templated path, ordered by
+// path and method.
+func listCatalog(cfg *config.Config, filter string) []endpointListing {
+	groupsOf := make(map[string][]string)
+	for _, group := range cfg.ListGroups() {
+		for _, id := range group.ToolIDs {
+			groupsOf[id] = append(groupsOf[id], group.Name)
+		}
+	}
+
+	filter = strings.ToLower(filter)
+	listings := []endpointListing{}
+	for _, tool := range cfg.ListTools() {
+		path, _ := observed.Template(toolPathOf(tool))
+		if filter != "" && !strings.Contains(strings.ToLower(path), filter) && !strings.Contains(strings.ToLower(tool.Method), filter) {
+			continue
+		}
+
+		listing := endpointListing{
+			Tool:          tool.Name,
+			Method:        tool.Method,
+			Path:          path,
+			Calls:         tool.UseCount,
+			LastUsed:      tool.LastUsed,
+			Errors:        tool.ErrorCount,
+			LastError:     tool.LastError,
+			FirstSeen:     tool.CreatedAt,
+			LastSeen:      tool.CreatedAt,
+			Groups:        groupsOf[tool.ID],
+			HasSampleBody: tool.Body != "",
+		}
+		if tool.Response != nil && tool.Response.SeenAt.After(listing.LastSeen) {
+			listing.LastSeen = tool.Response.SeenAt
+		}
+		sort.Strings(listing.Groups)
+		listings = append(listings, listing)
+	}
+
+	sort.Slice(listings, func(i, j int) bool {
+		if listings[i].Path != listings[j].Path {
+			return listings[i].Path < listings[j].Path
+		}
+		return listings[i].Method < listings[j].Method
+	})
+	return listings
+}
+
+// addListEndpoints registers the mcpify_list_endpoints meta-tool. Like
+// find_endpoint it is always present and reads the catalog on each call,
+// so endpoints discovered during the session show up.
+func addListEndpoints(mcpServer *mcp.Server, cfg *config.Config) {
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name: listEndpointsName,
+		Description: "List every endpoint mcpify has captured so far, with its tool name, method, " +
+			"templated path, call and error counts, the last error, when it was last called and " +
+			"first and last seen, the groups it is in and whether a sample request body exists. " +
+			"Optionally filter by text in the method or path.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[ListEndpointsParams]) (*mcp.CallToolResultFor[any], error) {
+		listings := listCatalog(cfg, params.Arguments.Filter)
+
+		data, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode endpoints: %w", err)
+		}
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(data)},
+			},
+		}, nil
+	})
+}
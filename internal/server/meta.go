@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/replica"
+)
+
+// metaHint documents the Meta line of tool results in descriptions.
+const metaHint = "Results include meta (a Meta line in text results): latency_ms, bytes (before truncation), cached, retries, rate_limit headers and base_url."
+
+// ResultMeta is the telemetry attached to tool results, so agents can
+// decide between paginating, retrying and giving up.
+type ResultMeta struct {
+	LatencyMS int64             `json:"latency_ms"`
+	Bytes     int               `json:"bytes"`
+	Cached    bool              `json:"cached"`
+	Retries   int               `json:"retries"`
+	RateLimit map[string]string `json:"rate_limit,omitempty"`
+	BaseURL   string            `json:"base_url"`
+	// Replica is the instance of the target that served the call, when
+	// calls are spread across replicas.
+	Replica string `json:"replica,omitempty"`
+}
+
+// SetResultMeta turns the Meta line of tool results on or off.
+func (e *extensions) SetResultMeta(on bool) {
+	e.noResultMeta = !on
+}
+
+// resultMeta describes the upstream exchange for resp, whose body was
+// body and which was requested at start. It returns nil when results
+// carry no meta.
+func (e *extensions) resultMeta(start time.Time, resp *http.Response, body []byte, upstream *replica.Replica) *ResultMeta {
+	if e.noResultMeta {
+		return nil
+	}
+	meta := &ResultMeta{
+		LatencyMS: time.Since(start).Milliseconds(),
+		Bytes:     len(body),
+	}
+	if u := resp.Request.URL; u != nil {
+		meta.BaseURL = (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+	}
+	if upstream != nil {
+		meta.Replica = upstream.Base
+	}
+	for name, values := range resp.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "ratelimit") || strings.HasPrefix(lower, "x-ratelimit") || lower == "retry-after" {
+			if meta.RateLimit == nil {
+				meta.RateLimit = make(map[string]string)
+			}
+			meta.RateLimit[name] = values[0]
+		}
+	}
+	return meta
+}
+
+func (e *extensions) metaHint() string {
+	if e.noResultMeta {
+		return ""
+	}
+	return metaHint
+}
+
+func (m *ResultMeta) String() string {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
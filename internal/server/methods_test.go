@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/capture"
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// freeAddr returns a local address nothing listens on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// responseWaiter is an MCPServer that reports each response capture
+// records, once it is saved.
+type responseWaiter struct {
+	*MCPServer
+	recorded chan *config.ResponseSample
+}
+
+func (w responseWaiter) RecordResponse(method, url string, sample *config.ResponseSample) {
+	w.MCPServer.RecordResponse(method, url, sample)
+	w.recorded <- sample
+}
+
+func TestPropfindThroughProxy(t *testing.T) {
+	const propfind = `<?xml version="1.0"?><propfind xmlns="DAV:"><prop><getetag/></prop></propfind>`
+	type request struct{ method, path, depth, body string }
+	got := make(chan request, 2)
+	webdav := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.Method, r.URL.Path, r.Header.Get("Depth"), string(body)}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`<multistatus xmlns="DAV:"><response><href>/files/docs/</href></response></multistatus>`))
+	}))
+	defer webdav.Close()
+	target, err := url.Parse(webdav.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig(t)
+	s := NewMCPServer("test", "v0", 10, cfg)
+	waiter := responseWaiter{s, make(chan *config.ResponseSample, 1)}
+	ec := capture.NewEndpointCapture(target, waiter, false, "", "", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	addr := freeAddr(t)
+	stopped := make(chan error, 1)
+	go func() { stopped <- ec.StartProxy(ctx, addr, nil) }()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	// Send the PROPFIND once the proxy is up
+	var resp *http.Response
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		req, err := http.NewRequest("PROPFIND", "http://"+addr+"/files/docs", strings.NewReader(propfind))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Depth", "1")
+		req.Header.Set("Content-Type", "application/xml")
+		if resp, err = http.DefaultClient.Do(req); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("proxy never came up: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		t.Fatalf("proxy answered %d, want 207", resp.StatusCode)
+	}
+	if r := <-got; r.method != "PROPFIND" || r.body != propfind {
+		t.Fatalf("target got %+v through the proxy", r)
+	}
+
+	// The tool is registered with the method, the captured request and
+	// the 207 it got
+	select {
+	case sample := <-waiter.recorded:
+		if sample.Status != http.StatusMultiStatus {
+			t.Errorf("recorded a %d response, want 207", sample.Status)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the response was never recorded")
+	}
+	tool := cfg.ToolFor("PROPFIND", webdav.URL+"/files/docs")
+	if tool == nil || !s.hasTool(tool.Name) {
+		t.Fatal("no PROPFIND tool registered")
+	}
+	if tool.Method != "PROPFIND" || tool.Headers["Depth"] != "1" || string(tool.Body) != propfind {
+		t.Errorf("registered %s %s with headers %v and body %q", tool.Method, tool.URL, tool.Headers, tool.Body)
+	}
+
+	client, _ := connect(t, s.mcpServer)
+	result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: tool.Name, Arguments: map[string]any{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("calling %s failed: %+v", tool.Name, result.Content)
+	}
+	want := request{"PROPFIND", "/files/docs", "1", propfind}
+	if r := <-got; r != want {
+		t.Errorf("the tool sent %+v, want %+v", r, want)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, "207") || !strings.Contains(text, "multistatus") {
+		t.Errorf("result %q doesn't show the 207 multistatus answer", text)
+	}
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+)
+
+// observedHintValues caps how many values per parameter go into a
+// description; the full set is available over HTTP.
+const observedHintValues = 5
+
+// observedKey identifies a tool the way the capture side records observed
+// values, e.g. "GET /orders/{order_id}".
+func observedKey(tool *config.Tool) string {
+	template, _ := observed.Template(tool.RawPath())
+	return tool.Method + " " + template
+}
+
+// observedHint lists real values seen for the tool's parameters, or
+// returns "" when there are none.
+func observedHint(t *observed.Tracker, tool *config.Tool) string {
+	if t == nil {
+		return ""
+	}
+
+	values := t.Values(observedKey(tool))
+	params := make([]string, 0, len(values))
+	for param := range values {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	var lines []string
+	for _, param := range params {
+		examples := make([]string, 0, observedHintValues)
+		for i, v := range values[param] {
+			if i == observedHintValues {
+				break
+			}
+			examples = append(examples, v.Value)
+		}
+		line := fmt.Sprintf("%s: %s", param, strings.Join(examples, ", "))
+		if extra := len(values[param]) - len(examples); extra > 0 {
+			line += fmt.Sprintf(" (+%d more)", extra)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "Observed values: " + strings.Join(lines, "; ")
+}
+
+// observedValuesHandler serves GET /api/tools/{name}/observed-values. The
+// name may also be a tool ID.
+func observedValuesHandler(cfg *config.Config, t *observed.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tool := cfg.LookupTool(r.PathValue("name"))
+		if tool == nil {
+			err := fmt.Errorf("%w: %q", ErrToolNotFound, r.PathValue("name"))
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tool":     tool.Name,
+			"id":       tool.ID,
+			"endpoint": observedKey(tool),
+			"values":   t.Values(observedKey(tool)),
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// redacted stands in for sensitive values in previews.
+const redacted = "[redacted]"
+
+// maxPreviewBody bounds the request body a preview shows.
+const maxPreviewBody = 4 << 10
+
+// RequestPreview is the request a call would send, as dry runs and
+// confirmations show it.
+type RequestPreview struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// previewRequest describes req, a call to tool with body, with sensitive
+// headers and the auth query redacted. Header secrets stay references.
+func (e *extensions) previewRequest(cfg *config.Config, tool *config.Tool, req *http.Request, body []byte) *RequestPreview {
+	preview := &RequestPreview{Method: req.Method, URL: e.withoutAuthQuery(req.URL), Headers: make(map[string]string)}
+
+	sensitive := append(cfg.SensitiveHeaderNames(), tool.StrippedHeaders...)
+	for name, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if slices.ContainsFunc(sensitive, func(s string) bool { return strings.EqualFold(strings.TrimSpace(s), name) }) {
+			value = redacted
+		}
+		preview.Headers[name] = value
+	}
+
+	switch {
+	case isBinary(body):
+		preview.Body = binarySummary(nil, body)
+	case len(body) > maxPreviewBody:
+		preview.Body = fmt.Sprintf("%s\n[%d more bytes]", body[:maxPreviewBody], len(body)-maxPreviewBody)
+	default:
+		preview.Body = string(body)
+	}
+	return preview
+}
+
+// String writes the preview as the request would go over the wire.
+func (p *RequestPreview) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", p.Method, p.URL)
+	for _, name := range slices.Sorted(maps.Keys(p.Headers)) {
+		fmt.Fprintf(&b, "%s: %s\n", name, p.Headers[name])
+	}
+	if p.Body != "" {
+		b.WriteString("\n" + p.Body)
+	}
+	return b.String()
+}
+
+// dryRunResult returns the request a call to tool would send, without
+// sending it: as structured content, or as text with plain results.
+func (e *extensions) dryRunResult(cfg *config.Config, tool *config.Tool, req *http.Request, body []byte) *mcp.CallToolResultFor[any] {
+	preview := e.previewRequest(cfg, tool, req, body)
+	if e.plainResults {
+		return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "Dry run; nothing was sent:\n\n" + preview.String()}}}
+	}
+	data, err := json.Marshal(preview)
+	if err != nil {
+		return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: preview.String()}}}
+	}
+	return &mcp.CallToolResultFor[any]{
+		Content:           []mcp.Content{&mcp.TextContent{Text: string(data)}},
+		StructuredContent: preview,
+	}
+}
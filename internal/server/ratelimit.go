@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultRetryAfterMax is the longest a call waits out a target's rate
+// limit before retrying by itself; longer waits go back to the agent.
+const DefaultRetryAfterMax = 5 * time.Second
+
+// maxRateLimitRetries is how often a rate-limited call is retried.
+const maxRateLimitRetries = 2
+
+// epochThreshold tells a reset given as a Unix time, as X-RateLimit-Reset
+// often is, from one given in seconds from now.
+const epochThreshold = 1_000_000_000
+
+// rateLimits remembers, per tool, until when the target asked mcpify to
+// stop calling it: after a 429 or 503 with Retry-After, or a response
+// saying no requests remain until a reset. Calls during that time wait,
+// or are turned away without reaching the target.
+type rateLimits struct {
+	mu      sync.Mutex
+	until   map[string]time.Time
+	maxWait time.Duration
+	// retries is how often transient failures are resent
+	retries int
+}
+
+func newRateLimits() *rateLimits {
+	return &rateLimits{until: make(map[string]time.Time), maxWait: DefaultRetryAfterMax, retries: DefaultRetries}
+}
+
+// RateLimitedError is the rate_limited error a call returns when the
+// target asks mcpify to back off for longer than it waits by itself.
+type RateLimitedError struct {
+	Tool string `json:"tool"`
+	// Status is the target's status, or 0 when mcpify held the call back
+	// without sending it.
+	Status            int       `json:"status,omitempty"`
+	RetryAfterSeconds float64   `json:"retry_after_seconds"`
+	RetryAt           time.Time `json:"retry_at"`
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited: retry %s after %s", e.Tool, e.RetryAt.Format(time.RFC3339))
+}
+
+// MarshalJSON adds "error": "rate_limited", so agents can match on it.
+func (e *RateLimitedError) MarshalJSON() ([]byte, error) {
+	type plain RateLimitedError
+	return json.Marshal(struct {
+		Error string `json:"error"`
+		*plain
+	}{"rate_limited", (*plain)(e)})
+}
+
+// result reports the error as a tool error, followed by the target's
+// response when there was one.
+func (e *RateLimitedError) result(response string) *mcp.CallToolResultFor[any] {
+	data, _ := json.Marshal(e)
+	text := "Rate limited: " + string(data)
+	if response != "" {
+		text += "\n\n" + response
+	}
+	return &mcp.CallToolResultFor[any]{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: text}},
+	}
+}
+
+// SetMaxWait sets the longest wait for a rate limit that calls sit out
+// before retrying; 0 hands every rate limit back to the agent.
+func (r *rateLimits) SetMaxWait(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxWait = max(d, 0)
+}
+
+// do sends req for tool with client. It first waits out any rate limit
+// the target has set for tool. When the target answers 429 or 503 with a
+// delay of at most the max wait, it waits and resends, up to
+// maxRateLimitRetries times. Longer delays end the call with a
+// *RateLimitedError, without sending it when the delay was already known.
+// Connection errors and 502, 503 and 504 answers are resent after a
+// backoff, as often as retriesFor allows. retries counts the resends.
+func (r *rateLimits) do(ctx context.Context, client *http.Client, tool *config.Tool, req *http.Request) (resp *http.Response, retries int, err error) {
+	limitRetries, failures, maxFailures := 0, 0, r.retriesFor(tool, req.Method)
+	for {
+		if err := r.wait(ctx, tool); err != nil {
+			return nil, retries, err
+		}
+		resp, err = client.Do(req)
+		var until time.Time
+		var limited bool
+		if err == nil {
+			until, limited = r.observe(tool, resp, time.Now())
+		}
+
+		var resend bool
+		switch {
+		case req.GetBody == nil && req.Body != nil:
+		case limited:
+			// Waited out before the resend
+			resend = limitRetries < maxRateLimitRetries && time.Until(until) <= r.max()
+			limitRetries++
+		case failures < maxFailures && transient(ctx, resp, err):
+			resend = true
+			failures++
+		}
+		if !resend {
+			if err != nil && retries > 0 {
+				err = fmt.Errorf("%w (%d attempts)", err, retries+1)
+			}
+			return resp, retries, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if !limited {
+			if err := backoff(ctx, failures-1); err != nil {
+				return nil, retries, err
+			}
+		}
+		retry := req.Clone(ctx)
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, retries, err
+			}
+		}
+		req = retry
+		retries++
+	}
+}
+
+// wait sleeps until tool's rate limit ends, unless that is further off
+// than the max wait.
+func (r *rateLimits) wait(ctx context.Context, tool *config.Tool) error {
+	r.mu.Lock()
+	until := r.until[tool.ID]
+	maxWait := r.maxWait
+	r.mu.Unlock()
+
+	delay := time.Until(until)
+	if delay <= 0 {
+		return nil
+	}
+	if delay > maxWait {
+		return &RateLimitedError{Tool: tool.Name, RetryAfterSeconds: seconds(delay), RetryAt: until}
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *rateLimits) max() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.maxWait
+}
+
+// observe records the limit resp sets for tool, if any, and returns when
+// it ends. limited is whether resp itself was refused for rate limiting.
+func (r *rateLimits) observe(tool *config.Tool, resp *http.Response, now time.Time) (until time.Time, limited bool) {
+	until, ok := rateLimitEnd(resp, now)
+	if !ok {
+		return time.Time{}, false
+	}
+	r.mu.Lock()
+	if until.After(r.until[tool.ID]) {
+		r.until[tool.ID] = until
+	}
+	r.mu.Unlock()
+	return until, resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// rateLimitedBy returns the error to report when resp refused tool's call
+// for rate limiting.
+func rateLimitedBy(tool *config.Tool, resp *http.Response, now time.Time) *RateLimitedError {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	until, ok := rateLimitEnd(resp, now)
+	if !ok {
+		return nil
+	}
+	return &RateLimitedError{Tool: tool.Name, Status: resp.StatusCode, RetryAfterSeconds: seconds(until.Sub(now)), RetryAt: until}
+}
+
+// rateLimitEnd reads when the rate limit resp reports ends. A 429 or 503
+// reports it in Retry-After, in seconds or as an HTTP date, and a 429 or
+// any other response in the RateLimit-*, X-RateLimit-* or structured
+// RateLimit headers once no requests remain. A 503 without Retry-After is
+// an outage, not a limit.
+func rateLimitEnd(resp *http.Response, now time.Time) (time.Time, bool) {
+	limited := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	if value := resp.Header.Get("Retry-After"); value != "" && limited {
+		if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs >= 0 {
+			return now.Add(time.Duration(secs) * time.Second), true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return maxTime(at, now), true
+		}
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		return time.Time{}, false
+	}
+
+	remaining, reset, ok := structuredRateLimit(resp.Header.Get("RateLimit"))
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-"} {
+		if ok {
+			break
+		}
+		remaining, reset = resp.Header.Get(prefix+"Remaining"), resp.Header.Get(prefix+"Reset")
+		ok = remaining != "" && reset != ""
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(remaining)); err != nil || n > 0 {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(reset), 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, false
+	}
+	if secs >= epochThreshold {
+		return maxTime(time.Unix(int64(secs), 0), now), true
+	}
+	return now.Add(time.Duration(secs * float64(time.Second))), true
+}
+
+// structuredRateLimit reads the remaining and reset parameters of a
+// RateLimit header such as `limit=100, remaining=0, reset=30`.
+func structuredRateLimit(value string) (remaining, reset string, ok bool) {
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(key) {
+		case "remaining", "r":
+			remaining = val
+		case "reset", "t":
+			reset = val
+		}
+	}
+	return remaining, reset, remaining != "" && reset != ""
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// seconds rounds d up to the millisecond, in seconds.
+func seconds(d time.Duration) float64 {
+	return math.Ceil(d.Seconds()*1000) / 1000
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestRetryAfter(t *testing.T) {
+	// The HTTP date is in whole seconds, so it is 1-2s away when sent
+	inTwoSeconds := func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }
+	inTwoMinutes := func() string { return time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat) }
+
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		maxWait    time.Duration
+		// requests is how many the target should get
+		requests int32
+		// waited bounds how long the call should take
+		waited [2]time.Duration
+		// retryAfterSeconds bounds the retry_after_seconds of a rate_limited
+		// error; zero when the call should not end with one
+		retryAfterSeconds [2]float64
+	}{
+		{
+			name:       "seconds, waited out",
+			retryAfter: func() string { return "1" },
+			maxWait:    5 * time.Second, requests: 2,
+			waited: [2]time.Duration{time.Second, 3 * time.Second},
+		},
+		{
+			name:       "HTTP date, waited out",
+			retryAfter: inTwoSeconds,
+			maxWait:    5 * time.Second, requests: 2,
+			waited: [2]time.Duration{500 * time.Millisecond, 3 * time.Second},
+		},
+		{
+			name:       "seconds, handed to the agent",
+			retryAfter: func() string { return "120" },
+			maxWait:    5 * time.Second, requests: 1,
+			waited:            [2]time.Duration{0, time.Second},
+			retryAfterSeconds: [2]float64{119, 120},
+		},
+		{
+			name:       "HTTP date, handed to the agent",
+			retryAfter: inTwoMinutes,
+			maxWait:    5 * time.Second, requests: 1,
+			waited:            [2]time.Duration{0, time.Second},
+			retryAfterSeconds: [2]float64{118, 120},
+		},
+		{
+			name:       "over the cap",
+			retryAfter: func() string { return "3" },
+			maxWait:    time.Second, requests: 1,
+			waited:            [2]time.Duration{0, time.Second},
+			retryAfterSeconds: [2]float64{2, 3},
+		},
+		{
+			name:       "waiting off",
+			retryAfter: func() string { return "1" },
+			maxWait:    0, requests: 1,
+			waited:            [2]time.Duration{0, time.Second},
+			retryAfterSeconds: [2]float64{0.5, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter())
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte(`{"ok":true}`))
+			}))
+			defer api.Close()
+
+			s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+			s.SetRetryAfterMax(tt.maxWait)
+			if err := s.RegisterTool("get_items", "GET", api.URL+"/items", nil, nil, nil, "Items"); err != nil {
+				t.Fatal(err)
+			}
+			client, _ := connect(t, s.mcpServer)
+
+			start := time.Now()
+			result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: "get_items", Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if took := time.Since(start); took < tt.waited[0] || took > tt.waited[1] {
+				t.Errorf("call took %s, want %s to %s", took, tt.waited[0], tt.waited[1])
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("target got %d requests, want %d", got, tt.requests)
+			}
+
+			text := result.Content[0].(*mcp.TextContent).Text
+			if tt.retryAfterSeconds == [2]float64{} {
+				if result.IsError {
+					t.Errorf("call failed: %s", text)
+				}
+				return
+			}
+			limited := rateLimitedResult(t, result)
+			if limited.Status != http.StatusTooManyRequests {
+				t.Errorf("status %d, want 429", limited.Status)
+			}
+			if secs := limited.RetryAfterSeconds; secs < tt.retryAfterSeconds[0] || secs > tt.retryAfterSeconds[1] {
+				t.Errorf("retry_after_seconds %v, want %v to %v", secs, tt.retryAfterSeconds[0], tt.retryAfterSeconds[1])
+			}
+
+			// Until then, calls are turned away without reaching the target
+			result, err = client.CallTool(context.Background(), &mcp.CallToolParams{Name: "get_items", Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if held := rateLimitedResult(t, result); held.Status != 0 || requests.Load() != 1 {
+				t.Errorf("second call reached the target: status %d, %d requests", held.Status, requests.Load())
+			}
+		})
+	}
+}
+
+func TestInvalidRetryAfter(t *testing.T) {
+	for _, value := range []string{"soon", "-5", "1.5", "Tomorrow, 12:00"} {
+		t.Run(value, func(t *testing.T) {
+			var requests atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.Header().Set("Retry-After", value)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			defer api.Close()
+
+			s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+			if err := s.RegisterTool("get_items", "GET", api.URL+"/items", nil, nil, nil, "Items"); err != nil {
+				t.Fatal(err)
+			}
+			client, _ := connect(t, s.mcpServer)
+
+			// An unreadable Retry-After is a plain 429: no wait, no
+			// rate_limited error, no limit on later calls
+			for range 2 {
+				result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: "get_items", Arguments: map[string]any{}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if text := result.Content[0].(*mcp.TextContent).Text; strings.Contains(text, "rate_limited") || !strings.Contains(text, "429") {
+					t.Errorf("result %q, want the target's 429", text)
+				}
+			}
+			if got := requests.Load(); got != 2 {
+				t.Errorf("target got %d requests, want 2", got)
+			}
+		})
+	}
+}
+
+// rateLimitedResult reads the rate_limited error result reports.
+func rateLimitedResult(t *testing.T, result *mcp.CallToolResultFor[any]) *RateLimitedError {
+	t.Helper()
+	text := result.Content[0].(*mcp.TextContent).Text
+	data, ok := strings.CutPrefix(text, "Rate limited: ")
+	if !result.IsError || !ok {
+		t.Fatalf("result %q, want a rate_limited error", text)
+	}
+	data, _, _ = strings.Cut(data, "\n")
+	var limited struct {
+		Error string `json:"error"`
+		RateLimitedError
+	}
+	if err := json.Unmarshal([]byte(data), &limited); err != nil || limited.Error != "rate_limited" {
+		t.Fatalf("rate_limited error %q: %v", data, err)
+	}
+	return &limited.RateLimitedError
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/approval"
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// SetReadOnly makes calls to tools whose method may change the target,
+// all but GET and HEAD, fail without being sent. Such endpoints are still
+// captured and listed. A tool's allow_execute overrides it either way.
+func (e *extensions) SetReadOnly(on bool) {
+	e.readOnly = on
+}
+
+// refusal returns why a call to tool isn't sent, or nil when it may be.
+// Refusals are blocked_by_policy errors, as are denied approvals.
+func (e *extensions) refusal(tool *config.Tool) error {
+	if tool.AllowExecute != nil {
+		if *tool.AllowExecute {
+			return nil
+		}
+		return fmt.Errorf("%w: calls to %s are disabled (allow_execute is false)", approval.ErrBlocked, tool.Name)
+	}
+	if e.readOnly && !readOnlyMethod(tool.Method) {
+		return fmt.Errorf("%w: %s %s isn't sent in read-only mode; allow_execute on the tool permits it", approval.ErrBlocked, tool.Method, tool.Name)
+	}
+	return nil
+}
+
+// readOnlyMethod reports whether calls with method leave the target as it
+// was.
+func readOnlyMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead:
+		return true
+	}
+	return false
+}
+
+// refusalHint tells agents up front that calls to tool are refused.
+func (e *extensions) refusalHint(tool *config.Tool) string {
+	if e.refusal(tool) == nil {
+		return ""
+	}
+	if e.readOnly && tool.AllowExecute == nil {
+		return "Calls to this endpoint are refused: mcpify runs in read-only mode."
+	}
+	return "Calls to this endpoint are disabled."
+}
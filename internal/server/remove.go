@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const removeToolName = "mcpify_remove_tool"
+
+type RemoveToolParams struct {
+	Name string `json:"name"`
+}
+
+// RemoveTool forgets the tool called name: it is unpublished and removed
+// from the saved config.
+func (s *MCPServer) RemoveTool(name string) error {
+	if err := s.config.RemoveTool(name); err != nil {
+		return err
+	}
+	s.toolRemoved(name)
+	return saveAndCollect(s.config)
+}
+
+// toolRemoved unpublishes a tool already removed from the config.
+func (s *MCPServer) toolRemoved(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[name]; !exists {
+		return
+	}
+	s.mcpServer.RemoveTools(name)
+	delete(s.tools, name)
+	delete(s.hints, name)
+}
+
+// RemoveTool forgets the tool called name and republishes the groups it
+// was in, which are dropped when it was their last tool.
+func (s *GroupedMCPServer) RemoveTool(name string) error {
+	if err := s.config.RemoveTool(name); err != nil {
+		return err
+	}
+	s.loadGroupsFromConfig()
+	return saveAndCollect(s.config)
+}
+
+func (s *HybridMCPServer) RemoveTool(name string) error {
+	if err := s.config.RemoveTool(name); err != nil {
+		return err
+	}
+	s.individual.toolRemoved(name)
+	s.grouped.loadGroupsFromConfig()
+	return saveAndCollect(s.config)
+}
+
+// saveAndCollect saves cfg after a tool was removed and deletes the blobs
+// only it referred to.
+func saveAndCollect(cfg *config.Config) error {
+	if err := cfg.Save(cfg.Path); err != nil {
+		return err
+	}
+	if _, err := cfg.CollectBlobs(); err != nil {
+		slog.Error("Failed to delete unreferenced blobs", "error", err)
+	}
+	return nil
+}
+
+// addRemoveTool registers the mcpify_remove_tool meta-tool, which prunes
+// noisy tools through remove.
+func addRemoveTool(mcpServer *mcp.Server, remove func(name string) error) {
+	mcp.AddTool(mcpServer, &mcp.Tool{
+		Name: removeToolName,
+		Description: "Remove a captured tool by name, e.g. a noisy get_favicon_ico, from the tool list " +
+			"and the saved config. In grouped mode the tool is taken out of its group. The endpoint is " +
+			"registered again if later traffic to it is captured.",
+		Annotations: annotations(false, true, true),
+	}, func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[RemoveToolParams]) (*mcp.CallToolResultFor[any], error) {
+		name := params.Arguments.Name
+		if err := remove(name); err != nil {
+			return nil, fmt.Errorf("failed to remove tool %q: %w", name, err)
+		}
+		slog.Info("Removed tool", "tool", name)
+
+		return &mcp.CallToolResultFor[any]{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: fmt.Sprintf("Removed tool %s.", name)},
+			},
+		}, nil
+	})
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/NilayYadav/mcpify/internal/events"
+	"github.com/NilayYadav/mcpify/internal/replica"
+)
+
+// SetReplicas spreads tool calls to the target across the replicas of p.
+func (e *extensions) SetReplicas(p *replica.Pool) {
+	e.replicas = p
+}
+
+// route points req at the replica picked for session. It returns nil for
+// calls that stay where they are.
+func (e *extensions) route(req *http.Request, session string) (*replica.Replica, error) {
+	routed, upstream, err := e.replicas.Route(req.URL.String(), session)
+	if err != nil || upstream == nil {
+		return nil, err
+	}
+	u, err := url.Parse(routed)
+	if err != nil {
+		return nil, err
+	}
+	req.URL = u
+	req.Host = ""
+	return upstream, nil
+}
+
+// replicaDone records how a call to upstream went, and publishes it
+// leaving or rejoining the rotation.
+func (e *extensions) replicaDone(upstream *replica.Replica, status int, err error) {
+	if !upstream.Done(status, err) {
+		return
+	}
+	if !upstream.Down() {
+		e.events.Publish(events.ReplicaUp, map[string]any{"replica": upstream.Base})
+		return
+	}
+	reason := http.StatusText(status)
+	if err != nil {
+		reason = err.Error()
+	}
+	e.events.Publish(events.ReplicaDown, map[string]any{"replica": upstream.Base, "error": reason})
+}
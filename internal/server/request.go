@@ -0,0 +1,37 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// newToolRequest builds the request a call to tool sends, before header
+// secrets are resolved: the tool's URL with the call's parameters and
+// the auth query, then the tool's headers, mcpify's User-Agent and the
+// call's headers. jsonBody marks a body built from body fields, sent as
+// JSON unless the tool says otherwise.
+func (e *extensions) newToolRequest(ctx context.Context, cfg *config.Config, tool *config.Tool, pathValues, queryValues map[string]string, body []byte, headers map[string]string, jsonBody bool) (*http.Request, error) {
+	queryValues = maps.Clone(queryValues)
+	e.addAuthQuery(queryValues)
+	req, err := http.NewRequestWithContext(ctx, tool.Method, tool.ResolveURL(pathValues, queryValues), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for k, v := range tool.Headers {
+		req.Header.Set(k, v)
+	}
+	if jsonBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	e.identify(req, cfg, tool)
+	applyHeaders(req.Header, headers)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	config.StripHopHeaders(req.Header)
+	return req, nil
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/diskbudget"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxResponseHint bounds the example response shown in tool descriptions.
+const maxResponseHint = 300
+
+// responseHint tells the client what tool returned when it was captured.
+func responseHint(tool *config.Tool) string {
+	r := tool.Response
+	if r == nil {
+		return ""
+	}
+
+	hint := fmt.Sprintf("Returns %d", r.Status)
+	if r.ContentType != "" {
+		hint += " (" + r.ContentType + ")"
+	}
+	if r.Body != "" {
+		example := strings.Join(strings.Fields(r.Body), " ")
+		if len(example) > maxResponseHint {
+			example = example[:maxResponseHint] + "..."
+		}
+		hint += ", e.g.: " + example
+	}
+	// Content-Type is already part of the hint
+	headers := make(map[string]string, len(r.Headers))
+	for name, value := range r.Headers {
+		if name != "Content-Type" {
+			headers[name] = value
+		}
+	}
+	if len(headers) > 0 {
+		hint += ". Headers: " + formatHeaders(headers)
+	}
+	if paging := paginationHint(r.Headers); paging != "" {
+		hint += ". " + paging
+	}
+	return hint
+}
+
+// paginationHint tells the client how tool's results are paged, judging by
+// the captured response headers.
+func paginationHint(headers map[string]string) string {
+	switch {
+	case strings.Contains(headers["Link"], `rel="next"`):
+		return `Paginated: follow the rel="next" URL in the Link header`
+	case headers["X-Total-Count"] != "":
+		return "Paginated: X-Total-Count holds the total number of items"
+	}
+	return ""
+}
+
+// formatHeaders renders headers as a JSON object, since values such as
+// Link and Content-Type contain separators of their own.
+func formatHeaders(headers map[string]string) string {
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// DefaultMaxResponseSize is how much of a response body a tool result
+// includes when --max-response-size isn't set.
+const DefaultMaxResponseSize = 100 << 10
+
+// responseContent describes a response body as read for a tool result.
+type responseContent struct {
+	Type string
+	// Length is the full size of the body, -1 when it isn't known
+	Length int64
+	// Truncated is set when only the first Limit bytes were read
+	Truncated bool
+	Limit     int64
+	// Encoding is the Content-Encoding mcpify couldn't decode, if any
+	Encoding string
+	// URL is what was called, naming binary bodies returned as resources
+	URL string
+	// rest is the body past what was read, when it was truncated
+	rest io.Reader
+}
+
+// SetMaxResponseSize sets how much of a response body tool results
+// include; the rest is cut off with a notice. 0 includes everything.
+func (e *extensions) SetMaxResponseSize(n int64) {
+	e.maxResponseSize = n
+}
+
+// readResponse reads resp's body up to the response size limit, so a huge
+// export never reaches the client whole.
+func (e *extensions) readResponse(resp *http.Response) ([]byte, *responseContent, error) {
+	encoding, err := decodeBody(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	content := &responseContent{Type: resp.Header.Get("Content-Type"), Length: resp.ContentLength, Limit: e.maxResponseSize, Encoding: encoding}
+	if resp.Request != nil {
+		content.URL = resp.Request.URL.String()
+	}
+	if content.Limit <= 0 {
+		body, err := io.ReadAll(resp.Body)
+		content.Length = int64(len(body))
+		return body, content, err
+	}
+
+	// One byte more tells a body of exactly the limit from a longer one
+	body, err := io.ReadAll(io.LimitReader(resp.Body, content.Limit+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if int64(len(body)) > content.Limit {
+		read := body
+		body, content.Truncated = body[:content.Limit], true
+		// Cut before a character split at the limit, so text stays text
+		for i := 1; i < utf8.UTFMax && len(body) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(body); r != utf8.RuneError || size != 1 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+		content.rest = io.MultiReader(bytes.NewReader(read[len(body):]), resp.Body)
+	} else {
+		content.Length = int64(len(body))
+	}
+	return body, content, nil
+}
+
+// resultText formats a tool call result. Only allowlisted response headers
+// are included, and meta when there is one. A truncated body ends with a
+// notice saying so.
+func resultText(status int, headers map[string]string, meta *ResultMeta, content *responseContent, body []byte) string {
+	text := fmt.Sprintf("Status: %d\n", status)
+	if len(headers) > 0 {
+		text += "Headers: " + formatHeaders(headers) + "\n"
+	}
+	if meta != nil {
+		text += "Meta: " + meta.String() + "\n"
+	}
+	if content != nil && content.Type != "" && headers["Content-Type"] == "" {
+		text += "Content-Type: " + content.Type + "\n"
+	}
+	if content != nil && content.Length >= 0 {
+		text += fmt.Sprintf("Content-Length: %d\n", content.Length)
+	}
+	binary := isBinaryContent(content, body)
+	if binary {
+		text += "Response: " + binarySummary(content, body)
+	} else {
+		text += "Response: " + string(body)
+	}
+	// The summary of a binary body gives its full size already
+	if content != nil && content.Truncated && !binary {
+		of := "an unknown size"
+		if content.Length >= 0 {
+			of = diskbudget.FormatSize(content.Length)
+		}
+		text += fmt.Sprintf("\n[response truncated at %s of %s; use pagination or filters to fetch less]", diskbudget.FormatSize(content.Limit), of)
+	}
+	return text
+}
+
+// ToolResult is the structured content of a tool call answered with JSON.
+// The text content of the result holds the same object, for clients that
+// don't read structured content.
+type ToolResult struct {
+	Status        int               `json:"status"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Meta          *ResultMeta       `json:"meta,omitempty"`
+	ContentType   string            `json:"content_type"`
+	ContentLength int64             `json:"content_length"`
+	Body          json.RawMessage   `json:"body"`
+}
+
+// SetStructuredResults turns structured content for JSON responses, and
+// isError for 4xx and 5xx ones, on or off. Off, every result is the plain
+// text resultText makes.
+func (e *extensions) SetStructuredResults(on bool) {
+	e.plainResults = !on
+}
+
+// toolResult is the result of a call the target answered with status. A
+// JSON body comes back as structured content; any other, or one cut off
+// at the response size limit, as text. 4xx and 5xx statuses are errors.
+func (e *extensions) toolResult(status int, headers map[string]string, meta *ResultMeta, content *responseContent, body []byte) *mcp.CallToolResultFor[any] {
+	text := resultText(status, headers, meta, content, body)
+	attached, note := e.binaryResult(content, body)
+	if note != "" {
+		text += "\n" + note
+	}
+	if e.plainResults {
+		result := &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: text}}}
+		if attached != nil {
+			result.Content = append(result.Content, attached)
+		}
+		return result
+	}
+
+	result := &mcp.CallToolResultFor[any]{IsError: status >= 400}
+	if content != nil && !content.Truncated && isJSONContent(content.Type) && json.Valid(body) {
+		structured := &ToolResult{
+			Status:        status,
+			Headers:       headers,
+			Meta:          meta,
+			ContentType:   content.Type,
+			ContentLength: int64(len(body)),
+			Body:          body,
+		}
+		if data, err := json.Marshal(structured); err == nil {
+			result.StructuredContent = structured
+			text = string(data)
+		}
+	}
+	result.Content = []mcp.Content{&mcp.TextContent{Text: text}}
+	if attached != nil {
+		result.Content = append(result.Content, attached)
+	}
+	return result
+}
+
+func isJSONContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+// ResponseHeadersHandler serves /api/tools/{name}/response-headers. GET
+// returns the tool's effective allowlist; PUT replaces it with
+// {"headers": [...]}, where null reverts to the global list.
+func ResponseHeadersHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ref := r.PathValue("name")
+		tool := cfg.LookupTool(ref)
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var in struct {
+				Headers []string `json:"headers"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+				return
+			}
+			var err error
+			if tool, err = cfg.SetToolResponseHeaders(ref, in.Headers); err != nil {
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+			if err := cfg.Save(cfg.Path); err != nil {
+				slog.Error("Failed to save config", "error", err)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if tool == nil {
+			err := fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tool":    tool.Name,
+			"headers": cfg.ResponseHeadersFor(tool),
+			"custom":  tool.ResponseHeaders != nil,
+		})
+	})
+}
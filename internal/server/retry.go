@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// DefaultRetries is how often a call failing with a connection error or a
+// 502, 503 or 504 is resent, unless its tool says otherwise.
+const DefaultRetries = 2
+
+const (
+	retryBaseDelay = 250 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// SetRetries sets how often a call that failed transiently is resent,
+// unless its tool sets retries of its own; 0 sends every call once.
+func (r *rateLimits) SetRetries(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries = max(n, 0)
+}
+
+// retriesFor returns how often a call to tool sent with method may be
+// resent after a transient failure. Calls that aren't idempotent, as POST
+// and PATCH, are sent once unless the tool allows retrying them.
+func (r *rateLimits) retriesFor(tool *config.Tool, method string) int {
+	if !idempotent(method) && !tool.RetryUnsafe {
+		return 0
+	}
+	if tool.Retries != nil {
+		return max(*tool.Retries, 0)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retries
+}
+
+// idempotent reports whether sending a request with method twice has the
+// effect of sending it once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// transient reports whether a call that got resp, or failed with err, may
+// succeed when resent: it couldn't reach the target, or a gateway in
+// front of it answered 502, 503 or 504. The call's own context ending is
+// no reason to retry.
+func transient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff waits before the attempt-th resend, starting at retryBaseDelay
+// and doubling up to retryMaxDelay, with jitter so calls failing together
+// don't come back together.
+func backoff(ctx context.Context, attempt int) error {
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	delay = delay/2 + rand.N(delay/2+1)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retriedNote tells the agent how many attempts a call took, when it was
+// resent.
+func retriedNote(result *mcp.CallToolResultFor[any], retries int) *mcp.CallToolResultFor[any] {
+	if result == nil || retries == 0 {
+		return result
+	}
+	note := &mcp.TextContent{Text: fmt.Sprintf("Took %d attempts; earlier ones failed or were rate limited.", retries+1)}
+	result.Content = append([]mcp.Content{note}, result.Content...)
+	return result
+}
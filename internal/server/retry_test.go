@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// flakyTarget fails its first failures requests with status, then
+// answers 200. It counts the requests it gets.
+func flakyTarget(t *testing.T, status, failures int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(api.Close)
+	return api, &requests
+}
+
+func TestTransientFailuresAreRetried(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		status  int
+		retries int
+		// requests is how many the target should get
+		requests int32
+		failed   bool
+	}{
+		{name: "503 twice, then success", method: "GET", status: 503, retries: DefaultRetries, requests: 3},
+		{name: "502 twice, then success", method: "PUT", status: 502, retries: DefaultRetries, requests: 3},
+		{name: "out of retries", method: "GET", status: 504, retries: 1, requests: 2, failed: true},
+		{name: "retries off", method: "DELETE", status: 503, retries: 0, requests: 1, failed: true},
+		{name: "POST isn't resent", method: "POST", status: 503, retries: DefaultRetries, requests: 1, failed: true},
+		{name: "500 isn't transient", method: "GET", status: 500, retries: DefaultRetries, requests: 1, failed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, requests := flakyTarget(t, tt.status, 2)
+			s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+			s.SetRetries(tt.retries)
+			name := strings.ToLower(tt.method) + "_items"
+			if err := s.RegisterTool(name, tt.method, api.URL+"/items", nil, nil, nil, "Items"); err != nil {
+				t.Fatal(err)
+			}
+			client, _ := connect(t, s.mcpServer)
+
+			result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("target got %d requests, want %d", got, tt.requests)
+			}
+			if result.IsError != tt.failed {
+				t.Errorf("IsError = %v, want %v: %+v", result.IsError, tt.failed, result.Content)
+			}
+
+			note, _ := result.Content[0].(*mcp.TextContent)
+			retried := note != nil && strings.HasPrefix(note.Text, "Took ")
+			if want := tt.requests > 1; retried != want {
+				t.Errorf("result notes the retries: %v, want %v", retried, want)
+			}
+			if retried && !strings.Contains(note.Text, fmt.Sprintf("Took %d attempts", tt.requests)) {
+				t.Errorf("note %q, want %d attempts", note.Text, tt.requests)
+			}
+		})
+	}
+}
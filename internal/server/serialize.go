@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxQueuedCalls is how many calls may wait behind the one running in a
+// serialized lane before further calls are turned away.
+const maxQueuedCalls = 4
+
+const serializeHint = "Calls run one at a time; concurrent calls queue."
+
+// serializer runs calls to serialized tools and groups one at a time.
+// Lanes come from config.SerialLane.
+type serializer struct {
+	mu    sync.Mutex
+	lanes map[string]*lane
+}
+
+type lane struct {
+	slot   chan struct{}
+	queued int
+}
+
+func newSerializer() *serializer {
+	return &serializer{lanes: make(map[string]*lane)}
+}
+
+// acquire waits for tool's lane and returns the function that frees it,
+// with how long the call waited. Tools that aren't serialized never wait.
+func (s *serializer) acquire(ctx context.Context, cfg *config.Config, tool *config.Tool) (release func(), waited time.Duration, err error) {
+	key := cfg.SerialLane(tool)
+	if key == "" {
+		return func() {}, 0, nil
+	}
+
+	s.mu.Lock()
+	l := s.lanes[key]
+	if l == nil {
+		l = &lane{slot: make(chan struct{}, 1)}
+		s.lanes[key] = l
+	}
+	select {
+	case l.slot <- struct{}{}:
+		s.mu.Unlock()
+		return func() { <-l.slot }, 0, nil
+	default:
+	}
+	if l.queued >= maxQueuedCalls {
+		s.mu.Unlock()
+		return nil, 0, fmt.Errorf("%w: %d calls already waiting for %s", ErrQueueFull, l.queued, tool.Name)
+	}
+	l.queued++
+	s.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		s.mu.Lock()
+		l.queued--
+		s.mu.Unlock()
+	}()
+	select {
+	case l.slot <- struct{}{}:
+		return func() { <-l.slot }, time.Since(start), nil
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
+
+// queuedNote tells the agent a call waited for its lane, so slow results
+// aren't mistaken for a slow upstream.
+func queuedNote(result *mcp.CallToolResultFor[any], waited time.Duration) *mcp.CallToolResultFor[any] {
+	if result == nil || waited == 0 {
+		return result
+	}
+	note := &mcp.TextContent{Text: fmt.Sprintf("Queued %s behind an in-flight call; calls to this tool run one at a time.", waited.Round(time.Millisecond))}
+	result.Content = append([]mcp.Content{note}, result.Content...)
+	return result
+}
+
+// SerializeHandler serves /api/tools/{name}/serialize. GET returns whether
+// calls to the tool run one at a time, and the lane they share; PUT sets
+// it with {"serialize": bool}.
+func SerializeHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ref := r.PathValue("name")
+		tool := cfg.LookupTool(ref)
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			on, ok := decodeSerialize(w, r)
+			if !ok {
+				return
+			}
+			var err error
+			if tool, err = cfg.SetToolSerialize(ref, on); err != nil {
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+			if err := cfg.Save(cfg.Path); err != nil {
+				slog.Error("Failed to save config", "error", err)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if tool == nil {
+			err := fmt.Errorf("%w: %q", ErrToolNotFound, ref)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tool":      tool.Name,
+			"serialize": tool.Serialize,
+			"lane":      cfg.SerialLane(tool),
+		})
+	})
+}
+
+// GroupSerializeHandler serves /api/groups/{name}/serialize, the group
+// counterpart of SerializeHandler.
+func GroupSerializeHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		group := cfg.GetGroup(name)
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			on, ok := decodeSerialize(w, r)
+			if !ok {
+				return
+			}
+			var err error
+			if group, err = cfg.SetGroupSerialize(name, on); err != nil {
+				http.Error(w, err.Error(), errorStatus(err))
+				return
+			}
+			if err := cfg.Save(cfg.Path); err != nil {
+				slog.Error("Failed to save config", "error", err)
+			}
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if group == nil {
+			err := fmt.Errorf("%w: %q", config.ErrGroupNotFound, name)
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"group":     group.Name,
+			"serialize": group.Serialize,
+		})
+	})
+}
+
+func decodeSerialize(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	var in struct {
+		Serialize *bool `json:"serialize"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+		return false, false
+	}
+	if in.Serialize == nil {
+		http.Error(w, `"serialize" is required`, http.StatusBadRequest)
+		return false, false
+	}
+	return *in.Serialize, true
+}
@@ -1,22 +1,28 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/NilayYadav/mcpify/internal/approval"
+	"github.com/NilayYadav/mcpify/internal/chaos"
 	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/observed"
+	"github.com/NilayYadav/mcpify/internal/workflow"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ToolRegistrar interface {
-	RegisterTool(name string, method, url string, headers map[string]string, body []byte, description string) error
+	RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error
 }
 
 type MCPServer struct {
@@ -25,24 +31,63 @@ type MCPServer struct {
 	maxTools  int
 	mu        sync.RWMutex
 	config    *config.Config
+	workflows *workflow.Miner
+	observed  *observed.Tracker
+	hints     map[string]string
+	verifier  *toolVerifier
+	chaos     *chaos.Chaos
+	approvals *approval.Gate
+	serial    *serializer
+	limits    *rateLimits
+	extensions
 }
 
 type CallParams struct {
-	OverrideBody string `json:"override_body,omitempty"`
+	OverrideBody   string                 `json:"override_body,omitempty"`
+	Confirm        bool                   `json:"confirm,omitempty"`
+	DryRun         bool                   `json:"dry_run,omitempty"`
+	Headers        map[string]string      `json:"headers,omitempty"`
+	ExpectStatus   int                    `json:"expect_status,omitempty"`
+	ExpectJSON     map[string]interface{} `json:"expect_json,omitempty"`
+	ExpectContains string                 `json:"expect_contains,omitempty"`
 }
 
 func NewMCPServer(name, version string, maxTools int, cfg *config.Config) *MCPServer {
+	return newMCPServerOn(mcp.NewServer(&mcp.Implementation{
+		Name:    name,
+		Version: version,
+	}, nil), maxTools, cfg)
+}
+
+// newMCPServerOn builds the individual tool view on an existing MCP server,
+// so it can share one server instance with other views.
+func newMCPServerOn(mcpServer *mcp.Server, maxTools int, cfg *config.Config) *MCPServer {
 	server := &MCPServer{
-		mcpServer: mcp.NewServer(&mcp.Implementation{
-			Name:    name,
-			Version: version,
-		}, nil),
-		tools:    make(map[string]*config.Tool),
-		maxTools: maxTools,
-		config:   cfg,
+		mcpServer: mcpServer,
+		tools:     make(map[string]*config.Tool),
+		hints:     make(map[string]string),
+		verifier:  newToolVerifier(),
+		serial:    newSerializer(),
+		limits:    newRateLimits(),
+		maxTools:  maxTools,
+		config:    cfg,
+	}
+
+	server.verifier.onHide = func(name string) {
+		server.mcpServer.RemoveTools(name)
+	}
+	server.verifier.onReveal = func(name string) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if tool := server.tools[name]; tool != nil {
+			server.addTool(tool, nil)
+		}
 	}
 
 	server.loadTools()
+	addFindEndpoint(mcpServer, cfg, server.exposes)
+	addListEndpoints(mcpServer, cfg)
+	addRemoveTool(mcpServer, server.RemoveTool)
 
 	return server
 }
@@ -51,38 +96,53 @@ func (s *MCPServer) loadTools() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	log.Printf("Loading %d tools from config", len(s.config.Tools))
+	slog.Info("Loading tools from config", "tools", len(s.config.Tools))
+	if extra := len(s.config.Tools) - s.maxTools; extra > 0 {
+		slog.Warn("Config has more tools than --max-tools; skipping the newest", "max_tools", s.maxTools, "skipped", extra)
+	}
 
-	for name, tool := range s.config.Tools {
+	for _, tool := range s.config.OldestTools(s.maxTools) {
+		name := tool.Name
 		s.tools[name] = tool
+		s.addTool(tool, nil)
 
-		handler := s.createToolHandler(tool)
-		mcp.AddTool(s.mcpServer, &mcp.Tool{
-			Name:        name,
-			Description: tool.Description,
-		}, handler)
-
-		log.Printf("Loaded tool: %s (%s %s)", name, tool.Method, tool.URL)
+		slog.Debug("Loaded tool", "tool", name, "method", tool.Method, "url", tool.URL)
 	}
 }
 
-func (s *MCPServer) RegisterTool(name string, method, url string, headers map[string]string, body []byte, description string) error {
+func (s *MCPServer) RegisterTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) error {
+	_, err := s.registerTool(name, method, url, pathParams, headers, body, description)
+	return err
+}
+
+// registerTool registers a tool as RegisterTool does, returning the name of
+// the tool evicted to make room for it, if any.
+func (s *MCPServer) registerTool(name string, method, url string, pathParams map[string]string, headers map[string]string, body []byte, description string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.tools[name]; exists {
-		return nil
+	// A tool per endpoint, whatever each sighting would have named it
+	if _, exists := s.tools[name]; exists || s.config.ToolFor(method, url) != nil {
+		return "", nil
 	}
 
-	// Enforce max tools limit
+	// Enforce max tools limit, evicting a served tool if the policy allows
+	var evicted string
 	if len(s.tools) >= s.maxTools {
-		return fmt.Errorf("maximum number of tools (%d) reached", s.maxTools)
+		var err error
+		if evicted, err = s.evict(s.config, slices.Collect(maps.Keys(s.tools)), name, s.maxTools); err != nil {
+			return "", err
+		}
+		s.mcpServer.RemoveTools(evicted)
+		delete(s.tools, evicted)
+		delete(s.hints, evicted)
 	}
 
 	req := &config.Tool{
 		Name:        name,
 		Method:      method,
 		URL:         url,
+		PathParams:  pathParams,
 		Headers:     headers,
 		Body:        string(body),
 		Description: description,
@@ -93,58 +153,426 @@ func (s *MCPServer) RegisterTool(name string, method, url string, headers map[st
 
 	s.config.AddTool(req)
 
-	if err := s.config.Save(s.config.Path); err != nil {
-		log.Printf("Failed to save config: %v", err)
+	if evicted != "" {
+		if err := saveAndCollect(s.config); err != nil {
+			slog.Error("Failed to save config", "error", err)
+		}
+	} else if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
 	}
 
-	handler := s.createToolHandler(req)
+	s.addTool(req, nil)
+	s.metrics.ToolRegistered()
+
+	return evicted, nil
+}
+
+// HasTool reports whether the endpoint method and url already has a tool.
+func (s *MCPServer) HasTool(method, url string) bool {
+	return s.config.ToolFor(method, url) != nil
+}
+
+// HasToolName reports whether a tool is called name.
+func (s *MCPServer) HasToolName(name string) bool {
+	return s.config.GetTool(name) != nil
+}
+
+// addTool publishes tool on the MCP server, appending any hints.
+// Callers must hold s.mu.
+func (s *MCPServer) addTool(tool *config.Tool, names map[string]string) {
+	if s.verifier.isHidden(tool.Name) {
+		return
+	}
+
+	// Capture may be changing the tool meanwhile; it is described as it
+	// is now, and each call takes its own snapshot
+	def := s.config.Snapshot(tool)
+	description := def.Description
+	hint := s.toolHints(def, names)
+	s.hints[tool.Name] = hint
+	if hint != "" {
+		description += "\n\n" + hint
+	}
+
+	schema, err := toolInputSchema(def, s.authQuery)
+	if err != nil {
+		slog.Error("Failed to build input schema", "tool", tool.Name, "error", err)
+		return
+	}
+
+	handler := s.createToolHandler(tool)
 	mcp.AddTool(s.mcpServer, &mcp.Tool{
-		Name:        name,
-		Description: req.Description,
+		Name:        tool.Name,
+		Description: description,
+		InputSchema: schema,
+		Annotations: toolAnnotations(def),
 	}, handler)
+}
 
-	return nil
+// VerifyTools hides tools the target doesn't serve for this run and keeps
+// re-checking them in the background.
+func (s *MCPServer) VerifyTools(ctx context.Context) {
+	s.verifier.start(ctx, s.config, &s.extensions)
+}
+
+// SetResultMeta turns the Meta line of tool results on or off and
+// republishes the tools whose descriptions mention it.
+func (s *MCPServer) SetResultMeta(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extensions.SetResultMeta(on)
+	for _, tool := range s.tools {
+		s.addTool(tool, nil)
+	}
+}
+
+// SetAuthQuery sets the query parameters every call sends and
+// republishes the tools, whose schemas mark them as provided.
+func (s *MCPServer) SetAuthQuery(params map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extensions.SetAuthQuery(params)
+	for _, tool := range s.tools {
+		s.addTool(tool, nil)
+	}
+}
+
+// SetChaos enables fault injection for tool calls.
+func (s *MCPServer) SetChaos(c *chaos.Chaos) {
+	s.chaos = c
+}
+
+// SetApprovals holds calls g needs approved, DELETE by default.
+func (s *MCPServer) SetApprovals(g *approval.Gate) {
+	s.approvals = g
+}
+
+// SetRetryAfterMax sets the longest rate limit a call waits out before
+// retrying by itself. Longer ones are returned as rate_limited errors.
+func (s *MCPServer) SetRetryAfterMax(d time.Duration) {
+	s.limits.SetMaxWait(d)
+}
+
+// SetRetries sets how often calls that fail with a connection error or a
+// 502, 503 or 504 are resent, unless their tool says otherwise.
+func (s *MCPServer) SetRetries(n int) {
+	s.limits.SetRetries(n)
+}
+
+// toolHints combines the workflow, observed-value and serialization hints
+// for tool.
+func (s *MCPServer) toolHints(tool *config.Tool, names map[string]string) string {
+	var hints []string
+	if hint := responseHint(tool); hint != "" {
+		hints = append(hints, hint)
+	}
+	if hint := strippedHeadersHint(tool); hint != "" {
+		hints = append(hints, hint)
+	}
+	if s.workflows != nil {
+		if names == nil {
+			names = toolNamesByEndpoint(s.config)
+		}
+		if hint := followedByHint(s.workflows, tool, names); hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+	if hint := observedHint(s.observed, tool); hint != "" {
+		hints = append(hints, hint)
+	}
+	if s.config.SerialLane(tool) != "" {
+		hints = append(hints, serializeHint)
+	}
+	if hint := s.refusalHint(tool); hint != "" {
+		hints = append(hints, hint)
+	} else if hint := s.confirmHint(tool); hint != "" {
+		hints = append(hints, hint)
+	}
+	if hint := s.metaHint(); hint != "" {
+		hints = append(hints, hint)
+	}
+	return strings.Join(hints, "\n")
+}
+
+// RecordResponse stores what a captured endpoint returned and republishes
+// its tool when that changes.
+func (s *MCPServer) RecordResponse(method, url string, sample *config.ResponseSample) {
+	tool, changed := s.config.SetResponse(method, url, sample)
+	if !changed {
+		return
+	}
+	if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[tool.Name]; exists {
+		s.addTool(tool, nil)
+	}
+}
+
+// RecordQueryParams adds newly seen query parameters to the matching tool
+// and republishes it with the wider input schema.
+func (s *MCPServer) RecordQueryParams(method, url string, params map[string]string) {
+	tool, changed := s.config.MergeQueryParams(method, url, params)
+	if !changed {
+		return
+	}
+	if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[tool.Name]; exists {
+		s.addTool(tool, nil)
+	}
 }
 
-func (s *MCPServer) createToolHandler(req *config.Tool) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CallParams]) (*mcp.CallToolResultFor[any], error) {
-	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[CallParams]) (*mcp.CallToolResultFor[any], error) {
-		// Use override body if provided, otherwise use captured body
+// RecordBodySchema widens the matching tool's body schema with fields
+// seen in later requests and republishes it with the new arguments.
+func (s *MCPServer) RecordBodySchema(method, url string, schema *config.BodySchema) {
+	tool, changed := s.config.MergeBodySchema(method, url, schema)
+	if !changed {
+		return
+	}
+	if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[tool.Name]; exists {
+		s.addTool(tool, nil)
+	}
+}
+
+// RecordRequestSample notes where the matching tool's headers and body
+// were captured, taking those of a trusted sample over ambient traffic.
+func (s *MCPServer) RecordRequestSample(method, url string, sample *config.RequestSample) {
+	tool, changed := s.config.SetRequestSample(method, url, sample)
+	if !changed {
+		return
+	}
+	if err := s.config.Save(s.config.Path); err != nil {
+		slog.Error("Failed to save config", "error", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[tool.Name]; exists {
+		s.addTool(tool, nil)
+	}
+}
+
+// ToolChanged republishes tool after its definition was changed outside
+// capture, e.g. reverted to an earlier revision. oldName is what it was
+// published as.
+func (s *MCPServer) ToolChanged(tool *config.Tool, oldName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[oldName]; !exists {
+		return
+	}
+	if oldName != tool.Name {
+		s.mcpServer.RemoveTools(oldName)
+		delete(s.tools, oldName)
+		delete(s.hints, oldName)
+		s.tools[tool.Name] = tool
+	}
+	s.addTool(tool, nil)
+}
+
+// SetObservedValues adds real parameter values to tool descriptions and
+// serves them at /api/tools/{name}/observed-values.
+func (s *MCPServer) SetObservedValues(t *observed.Tracker) {
+	s.mu.Lock()
+	s.observed = t
+	s.mu.Unlock()
+
+	s.Handle("GET /api/tools/{name}/observed-values", observedValuesHandler(s.config, t))
+}
+
+// SetWorkflows enables "commonly followed by" hints and the workflow
+// prompt, both derived from m.
+func (s *MCPServer) SetWorkflows(m *workflow.Miner) {
+	s.mu.Lock()
+	s.workflows = m
+	s.mu.Unlock()
+
+	addWorkflowPrompt(s.mcpServer, m, s.config)
+}
+
+// refreshHints republishes tools whose hints have changed since they were
+// added: right away, for settings made after the tools were loaded, and
+// then periodically.
+func (s *MCPServer) refreshHints(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		names := toolNamesByEndpoint(s.config)
+		for name, tool := range s.tools {
+			if s.toolHints(tool, names) != s.hints[name] {
+				s.addTool(tool, names)
+			}
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// exposes reports whether tool can be called directly right now.
+func (s *MCPServer) exposes(tool *config.Tool, group string) bool {
+	return s.hasTool(tool.Name) && !s.verifier.isHidden(tool.Name)
+}
+
+func (s *MCPServer) hasTool(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.tools[name]
+	return exists
+}
+
+// createToolHandler returns the handler calling tool. Each call runs on a
+// snapshot taken when it starts, so it finishes as the tool was defined
+// then, whatever changes or removes it meanwhile.
+func (s *MCPServer) createToolHandler(tool *config.Tool) mcp.ToolHandler {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[map[string]any]) (result *mcp.CallToolResultFor[any], err error) {
+		req := s.config.Snapshot(tool)
+		pathValues, queryValues, bodyValues, args, err := splitArguments(req, params.Arguments)
+		if err != nil {
+			return nil, err
+		}
+
+		// Use override body if provided, otherwise merge the body fields
+		// passed over the captured body
 		var body []byte
-		if params.Arguments.OverrideBody != "" {
-			body = []byte(params.Arguments.OverrideBody)
-		} else {
-			body = []byte(req.Body)
+		if args.OverrideBody != "" {
+			if len(bodyValues) > 0 {
+				return nil, errors.New("override_body replaces the whole body and can't be combined with body fields")
+			}
+			body = []byte(args.OverrideBody)
+		} else if body, err = buildBody(req.Body, bodyValues); err != nil {
+			return nil, err
 		}
 
-		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, bytes.NewReader(body))
+		if err := s.refusal(req); err != nil {
+			return blockedResult(err), nil
+		}
+		httpReq, err := s.newToolRequest(ctx, s.config, req, pathValues, queryValues, body, args.Headers, len(bodyValues) > 0)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
+			return nil, err
+		}
+		if args.DryRun {
+			return s.dryRunResult(s.config, req, httpReq, body), nil
+		}
+		if s.needsConfirmation(httpReq) && !args.Confirm {
+			return s.confirmationResult(s.config, req, httpReq, body), nil
+		}
+		if err := s.approvals.Wait(ctx, req, string(body), session.ID()); err != nil {
+			return blockedResult(err), nil
+		}
+
+		release, waited, err := s.serial.acquire(ctx, s.config, req)
+		if errors.Is(err, ErrQueueFull) {
+			return blockedResult(err), nil
+		} else if err != nil {
+			return nil, err
+		}
+		defer release()
+		defer func() { result = queuedNote(result, waited) }()
+
+		plan := s.chaos.Plan(req.Name)
+		if err := plan.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		if plan.Fail {
+			return s.toolResult(plan.Status, nil, nil, nil, plan.FailureBody()), nil
 		}
 
-		// Set headers
-		for k, v := range req.Headers {
-			httpReq.Header.Set(k, v)
+		ctx, cancel := s.withTimeout(ctx, req)
+		defer cancel()
+		called := s.recordCall(req, pathValues, queryValues)
+		// Update usage stats, which --eviction lru and /debug/usage go by
+		s.config.RecordUse(req.ID, "")
+		httpReq = httpReq.WithContext(ctx)
+		if err := config.ResolveHeaderSecrets(httpReq.Header); err != nil {
+			return nil, err
+		}
+		upstream, err := s.route(httpReq, session.ID())
+		if err != nil {
+			s.callFailed(s.config, req, nil, 0, err.Error())
+			called(0, false)
+			return nil, fmt.Errorf("request failed: %w", err)
 		}
 
-		client := &http.Client{Timeout: 30 * time.Second}
-		resp, err := client.Do(httpReq)
+		start := time.Now()
+		resp, retries, err := s.limits.do(ctx, s.toolClient(), req, httpReq)
+		err = s.hideAuthQuery(err)
+		var limited *RateLimitedError
+		if errors.As(err, &limited) {
+			// Settle the call on the replica like any other answer
+			s.replicaDone(upstream, limited.Status, nil)
+			s.callFailed(s.config, req, upstream, limited.Status, err.Error())
+			called(0, false)
+			return limited.result(""), nil
+		}
 		if err != nil {
+			err = s.timedOut(ctx, req, err)
+			s.replicaDone(upstream, 0, err)
+			s.callFailed(s.config, req, upstream, 0, err.Error())
+			called(0, false)
 			return nil, fmt.Errorf("request failed: %w", err)
 		}
 		defer resp.Body.Close()
+		defer func() { result = retriedNote(result, retries) }()
+		s.replicaDone(upstream, resp.StatusCode, nil)
 
-		respBody, err := io.ReadAll(resp.Body)
+		respBody, content, err := s.readResponse(resp)
 		if err != nil {
+			err = s.timedOut(ctx, req, err)
+			s.callFailed(s.config, req, upstream, resp.StatusCode, err.Error())
+			called(resp.StatusCode, false)
 			return nil, fmt.Errorf("failed to read response: %w", err)
 		}
+		meta := s.resultMeta(start, resp, respBody, upstream)
+		respBody = plan.Apply(respBody)
+		if meta != nil {
+			meta.Retries = retries
+			if content.Length >= 0 {
+				meta.Bytes = int(content.Length)
+			}
+		}
+		if limited := rateLimitedBy(req, resp, time.Now()); limited != nil {
+			s.callFailed(s.config, req, upstream, resp.StatusCode, limited.Error())
+			called(resp.StatusCode, false)
+			return limited.result(resultText(resp.StatusCode, config.FilterHeaders(resp.Header, s.config.ResponseHeadersFor(req)), meta, content, respBody)), nil
+		}
 
-		return &mcp.CallToolResultFor[any]{
-			Content: []mcp.Content{
-				&mcp.TextContent{
-					Text: fmt.Sprintf("Status: %d\nResponse: %s", resp.StatusCode, string(respBody)),
-				},
-			},
-		}, nil
+		assertions := effectiveAssertions(&config.Assertions{
+			ExpectStatus:   args.ExpectStatus,
+			ExpectJSON:     args.ExpectJSON,
+			ExpectContains: args.ExpectContains,
+		}, req)
+		if failures := checkAssertions(assertions, resp.StatusCode, respBody); len(failures) > 0 {
+			s.callFailed(s.config, req, upstream, resp.StatusCode, "assertion failed: "+failures[0])
+			called(resp.StatusCode, false)
+			return assertionFailureResult(failures, resp.StatusCode, meta, content, respBody), nil
+		}
+		if resp.StatusCode >= 400 {
+			s.callFailed(s.config, req, upstream, resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		called(resp.StatusCode, resp.StatusCode < 400)
+
+		return s.toolResult(resp.StatusCode, config.FilterHeaders(resp.Header, s.config.ResponseHeadersFor(req)), meta, content, respBody), nil
 	}
 }
 
@@ -162,34 +590,66 @@ func (s *MCPServer) Start(ctx context.Context, addr string) error {
 		s.mu.RUnlock()
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
+		json.NewEncoder(w).Encode(s.addDebugInfoTo(map[string]interface{}{
 			"tool_count": len(tools),
 			"tool_names": names,
 			"tools":      tools,
-		})
+		}))
 	})
 
+	if s.workflows != nil {
+		mux.HandleFunc("/api/workflows", workflowsHandler(s.workflows))
+	}
+	if s.workflows != nil || s.observed != nil {
+		go s.refreshHints(ctx)
+	}
+
 	mcpHandler := mcp.NewSSEHandler(func(request *http.Request) *mcp.Server {
-		log.Printf("🔗 MCP connection request from %s to %s", request.RemoteAddr, request.URL.Path)
+		slog.Info("MCP connection", "remote", request.RemoteAddr, "path", request.URL.Path)
 		return s.mcpServer
 	})
 
 	mux.Handle("/mcp", mcpHandler)
+	s.registerHandlers(mux)
 
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: mux,
 	}
 
-	go func() {
-		<-ctx.Done()
-		log.Println("Shutting down MCP server...")
-		srv.Shutdown(context.Background())
-	}()
+	slog.Info("MCP server listening", "mcp", "http://localhost"+addr+"/mcp", "debug", "http://localhost"+addr+"/debug")
 
-	log.Printf("MCP server listening on http://localhost%s", addr)
-	log.Printf("MCP endpoint: http://localhost%s/mcp", addr)
-	log.Printf("Debug endpoint: http://localhost%s/debug", addr)
+	return serve(ctx, srv)
+}
+
+// shutdownTimeout is how long a stopping server waits for calls in
+// flight before closing the connections still open, such as SSE streams.
+const shutdownTimeout = 5 * time.Second
+
+// serve runs srv until ctx is done, then shuts it down, returning nil once
+// it has.
+func serve(ctx context.Context, srv *http.Server) error {
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down MCP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+	}
+	<-served
+	return nil
+}
 
-	return srv.ListenAndServe()
+// ServeStdio serves MCP over stdin/stdout until the client disconnects or
+// ctx is cancelled.
+func (s *MCPServer) ServeStdio(ctx context.Context) error {
+	slog.Info("MCP server on stdio")
+	return s.mcpServer.Run(ctx, mcp.NewStdioTransport())
 }
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// TestToolCallsRaceWithEdits calls a tool while its definition is edited,
+// republished and saved. Run with -race.
+func TestToolCallsRaceWithEdits(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	cfg := newTestConfig(t)
+	s := NewMCPServer("test", "v0", 10, cfg)
+	url := api.URL + "/notes"
+	if err := s.RegisterTool("post_notes", "POST", url, nil, map[string]string{"Content-Type": "application/json"}, []byte(`{"text":"hi"}`), "Create a note"); err != nil {
+		t.Fatal(err)
+	}
+	client, _ := connect(t, s.mcpServer)
+
+	const rounds = 25
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				result, err := client.CallTool(context.Background(), &mcp.CallToolParams{Name: "post_notes", Arguments: map[string]any{}})
+				if err != nil {
+					t.Errorf("CallTool: %v", err)
+					return
+				}
+				if result.IsError {
+					t.Errorf("CallTool failed: %+v", result.Content)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := range rounds {
+			description := fmt.Sprintf("Create a note (%d)", i)
+			body := fmt.Sprintf(`{"text":"edit %d"}`, i)
+			tool, oldName, err := cfg.UpdateTool("post_notes", config.ToolPatch{Description: &description, Body: &body})
+			if err != nil {
+				t.Errorf("UpdateTool: %v", err)
+				return
+			}
+			s.ToolChanged(tool, oldName)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range rounds {
+			s.RecordResponse("POST", url, &config.ResponseSample{
+				Status:      200 + i%2,
+				ContentType: "application/json",
+				Body:        `{"ok":true}`,
+				Headers:     map[string]string{"X-Round": fmt.Sprint(i)},
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range rounds {
+			if err := cfg.Save(cfg.Path); err != nil {
+				t.Errorf("Save: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestOverrideBodyGetsItsOwnLength(t *testing.T) {
+	type received struct {
+		length int64
+		body   string
+		header http.Header
+	}
+	got := make(chan received, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.ContentLength, string(body), r.Header}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	s := NewMCPServer("test", "v0", 10, newTestConfig(t))
+	captured := `{"text":"hi"}`
+	headers := map[string]string{
+		"Content-Type":      "application/json",
+		"Content-Length":    fmt.Sprint(len(captured)),
+		"Transfer-Encoding": "identity",
+		"Connection":        "keep-alive, X-Trace",
+		"X-Trace":           "abc",
+	}
+	if err := s.RegisterTool("post_notes", "POST", api.URL+"/notes", nil, headers, []byte(captured), "Create a note"); err != nil {
+		t.Fatal(err)
+	}
+	client, _ := connect(t, s.mcpServer)
+
+	override := `{"text":"` + strings.Repeat("a much longer note ", 100) + `"}`
+	result, err := client.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "post_notes",
+		Arguments: map[string]any{"override_body": override},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool: %v %+v", err, result)
+	}
+
+	r := <-got
+	if r.body != override {
+		t.Errorf("target got a %d byte body, want the %d byte override", len(r.body), len(override))
+	}
+	if r.length != int64(len(override)) {
+		t.Errorf("Content-Length %d, want %d", r.length, len(override))
+	}
+	if r.header.Get("X-Trace") != "" {
+		t.Error("header named by Connection was replayed")
+	}
+}
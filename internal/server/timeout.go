@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// DefaultRequestTimeout bounds tool calls whose tool sets no timeout.
+const DefaultRequestTimeout = 30 * time.Second
+
+// SetRequestTimeout sets how long a tool call may take, retries and
+// reading the response included, unless its tool sets a timeout of its
+// own. 0 leaves calls unbounded.
+func (e *extensions) SetRequestTimeout(d time.Duration) {
+	e.requestTimeout = d
+}
+
+// timeoutFor returns how long a call to tool may take: its own timeout, or
+// the global one when it has none or one that doesn't parse.
+func (e *extensions) timeoutFor(tool *config.Tool) time.Duration {
+	if tool.Timeout != "" {
+		if d, err := time.ParseDuration(tool.Timeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return e.requestTimeout
+}
+
+// withTimeout bounds ctx by the timeout of a call to tool.
+func (e *extensions) withTimeout(ctx context.Context, tool *config.Tool) (context.Context, context.CancelFunc) {
+	if d := e.timeoutFor(tool); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return ctx, func() {}
+}
+
+// timedOut names the timeout that ended a call to tool, for errors caused
+// by ctx, from withTimeout, running out.
+func (e *extensions) timedOut(ctx context.Context, tool *config.Tool, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	source := "--request-timeout"
+	if d, perr := time.ParseDuration(tool.Timeout); perr == nil && d > 0 {
+		source = "the tool's timeout"
+	}
+	return fmt.Errorf("%w after %s (%s)", ErrRequestTimeout, e.timeoutFor(tool), source)
+}
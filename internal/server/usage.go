@@ -0,0 +1,102 @@
+package server
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// usageTop is how many tools each list in the /debug usage section holds.
+const usageTop = 10
+
+// usageSorts orders tools for /debug/usage?sort=, each breaking ties by
+// name: most calls, most failures or most recent call first, or by name.
+var usageSorts = map[string]func(a, b ToolUsage) int{
+	"calls":     func(a, b ToolUsage) int { return cmp.Compare(b.Calls, a.Calls) },
+	"errors":    func(a, b ToolUsage) int { return cmp.Compare(b.Errors, a.Errors) },
+	"last_used": func(a, b ToolUsage) int { return b.LastUsed.Compare(a.LastUsed) },
+	"name":      func(a, b ToolUsage) int { return 0 },
+}
+
+// ToolUsage is how much a tool has been called, and how often that failed.
+type ToolUsage struct {
+	Tool        string    `json:"tool"`
+	Calls       int       `json:"calls"`
+	LastUsed    time.Time `json:"last_used,omitzero"`
+	Errors      int       `json:"errors"`
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitzero"`
+}
+
+// toolUsage lists every tool's usage, ordered by sort, a key of
+// usageSorts.
+func toolUsage(cfg *config.Config, sort string) []ToolUsage {
+	usage := []ToolUsage{}
+	for _, tool := range cfg.ListTools() {
+		usage = append(usage, ToolUsage{
+			Tool:        tool.Name,
+			Calls:       tool.UseCount,
+			LastUsed:    tool.LastUsed,
+			Errors:      tool.ErrorCount,
+			LastError:   tool.LastError,
+			LastErrorAt: tool.LastErrorAt,
+		})
+	}
+	by := usageSorts[sort]
+	slices.SortFunc(usage, func(a, b ToolUsage) int {
+		return cmp.Or(by(a, b), cmp.Compare(a.Tool, b.Tool))
+	})
+	return usage
+}
+
+// UsageSummary is the usage section of /debug: the most used and most
+// failing tools, and those never called.
+func UsageSummary(cfg *config.Config) any {
+	mostUsed := []ToolUsage{}
+	failing := []ToolUsage{}
+	neverUsed := []string{}
+	for _, u := range toolUsage(cfg, "calls") {
+		if u.Calls == 0 {
+			neverUsed = append(neverUsed, u.Tool)
+		} else if len(mostUsed) < usageTop {
+			mostUsed = append(mostUsed, u)
+		}
+	}
+	for _, u := range toolUsage(cfg, "errors") {
+		if u.Errors == 0 || len(failing) == usageTop {
+			break
+		}
+		failing = append(failing, u)
+	}
+	return map[string]any{
+		"most_used":  mostUsed,
+		"failing":    failing,
+		"never_used": neverUsed,
+	}
+}
+
+// UsageHandler serves /debug/usage, every tool's usage ordered by the sort
+// query parameter: calls (the default), errors, last_used or name.
+func UsageHandler(cfg *config.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sort := r.URL.Query().Get("sort")
+		if sort == "" {
+			sort = "calls"
+		}
+		if usageSorts[sort] == nil {
+			http.Error(w, fmt.Sprintf("unknown sort %q (want calls, errors, last_used or name)", sort), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"sort":  sort,
+			"tools": toolUsage(cfg, sort),
+		})
+	})
+}
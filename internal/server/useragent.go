@@ -0,0 +1,20 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// SetPreserveUserAgent makes tool calls resend the captured User-Agent
+// instead of identifying as mcpify. Overrides in the config still win.
+func (e *extensions) SetPreserveUserAgent(on bool) {
+	e.preserveUserAgent = on
+}
+
+// identify sets the User-Agent and marker header of a call to tool. It
+// runs after the tool's captured headers so it replaces their User-Agent.
+func (e *extensions) identify(req *http.Request, cfg *config.Config, tool *config.Tool) {
+	req.Header.Set("User-Agent", cfg.UserAgentFor(tool, e.preserveUserAgent))
+	req.Header.Set(config.MarkerHeader, "tool="+tool.Name)
+}
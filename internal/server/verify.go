@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+)
+
+// verifyInterval is how often tools are re-probed after the startup pass,
+// so hidden tools come back once the target starts serving them.
+const verifyInterval = 10 * time.Minute
+
+// toolVerifier probes tools against the live target and tracks which ones
+// are hidden for this run. The stored catalog is never modified.
+type toolVerifier struct {
+	mu       sync.RWMutex
+	hidden   map[string]int
+	failing  map[string][]string
+	lastRun  time.Time
+	onHide   func(name string)
+	onReveal func(name string)
+}
+
+func newToolVerifier() *toolVerifier {
+	return &toolVerifier{
+		hidden:  make(map[string]int),
+		failing: make(map[string][]string),
+	}
+}
+
+func (v *toolVerifier) isHidden(name string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	_, hidden := v.hidden[name]
+	return hidden
+}
+
+// probe reports the status and body the target returns for tool. Only safe
+// methods are sent as-is; everything else is probed with OPTIONS.
+func probe(ctx context.Context, client *http.Client, tool *config.Tool) (int, []byte, error) {
+	method := http.MethodOptions
+	if tool.Method == http.MethodGet || tool.Method == http.MethodHead {
+		method = tool.Method
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, tool.ResolveURL(nil, nil), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range tool.Headers {
+		req.Header.Set(k, v)
+	}
+	config.StripHopHeaders(req.Header)
+	// Headers whose secret isn't set are left out
+	config.ResolveHeaderSecrets(req.Header)
+	req.Header.Set("User-Agent", config.DefaultUserAgent(tool.Name))
+	req.Header.Set(config.MarkerHeader, "verify="+tool.Name)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, body, err
+}
+
+// run probes every tool and hides those answering 404 or 405. Tools that
+// can't be reached keep their current state.
+func (v *toolVerifier) run(ctx context.Context, tools []*config.Tool) {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	for _, tool := range tools {
+		status, body, err := probe(ctx, client, tool)
+		if err != nil {
+			slog.Warn("Verify: could not probe tool", "tool", tool.Name, "error", err)
+			continue
+		}
+
+		missing := status == http.StatusNotFound || status == http.StatusMethodNotAllowed
+
+		// Stored assertions make a richer health check, but only when the
+		// probe actually sent the tool's own request
+		var failures []string
+		if !missing && (tool.Method == http.MethodGet || tool.Method == http.MethodHead) {
+			failures = checkAssertions(tool.Assertions, status, body)
+		}
+
+		v.mu.Lock()
+		_, wasHidden := v.hidden[tool.Name]
+		if missing {
+			v.hidden[tool.Name] = status
+		} else {
+			delete(v.hidden, tool.Name)
+		}
+		if len(failures) > 0 {
+			v.failing[tool.Name] = failures
+		} else {
+			delete(v.failing, tool.Name)
+		}
+		v.mu.Unlock()
+
+		if len(failures) > 0 {
+			slog.Warn("Verify: tool failed assertions", "tool", tool.Name, "failures", strings.Join(failures, "; "))
+		}
+
+		switch {
+		case missing && !wasHidden:
+			slog.Warn("Verify: hiding tool", "tool", tool.Name, "method", tool.Method, "url", tool.URL, "status", status)
+			if v.onHide != nil {
+				v.onHide(tool.Name)
+			}
+		case !missing && wasHidden:
+			slog.Info("Verify: tool is back", "tool", tool.Name, "status", status)
+			if v.onReveal != nil {
+				v.onReveal(tool.Name)
+			}
+		}
+	}
+
+	v.mu.Lock()
+	v.lastRun = time.Now()
+	v.mu.Unlock()
+}
+
+// loop repeats run until ctx is done.
+func (v *toolVerifier) loop(ctx context.Context, tools func() []*config.Tool) {
+	ticker := time.NewTicker(verifyInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			v.run(ctx, tools())
+		}
+	}
+}
+
+func (v *toolVerifier) summary() map[string]interface{} {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	names := make([]string, 0, len(v.hidden))
+	statuses := make(map[string]int, len(v.hidden))
+	for name, status := range v.hidden {
+		names = append(names, name)
+		statuses[name] = status
+	}
+	sort.Strings(names)
+
+	failing := make(map[string][]string, len(v.failing))
+	for name, failures := range v.failing {
+		failing[name] = failures
+	}
+
+	return map[string]interface{}{
+		"hidden_tools":       names,
+		"statuses":           statuses,
+		"assertion_failures": failing,
+		"last_run":           v.lastRun,
+	}
+}
+
+// start runs the startup pass, reports it, and keeps re-verifying in the
+// background until ctx is done.
+func (v *toolVerifier) start(ctx context.Context, cfg *config.Config, ext *extensions) {
+	tools := cfg.ListTools()
+	slog.Info("Verifying tools against the target", "tools", len(tools))
+	v.run(ctx, tools)
+
+	hidden := v.summary()["hidden_tools"].([]string)
+	if len(hidden) > 0 {
+		slog.Warn("Verify: tools hidden for this run", "hidden", len(hidden), "tools", len(tools), "names", strings.Join(hidden, ","))
+	} else {
+		slog.Info("Verify: all tools found on the target", "tools", len(tools))
+	}
+
+	ext.AddDebugInfo("verification", func() any { return v.summary() })
+	go v.loop(ctx, cfg.ListTools)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolView selects which projection of the catalog a session sees.
+type ToolView string
+
+const (
+	ViewIndividual ToolView = "individual"
+	ViewGrouped    ToolView = "grouped"
+	ViewBoth       ToolView = "both"
+)
+
+// toolViewMetaKey is read from the initialize request's _meta so a client
+// can pick its view when it connects.
+const toolViewMetaKey = "mcpify/tool_view"
+
+const setToolViewName = "mcpify_set_tool_view"
+
+// viewChangedToken marks the progress notification handleSetToolView sends
+// to the switching session; notifyMiddleware turns it into list_changed.
+const viewChangedToken = "mcpify/tool_view_changed"
+
+func ParseToolView(s string) (ToolView, error) {
+	switch ToolView(strings.ToLower(strings.TrimSpace(s))) {
+	case ViewIndividual:
+		return ViewIndividual, nil
+	case ViewGrouped:
+		return ViewGrouped, nil
+	case ViewBoth, "":
+		return ViewBoth, nil
+	}
+	return "", fmt.Errorf("%w %q (want individual, grouped or both)", ErrUnknownToolView, s)
+}
+
+// viewRouter tracks the view chosen by each session and hides tools that
+// do not belong to it.
+type viewRouter struct {
+	mu          sync.RWMutex
+	sessions    map[*mcp.ServerSession]ToolView
+	defaultView ToolView
+	isTool      func(name string) bool
+	isGroup     func(name string) bool
+}
+
+func newViewRouter(defaultView ToolView, isTool, isGroup func(string) bool) *viewRouter {
+	return &viewRouter{
+		sessions:    make(map[*mcp.ServerSession]ToolView),
+		defaultView: defaultView,
+		isTool:      isTool,
+		isGroup:     isGroup,
+	}
+}
+
+func (r *viewRouter) view(ss *mcp.ServerSession) ToolView {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if v, ok := r.sessions[ss]; ok {
+		return v
+	}
+	return r.defaultView
+}
+
+func (r *viewRouter) setView(ss *mcp.ServerSession, view ToolView, live func() []*mcp.ServerSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Forget sessions that have disconnected
+	alive := make(map[*mcp.ServerSession]bool)
+	for _, s := range live() {
+		alive[s] = true
+	}
+	for s := range r.sessions {
+		if !alive[s] {
+			delete(r.sessions, s)
+		}
+	}
+
+	r.sessions[ss] = view
+}
+
+func (r *viewRouter) visible(view ToolView, name string) bool {
+	if name == setToolViewName || name == findEndpointName || name == listEndpointsName || name == removeToolName {
+		return true
+	}
+	switch view {
+	case ViewIndividual:
+		return r.isTool(name)
+	case ViewGrouped:
+		return r.isGroup(name)
+	}
+	return true
+}
+
+// middleware filters tools/list and guards tools/call according to the
+// session's view, and picks up a view requested at initialize time.
+func (r *viewRouter) middleware(live func() []*mcp.ServerSession) mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			switch method {
+			case "initialize":
+				if p, ok := params.(*mcp.InitializeParams); ok {
+					if requested, ok := p.Meta[toolViewMetaKey].(string); ok {
+						if view, err := ParseToolView(requested); err == nil {
+							r.setView(ss, view, live)
+						}
+					}
+				}
+			case "tools/call":
+				if p, ok := params.(*mcp.CallToolParamsFor[json.RawMessage]); ok {
+					if view := r.view(ss); !r.visible(view, p.Name) {
+						return nil, fmt.Errorf("%w: %q is not in the %s view", ErrToolUnavailable, p.Name, view)
+					}
+				}
+			}
+
+			result, err := next(ctx, ss, method, params)
+			if err != nil || method != "tools/list" {
+				return result, err
+			}
+
+			if res, ok := result.(*mcp.ListToolsResult); ok {
+				view := r.view(ss)
+				filtered := []*mcp.Tool{}
+				for _, tool := range res.Tools {
+					if r.visible(view, tool.Name) {
+						filtered = append(filtered, tool)
+					}
+				}
+				res.Tools = filtered
+			}
+			return result, nil
+		}
+	}
+}
+
+// notifyMiddleware sends tools/list_changed to one session in place of
+// the marker progress notification. The SDK otherwise only notifies every
+// session at once, on catalog changes.
+func notifyMiddleware(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+	return func(ctx context.Context, ss *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+		if p, ok := params.(*mcp.ProgressNotificationParams); ok && p.ProgressToken == viewChangedToken {
+			return next(ctx, ss, "notifications/tools/list_changed", &mcp.ToolListChangedParams{})
+		}
+		return next(ctx, ss, method, params)
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/grouping"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// newTestConfig loads an empty config from a temporary directory.
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.LoadConfig(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// connect connects a client to s and returns its session and a channel
+// that receives its tools/list_changed notifications.
+func connect(t *testing.T, s *mcp.Server) (*mcp.ClientSession, <-chan struct{}) {
+	t.Helper()
+	changed := make(chan struct{}, 1)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0"}, &mcp.ClientOptions{
+		ToolListChangedHandler: func(context.Context, *mcp.ClientSession, *mcp.ToolListChangedParams) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+	})
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := s.Connect(context.Background(), serverTransport); err != nil {
+		t.Fatal(err)
+	}
+	cs, err := client.Connect(context.Background(), clientTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cs.Close() })
+	return cs, changed
+}
+
+func TestSetToolViewNotifiesOnlyTheCaller(t *testing.T) {
+	cfg := newTestConfig(t)
+	s := NewHybridMCPServer("test", "v0", 10, cfg, ViewBoth, grouping.NewPrefixGrouper(10))
+
+	caller, callerChanged := connect(t, s.mcpServer)
+	_, otherChanged := connect(t, s.mcpServer)
+
+	result, err := caller.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      setToolViewName,
+		Arguments: map[string]any{"view": "grouped"},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("CallTool: %v %+v", err, result)
+	}
+
+	select {
+	case <-callerChanged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("caller was not sent list_changed")
+	}
+	select {
+	case <-otherChanged:
+		t.Error("another session was sent list_changed")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NilayYadav/mcpify/internal/config"
+	"github.com/NilayYadav/mcpify/internal/workflow"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	workflowHintCount   = 3
+	workflowPromptCount = 10
+)
+
+// endpointKey identifies a tool the same way the capture side identifies
+// requests, e.g. "GET /users".
+func endpointKey(tool *config.Tool) string {
+	path := tool.URL
+	if u, err := url.Parse(tool.URL); err == nil {
+		path = u.Path
+	}
+	return tool.Method + " " + path
+}
+
+func toolNamesByEndpoint(cfg *config.Config) map[string]string {
+	names := make(map[string]string)
+	for _, tool := range cfg.ListTools() {
+		names[endpointKey(tool)] = tool.Name
+	}
+	return names
+}
+
+// followedByHint describes which tools usually come after tool, or returns
+// "" when nothing has been observed yet.
+func followedByHint(m *workflow.Miner, tool *config.Tool, names map[string]string) string {
+	if m == nil {
+		return ""
+	}
+
+	var next []string
+	for _, endpoint := range m.FollowedBy(endpointKey(tool), workflowHintCount) {
+		if name, ok := names[endpoint]; ok {
+			next = append(next, name)
+		} else {
+			next = append(next, endpoint)
+		}
+	}
+	if len(next) == 0 {
+		return ""
+	}
+	return "Commonly followed by: " + strings.Join(next, ", ")
+}
+
+func describeSequence(seq workflow.Sequence, names map[string]string) string {
+	steps := make([]string, len(seq.Steps))
+	for i, endpoint := range seq.Steps {
+		if name, ok := names[endpoint]; ok {
+			steps[i] = fmt.Sprintf("%s (%s)", name, endpoint)
+		} else {
+			steps[i] = endpoint
+		}
+	}
+	return strings.Join(steps, " → ")
+}
+
+// addWorkflowPrompt exposes the mined sequences as an MCP prompt.
+func addWorkflowPrompt(srv *mcp.Server, m *workflow.Miner, cfg *config.Config) {
+	srv.AddPrompt(&mcp.Prompt{
+		Name:        "suggested_workflows",
+		Description: "Tool sequences that were commonly called one after another in observed traffic",
+	}, func(ctx context.Context, session *mcp.ServerSession, params *mcp.GetPromptParams) (*mcp.GetPromptResult, error) {
+		names := toolNamesByEndpoint(cfg)
+
+		var b strings.Builder
+		b.WriteString("Workflows observed in real traffic, most common first:\n")
+		sequences := m.Top(workflowPromptCount)
+		for _, seq := range sequences {
+			fmt.Fprintf(&b, "- %s (seen %d times)\n", describeSequence(seq, names), seq.Count)
+		}
+		if len(sequences) == 0 {
+			b.WriteString("- none observed yet\n")
+		}
+
+		return &mcp.GetPromptResult{
+			Description: "Suggested tool sequences",
+			Messages: []*mcp.PromptMessage{
+				{
+					Role:    "user",
+					Content: &mcp.TextContent{Text: b.String()},
+				},
+			},
+		}, nil
+	})
+}
+
+func workflowsHandler(m *workflow.Miner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"sequences": m.Top(50),
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// Package session records every exchange capture sees to a JSON-lines
+// session file, as an audit trail of a run that `mcpify inspect` reads
+// back.
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// FlushInterval is how often recorded exchanges are written out.
+	FlushInterval = time.Second
+	// ResponseWait is how long an exchange waits for its response before
+	// it is recorded without one.
+	ResponseWait = 30 * time.Second
+	// maxLine bounds a line Read accepts.
+	maxLine = 16 << 20
+)
+
+// Entry is one line of a session file: a request capture saw, redacted as
+// tools are, and its response when one was seen.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Endpoint is the method and templated path the request counts
+	// toward, e.g. "GET /users/{id}".
+	Endpoint string            `json:"endpoint"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Query    map[string]string `json:"query,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	// Via is how the request was captured: pcap, proxy or ingest.
+	Via      string    `json:"via,omitempty"`
+	Response *Response `json:"response,omitempty"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// Elapsed is how long the response took, in milliseconds.
+	Elapsed int64 `json:"elapsed_ms"`
+}
+
+// Recorder appends entries to a session file. Entries are buffered and
+// written out by Run; a failing write is logged and never stops capture.
+// A nil *Recorder records nothing.
+type Recorder struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	// pending are the exchanges waiting for their response
+	pending map[*Exchange]struct{}
+	failed  bool
+}
+
+// Exchange is a recorded request waiting for its response.
+type Exchange struct {
+	r     *Recorder
+	entry Entry
+}
+
+// Open opens the session file at path for appending, creating it if need
+// be.
+func Open(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open session file: %w", err)
+	}
+	return &Recorder{path: path, file: file, w: bufio.NewWriter(file), pending: make(map[*Exchange]struct{})}, nil
+}
+
+// Request records e, which is written out once Response completes it or
+// after ResponseWait without one.
+func (r *Recorder) Request(e Entry) *Exchange {
+	if r == nil {
+		return nil
+	}
+	x := &Exchange{r: r, entry: e}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending != nil {
+		r.pending[x] = struct{}{}
+	}
+	return x
+}
+
+// Response completes the exchange with its response.
+func (x *Exchange) Response(resp Response) {
+	if x == nil {
+		return
+	}
+	r := x.r
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[x]; !ok {
+		// Already written without it
+		return
+	}
+	delete(r.pending, x)
+	if resp.Elapsed == 0 {
+		resp.Elapsed = time.Since(x.entry.Time).Milliseconds()
+	}
+	x.entry.Response = &resp
+	r.write(x.entry)
+}
+
+// write buffers e. r.mu must be held.
+func (r *Recorder) write(e Entry) {
+	line, err := json.Marshal(e)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = r.w.Write(line)
+	}
+	r.report(err)
+}
+
+// report logs the first write error, so a full disk doesn't log one per
+// request. r.mu must be held.
+func (r *Recorder) report(err error) {
+	if err != nil && !r.failed {
+		slog.Warn("Failed to write session file; exchanges are being lost", "path", r.path, "error", err)
+		r.failed = true
+	}
+}
+
+// Run writes out the buffered entries every interval, and the exchanges
+// that waited longer than ResponseWait, until ctx is done. Close writes
+// the rest.
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.flush(now.Add(-ResponseWait))
+		}
+	}
+}
+
+// flush writes the exchanges waiting since before cutoff without their
+// response, then everything buffered.
+func (r *Recorder) flush(cutoff time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		// Closed
+		return
+	}
+	for x := range r.pending {
+		if x.entry.Time.Before(cutoff) {
+			delete(r.pending, x)
+			r.write(x.entry)
+		}
+	}
+	r.report(r.w.Flush())
+}
+
+// Close writes every exchange, answered or not, and closes the file.
+// Exchanges recorded after it are dropped.
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending == nil {
+		return nil
+	}
+	for x := range r.pending {
+		r.write(x.entry)
+	}
+	r.pending = nil
+	r.report(r.w.Flush())
+	return r.file.Close()
+}
+
+// Read parses a session file. A line that can't be parsed, such as one
+// cut off by a crash, is skipped and counted in skipped.
+func Read(rd io.Reader) (entries []Entry, skipped int, err error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, maxLine)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if json.Unmarshal(line, &e) != nil {
+			skipped++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, skipped, scanner.Err()
+}
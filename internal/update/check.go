@@ -0,0 +1,43 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// CheckInterval is how often the passive check asks GitHub.
+const CheckInterval = 24 * time.Hour
+
+// checkState is what the passive check remembers between runs.
+type checkState struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// CheckDaily returns the latest release version when it is newer than
+// the running one, or "". GitHub is asked at most once per CheckInterval;
+// in between the answer is read from stateFile.
+func (u *Updater) CheckDaily(ctx context.Context, stateFile string) (string, error) {
+	var state checkState
+	if data, err := os.ReadFile(stateFile); err == nil {
+		json.Unmarshal(data, &state)
+	}
+
+	if time.Since(state.CheckedAt) >= CheckInterval {
+		release, err := u.Latest(ctx)
+		if err != nil {
+			return "", err
+		}
+		state = checkState{CheckedAt: time.Now(), Latest: release.Version()}
+		if data, err := json.Marshal(state); err == nil {
+			os.WriteFile(stateFile, data, 0644)
+		}
+	}
+
+	if compareVersions(state.Latest, u.Current) > 0 {
+		return state.Latest, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,293 @@
+// Package update finds newer mcpify releases on GitHub and replaces the
+// running binary with one.
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReleasesURL is the GitHub API endpoint for the latest release.
+const DefaultReleasesURL = "https://api.github.com/repos/NilayYadav/mcpify/releases/latest"
+
+var (
+	ErrMajorUpgrade     = errors.New("update crosses a major version")
+	ErrNoAsset          = errors.New("release has no binary for this platform")
+	ErrNoChecksum       = errors.New("release publishes no checksum for the binary")
+	ErrChecksumMismatch = errors.New("downloaded binary doesn't match its checksum")
+)
+
+type Release struct {
+	Tag    string  `json:"tag_name"`
+	Assets []Asset `json:"assets"`
+}
+
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	// Digest is "sha256:<hex>", filled in by GitHub for new uploads.
+	Digest string `json:"digest,omitempty"`
+}
+
+// Version is the release tag without its "v".
+func (r *Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+func (r *Release) asset(name string) *Asset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// Updater checks for and installs releases. Every field can point at a
+// test server or a mirror.
+type Updater struct {
+	ReleasesURL string
+	Client      *http.Client
+	// Current is the running version, e.g. "1.2.0".
+	Current string
+	GOOS    string
+	GOARCH  string
+}
+
+func New(current string) *Updater {
+	return &Updater{
+		ReleasesURL: DefaultReleasesURL,
+		Client:      &http.Client{Timeout: 60 * time.Second},
+		Current:     current,
+		GOOS:        runtime.GOOS,
+		GOARCH:      runtime.GOARCH,
+	}
+}
+
+// AssetName is the binary the release workflow publishes for the
+// updater's platform.
+func (u *Updater) AssetName() string {
+	return fmt.Sprintf("mcpify-%s-%s", u.GOOS, u.GOARCH)
+}
+
+// Latest fetches the latest release.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	resp, err := u.get(ctx, u.ReleasesURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch latest release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var release Release
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("decode latest release: %w", err)
+	}
+	if _, err := parseVersion(release.Version()); err != nil {
+		return nil, fmt.Errorf("latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// Newer reports whether release is newer than the running version.
+func (u *Updater) Newer(release *Release) bool {
+	return compareVersions(release.Version(), u.Current) > 0
+}
+
+// CrossesMajor reports whether installing release changes the major
+// version, which may change on-disk formats.
+func (u *Updater) CrossesMajor(release *Release) bool {
+	next, err1 := parseVersion(release.Version())
+	current, err2 := parseVersion(u.Current)
+	return err1 == nil && err2 == nil && next[0] != current[0]
+}
+
+// Apply downloads release's binary for this platform, checks it against
+// the published checksum and swaps it in for exe. If the new binary can't
+// be installed or doesn't run, exe is restored.
+func (u *Updater) Apply(ctx context.Context, release *Release, exe string, allowMajor bool) error {
+	if u.CrossesMajor(release) && !allowMajor {
+		return fmt.Errorf("%w: %s to %s", ErrMajorUpgrade, u.Current, release.Version())
+	}
+	asset := release.asset(u.AssetName())
+	if asset == nil {
+		return fmt.Errorf("%w: want %s", ErrNoAsset, u.AssetName())
+	}
+	want, err := u.checksum(ctx, release, asset)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	// The new binary is written next to the old one so the swap is a
+	// rename within one filesystem
+	tmp, err := u.download(ctx, asset, filepath.Dir(exe), want)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	backup := exe + ".old"
+	if err := os.Rename(exe, backup); err != nil {
+		return fmt.Errorf("move current binary aside: %w", err)
+	}
+	if err := os.Rename(tmp, exe); err != nil {
+		return rollback(exe, backup, fmt.Errorf("install new binary: %w", err))
+	}
+	if err := selfCheck(ctx, exe); err != nil {
+		return rollback(exe, backup, fmt.Errorf("new binary doesn't run: %w", err))
+	}
+	os.Remove(backup)
+	return nil
+}
+
+func rollback(exe, backup string, cause error) error {
+	os.Remove(exe)
+	if err := os.Rename(backup, exe); err != nil {
+		return fmt.Errorf("%w; restoring %s also failed: %v", cause, exe, err)
+	}
+	return cause
+}
+
+// selfCheck runs `exe version` to make sure the installed binary works on
+// this machine.
+func selfCheck(ctx context.Context, exe string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, exe, "version").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// checksum returns the expected SHA-256 of asset from its digest, or
+// from a "<asset>.sha256" file in the release.
+func (u *Updater) checksum(ctx context.Context, release *Release, asset *Asset) (string, error) {
+	if sum, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok {
+		return strings.ToLower(sum), nil
+	}
+	file := release.asset(asset.Name + ".sha256")
+	if file == nil {
+		return "", fmt.Errorf("%w: %s", ErrNoChecksum, asset.Name)
+	}
+	resp, err := u.get(ctx, file.URL)
+	if err != nil {
+		return "", fmt.Errorf("fetch checksum: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("fetch checksum: %w", err)
+	}
+	// sha256sum format: "<hex>  <file name>"
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: %s is empty", ErrNoChecksum, file.Name)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// download saves asset to a temporary file in dir and returns its path
+// once its SHA-256 matches want.
+func (u *Updater) download(ctx context.Context, asset *Asset, dir, want string) (string, error) {
+	resp, err := u.get(ctx, asset.URL)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp(dir, ".mcpify-update-*")
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, hash), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("download %s: %w", asset.Name, err)
+	}
+	if got := hex.EncodeToString(hash.Sum(nil)); got != want {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("%w: %s has sha256 %s, release says %s", ErrChecksumMismatch, asset.Name, got, want)
+	}
+	return f.Name(), nil
+}
+
+func (u *Updater) get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "mcpify/"+u.Current)
+	resp, err := u.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// parseVersion parses "1.2.3", ignoring any pre-release or build suffix.
+func parseVersion(v string) ([3]int, error) {
+	var parts [3]int
+	core, _, _ := strings.Cut(v, "-")
+	core, _, _ = strings.Cut(core, "+")
+	fields := strings.Split(core, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, fmt.Errorf("invalid version %q", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// compareVersions orders a and b; unparseable versions sort first.
+func compareVersions(a, b string) int {
+	va, errA := parseVersion(a)
+	vb, errB := parseVersion(b)
+	switch {
+	case errA != nil && errB != nil:
+		return 0
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] - vb[i]
+		}
+	}
+	return 0
+}
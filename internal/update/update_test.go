@@ -0,0 +1,129 @@
+package update
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const (
+	oldBinary = "#!/bin/sh\necho mcpify 1.0.0\n"
+	newBinary = "#!/bin/sh\necho mcpify 1.1.0\n"
+	// brokenBinary is one the self-check rejects
+	brokenBinary = "#!/bin/sh\necho 'cannot execute binary file' >&2\nexit 126\n"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// releaseServer serves a latest release tagged tag whose binary for
+// linux/amd64 is binary. digest and sumFile, when set, are the asset's
+// digest and the contents of its .sha256 file.
+func releaseServer(t *testing.T, tag, binary, digest, sumFile string) *Updater {
+	t.Helper()
+	const asset = "mcpify-linux-amd64"
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	release := Release{Tag: tag, Assets: []Asset{{Name: asset, URL: srv.URL + "/download/" + asset, Digest: digest}}}
+	if sumFile != "" {
+		release.Assets = append(release.Assets, Asset{Name: asset + ".sha256", URL: srv.URL + "/download/" + asset + ".sha256"})
+	}
+	mux.HandleFunc("GET /releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(release)
+	})
+	mux.HandleFunc("GET /download/"+asset, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(binary))
+	})
+	mux.HandleFunc("GET /download/"+asset+".sha256", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(sumFile))
+	})
+
+	return &Updater{ReleasesURL: srv.URL + "/releases/latest", Client: srv.Client(), Current: "1.0.0", GOOS: "linux", GOARCH: "amd64"}
+}
+
+func TestApply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test binaries are shell scripts")
+	}
+	tests := []struct {
+		name    string
+		tag     string
+		binary  string
+		digest  string
+		sumFile string
+		major   bool
+		err     error
+		// installed is the binary in place afterwards
+		installed string
+	}{
+		{name: "digest", tag: "v1.1.0", binary: newBinary, digest: "sha256:" + sha256Hex(newBinary), installed: newBinary},
+		{name: "upper-case digest", tag: "v1.1.0", binary: newBinary, digest: "sha256:" + strings.ToUpper(sha256Hex(newBinary)), installed: newBinary},
+		{name: "checksum file", tag: "v1.1.0", binary: newBinary, sumFile: sha256Hex(newBinary) + "  mcpify-linux-amd64\n", installed: newBinary},
+		{name: "digest mismatch", tag: "v1.1.0", binary: newBinary, digest: "sha256:" + sha256Hex(oldBinary), err: ErrChecksumMismatch, installed: oldBinary},
+		{name: "checksum file mismatch", tag: "v1.1.0", binary: newBinary, sumFile: sha256Hex("tampered") + "  mcpify-linux-amd64\n", err: ErrChecksumMismatch, installed: oldBinary},
+		{name: "no checksum", tag: "v1.1.0", binary: newBinary, err: ErrNoChecksum, installed: oldBinary},
+		{name: "empty checksum file", tag: "v1.1.0", binary: newBinary, sumFile: "\n", err: ErrNoChecksum, installed: oldBinary},
+		{name: "self-check fails", tag: "v1.1.0", binary: brokenBinary, digest: "sha256:" + sha256Hex(brokenBinary), installed: oldBinary},
+		{name: "major upgrade", tag: "v2.0.0", binary: newBinary, digest: "sha256:" + sha256Hex(newBinary), err: ErrMajorUpgrade, installed: oldBinary},
+		{name: "allowed major upgrade", tag: "v2.0.0", binary: newBinary, digest: "sha256:" + sha256Hex(newBinary), major: true, installed: newBinary},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := releaseServer(t, tt.tag, tt.binary, tt.digest, tt.sumFile)
+			dir := t.TempDir()
+			exe := filepath.Join(dir, "mcpify")
+			if err := os.WriteFile(exe, []byte(oldBinary), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			release, err := u.Latest(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !u.Newer(release) {
+				t.Fatalf("%s isn't newer than %s", release.Version(), u.Current)
+			}
+			err = u.Apply(context.Background(), release, exe, tt.major)
+			switch {
+			case tt.err != nil && !errors.Is(err, tt.err):
+				t.Errorf("Apply = %v, want %v", err, tt.err)
+			case tt.err == nil && tt.installed == oldBinary && err == nil:
+				t.Error("Apply succeeded, want it to roll back")
+			case tt.err == nil && tt.installed == newBinary && err != nil:
+				t.Errorf("Apply = %v", err)
+			}
+
+			got, err := os.ReadFile(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.installed {
+				t.Errorf("installed binary is %q, want %q", got, tt.installed)
+			}
+			if info, err := os.Stat(exe); err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("installed binary mode %v, %v; want 0755", info.Mode(), err)
+			}
+			// Neither the download nor the backup is left behind
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				t.Errorf("left %v next to the binary", names)
+			}
+		})
+	}
+}
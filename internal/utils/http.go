@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
@@ -21,3 +22,18 @@ func GenerateToolName(method, path string) string {
 	safePath = strings.Trim(safePath, "_")
 	return fmt.Sprintf("%s_%s", strings.ToLower(method), safePath)
 }
+
+// RequireToken rejects requests that don't carry token as a bearer token.
+// An empty token disables the check.
+func RequireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,160 @@
+// Package webhook tells an outside service about endpoints capture
+// discovers, by POSTing each one to the --discovery-webhook URL.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultPerMinute is how many discoveries are delivered per minute;
+	// the rest of a burst is dropped.
+	DefaultPerMinute = 10
+	// attempts is how many times a delivery is tried before giving up.
+	attempts = 3
+	// retryDelay is the wait before the first retry, doubled for each
+	// one after it.
+	retryDelay = time.Second
+	// queueSize is how many discoveries may wait for delivery; more are
+	// dropped rather than holding capture up.
+	queueSize = 64
+	timeout   = 10 * time.Second
+)
+
+// Discovery is the JSON payload of a delivery.
+type Discovery struct {
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Tool      string    `json:"tool"`
+	Timestamp time.Time `json:"timestamp"`
+	Target    string    `json:"target"`
+}
+
+// Notifier delivers discoveries from a goroutine of its own, so a slow or
+// failing webhook never blocks capture. A nil *Notifier delivers nothing.
+type Notifier struct {
+	url       string
+	perMinute int
+	client    *http.Client
+	queue     chan Discovery
+	// window is when the current minute of deliveries started, and sent
+	// how many were delivered in it
+	window time.Time
+	sent   int
+}
+
+var ErrInvalidURL = errors.New("invalid webhook URL")
+
+// ParseURL checks that s is an absolute http or https URL.
+func ParseURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w %q (want an http or https URL)", ErrInvalidURL, s)
+	}
+	return u.String(), nil
+}
+
+// New returns a Notifier posting to url at most perMinute times a minute
+// once Run is started.
+func New(url string, perMinute int) *Notifier {
+	return &Notifier{
+		url:       url,
+		perMinute: perMinute,
+		client:    &http.Client{Timeout: timeout},
+		queue:     make(chan Discovery, queueSize),
+	}
+}
+
+// Notify queues d for delivery, dropping it if the queue is full.
+func (n *Notifier) Notify(d Discovery) {
+	if n == nil {
+		return
+	}
+	select {
+	case n.queue <- d:
+	default:
+		slog.Warn("Discovery webhook queue full, dropping discovery", "method", d.Method, "path", d.Path)
+	}
+}
+
+// Run delivers queued discoveries until ctx is done.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-n.queue:
+			if !n.allow(time.Now()) {
+				slog.Warn("Discovery webhook rate limited, dropping discovery",
+					"method", d.Method, "path", d.Path, "per_minute", n.perMinute)
+				continue
+			}
+			n.deliver(ctx, d)
+		}
+	}
+}
+
+// allow reports whether a delivery at now is within the per-minute cap,
+// counting it if so.
+func (n *Notifier) allow(now time.Time) bool {
+	if now.Sub(n.window) >= time.Minute {
+		n.window = now
+		n.sent = 0
+	}
+	if n.sent >= n.perMinute {
+		return false
+	}
+	n.sent++
+	return true
+}
+
+func (n *Notifier) deliver(ctx context.Context, d Discovery) {
+	body, err := json.Marshal(d)
+	if err != nil {
+		slog.Error("Failed to encode discovery", "error", err)
+		return
+	}
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err = n.post(ctx, body)
+		if err == nil {
+			slog.Debug("Discovery webhook delivered", "method", d.Method, "path", d.Path)
+			return
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	slog.Warn("Failed to deliver discovery webhook", "method", d.Method, "path", d.Path, "attempts", attempts, "error", err)
+}
+
+func (n *Notifier) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// A gap longer than this starts a new sequence for the session.
+	sessionGap = 10 * time.Minute
+	// Scores halve every halfLife so old workflows fade out.
+	halfLife = time.Hour
+	// Upper bound on tracked pairs and triples.
+	maxSequences = 500
+)
+
+// Sequence is a run of endpoints (e.g. "GET /users") seen back to back
+// within one client session.
+type Sequence struct {
+	Steps    []string  `json:"steps"`
+	Score    float64   `json:"score"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type sessionState struct {
+	recent   []string
+	lastSeen time.Time
+}
+
+// Miner records per-session request order and mines frequent consecutive
+// pairs and triples. Everything is computed locally.
+type Miner struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+	seqs     map[string]*Sequence
+}
+
+func NewMiner() *Miner {
+	return &Miner{
+		sessions: make(map[string]*sessionState),
+		seqs:     make(map[string]*Sequence),
+	}
+}
+
+// Observe records that session called endpoint at the given time.
+func (m *Miner) Observe(session, endpoint string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.sessions[session]
+	if state == nil || at.Sub(state.lastSeen) > sessionGap {
+		state = &sessionState{}
+		m.sessions[session] = state
+	}
+
+	// Repeated calls to the same endpoint don't make a workflow step
+	if n := len(state.recent); n > 0 && state.recent[n-1] == endpoint {
+		state.lastSeen = at
+		return
+	}
+
+	state.recent = append(state.recent, endpoint)
+	if len(state.recent) > 3 {
+		state.recent = state.recent[len(state.recent)-3:]
+	}
+	state.lastSeen = at
+
+	if n := len(state.recent); n >= 2 {
+		m.bump(state.recent[n-2:], at)
+		if n == 3 {
+			m.bump(state.recent, at)
+		}
+	}
+
+	m.pruneSessions(at)
+}
+
+func (m *Miner) bump(steps []string, at time.Time) {
+	key := strings.Join(steps, "\n")
+	seq := m.seqs[key]
+	if seq == nil {
+		seq = &Sequence{Steps: append([]string(nil), steps...), LastSeen: at}
+		m.seqs[key] = seq
+	}
+	seq.Score = decayed(seq.Score, seq.LastSeen, at) + 1
+	seq.Count++
+	seq.LastSeen = at
+
+	if len(m.seqs) > maxSequences {
+		m.evictWeakest(at)
+	}
+}
+
+func (m *Miner) evictWeakest(now time.Time) {
+	var weakestKey string
+	weakest := math.MaxFloat64
+	for key, seq := range m.seqs {
+		if score := decayed(seq.Score, seq.LastSeen, now); score < weakest {
+			weakest = score
+			weakestKey = key
+		}
+	}
+	delete(m.seqs, weakestKey)
+}
+
+func (m *Miner) pruneSessions(now time.Time) {
+	for id, state := range m.sessions {
+		if now.Sub(state.lastSeen) > sessionGap {
+			delete(m.sessions, id)
+		}
+	}
+}
+
+func decayed(score float64, last, now time.Time) float64 {
+	elapsed := now.Sub(last)
+	if elapsed <= 0 {
+		return score
+	}
+	return score * math.Pow(0.5, float64(elapsed)/float64(halfLife))
+}
+
+// Top returns up to k sequences ordered by decayed score.
+func (m *Miner) Top(k int) []Sequence {
+	return m.top(k, func(*Sequence) bool { return true })
+}
+
+// FollowedBy returns up to k endpoints that commonly follow endpoint.
+func (m *Miner) FollowedBy(endpoint string, k int) []string {
+	pairs := m.top(k, func(seq *Sequence) bool {
+		return len(seq.Steps) == 2 && seq.Steps[0] == endpoint
+	})
+
+	next := make([]string, 0, len(pairs))
+	for _, seq := range pairs {
+		next = append(next, seq.Steps[1])
+	}
+	return next
+}
+
+func (m *Miner) top(k int, keep func(*Sequence) bool) []Sequence {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	result := []Sequence{}
+	for _, seq := range m.seqs {
+		if keep(seq) {
+			snapshot := *seq
+			snapshot.Score = decayed(seq.Score, seq.LastSeen, now)
+			result = append(result, snapshot)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+	if len(result) > k {
+		result = result[:k]
+	}
+	return result
+}
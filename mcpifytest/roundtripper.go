@@ -0,0 +1,228 @@
+// Package mcpifytest mirrors HTTP traffic from test suites to a running
+// mcpify instance, so endpoints exercised by tests become MCP tools without
+// packet capture.
+package mcpifytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdminTokenEnv is read for the admin token when mcpify requires one.
+const AdminTokenEnv = "MCPIFY_ADMIN_TOKEN"
+
+const (
+	// maxReportedBody bounds the request and response bodies kept for a
+	// report; longer ones are reported without their body.
+	maxReportedBody = 1 << 20
+	// maxReporting bounds the reports being sent at once.
+	maxReporting = 8
+)
+
+type ingestRequest struct {
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Response *ingestResponse   `json:"response,omitempty"`
+}
+
+type ingestResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+type roundTripper struct {
+	base      http.RoundTripper
+	ingestURL string
+	token     string
+	client    *http.Client
+	// pending tracks reports still being sent, for Flush
+	pending sync.WaitGroup
+	slots   chan struct{}
+}
+
+// RoundTripper wraps base so every request is also reported to the mcpify
+// instance at mcpifyURL (e.g. "http://localhost:8081"). Bodies are copied
+// as the caller reads them, so responses still stream, and the exchange is
+// reported in the background once the caller closes the response body.
+// Reporting is best effort and never changes what the caller sees. A nil
+// base uses http.DefaultTransport.
+func RoundTripper(base http.RoundTripper, mcpifyURL string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &roundTripper{
+		base:      base,
+		ingestURL: strings.TrimRight(mcpifyURL, "/") + "/api/ingest",
+		token:     os.Getenv(AdminTokenEnv),
+		client:    &http.Client{Timeout: 2 * time.Second},
+		slots:     make(chan struct{}, maxReporting),
+	}
+}
+
+// Flush waits up to timeout for the reports rt, a RoundTripper from this
+// package, is still sending, e.g. before a test binary exits. It reports
+// whether they all went out.
+func Flush(rt http.RoundTripper, timeout time.Duration) bool {
+	r, ok := rt.(*roundTripper)
+	if !ok {
+		return true
+	}
+	done := make(chan struct{})
+	go func() {
+		r.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The caller's request is left alone; a clone carries the copying body
+	sent := req
+	reqBody := &cappedBuffer{}
+	if req.Body != nil && req.Body != http.NoBody {
+		sent = req.Clone(req.Context())
+		sent.Body = &teeBody{ReadCloser: req.Body, copy: reqBody}
+	}
+
+	resp, err := rt.base.RoundTrip(sent)
+	if err != nil {
+		return resp, err
+	}
+
+	respBody := &cappedBuffer{}
+	resp.Body = &teeBody{
+		ReadCloser: resp.Body,
+		copy:       respBody,
+		onClose: func(complete bool) {
+			rt.report(req, reqBody, resp, respBody, complete)
+		},
+	}
+	return resp, nil
+}
+
+// report sends the exchange to mcpify in the background. The response
+// body is only included when the caller read all of it.
+func (rt *roundTripper) report(req *http.Request, reqBody *cappedBuffer, resp *http.Response, respBody *cappedBuffer, complete bool) {
+	in := ingestRequest{
+		Method:  req.Method,
+		Path:    req.URL.RequestURI(),
+		Headers: firstValues(req.Header),
+		Body:    reqBody.String(),
+		Response: &ingestResponse{
+			Status:  resp.StatusCode,
+			Headers: firstValues(resp.Header),
+		},
+	}
+	if complete {
+		in.Response.Body = respBody.String()
+	}
+
+	rt.pending.Add(1)
+	go func() {
+		defer rt.pending.Done()
+		rt.slots <- struct{}{}
+		defer func() { <-rt.slots }()
+		rt.send(in)
+	}()
+}
+
+func (rt *roundTripper) send(in ingestRequest) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return
+	}
+
+	post, err := http.NewRequest(http.MethodPost, rt.ingestURL, bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+	post.Header.Set("Content-Type", "application/json")
+	if rt.token != "" {
+		post.Header.Set("Authorization", "Bearer "+rt.token)
+	}
+
+	if ack, err := rt.client.Do(post); err == nil {
+		ack.Body.Close()
+	}
+}
+
+// teeBody copies what is read from a body into copy, and calls onClose
+// once when it is closed, saying whether it was read to the end.
+type teeBody struct {
+	io.ReadCloser
+	copy    *cappedBuffer
+	onClose func(complete bool)
+
+	once sync.Once
+	eof  bool
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.copy.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *teeBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if b.onClose != nil {
+			b.onClose(b.eof)
+		}
+	})
+	return err
+}
+
+// cappedBuffer keeps up to maxReportedBody bytes. The transport may still
+// be writing a request body while the response is read, so it is locked.
+type cappedBuffer struct {
+	mu       sync.Mutex
+	buf      bytes.Buffer
+	overflow bool
+}
+
+func (c *cappedBuffer) Write(p []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.overflow || c.buf.Len()+len(p) > maxReportedBody {
+		c.overflow = true
+		c.buf.Reset()
+		return
+	}
+	c.buf.Write(p)
+}
+
+// String returns what was written, or "" when it didn't fit.
+func (c *cappedBuffer) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String()
+}
+
+func firstValues(h http.Header) map[string]string {
+	values := make(map[string]string)
+	for k, v := range h {
+		if len(v) > 0 {
+			values[k] = v[0]
+		}
+	}
+	return values
+}
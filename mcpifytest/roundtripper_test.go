@@ -0,0 +1,161 @@
+package mcpifytest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ingestServer collects what RoundTripper reports.
+type ingestServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	received []ingestRequest
+}
+
+func newIngestServer(t *testing.T) *ingestServer {
+	s := &ingestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in ingestRequest
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			t.Errorf("bad report: %v", err)
+		}
+		s.mu.Lock()
+		s.received = append(s.received, in)
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ingestServer) reports() []ingestRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ingestRequest(nil), s.received...)
+}
+
+func TestRoundTripperReportsExchange(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer api.Close()
+	ingest := newIngestServer(t)
+
+	rt := RoundTripper(nil, ingest.URL)
+	body := strings.NewReader(`{"name":"ada"}`)
+	req, _ := http.NewRequest(http.MethodPost, api.URL+"/users?active=true", body)
+	callerBody := req.Body
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(got) != `{"echo":{"name":"ada"}}` {
+		t.Errorf("caller got %q", got)
+	}
+	if req.Body != callerBody {
+		t.Error("RoundTrip replaced the caller's request body")
+	}
+	if !Flush(rt, 5*time.Second) {
+		t.Fatal("reports still pending")
+	}
+
+	reports := ingest.reports()
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	r := reports[0]
+	if r.Method != "POST" || r.Path != "/users?active=true" || r.Body != `{"name":"ada"}` {
+		t.Errorf("reported request %s %s %q", r.Method, r.Path, r.Body)
+	}
+	if r.Response == nil || r.Response.Status != http.StatusCreated || r.Response.Body != string(got) {
+		t.Errorf("reported response %+v", r.Response)
+	}
+}
+
+func TestRoundTripperStreamsResponses(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second\n"))
+	}))
+	defer api.Close()
+	defer close(release)
+	ingest := newIngestServer(t)
+
+	rt := RoundTripper(nil, ingest.URL)
+	resp, err := (&http.Client{Transport: rt}).Get(api.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// The first chunk arrives while the server still holds the second back
+	line := make([]byte, len("first\n"))
+	if _, err := io.ReadFull(resp.Body, line); err != nil || string(line) != "first\n" {
+		t.Fatalf("read %q, %v", line, err)
+	}
+	if len(ingest.reports()) != 0 {
+		t.Error("reported before the body was closed")
+	}
+}
+
+// failingBody fails partway through.
+type failingBody struct{ read bool }
+
+var errConnReset = errors.New("connection reset")
+
+func (b *failingBody) Read(p []byte) (int, error) {
+	if b.read {
+		return 0, errConnReset
+	}
+	b.read = true
+	return copy(p, "partial"), nil
+}
+
+func (b *failingBody) Close() error { return nil }
+
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: &failingBody{}, Request: req}, nil
+}
+
+func TestRoundTripperPassesOnReadErrors(t *testing.T) {
+	ingest := newIngestServer(t)
+	rt := RoundTripper(stubTransport{}, ingest.URL)
+
+	req, _ := http.NewRequest(http.MethodGet, "http://api.test/items", nil)
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !errors.Is(err, errConnReset) {
+		t.Errorf("read error = %v, want %v", err, errConnReset)
+	}
+
+	if !Flush(rt, 5*time.Second) {
+		t.Fatal("reports still pending")
+	}
+	reports := ingest.reports()
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	if reports[0].Response.Body != "" {
+		t.Errorf("reported the truncated body %q", reports[0].Response.Body)
+	}
+}